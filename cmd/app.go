@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/config"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/bilibili"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/demo"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+// app 保存已初始化的基础设施组件和应用服务，供服务器和各个子命令共用。
+type app struct {
+	cfg *config.Config
+	db  *gorm.DB // 演示模式下为 nil
+
+	videoProgressRepo repository.VideoProgressRepository
+	aggregateRepo     repository.WatchTimeAggregateRepository
+
+	watchTimeCalculator service.WatchTimeCalculator
+	watchTimeStrategy   service.WatchTimeStrategy
+
+	videoProgressService  *application.VideoProgressService
+	videoCatalogService   *application.VideoCatalogService
+	aggregationService    *application.WatchTimeAggregationService
+	labelService          *application.ProgressLabelService
+	videoAnalyticsService application.VideoAnalyticsService
+
+	progressExchangeService *application.ProgressExchangeService
+	rawArchiveService       *application.RawArchiveService
+	progressRecordService   *application.ProgressRecordService
+	coverageService         *application.CoverageService
+	sessionService          *application.SessionService
+	forecastService         *application.ForecastService
+	goalService             *application.GoalService
+	coursePlanService       *application.CoursePlanService
+	heatmapService          *application.HeatmapService
+
+	combinedAnalyticsService *application.CombinedAnalyticsService
+	comparisonService        *application.ComparisonService
+	leaderboardService       *application.LeaderboardService
+
+	watchTimeService  application.WatchTimeService
+	simulationService *application.SimulationService
+}
+
+// archivingClient 是支持原始响应归档的 Bilibili 客户端 (真实客户端和模拟客户端都实现了它)。
+type archivingClient interface {
+	application.BilibiliClient
+	SetArchiver(archiver application.RawResponseArchiver)
+}
+
+// newApp 根据配置初始化基础设施组件、领域服务和应用服务。
+func newApp(cfg *config.Config) (*app, error) {
+	a := &app{cfg: cfg}
+
+	// --- 初始化基础设施组件 ---
+	var biliClient archivingClient
+	var videoRepo repository.VideoRepository
+	var rawResponseRepo repository.RawResponseRepository
+	var goalRepo repository.GoalRepository
+	var coursePlanRepo repository.CoursePlanRepository
+	var demoClient *demo.Client
+	if cfg.Demo.Enabled {
+		log.Println("Running in demo mode: using in-memory repository and synthetic Bilibili client.")
+		demoClient = demo.NewClient(time.Now().AddDate(0, 0, -cfg.Demo.SeedDays))
+		biliClient = demoClient
+		a.videoProgressRepo = persistence.NewMemoryVideoProgressRepository()
+		videoRepo = persistence.NewMemoryVideoRepository()
+		a.aggregateRepo = persistence.NewMemoryWatchTimeAggregateRepository()
+		rawResponseRepo = persistence.NewMemoryRawResponseRepository()
+		goalRepo = persistence.NewMemoryGoalRepository()
+		coursePlanRepo = persistence.NewMemoryCoursePlanRepository()
+		if len(cfg.Bilibili.TargetBVIDs) == 0 {
+			cfg.Bilibili.TargetBVIDs = demo.BVIDs()
+		}
+	} else {
+		// 初始化数据库连接
+		db, err := persistence.NewDatabaseConnection(&cfg.Database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		log.Println("Successfully connected to the database.")
+		a.db = db
+		biliClient = bilibili.NewClient(cfg.Bilibili.SessData)
+		a.videoProgressRepo = persistence.NewGormVideoProgressRepository(db)
+		videoRepo = persistence.NewGormVideoRepository(db)
+		a.aggregateRepo = persistence.NewGormWatchTimeAggregateRepository(db)
+		rawResponseRepo = persistence.NewGormRawResponseRepository(db)
+		goalRepo = persistence.NewGormGoalRepository(db)
+		coursePlanRepo = persistence.NewGormCoursePlanRepository(db)
+	}
+	log.Println("Bilibili client initialized.")
+	log.Println("Video progress repository initialized.")
+	log.Println("Video repository initialized.")
+
+	// --- 初始化领域服务 ---
+	a.watchTimeCalculator = service.NewWatchTimeCalculator()
+	log.Println("Watch time calculator initialized.")
+	strategy, err := service.NewWatchTimeStrategy(cfg.WatchTime.Strategy, a.watchTimeCalculator, cfg.WatchTime.MaxSpeed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCH_TIME_STRATEGY: %w", err)
+	}
+	a.watchTimeStrategy = strategy
+	log.Printf("Watch time strategy initialized (%s, max speed %gx).", cfg.WatchTime.Strategy, cfg.WatchTime.MaxSpeed)
+
+	// --- 初始化应用服务 ---
+	a.videoCatalogService = application.NewVideoCatalogService(videoRepo, biliClient)
+	log.Println("Video catalog service initialized.")
+	if err := service.CheckHourAlignment(cfg.Aggregate.Location, time.Now().Year()); err != nil {
+		return nil, fmt.Errorf("invalid AGGREGATE_TIMEZONE: %w", err)
+	}
+	a.aggregationService = application.NewWatchTimeAggregationService(a.videoProgressRepo, a.aggregateRepo,
+		a.videoCatalogService, a.watchTimeStrategy, cfg.Aggregate.Location)
+	log.Printf("Watch time aggregation service initialized (timezone: %s).", cfg.Aggregate.Timezone)
+	a.labelService = application.NewProgressLabelService(a.videoProgressRepo, a.videoCatalogService,
+		service.NewPairClassifier(cfg.WatchTime.MaxSpeed))
+	a.videoProgressService = application.NewVideoProgressService(a.videoProgressRepo, biliClient, a.aggregationService, a.labelService)
+	log.Println("Video progress service initialized.")
+	attribution, err := application.ParseAttribution(cfg.WatchTime.Attribution)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCH_TIME_ATTRIBUTION: %w", err)
+	}
+	a.videoAnalyticsService = application.NewVideoAnalyticsService(a.videoCatalogService, a.videoProgressRepo,
+		a.aggregateRepo, a.watchTimeStrategy, a.aggregationService, attribution)
+	log.Printf("Video analytics service initialized (attribution: %s).", attribution)
+	a.progressExchangeService = application.NewProgressExchangeService(a.videoProgressRepo, a.videoCatalogService,
+		a.videoAnalyticsService, a.aggregationService, a.labelService)
+	log.Println("Progress exchange service initialized.")
+	a.progressRecordService = application.NewProgressRecordService(a.videoCatalogService, a.videoProgressRepo)
+	a.coverageService = application.NewCoverageService(a.videoCatalogService, a.videoProgressRepo,
+		a.aggregateRepo, a.watchTimeCalculator, cfg.WatchTime.MaxSpeed)
+	a.sessionService = application.NewSessionService(a.videoCatalogService, a.videoProgressRepo,
+		a.watchTimeStrategy, cfg.WatchTime.IdleGap)
+	a.forecastService = application.NewForecastService(a.videoAnalyticsService, a.coverageService, a.aggregationService)
+	a.heatmapService = application.NewHeatmapService(a.videoCatalogService, a.videoAnalyticsService, a.aggregationService)
+	a.combinedAnalyticsService = application.NewCombinedAnalyticsService(a.videoCatalogService, a.videoAnalyticsService)
+	a.comparisonService = application.NewComparisonService(a.combinedAnalyticsService)
+	a.leaderboardService = application.NewLeaderboardService(a.combinedAnalyticsService)
+	a.watchTimeService = application.NewWatchTimeService(a.videoCatalogService, a.watchTimeCalculator)
+	// 模拟使用与正式服务相同的策略、分类器和归属方式，但每次都在新的内存仓库中进行
+	a.simulationService = application.NewSimulationService(func() application.SimulationRepositories {
+		return application.SimulationRepositories{
+			Videos:     persistence.NewMemoryVideoRepository(),
+			Progress:   persistence.NewMemoryVideoProgressRepository(),
+			Aggregates: persistence.NewMemoryWatchTimeAggregateRepository(),
+		}
+	}, a.watchTimeStrategy, service.NewPairClassifier(cfg.WatchTime.MaxSpeed), attribution, cfg.Aggregate.Location)
+	studyDay, err := service.NewStudyDayClock(cfg.Aggregate.Location, cfg.Goal.DayCutoffHour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GOAL_DAY_CUTOFF_HOUR: %w", err)
+	}
+	a.goalService = application.NewGoalService(goalRepo, a.videoCatalogService, a.videoAnalyticsService,
+		a.coverageService, studyDay)
+	a.coursePlanService = application.NewCoursePlanService(coursePlanRepo, a.videoCatalogService, a.coverageService, studyDay)
+	a.rawArchiveService = application.NewRawArchiveService(rawResponseRepo, a.videoProgressRepo,
+		bilibili.ResponseParser{}, a.aggregationService, a.labelService)
+	if cfg.Archive.Enabled {
+		biliClient.SetArchiver(a.rawArchiveService)
+		log.Println("Raw response archive enabled.")
+	}
+
+	if demoClient != nil {
+		// 生成历史进度，模拟调度器过去每 10 分钟的轮询结果；种子数据直接写入仓库，因此需要分类记录对并重建聚合
+		now := time.Now()
+		from := now.AddDate(0, 0, -cfg.Demo.SeedDays)
+		ctx := context.Background()
+		if _, err := demoClient.Seed(ctx, a.videoProgressRepo, from, now, 10*time.Minute); err != nil {
+			return nil, fmt.Errorf("failed to seed demo data: %w", err)
+		}
+		if _, err := a.labelService.RelabelAll(ctx); err != nil {
+			return nil, fmt.Errorf("failed to label demo progress: %w", err)
+		}
+		if err := a.aggregationService.RebuildAll(ctx, from, now); err != nil {
+			return nil, fmt.Errorf("failed to build demo aggregates: %w", err)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/exchange"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+// command 描述一个子命令。
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+// commands 是所有可用的子命令，未指定子命令时等同于 serve。
+var commands = []command{
+	{name: "serve", summary: "启动后端服务 (默认)", run: func(a *app, args []string) error {
+		runServer(a)
+		return nil
+	}},
+	{name: "rebuild-aggregates", summary: "从原始进度记录重建小时/天观看时长聚合 (计算逻辑变化后使用)", run: runRebuildAggregates},
+	{name: "relabel-progress", summary: "重新给相邻进度记录对分类，并重建分类变化处的聚合", run: runRelabelProgress},
+	{name: "export", summary: "导出原始进度记录或观看分段 (export progress|segments)", run: runExport},
+	{name: "import", summary: "幂等导入进度记录 (CSV、NDJSON、JSON)", run: runImport},
+	{name: "reprocess-archive", summary: "从 Bilibili API 原始响应归档重新生成进度记录", run: runReprocessArchive},
+	{name: "migrate-utc", summary: "把旧版本按容器时区保存的时间转换为 UTC (从以当地时间存储的版本升级时执行一次)", run: runMigrateUTC},
+}
+
+// runCommand 按名称执行子命令。
+func runCommand(a *app, name string, args []string) error {
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(a, args)
+		}
+	}
+	flag.Usage()
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// runRebuildAggregates 重建指定视频 (默认全部视频) 在 [from, to) 范围内的聚合。
+// 已被保留策略清理的原始记录无法重建，对应水位线之前的小时聚合保持不变。
+func runRebuildAggregates(a *app, args []string) error {
+	fs := flag.NewFlagSet("rebuild-aggregates", flag.ExitOnError)
+	bvid := fs.String("bvid", "", "只重建该视频，默认重建所有存在进度记录的视频")
+	fromStr := fs.String("from", "", "开始时间 (YYYY-MM-DD 或 RFC3339)，默认从最早的记录开始")
+	toStr := fs.String("to", "", "结束时间 (YYYY-MM-DD 或 RFC3339，不含)，默认当前时间")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	loc := a.cfg.Aggregate.Location
+	from, to := time.Unix(0, 0), time.Now()
+	var err error
+	if *fromStr != "" {
+		if from, err = parseCommandTime(*fromStr, loc); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if *toStr != "" {
+		if to, err = parseCommandTime(*toStr, loc); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("--from must be before --to")
+	}
+
+	ctx := context.Background()
+	if *bvid == "" {
+		log.Printf("Rebuilding aggregates for all videos in [%s, %s)", from, to)
+		return a.aggregationService.RebuildAll(ctx, from, to)
+	}
+	video, err := a.videoCatalogService.GetVideo(ctx, "", *bvid)
+	if err != nil {
+		return err
+	}
+	log.Printf("Rebuilding aggregates for BVID %s (AID %d) in [%s, %s)", *bvid, video.AID, from, to)
+	return a.aggregationService.Rebuild(ctx, video.AID, from, to)
+}
+
+// runRelabelProgress 重新计算指定视频 (默认全部视频) 所有进度记录对的分类，并重建分类发生变化的范围内的聚合。
+func runRelabelProgress(a *app, args []string) error {
+	fs := flag.NewFlagSet("relabel-progress", flag.ExitOnError)
+	bvid := fs.String("bvid", "", "只重新分类该视频，默认处理所有存在进度记录的视频")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var changes map[int64]application.LabelChanges
+	if *bvid == "" {
+		var err error
+		if changes, err = a.labelService.RelabelAll(ctx); err != nil {
+			return err
+		}
+	} else {
+		video, err := a.videoCatalogService.GetVideo(ctx, "", *bvid)
+		if err != nil {
+			return err
+		}
+		c, err := a.labelService.Relabel(ctx, video.AID, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+		changes = map[int64]application.LabelChanges{video.AID: c}
+	}
+	for aid, c := range changes {
+		if c.Changed == 0 {
+			continue
+		}
+		log.Printf("Relabeled %d records of AID %d, rebuilding aggregates in [%s, %s]", c.Changed, aid, c.From, c.To)
+		if err := a.aggregationService.RebuildAround(ctx, aid, c.From, c.To); err != nil {
+			return fmt.Errorf("failed to rebuild aggregates for aid %d: %w", aid, err)
+		}
+	}
+	return nil
+}
+
+// parseCommandTime 解析命令行中的时间参数，日期按聚合时区的零点解释。
+func parseCommandTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// runExport 导出原始进度记录 (export progress) 或观看分段 (export segments)。
+func runExport(a *app, args []string) error {
+	if len(args) == 0 || (args[0] != "progress" && args[0] != "segments") {
+		return fmt.Errorf("usage: export progress|segments [flags]")
+	}
+	kind := args[0]
+	fs := flag.NewFlagSet("export "+kind, flag.ExitOnError)
+	aid := fs.String("aid", "", "只导出该视频 (AV 号)")
+	bvid := fs.String("bvid", "", "只导出该视频 (BV 号)；导出 segments 时 aid 和 bvid 必须提供一个")
+	fromStr := fs.String("from", "", "开始时间 (YYYY-MM-DD 或 RFC3339，含)")
+	toStr := fs.String("to", "", "结束时间 (YYYY-MM-DD 或 RFC3339，不含)")
+	intervalStr := fs.String("interval", "24h", "导出 segments 时的分段间隔：固定时长 (如 1h, 1d) 或日历单位 (day, week, month, quarter, year)")
+	cutoffHour := fs.Int("cutoff-hour", 0, "日历单位下每天开始的整点 (0-23)")
+	tz := fs.String("tz", "", "解释日期和划分分段使用的时区 (IANA 名称)，默认为 AGGREGATE_TIMEZONE")
+	attributionStr := fs.String("attribution", "", "导出 segments 时的观看时长归属方式 (start, proportional)，默认为 WATCH_TIME_ATTRIBUTION")
+	includeSuspicious := fs.Bool("include-suspicious", false, "导出 segments 时计入被标记为可疑的记录对")
+	formatStr := fs.String("format", "", "导出格式 (csv, ndjson, json)，默认根据 --out 的扩展名判断，否则为 ndjson")
+	out := fs.String("out", "", "输出文件，默认写到标准输出")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	format, err := resolveFormat(*formatStr, *out)
+	if err != nil {
+		return err
+	}
+	loc := a.cfg.Aggregate.Location
+	if *tz != "" {
+		if loc, err = time.LoadLocation(*tz); err != nil {
+			return fmt.Errorf("invalid --tz: %w", err)
+		}
+	}
+	var from, to time.Time
+	if *fromStr != "" {
+		if from, err = parseCommandTime(*fromStr, loc); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if *toStr != "" {
+		if to, err = parseCommandTime(*toStr, loc); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx := context.Background()
+	if kind == "progress" {
+		count, err := a.progressExchangeService.ExportProgress(ctx, *aid, *bvid, from, to, exchange.NewProgressEncoder(w, format))
+		log.Printf("Exported %d progress records", count)
+		return err
+	}
+
+	if *aid == "" && *bvid == "" {
+		return fmt.Errorf("--aid or --bvid is required for segments")
+	}
+	if from.IsZero() || to.IsZero() {
+		return fmt.Errorf("--from and --to are required for segments")
+	}
+	interval, err := application.ParseSegmentInterval(*intervalStr, *cutoffHour)
+	if err != nil {
+		return fmt.Errorf("invalid --interval: %w", err)
+	}
+	var attribution application.Attribution
+	if *attributionStr != "" {
+		if attribution, err = application.ParseAttribution(*attributionStr); err != nil {
+			return fmt.Errorf("invalid --attribution: %w", err)
+		}
+	}
+	count, err := a.progressExchangeService.ExportSegments(ctx, *aid, *bvid, from, to, interval, loc, attribution, *includeSuspicious,
+		exchange.NewSegmentEncoder(w, format))
+	log.Printf("Exported %d segments", count)
+	return err
+}
+
+// runImport 导入进度记录，已存在的记录会被跳过，可重复执行。
+func runImport(a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatStr := fs.String("format", "", "导入格式 (csv, ndjson, json)，默认根据 --in 的扩展名判断，否则为 ndjson")
+	in := fs.String("in", "", "输入文件，默认从标准输入读取")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := resolveFormat(*formatStr, *in)
+	if err != nil {
+		return err
+	}
+	r := io.Reader(os.Stdin)
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	result, err := a.progressExchangeService.ImportProgress(context.Background(), exchange.NewProgressDecoder(r, format))
+	log.Printf("Imported %d of %d records (%d duplicates skipped)", result.Inserted, result.Read, result.Duplicates)
+	return err
+}
+
+// resolveFormat 确定交换格式：优先使用 --format，其次根据文件扩展名判断，默认 NDJSON。
+func resolveFormat(formatStr, filename string) (exchange.Format, error) {
+	if formatStr != "" {
+		return exchange.ParseFormat(formatStr)
+	}
+	if filename != "" {
+		return exchange.FormatFromFilename(filename)
+	}
+	return exchange.FormatNDJSON, nil
+}
+
+// runReprocessArchive 从原始响应归档重新生成进度记录。
+func runReprocessArchive(a *app, args []string) error {
+	fs := flag.NewFlagSet("reprocess-archive", flag.ExitOnError)
+	bvid := fs.String("bvid", "", "只处理该视频，默认处理归档中的所有视频")
+	fromStr := fs.String("from", "", "开始时间 (YYYY-MM-DD 或 RFC3339，含)，默认不限")
+	toStr := fs.String("to", "", "结束时间 (YYYY-MM-DD 或 RFC3339，不含)，默认不限")
+	overwrite := fs.Bool("overwrite", false, "覆盖已存在记录的分P和播放进度 (解析规则变化后使用)，默认只补充缺失的记录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	loc := a.cfg.Aggregate.Location
+	var from, to time.Time
+	var err error
+	if *fromStr != "" {
+		if from, err = parseCommandTime(*fromStr, loc); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if *toStr != "" {
+		if to, err = parseCommandTime(*toStr, loc); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+	var aid int64
+	if *bvid != "" {
+		video, err := a.videoCatalogService.GetVideo(ctx, "", *bvid)
+		if err != nil {
+			return err
+		}
+		aid = video.AID
+	}
+	result, err := a.rawArchiveService.Reprocess(ctx, aid, from, to, *overwrite)
+	log.Printf("Reprocessed %d archived responses: %d with progress, %d records written, %d failed",
+		result.Responses, result.Progress, result.Written, result.Failed)
+	return err
+}
+
+// runMigrateUTC 把旧版本按 --from-tz 当地时间保存的时间列转换为 UTC，每个时间按当时的偏移转换。
+func runMigrateUTC(a *app, args []string) error {
+	fs := flag.NewFlagSet("migrate-utc", flag.ExitOnError)
+	fromTZ := fs.String("from-tz", "", "旧版本保存时间所用的时区 (升级前容器的 TZ，如 Asia/Shanghai)，必填")
+	dryRun := fs.Bool("dry-run", false, "只统计需要转换的行数，不写入")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fromTZ == "" {
+		return fmt.Errorf("--from-tz is required")
+	}
+	loc, err := time.LoadLocation(*fromTZ)
+	if err != nil {
+		return fmt.Errorf("invalid --from-tz: %w", err)
+	}
+	if a.db == nil {
+		return fmt.Errorf("migrate-utc requires a database and is not available in demo mode")
+	}
+
+	counts, err := persistence.MigrateLocalTimesToUTC(context.Background(), a.db, loc, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, table := range persistence.LocalTimeTableNames() {
+		log.Printf("%s: %d rows", table, counts[table])
+	}
+	if *dryRun {
+		log.Printf("Dry run: nothing was written")
+		return nil
+	}
+	log.Printf("Converted local times in %s to UTC; run rebuild-aggregates to recompute the aggregates", loc)
+	return nil
+}
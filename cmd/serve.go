@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/scheduler"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest"
+)
+
+// runServer 启动 HTTP 服务器和定时任务调度器，直到收到中断信号后优雅退出。
+func runServer(a *app) {
+	cfg := a.cfg
+
+	// --- 设置 Gin 和路由 (使用新的 rest 包) ---
+	router := rest.SetupRouter(a.db, cfg.GinMode, rest.Services{
+		VideoAnalytics:    a.videoAnalyticsService,
+		ProgressExchange:  a.progressExchangeService,
+		ProgressRecord:    a.progressRecordService,
+		Coverage:          a.coverageService,
+		Session:           a.sessionService,
+		Forecast:          a.forecastService,
+		Goal:              a.goalService,
+		Heatmap:           a.heatmapService,
+		CombinedAnalytics: a.combinedAnalyticsService,
+		Comparison:        a.comparisonService,
+		Leaderboard:       a.leaderboardService,
+		WatchTime:         a.watchTimeService,
+		Simulation:        a.simulationService,
+		CoursePlan:        a.coursePlanService,
+		// other services
+	})
+
+	// --- 初始化并启动调度器 ---
+	appScheduler := scheduler.NewScheduler()
+
+	// 创建获取单个 BVID 视频进度的函数
+	createFetchVideoProgressJobForBVID := func(bvid string) func() {
+		return func() {
+			if bvid == "" {
+				log.Println("Error: Empty BVID provided. Skipping progress fetch job.")
+				return
+			}
+
+			jobName := fmt.Sprintf("FetchVideoProgress(BVID: %s)", bvid)
+			log.Printf("Cron job starting: %s", jobName)
+			ctx := context.Background()
+
+			// 1. 刷新视频目录，并获取视频的 AID 和第一个 CID
+			// 刷新失败 (例如 Bilibili 不可达) 时使用目录中已保存的分P列表继续获取进度
+			video, err := a.videoCatalogService.RefreshVideo(ctx, "", bvid)
+			if err != nil {
+				log.Printf("Error refreshing video catalog for BVID %s in job '%s': %v. Falling back to stored catalog.", bvid, jobName, err)
+				video, err = a.videoCatalogService.GetVideo(ctx, "", bvid)
+				if err != nil {
+					log.Printf("Error reading video catalog for BVID %s in job '%s': %v. Skipping progress fetch.", bvid, jobName, err)
+					return
+				}
+			}
+			pageHistory, err := a.videoCatalogService.GetPageHistory(ctx, video.AID)
+			if err != nil {
+				log.Printf("Error reading page history for BVID %s in job '%s': %v. Skipping progress fetch.", bvid, jobName, err)
+				return
+			}
+			latestPages, ok := pageHistory.Latest()
+			if !ok || len(latestPages.Pages) == 0 {
+				log.Printf("No pages found for video BVID %s in job '%s'. Skipping progress fetch.", bvid, jobName)
+				return
+			}
+			targetCID := latestPages.Pages[0].Cid
+			log.Printf("Determined targetCID: %d for BVID: %s", targetCID, bvid)
+
+			// 2. 获取并保存进度
+			if err := a.videoProgressService.FetchAndSaveVideoProgress(ctx, "", bvid, strconv.FormatInt(targetCID, 10)); err != nil {
+				log.Printf("Error executing FetchAndSaveVideoProgress for BVID '%s', CID %d in job '%s': %v", bvid, targetCID, jobName, err)
+			}
+			log.Printf("Cron job finished: %s (processed BVID: %s, CID: %d)", jobName, bvid, targetCID)
+		}
+	}
+
+	// 为每个 BVID 创建单独的定时任务
+	if len(cfg.Bilibili.TargetBVIDs) > 0 {
+		for _, bvid := range cfg.Bilibili.TargetBVIDs {
+			jobName := fmt.Sprintf("FetchVideoProgress_BVID_%s", bvid)
+			if err := appScheduler.ScheduleJob(jobName, cfg.Scheduler.Cron, createFetchVideoProgressJobForBVID(bvid)); err != nil {
+				log.Printf("Failed to schedule job '%s' for BVID '%s': %v", jobName, bvid, err)
+			}
+		}
+	}
+
+	// 原始记录保留策略：超过保留期的记录确保已汇总为聚合后删除
+	if cfg.Retention.RawDays > 0 {
+		retentionService := application.NewRetentionService(a.videoProgressRepo, a.aggregateRepo, a.aggregationService,
+			time.Duration(cfg.Retention.RawDays)*24*time.Hour)
+		err := appScheduler.ScheduleJob("ProgressRetention", cfg.Retention.Cron, func() {
+			log.Printf("Cron job starting: ProgressRetention (raw days: %d)", cfg.Retention.RawDays)
+			if err := retentionService.Run(context.Background(), time.Now()); err != nil {
+				log.Printf("Error executing ProgressRetention: %v", err)
+			}
+			log.Println("Cron job finished: ProgressRetention")
+		})
+		if err != nil {
+			log.Printf("Failed to schedule job 'ProgressRetention': %v", err)
+		}
+	}
+
+	go appScheduler.Start() // 在单独的 goroutine 中启动调度器
+
+	// --- 启动 Gin 服务器 ---
+	serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
+	srv := &http.Server{
+		Addr:    serverAddr,
+		Handler: router, // 使用 rest.SetupRouter 返回的 router
+	}
+
+	go func() {
+		// 服务连接
+		// 使用 http.Server 的方式是为了支持优雅停机，这是比直接使用 router.Run() 更健壮的做法
+		log.Printf("Starting server on %s", serverAddr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	// --- 等待中断信号以优雅关闭服务器和调度器 ---
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+
+	// 停止调度器
+	schedulerCtx := appScheduler.Stop()
+	<-schedulerCtx.Done() // 等待调度器任务完成
+	log.Println("Scheduler stopped.")
+
+	// context 用于通知服务器它有 5 秒钟时间来处理当前正在处理的请求
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatal("Server forced to shutdown: ", err)
+	}
+
+	log.Println("Server exiting")
+}
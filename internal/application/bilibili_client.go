@@ -2,6 +2,8 @@ package application
 
 import (
 	"context"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 )
 
 // BilibiliClient 定义了应用层与 Bilibili API 交互所需的操作。
@@ -22,3 +24,16 @@ type BilibiliClient interface {
 
 	// TODO: 未来可以添加更多 Bilibili API 方法
 }
+
+// RawResponseArchiver 接收 Bilibili API 的原始响应用于归档，由 RawArchiveService 实现。
+// 归档失败不应影响正常的请求流程，因此没有返回值。
+type RawResponseArchiver interface {
+	ArchiveRawResponse(ctx context.Context, response *model.RawResponse)
+}
+
+// RawProgressParser 从归档的原始进度响应中解析出进度 DTO。
+// 基础设施层需要实现此接口，解析规则应与 BilibiliClient.GetVideoProgress 一致。
+type RawProgressParser interface {
+	// ParseVideoProgress 解析进度接口的响应体，没有有效进度时返回 nil, nil。
+	ParseVideoProgress(body []byte) (*VideoProgressDTO, error)
+}
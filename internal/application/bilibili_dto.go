@@ -1,11 +1,14 @@
 package application
 
+import "time"
+
 // VideoProgressDTO 应用层关心的视频进度数据
 type VideoProgressDTO struct {
-	AID          int64  `json:"aid"`
-	BVID         string `json:"bvid"`
-	LastPlayTime int64  `json:"last_play_time"` // 观看进度，单位毫秒
-	LastPlayCid  int64  `json:"last_play_cid"`  // 上次播放的视频分 P ID
+	AID          int64     `json:"aid"`
+	BVID         string    `json:"bvid"`
+	LastPlayTime int64     `json:"last_play_time"` // 观看进度，单位毫秒
+	LastPlayCid  int64     `json:"last_play_cid"`  // 上次播放的视频分 P ID
+	FetchedAt    time.Time `json:"fetched_at"`     // 收到响应的时间，用作进度记录的记录时间
 	// 可以根据需要从 bilibili.VideoProgressData 添加更多字段
 }
 
@@ -26,6 +29,9 @@ type VideoViewDTO struct {
 	Pubdate   int64              `json:"pubdate"`  // 发布时间戳
 	Duration  int64              `json:"duration"` // 总时长(秒)
 	OwnerName string             `json:"owner_name"`
+	OwnerMid  int64              `json:"owner_mid"`
+	Tid       int                `json:"tid"`
+	Tname     string             `json:"tname"`
 	Pages     []VideoViewPageDTO `json:"pages"` // 新增：分P信息列表
 	// 可以根据需要从 bilibili.VideoViewData 添加更多字段
 }
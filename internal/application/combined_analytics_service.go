@@ -0,0 +1,153 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// maxConcurrentVideoAnalytics 汇总多个视频时同时计算的视频数，避免占满数据库连接。
+const maxConcurrentVideoAnalytics = 4
+
+// VideoWatchTime 一个视频在分段 (或整个范围) 内的观看时长。
+type VideoWatchTime struct {
+	Video     *model.Video
+	Watched   time.Duration // 观看时长，包含重看时长
+	Rewatched time.Duration
+	Skipped   time.Duration
+}
+
+// CombinedSegment 所有视频在一个分段内的观看时长，Videos 与 CombinedAnalyticsResult.Videos 顺序一致。
+type CombinedSegment struct {
+	SegmentStartTime time.Time
+	SegmentEndTime   time.Time
+	Watched          time.Duration
+	Rewatched        time.Duration
+	Skipped          time.Duration
+	Videos           []VideoWatchTime
+}
+
+// CombinedAnalyticsResult 多个视频的观看分段汇总结果。
+type CombinedAnalyticsResult struct {
+	Videos         []*model.Video
+	Segments       []CombinedSegment
+	VideoTotals    []VideoWatchTime // 每个视频在整个范围内的观看时长，与 Videos 顺序一致
+	TotalWatched   time.Duration
+	TotalRewatched time.Duration
+	TotalSkipped   time.Duration
+}
+
+// CombinedAnalyticsService 应用服务，并发计算多个视频的观看分段并按分段汇总，回答 "今天一共看了多久"。
+type CombinedAnalyticsService struct {
+	catalog   *VideoCatalogService
+	analytics VideoAnalyticsService
+}
+
+// NewCombinedAnalyticsService 创建 CombinedAnalyticsService 实例。
+func NewCombinedAnalyticsService(catalog *VideoCatalogService, analytics VideoAnalyticsService) *CombinedAnalyticsService {
+	return &CombinedAnalyticsService{catalog: catalog, analytics: analytics}
+}
+
+// GetCombinedSegments 计算 bvids 指定的视频 (为空时为目录中的所有视频) 的观看分段并按分段汇总。
+// 其余参数与 VideoAnalyticsService.GetWatchedSegments 相同；所有视频使用相同的分段，因此可以按下标对齐。
+// 每个视频的计算并发进行 (最多 maxConcurrentVideoAnalytics 个)，任一视频失败时返回错误。
+func (s *CombinedAnalyticsService) GetCombinedSegments(ctx context.Context,
+	bvids []string,
+	start, end time.Time,
+	interval SegmentInterval,
+	loc *time.Location,
+	attribution Attribution,
+	includeSuspicious bool,
+) (*CombinedAnalyticsResult, error) {
+	videos, err := s.resolveVideos(ctx, bvids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VideoAnalyticsResult, len(videos))
+	errs := make([]error, len(videos))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, maxConcurrentVideoAnalytics)
+	var wg sync.WaitGroup
+	for i, video := range videos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			results[i], errs[i] = s.analytics.GetWatchedSegments(ctx, strconv.FormatInt(video.AID, 10), "",
+				start, end, interval, loc, attribution, includeSuspicious)
+			if errs[i] != nil {
+				cancel() // 结果已无法使用，尽快结束其余视频的计算
+			}
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("计算视频 %s 的观看分段失败: %w", videos[i].BVID, err)
+		}
+	}
+	return combineResults(videos, results), nil
+}
+
+// resolveVideos 按 BV 号查找视频 (去重并保持请求中的顺序)，bvids 为空时返回目录中的所有视频。
+func (s *CombinedAnalyticsService) resolveVideos(ctx context.Context, bvids []string) ([]*model.Video, error) {
+	if len(bvids) == 0 {
+		videos, err := s.catalog.ListVideos(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取视频列表失败: %w", err)
+		}
+		return videos, nil
+	}
+	videos := make([]*model.Video, 0, len(bvids))
+	seen := make(map[int64]bool, len(bvids))
+	for _, bvid := range bvids {
+		video, err := s.catalog.GetVideo(ctx, "", bvid)
+		if err != nil {
+			return nil, fmt.Errorf("获取视频 %s 的信息失败: %w", bvid, err)
+		}
+		if !seen[video.AID] {
+			seen[video.AID] = true
+			videos = append(videos, video)
+		}
+	}
+	return videos, nil
+}
+
+// combineResults 按分段下标合并每个视频的结果。
+func combineResults(videos []*model.Video, results []VideoAnalyticsResult) *CombinedAnalyticsResult {
+	combined := &CombinedAnalyticsResult{Videos: videos, Segments: []CombinedSegment{}, VideoTotals: make([]VideoWatchTime, len(videos))}
+	for i, result := range results {
+		for j, seg := range result.Segments {
+			if j == len(combined.Segments) {
+				combined.Segments = append(combined.Segments, CombinedSegment{
+					SegmentStartTime: seg.SegmentStartTime,
+					SegmentEndTime:   seg.SegmentEndTime,
+					Videos:           make([]VideoWatchTime, len(videos)),
+				})
+			}
+			segment := &combined.Segments[j]
+			segment.Watched += seg.WatchedDuration
+			segment.Rewatched += seg.RewatchedDuration
+			segment.Skipped += seg.SkippedDuration
+			segment.Videos[i] = VideoWatchTime{Video: videos[i], Watched: seg.WatchedDuration,
+				Rewatched: seg.RewatchedDuration, Skipped: seg.SkippedDuration}
+		}
+		combined.VideoTotals[i] = VideoWatchTime{Video: videos[i], Watched: result.TotalWatchedDuration,
+			Rewatched: result.TotalRewatchedDuration, Skipped: result.TotalSkippedDuration}
+		combined.TotalWatched += result.TotalWatchedDuration
+		combined.TotalRewatched += result.TotalRewatchedDuration
+		combined.TotalSkipped += result.TotalSkippedDuration
+	}
+	return combined
+}
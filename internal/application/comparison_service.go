@@ -0,0 +1,164 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ComparisonOffset 对比期相对于当前期的偏移方式。
+type ComparisonOffset string
+
+const (
+	ComparisonPrevious ComparisonOffset = "previous" // 紧邻当前期之前、长度相同的一期
+	ComparisonYear     ComparisonOffset = "year"     // 去年同期
+)
+
+// ErrInvalidComparison 表示对比请求的参数无效。
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// ParseComparisonOffset 解析对比期的偏移方式，为空时为 ComparisonPrevious。
+func ParseComparisonOffset(name string) (ComparisonOffset, error) {
+	switch offset := ComparisonOffset(name); offset {
+	case "":
+		return ComparisonPrevious, nil
+	case ComparisonPrevious, ComparisonYear:
+		return offset, nil
+	default:
+		return "", fmt.Errorf("%w: unknown comparison offset %q", ErrInvalidComparison, name)
+	}
+}
+
+// WatchTimeChange 当前期与对比期的观看时长。
+type WatchTimeChange struct {
+	Current  time.Duration
+	Previous time.Duration
+}
+
+// Delta 返回当前期比对比期多出的观看时长 (减少时为负数)。
+func (c WatchTimeChange) Delta() time.Duration {
+	return c.Current - c.Previous
+}
+
+// Percent 返回变化的百分比；对比期没有观看时百分比没有意义，返回 false。
+func (c WatchTimeChange) Percent() (float64, bool) {
+	if c.Previous <= 0 {
+		return 0, false
+	}
+	return float64(c.Delta()) / float64(c.Previous) * 100, true
+}
+
+// ComparisonBucket 按下标对齐的一对分段。两期的分段数不同时 (如去年同期跨越闰日)，缺少的一侧时间为零值、时长为 0。
+type ComparisonBucket struct {
+	CurrentStart, CurrentEnd   time.Time
+	PreviousStart, PreviousEnd time.Time
+	Watched                    WatchTimeChange // 观看时长，包含重看时长
+}
+
+// PeriodComparison 当前期与对比期的观看时长对比结果。
+type PeriodComparison struct {
+	Videos                     []*model.Video
+	Offset                     ComparisonOffset
+	CurrentStart, CurrentEnd   time.Time // 当前期的实际范围 (日历单位的间隔会扩展到完整的周期)
+	PreviousStart, PreviousEnd time.Time
+	Buckets                    []ComparisonBucket
+	Total                      WatchTimeChange
+}
+
+// ComparisonService 应用服务，对比两个时期的观看时长，回答 "这周是不是比上周看得多"。
+type ComparisonService struct {
+	combined *CombinedAnalyticsService
+}
+
+// NewComparisonService 创建 ComparisonService 实例。
+func NewComparisonService(combined *CombinedAnalyticsService) *ComparisonService {
+	return &ComparisonService{combined: combined}
+}
+
+// Compare 计算 [start, end) 与按 offset 得到的对比期的观看分段，并按分段下标对齐。
+// 视频的选择及其余参数与 CombinedAnalyticsService.GetCombinedSegments 相同。
+// ComparisonPrevious 的对比期紧邻当前期之前：日历单位的间隔向前移动相同个数的周期，整天的范围按当地日历日移动，
+// 其余按相同时长移动；ComparisonYear 的对比期为前一年的同一时间 (日历单位的间隔同样扩展到完整的周期)。
+func (s *ComparisonService) Compare(ctx context.Context,
+	bvids []string,
+	start, end time.Time,
+	interval SegmentInterval,
+	loc *time.Location,
+	attribution Attribution,
+	includeSuspicious bool,
+	offset ComparisonOffset,
+) (*PeriodComparison, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidComparison)
+	}
+	current, err := s.combined.GetCombinedSegments(ctx, bvids, start, end, interval, loc, attribution, includeSuspicious)
+	if err != nil {
+		return nil, fmt.Errorf("计算当前期的观看分段失败: %w", err)
+	}
+	comparison := &PeriodComparison{Videos: current.Videos, Offset: offset, CurrentStart: start, CurrentEnd: end}
+	if n := len(current.Segments); n > 0 {
+		comparison.CurrentStart = current.Segments[0].SegmentStartTime
+		comparison.CurrentEnd = current.Segments[n-1].SegmentEndTime
+	}
+
+	prevStart, prevEnd, err := comparisonPeriod(comparison.CurrentStart, comparison.CurrentEnd, len(current.Segments), interval, offset)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.combined.GetCombinedSegments(ctx, bvids, prevStart, prevEnd, interval, loc, attribution, includeSuspicious)
+	if err != nil {
+		return nil, fmt.Errorf("计算对比期的观看分段失败: %w", err)
+	}
+	comparison.PreviousStart, comparison.PreviousEnd = prevStart, prevEnd
+	if n := len(previous.Segments); n > 0 {
+		comparison.PreviousStart = previous.Segments[0].SegmentStartTime
+		comparison.PreviousEnd = previous.Segments[n-1].SegmentEndTime
+	}
+
+	comparison.Buckets = make([]ComparisonBucket, max(len(current.Segments), len(previous.Segments)))
+	for i, seg := range current.Segments {
+		bucket := &comparison.Buckets[i]
+		bucket.CurrentStart, bucket.CurrentEnd = seg.SegmentStartTime, seg.SegmentEndTime
+		bucket.Watched.Current = seg.Watched
+	}
+	for i, seg := range previous.Segments {
+		bucket := &comparison.Buckets[i]
+		bucket.PreviousStart, bucket.PreviousEnd = seg.SegmentStartTime, seg.SegmentEndTime
+		bucket.Watched.Previous = seg.Watched
+	}
+	comparison.Total = WatchTimeChange{Current: current.TotalWatched, Previous: previous.TotalWatched}
+	return comparison, nil
+}
+
+// comparisonPeriod 返回当前期 [start, end) (包含 segments 个分段) 的对比期。
+func comparisonPeriod(start, end time.Time, segments int, interval SegmentInterval, offset ComparisonOffset) (time.Time, time.Time, error) {
+	switch offset {
+	case ComparisonYear:
+		return start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0), nil
+	case ComparisonPrevious:
+		if interval.IsCalendar() && segments > 0 {
+			return interval.Unit.Add(start, -segments), start, nil
+		}
+		if days := localDaysBetween(start, end); days > 0 {
+			return start.AddDate(0, 0, -days), start, nil // 跨越夏令时切换时保持当地时刻不变
+		}
+		return start.Add(-end.Sub(start)), start, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: unknown comparison offset %q", ErrInvalidComparison, offset)
+	}
+}
+
+// localDaysBetween 返回 start 和 end 之间相差的当地日历日数 (按 start 的时区)；两者的当地时刻不同 (范围不是整天) 时返回 0。
+func localDaysBetween(start, end time.Time) int {
+	end = end.In(start.Location())
+	if start.Hour() != end.Hour() || start.Minute() != end.Minute() || start.Second() != end.Second() ||
+		start.Nanosecond() != end.Nanosecond() {
+		return 0
+	}
+	sy, sm, sd := start.Date()
+	ey, em, ed := end.Date()
+	return int(time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC).Sub(time.Date(sy, sm, sd, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+}
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+func TestComparisonPeriod(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	at := func(value string) time.Time {
+		return mustParseTime(t, value).In(newYork)
+	}
+	tests := []struct {
+		name               string
+		start, end         string
+		segments           int
+		interval           SegmentInterval
+		offset             ComparisonOffset
+		wantStart, wantEnd string
+	}{
+		{
+			name: "previous weeks", start: "2025-03-10T04:00:00-04:00", end: "2025-03-24T04:00:00-04:00", segments: 2,
+			interval: CalendarInterval(service.CalendarWeek, 4), offset: ComparisonPrevious,
+			wantStart: "2025-02-24T04:00:00-05:00", wantEnd: "2025-03-10T04:00:00-04:00",
+		},
+		{
+			name: "previous month is shorter", start: "2025-03-01T00:00:00-05:00", end: "2025-04-01T00:00:00-04:00", segments: 1,
+			interval: CalendarInterval(service.CalendarMonth, 0), offset: ComparisonPrevious,
+			wantStart: "2025-02-01T00:00:00-05:00", wantEnd: "2025-03-01T00:00:00-05:00",
+		},
+		{
+			name: "previous months move by the number of segments", start: "2025-03-01T00:00:00-05:00", end: "2025-05-01T00:00:00-04:00", segments: 2,
+			interval: CalendarInterval(service.CalendarMonth, 0), offset: ComparisonPrevious,
+			wantStart: "2025-01-01T00:00:00-05:00", wantEnd: "2025-03-01T00:00:00-05:00",
+		},
+		{
+			name: "previous quarter", start: "2025-04-01T04:00:00-04:00", end: "2025-07-01T04:00:00-04:00", segments: 1,
+			interval: CalendarInterval(service.CalendarQuarter, 4), offset: ComparisonPrevious,
+			wantStart: "2025-01-01T04:00:00-05:00", wantEnd: "2025-04-01T04:00:00-04:00",
+		},
+		{
+			name: "calendar interval without segments moves by local days", start: "2025-03-10T00:00:00-04:00", end: "2025-03-17T00:00:00-04:00",
+			interval: CalendarInterval(service.CalendarWeek, 0), offset: ComparisonPrevious,
+			wantStart: "2025-03-03T00:00:00-05:00", wantEnd: "2025-03-10T00:00:00-04:00",
+		},
+		{
+			name: "whole days across a DST change keep the local time", start: "2025-03-10T00:00:00-04:00", end: "2025-03-17T00:00:00-04:00", segments: 7,
+			interval: FixedInterval(24 * time.Hour), offset: ComparisonPrevious,
+			wantStart: "2025-03-03T00:00:00-05:00", wantEnd: "2025-03-10T00:00:00-04:00",
+		},
+		{
+			name: "partial days move by the same duration", start: "2025-03-10T10:00:00-04:00", end: "2025-03-10T13:30:00-04:00", segments: 7,
+			interval: FixedInterval(30 * time.Minute), offset: ComparisonPrevious,
+			wantStart: "2025-03-10T06:30:00-04:00", wantEnd: "2025-03-10T10:00:00-04:00",
+		},
+		{
+			name: "local day of 23 hours", start: "2025-03-08T12:00:00-05:00", end: "2025-03-09T12:00:00-04:00", segments: 1,
+			interval: FixedInterval(23 * time.Hour), offset: ComparisonPrevious,
+			wantStart: "2025-03-07T12:00:00-05:00", wantEnd: "2025-03-08T12:00:00-05:00",
+		},
+		{
+			name: "24 hours across a DST change move by the same duration", start: "2025-03-08T12:00:00-05:00", end: "2025-03-09T13:00:00-04:00", segments: 1,
+			interval: FixedInterval(24 * time.Hour), offset: ComparisonPrevious,
+			wantStart: "2025-03-07T12:00:00-05:00", wantEnd: "2025-03-08T12:00:00-05:00",
+		},
+		{
+			name: "same weeks a year earlier", start: "2025-03-10T04:00:00-04:00", end: "2025-03-24T04:00:00-04:00", segments: 2,
+			interval: CalendarInterval(service.CalendarWeek, 4), offset: ComparisonYear,
+			wantStart: "2024-03-10T04:00:00-04:00", wantEnd: "2024-03-24T04:00:00-04:00",
+		},
+		{
+			name: "month a year earlier in a leap year", start: "2025-02-01T00:00:00-05:00", end: "2025-03-01T00:00:00-05:00", segments: 1,
+			interval: CalendarInterval(service.CalendarMonth, 0), offset: ComparisonYear,
+			wantStart: "2024-02-01T00:00:00-05:00", wantEnd: "2024-03-01T00:00:00-05:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := comparisonPeriod(at(tt.start), at(tt.end), tt.segments, tt.interval, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !start.Equal(at(tt.wantStart)) || !end.Equal(at(tt.wantEnd)) {
+				t.Errorf("comparisonPeriod = [%s, %s), want [%s, %s)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	if _, _, err := comparisonPeriod(at("2025-03-10T00:00:00-04:00"), at("2025-03-11T00:00:00-04:00"), 1,
+		FixedInterval(time.Hour), ComparisonOffset("week")); !errors.Is(err, ErrInvalidComparison) {
+		t.Errorf("unknown offset: err = %v, want ErrInvalidComparison", err)
+	}
+}
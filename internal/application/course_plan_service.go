@@ -0,0 +1,307 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// ErrInvalidCoursePlan 表示创建课程计划的参数无效。
+var ErrInvalidCoursePlan = errors.New("invalid course plan")
+
+// MaxCoursePlanItems 一个课程计划最多包含的分P数。
+const MaxCoursePlanItems = 1000
+
+// CoursePlanItemInput 课程计划中的一项：一个视频的某个分P (按 CID 或分P序号指定)，两者都为 0 时为该视频的所有分P。
+type CoursePlanItemInput struct {
+	BVID string
+	CID  int64
+	Page int
+}
+
+// CoursePlanInput 创建课程计划的参数。
+type CoursePlanInput struct {
+	Name       string
+	Items      []CoursePlanItemInput // 按观看顺序排列
+	StartDate  time.Time             // 计划开始的日期，零值表示今天
+	TargetDate time.Time             // 目标完成日期 (含)
+}
+
+// CoursePlanPart 计划中的一个分P及其当前的观看覆盖情况。
+type CoursePlanPart struct {
+	Item    model.CoursePlanItem
+	Video   *model.Video
+	Page    model.VideoPage // 最新分P列表中的分P
+	Missing bool            // 分P已从视频中移除，不参与排期和统计
+	// 截至现在看过的位置区间和时长
+	Covered        []service.PositionRange
+	CoveredSeconds int64
+}
+
+// CoursePlanAssignment 一个学习日中要看的一个分P的一部分。
+type CoursePlanAssignment struct {
+	Part *CoursePlanPart
+	service.SyllabusAssignment
+	WatchedSeconds int64 // 要看的区间中已经看过的时长 (排期按今天开始时的进度，即今天看过的部分)
+}
+
+// CoursePlanDay 一个学习日的任务。
+type CoursePlanDay struct {
+	Date           time.Time
+	Assignments    []CoursePlanAssignment
+	Seconds        int64
+	WatchedSeconds int64
+}
+
+// CoursePlanStatus 课程计划截至当前学习日的进度和重新排期的课程表。
+type CoursePlanStatus struct {
+	Plan           *model.CoursePlan
+	Parts          []CoursePlanPart // 与 Plan.Items 一一对应
+	Today          time.Time        // 当前学习日的日期
+	TotalSeconds   int64            // 所有分P的总时长
+	CoveredSeconds int64            // 截至现在看过的时长
+	// 按原计划 (从开始日期起把整个课程平均分配到每一天) 截至今天结束应看完的时长
+	ExpectedSeconds int64
+	DaysLeft        int // 包括今天在内距离目标完成日期还剩的学习日，已过目标日期时为 0
+	// 从今天 (计划尚未开始时为开始日期) 起的课程表，按今天开始时还没看过的内容重新排期；看完后为空
+	Syllabus []CoursePlanDay
+	// 计划中的视频早于该时间的原始记录已被清理，这部分观看不计入进度 (取所有视频中最晚的水位线，见 VideoCoverage.PrunedBefore)
+	PrunedBefore time.Time
+}
+
+// Started 判断计划是否已经开始。
+func (s *CoursePlanStatus) Started() bool {
+	return !s.Today.Before(s.Plan.StartDate)
+}
+
+// Completed 判断计划中的所有分P是否都已看完。
+func (s *CoursePlanStatus) Completed() bool {
+	return s.CoveredSeconds >= s.TotalSeconds
+}
+
+// ScheduleOffsetSeconds 返回实际进度与原计划的差距：正数为超前的秒数，负数为落后的秒数。
+// 原计划按今天结束时应看完的时长计算，看完今天的任务后不再落后 (除非之前已经落后)。
+func (s *CoursePlanStatus) ScheduleOffsetSeconds() int64 {
+	return s.CoveredSeconds - s.ExpectedSeconds
+}
+
+// TodayAssignment 返回今天的任务，计划尚未开始或已看完时返回 nil。
+func (s *CoursePlanStatus) TodayAssignment() *CoursePlanDay {
+	if len(s.Syllabus) == 0 || !s.Syllabus[0].Date.Equal(s.Today) {
+		return nil
+	}
+	return &s.Syllabus[0]
+}
+
+// CoursePlanService 应用服务，管理课程计划，根据分P时长和实际观看进度按学习日排出课程表。
+//
+// 观看进度取自 CoverageService (看过的位置区间)：课程表每天按当天开始时还没看过的内容重新排期，
+// 把剩余内容平均分配到距离目标日期的每一天；当天看过的部分计入当天的任务。
+type CoursePlanService struct {
+	planRepo repository.CoursePlanRepository
+	catalog  *VideoCatalogService
+	coverage *CoverageService
+	clock    service.StudyDayClock
+}
+
+// NewCoursePlanService 创建 CoursePlanService 实例。clock 决定学习日的划分，与学习目标一致。
+func NewCoursePlanService(
+	planRepo repository.CoursePlanRepository,
+	catalog *VideoCatalogService,
+	coverage *CoverageService,
+	clock service.StudyDayClock,
+) *CoursePlanService {
+	return &CoursePlanService{planRepo: planRepo, catalog: catalog, coverage: coverage, clock: clock}
+}
+
+// Clock 返回学习日的划分方式。
+func (s *CoursePlanService) Clock() service.StudyDayClock {
+	return s.clock
+}
+
+// CreatePlan 校验参数，按视频的最新分P列表展开分P并保存一个新计划。
+func (s *CoursePlanService) CreatePlan(ctx context.Context, input CoursePlanInput, now time.Time) (*model.CoursePlan, error) {
+	plan := &model.CoursePlan{Name: input.Name, StartDate: input.StartDate, TargetDate: input.TargetDate}
+	if plan.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCoursePlan)
+	}
+	if plan.StartDate.IsZero() {
+		plan.StartDate = s.clock.Date(now)
+	}
+	if plan.TargetDate.IsZero() {
+		return nil, fmt.Errorf("%w: target date is required", ErrInvalidCoursePlan)
+	}
+	if plan.TargetDate.Before(plan.StartDate) {
+		return nil, fmt.Errorf("%w: target date is before start date", ErrInvalidCoursePlan)
+	}
+	if len(input.Items) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", ErrInvalidCoursePlan)
+	}
+
+	seen := make(map[int64]bool)
+	for i, item := range input.Items {
+		video, err := s.catalog.GetVideo(ctx, "", item.BVID)
+		if err != nil {
+			return nil, fmt.Errorf("获取视频 %s 信息失败: %w", item.BVID, err)
+		}
+		pages, err := s.latestPages(ctx, video)
+		if err != nil {
+			return nil, err
+		}
+		selected, err := selectPlanPages(pages, item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: items[%d]: %v", ErrInvalidCoursePlan, i, err)
+		}
+		for _, page := range selected {
+			if seen[page.Cid] {
+				return nil, fmt.Errorf("%w: part %d of %s appears more than once", ErrInvalidCoursePlan, page.Page, video.BVID)
+			}
+			seen[page.Cid] = true
+			plan.Items = append(plan.Items, model.CoursePlanItem{AID: video.AID, BVID: video.BVID, CID: page.Cid})
+		}
+	}
+	if len(plan.Items) > MaxCoursePlanItems {
+		return nil, fmt.Errorf("%w: plan must not contain more than %d parts", ErrInvalidCoursePlan, MaxCoursePlanItems)
+	}
+
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ListPlans 返回所有计划。
+func (s *CoursePlanService) ListPlans(ctx context.Context) ([]*model.CoursePlan, error) {
+	return s.planRepo.ListAll(ctx)
+}
+
+// DeletePlan 删除计划，不存在时返回 repository.ErrCoursePlanNotFound。
+func (s *CoursePlanService) DeletePlan(ctx context.Context, id uint) error {
+	return s.planRepo.Delete(ctx, id)
+}
+
+// GetStatus 评估计划截至 now 的进度并重新排出课程表，不存在时返回 repository.ErrCoursePlanNotFound。
+func (s *CoursePlanService) GetStatus(ctx context.Context, id uint, now time.Time) (*CoursePlanStatus, error) {
+	plan, err := s.planRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	today := s.clock.Date(now)
+	status := &CoursePlanStatus{
+		Plan:     plan,
+		Parts:    make([]CoursePlanPart, len(plan.Items)),
+		Today:    today,
+		DaysLeft: max(service.DaysBetween(today, plan.TargetDate)+1, 0),
+		Syllabus: []CoursePlanDay{},
+	}
+
+	// 每个视频计算两次覆盖：截至今天开始时 (用于排期) 和截至现在 (用于统计进度)
+	todayStart := s.clock.Start(today)
+	before := make(map[int64]service.PageCoverage)
+	current := make(map[int64]service.PageCoverage)
+	videos := make(map[string]*model.Video)
+	for _, item := range plan.Items {
+		if _, ok := videos[item.BVID]; ok {
+			continue
+		}
+		coverage, err := s.coverage.GetCoverage(ctx, item.BVID, time.Time{}, todayStart)
+		if err != nil {
+			return nil, fmt.Errorf("计算视频 %s 的观看覆盖失败: %w", item.BVID, err)
+		}
+		for _, page := range coverage.Pages {
+			before[page.Page.Cid] = page
+		}
+		if coverage, err = s.coverage.GetCoverage(ctx, item.BVID, time.Time{}, time.Time{}); err != nil {
+			return nil, fmt.Errorf("计算视频 %s 的观看覆盖失败: %w", item.BVID, err)
+		}
+		for _, page := range coverage.Pages {
+			current[page.Page.Cid] = page
+		}
+		videos[item.BVID] = coverage.Video
+		if coverage.PrunedBefore.After(status.PrunedBefore) {
+			status.PrunedBefore = coverage.PrunedBefore
+		}
+	}
+
+	remaining := make([]service.SyllabusPart, 0, len(plan.Items))
+	full := make([]service.SyllabusPart, 0, len(plan.Items))
+	partIndex := make([]int, 0, len(plan.Items)) // 排期输入的下标对应的 Parts 下标
+	for i, item := range plan.Items {
+		part := &status.Parts[i]
+		part.Item = item
+		part.Video = videos[item.BVID]
+		coverage, ok := current[item.CID]
+		if !ok {
+			part.Missing = true
+			log.Printf("课程计划 %d 的分P %d (%s) 已不在视频中，不参与排期", plan.ID, item.CID, item.BVID)
+			continue
+		}
+		part.Page = coverage.Page
+		part.Covered = coverage.Covered
+		part.CoveredSeconds = coverage.CoveredSeconds
+		status.TotalSeconds += coverage.Page.Duration
+		status.CoveredSeconds += coverage.CoveredSeconds
+
+		remaining = append(remaining, service.SyllabusPart{CID: item.CID, Gaps: before[item.CID].Gaps})
+		full = append(full, service.SyllabusPart{CID: item.CID,
+			Gaps: []service.PositionRange{{Start: 0, End: coverage.Page.Duration}}})
+		partIndex = append(partIndex, i)
+	}
+
+	planDays := service.DaysBetween(plan.StartDate, plan.TargetDate) + 1
+	status.ExpectedSeconds = service.ScheduledThrough(service.PlanSyllabus(full, plan.StartDate, planDays), today)
+
+	first := today
+	if first.Before(plan.StartDate) {
+		first = plan.StartDate
+	}
+	for _, day := range service.PlanSyllabus(remaining, first, service.DaysBetween(first, plan.TargetDate)+1) {
+		planned := CoursePlanDay{Date: day.Date, Seconds: day.Seconds, Assignments: make([]CoursePlanAssignment, 0, len(day.Assignments))}
+		for _, a := range day.Assignments {
+			part := &status.Parts[partIndex[a.Part]]
+			assignment := CoursePlanAssignment{Part: part, SyllabusAssignment: a}
+			for _, r := range a.Ranges {
+				assignment.WatchedSeconds += service.OverlapSeconds(part.Covered, r)
+			}
+			planned.WatchedSeconds += assignment.WatchedSeconds
+			planned.Assignments = append(planned.Assignments, assignment)
+		}
+		status.Syllabus = append(status.Syllabus, planned)
+	}
+	return status, nil
+}
+
+// latestPages 返回视频最新的分P列表。
+func (s *CoursePlanService) latestPages(ctx context.Context, video *model.Video) ([]model.VideoPage, error) {
+	history, err := s.catalog.GetPageHistory(ctx, video.AID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频分P历史失败: %w", err)
+	}
+	latest, ok := history.Latest()
+	if !ok {
+		return nil, fmt.Errorf("视频 %s 没有分页信息", video.BVID)
+	}
+	return latest.Pages, nil
+}
+
+// selectPlanPages 按 CID 或分P序号从 pages 中选出计划的一项，两者都为 0 时返回所有分P。
+func selectPlanPages(pages []model.VideoPage, item CoursePlanItemInput) ([]model.VideoPage, error) {
+	if item.CID == 0 && item.Page == 0 {
+		return pages, nil
+	}
+	for _, page := range pages {
+		if (item.CID != 0 && page.Cid == item.CID) || (item.CID == 0 && page.Page == item.Page) {
+			return []model.VideoPage{page}, nil
+		}
+	}
+	if item.CID != 0 {
+		return nil, fmt.Errorf("part with cid %d not found in %s", item.CID, item.BVID)
+	}
+	return nil, fmt.Errorf("part %d not found in %s", item.Page, item.BVID)
+}
@@ -0,0 +1,104 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// VideoCoverage 一个视频的观看覆盖情况，分P按最新的分P列表排列。
+type VideoCoverage struct {
+	Video *model.Video
+	service.CourseCoverage
+	// PrunedBefore 查询范围内早于该时间的原始记录已被保留策略清理，聚合中没有播放位置，这部分观看不计入覆盖；
+	// 零值表示查询范围内的原始记录完整。
+	PrunedBefore time.Time
+}
+
+// CoverageService 应用服务，根据原始进度记录计算每个分P看过的位置和完成度。
+type CoverageService struct {
+	catalog       *VideoCatalogService
+	progressRepo  repository.VideoProgressRepository
+	aggregateRepo repository.WatchTimeAggregateRepository // 读取保留策略的水位线
+	calculator    service.WatchTimeCalculator
+	maxSpeed      float64 // 与观看时长策略使用的最大倍速一致
+}
+
+// NewCoverageService 创建 CoverageService 实例。
+func NewCoverageService(
+	catalog *VideoCatalogService,
+	progressRepo repository.VideoProgressRepository,
+	aggregateRepo repository.WatchTimeAggregateRepository,
+	calculator service.WatchTimeCalculator,
+	maxSpeed float64,
+) *CoverageService {
+	return &CoverageService{
+		catalog:       catalog,
+		progressRepo:  progressRepo,
+		aggregateRepo: aggregateRepo,
+		calculator:    calculator,
+		maxSpeed:      maxSpeed,
+	}
+}
+
+// GetCoverage 计算视频在 [start, end) 范围内 (零值表示不限) 的原始进度记录覆盖了哪些位置。
+// 超过保留期被清理的原始记录只保留了观看时长聚合，不参与覆盖计算，此时结果的 PrunedBefore 为清理的水位线；
+// 可疑的记录对也不计入覆盖。
+func (s *CoverageService) GetCoverage(ctx context.Context, bvid string, start, end time.Time) (*VideoCoverage, error) {
+	video, err := s.catalog.GetVideo(ctx, "", bvid)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频信息失败: %w", err)
+	}
+	history, err := s.catalog.GetPageHistory(ctx, video.AID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频分P历史失败: %w", err)
+	}
+	latest, ok := history.Latest()
+	if !ok {
+		return nil, fmt.Errorf("视频没有分页信息")
+	}
+
+	watermark, err := s.aggregateRepo.GetWatermark(ctx, video.AID)
+	if err != nil {
+		return nil, fmt.Errorf("获取汇总水位线失败: %w", err)
+	}
+	coverage := &VideoCoverage{Video: video}
+	if watermark.After(start) {
+		coverage.PrunedBefore = watermark
+	}
+
+	builder, err := service.NewCoverageBuilder(s.calculator, s.maxSpeed)
+	if err != nil {
+		return nil, err
+	}
+	var prev *model.VideoProgress
+	pairs, skipped := 0, 0
+	filter := repository.ProgressFilter{AID: video.AID, Start: start, End: end}
+	err = s.progressRepo.Iterate(ctx, filter, func(progress *model.VideoProgress) error {
+		if prev != nil && progress.PairLabel != model.PairLabelSuspicious {
+			pairs++
+			from, to := playbackPoint(prev), playbackPoint(progress)
+			err := withPairPages(history, prev, progress, func(pages []model.VideoPage) error {
+				return builder.AddPair(pages, from, to)
+			})
+			if err != nil {
+				skipped++ // 分P不存在或进度超出时长的记录对不计入覆盖
+			}
+		}
+		prev = progress
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历进度记录失败: %w", err)
+	}
+	if skipped > 0 {
+		log.Printf("AID %d 覆盖计算跳过 %d/%d 对无法解释的记录", video.AID, skipped, pairs)
+	}
+	coverage.CourseCoverage = builder.Coverage(latest.Pages)
+	return coverage, nil
+}
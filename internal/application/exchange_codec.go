@@ -0,0 +1,30 @@
+package application
+
+import (
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ProgressEncoder 将进度记录逐条写出为某种交换格式 (CSV、NDJSON、JSON)。
+// 基础设施层需要实现此接口。
+type ProgressEncoder interface {
+	// Encode 写出一条进度记录。
+	Encode(progress *model.VideoProgress) error
+	// Close 写出格式结尾 (例如 JSON 数组的右括号) 并刷新缓冲区，不会关闭底层 Writer。
+	Close() error
+}
+
+// ProgressDecoder 从某种交换格式中逐条读取进度记录。
+// 基础设施层需要实现此接口。
+type ProgressDecoder interface {
+	// Decode 读取下一条进度记录，没有更多记录时返回 io.EOF。
+	Decode() (*model.VideoProgress, error)
+}
+
+// SegmentEncoder 将观看分段逐条写出为某种交换格式。
+// 基础设施层需要实现此接口。
+type SegmentEncoder interface {
+	// Encode 写出一个观看分段。
+	Encode(segment WatchedSegmentResult) error
+	// Close 写出格式结尾并刷新缓冲区，不会关闭底层 Writer。
+	Close() error
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// 预测使用的最近天数的默认值和上限。
+const (
+	DefaultForecastDays = 14
+	MaxForecastDays     = 90
+)
+
+// DailyProgress 某一天首次观看的时长 (观看时长减去重看时长)，即当天推进的课程进度。
+type DailyProgress struct {
+	Date    time.Time // 当天零点 (预测时区)
+	Watched time.Duration
+}
+
+// VideoForecast 一个视频的完成预测。日期都是预测时区内的零点。
+type VideoForecast struct {
+	*VideoCoverage
+	Daily []DailyProgress // 用于计算进度的最近几天 (不含今天)，按日期升序排列
+	Today time.Time
+	service.CompletionForecast
+}
+
+// ForecastService 应用服务，根据最近每天的观看进度和剩余未看的时长预测什么时候能看完视频。
+type ForecastService struct {
+	analytics   VideoAnalyticsService
+	coverage    *CoverageService
+	aggregation *WatchTimeAggregationService
+}
+
+// NewForecastService 创建 ForecastService 实例。
+func NewForecastService(
+	analytics VideoAnalyticsService,
+	coverage *CoverageService,
+	aggregation *WatchTimeAggregationService,
+) *ForecastService {
+	return &ForecastService{analytics: analytics, coverage: coverage, aggregation: aggregation}
+}
+
+// GetForecast 用截至 now 的最近 days 个完整的日历日 (loc 时区，不含今天) 预测视频的完成时间。
+// 剩余时长为所有分P中还没有被原始进度记录覆盖的部分；每日进度只计首次观看，重看不推进课程。
+// days 不大于 0 时使用默认值，loc 为 nil 时使用聚合时区。
+func (s *ForecastService) GetForecast(ctx context.Context, bvid string, days int, loc *time.Location, now time.Time) (*VideoForecast, error) {
+	if days <= 0 {
+		days = DefaultForecastDays
+	}
+	if days > MaxForecastDays {
+		return nil, fmt.Errorf("预测天数不能超过 %d", MaxForecastDays)
+	}
+	if loc == nil {
+		loc = s.aggregation.Location()
+	}
+
+	coverage, err := s.coverage.GetCoverage(ctx, bvid, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	y, m, d := now.In(loc).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	windowStart := today.AddDate(0, 0, -days)
+	result, err := s.analytics.GetWatchedSegments(ctx, "", bvid, windowStart, today, FixedInterval(24*time.Hour), loc, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("获取每日观看时长失败: %w", err)
+	}
+
+	daily := make([]DailyProgress, 0, len(result.Segments))
+	paces := make([]time.Duration, 0, len(result.Segments))
+	for _, segment := range result.Segments {
+		watched := segment.WatchedDuration - segment.RewatchedDuration
+		daily = append(daily, DailyProgress{Date: segment.SegmentStartTime, Watched: watched})
+		paces = append(paces, watched)
+	}
+
+	remaining := time.Duration(coverage.TotalSeconds-coverage.CoveredSeconds) * time.Second
+	// 指数移动平均常用的跨度 (span) 约定 alpha = 2/(N+1)：权重的平均"年龄"为 (1-alpha)/alpha = (N-1)/2 天，
+	// 与最近 N 天的简单平均相同，这里 N 取参与预测的天数
+	alpha := 2 / float64(days+1)
+	return &VideoForecast{
+		VideoCoverage:      coverage,
+		Daily:              daily,
+		Today:              today,
+		CompletionForecast: service.ForecastCompletion(paces, remaining, alpha),
+	}, nil
+}
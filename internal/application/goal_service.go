@@ -0,0 +1,215 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// ErrInvalidGoal 表示创建学习目标的参数无效。
+var ErrInvalidGoal = errors.New("invalid goal")
+
+// MaxGoalHistoryDays 评估目标时最多回溯的学习日数 (含今天)，更早的日子不参与连续天数统计。
+const MaxGoalHistoryDays = 366
+
+// GoalInput 创建学习目标的参数。
+type GoalInput struct {
+	BVID        string
+	Kind        model.GoalKind
+	DailyTarget time.Duration // daily 目标每天的观看时长
+	StartDate   time.Time     // 开始统计的日期，零值表示今天
+	Deadline    time.Time     // deadline 目标的截止日期 (含)
+}
+
+// GoalStatus 学习目标截至当前学习日的完成情况。
+type GoalStatus struct {
+	Goal          *model.Goal
+	Video         *model.Video
+	Today         time.Time         // 当前学习日的日期
+	Days          []service.GoalDay // 从开始日期 (最多回溯 MaxGoalHistoryDays 天) 到今天，按日期升序排列；目标尚未开始时为空
+	CurrentStreak int
+	LongestStreak int
+
+	// 以下字段仅 deadline 目标使用
+	Remaining     time.Duration // 还没看过的时长
+	DaysLeft      int           // 包括今天在内距离截止日期还剩的学习日，已过截止日期时为 0
+	RequiredDaily time.Duration // 从今天起每天需要的首次观看时长，已看完时为 0
+	PrunedBefore  time.Time     // 早于该时间的原始记录已被清理，剩余时长不含这部分观看 (见 VideoCoverage.PrunedBefore)
+}
+
+// Completed 判断 deadline 目标是否已看完。
+func (s *GoalStatus) Completed() bool {
+	return s.Goal.Kind == model.GoalKindDeadline && s.Remaining <= 0
+}
+
+// GoalService 应用服务，管理学习目标并按学习日评估完成情况和连续天数。
+//
+// daily 目标每天计入全部观看时长 (含重看)；deadline 目标每天计入首次观看时长，
+// 当天的目标为按当天开始时的剩余时长和剩余天数平均分配的时长。
+type GoalService struct {
+	goalRepo  repository.GoalRepository
+	catalog   *VideoCatalogService
+	analytics VideoAnalyticsService
+	coverage  *CoverageService
+	clock     service.StudyDayClock
+}
+
+// NewGoalService 创建 GoalService 实例。clock 决定学习日的划分 (时区和每天的开始时刻)。
+func NewGoalService(
+	goalRepo repository.GoalRepository,
+	catalog *VideoCatalogService,
+	analytics VideoAnalyticsService,
+	coverage *CoverageService,
+	clock service.StudyDayClock,
+) *GoalService {
+	return &GoalService{goalRepo: goalRepo, catalog: catalog, analytics: analytics, coverage: coverage, clock: clock}
+}
+
+// Clock 返回学习日的划分方式。
+func (s *GoalService) Clock() service.StudyDayClock {
+	return s.clock
+}
+
+// CreateGoal 校验参数并保存一个新目标。
+func (s *GoalService) CreateGoal(ctx context.Context, input GoalInput, now time.Time) (*model.Goal, error) {
+	goal := &model.Goal{Kind: input.Kind, StartDate: input.StartDate}
+	if goal.StartDate.IsZero() {
+		goal.StartDate = s.clock.Date(now)
+	}
+	switch input.Kind {
+	case model.GoalKindDaily:
+		if input.DailyTarget < time.Second {
+			return nil, fmt.Errorf("%w: daily goal requires a positive daily target", ErrInvalidGoal)
+		}
+		goal.DailySeconds = int64(input.DailyTarget.Seconds())
+	case model.GoalKindDeadline:
+		if input.Deadline.IsZero() {
+			return nil, fmt.Errorf("%w: deadline goal requires a deadline", ErrInvalidGoal)
+		}
+		if input.Deadline.Before(goal.StartDate) {
+			return nil, fmt.Errorf("%w: deadline is before start date", ErrInvalidGoal)
+		}
+		goal.Deadline = input.Deadline
+	default:
+		return nil, fmt.Errorf("%w: unknown goal kind %q", ErrInvalidGoal, input.Kind)
+	}
+
+	video, err := s.catalog.GetVideo(ctx, "", input.BVID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频信息失败: %w", err)
+	}
+	goal.AID = video.AID
+	goal.BVID = video.BVID
+	if err := s.goalRepo.Create(ctx, goal); err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+// ListGoals 返回所有目标。
+func (s *GoalService) ListGoals(ctx context.Context) ([]*model.Goal, error) {
+	return s.goalRepo.ListAll(ctx)
+}
+
+// DeleteGoal 删除目标，不存在时返回 repository.ErrGoalNotFound。
+func (s *GoalService) DeleteGoal(ctx context.Context, id uint) error {
+	return s.goalRepo.Delete(ctx, id)
+}
+
+// GetStatus 评估单个目标截至 now 的完成情况，不存在时返回 repository.ErrGoalNotFound。
+func (s *GoalService) GetStatus(ctx context.Context, id uint, now time.Time) (*GoalStatus, error) {
+	goal, err := s.goalRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluate(ctx, goal, now)
+}
+
+// ListStatuses 评估所有目标截至 now 的完成情况。
+func (s *GoalService) ListStatuses(ctx context.Context, now time.Time) ([]*GoalStatus, error) {
+	goals, err := s.goalRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*GoalStatus, 0, len(goals))
+	for _, goal := range goals {
+		status, err := s.evaluate(ctx, goal, now)
+		if err != nil {
+			return nil, fmt.Errorf("评估目标 %d 失败: %w", goal.ID, err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// evaluate 按学习日计算目标每天的完成情况和连续天数。
+func (s *GoalService) evaluate(ctx context.Context, goal *model.Goal, now time.Time) (*GoalStatus, error) {
+	video, err := s.catalog.GetVideo(ctx, "", goal.BVID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频信息失败: %w", err)
+	}
+	today := s.clock.Date(now)
+	status := &GoalStatus{Goal: goal, Video: video, Today: today, Days: []service.GoalDay{}}
+
+	first := goal.StartDate
+	if earliest := today.AddDate(0, 0, 1-MaxGoalHistoryDays); first.Before(earliest) {
+		first = earliest
+	}
+	var watched []WatchedSegmentResult
+	if !first.After(today) {
+		result, err := s.analytics.GetWatchedSegments(ctx, "", goal.BVID,
+			s.clock.Start(first), s.clock.Start(today.AddDate(0, 0, 1)), FixedInterval(24*time.Hour), s.clock.Location, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("获取每日观看时长失败: %w", err)
+		}
+		watched = result.Segments
+	}
+
+	switch goal.Kind {
+	case model.GoalKindDaily:
+		target := time.Duration(goal.DailySeconds) * time.Second
+		for i, segment := range watched {
+			status.Days = append(status.Days, service.GoalDay{
+				Date:    first.AddDate(0, 0, i),
+				Watched: segment.WatchedDuration,
+				Target:  target,
+				Met:     segment.WatchedDuration >= target,
+			})
+		}
+	case model.GoalKindDeadline:
+		coverage, err := s.coverage.GetCoverage(ctx, goal.BVID, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		status.Remaining = time.Duration(coverage.TotalSeconds-coverage.CoveredSeconds) * time.Second
+		status.PrunedBefore = coverage.PrunedBefore
+		status.Days = deadlineDays(first, watched, status.Remaining, goal.Deadline)
+		status.DaysLeft = max(service.DaysBetween(today, goal.Deadline)+1, 0)
+		if n := len(status.Days); n > 0 {
+			status.RequiredDaily = status.Days[n-1].Target
+		} else {
+			status.RequiredDaily = service.RequiredDailyPace(status.Remaining, status.DaysLeft)
+		}
+	}
+	status.CurrentStreak, status.LongestStreak = service.CountStreaks(status.Days)
+	return status, nil
+}
+
+// deadlineDays 计算 deadline 目标每个学习日的完成情况。
+// 从今天的剩余时长往前倒推每天开始时的剩余时长 (加回当天的首次观看时长)，当天的目标为把它平均分配到截止日期前的每一天。
+func deadlineDays(first time.Time, watched []WatchedSegmentResult, remaining time.Duration, deadline time.Time) []service.GoalDay {
+	days := make([]service.GoalDay, len(watched))
+	for i := len(watched) - 1; i >= 0; i-- {
+		date := first.AddDate(0, 0, i)
+		progress := watched[i].WatchedDuration - watched[i].RewatchedDuration
+		remaining += progress
+		target := service.RequiredDailyPace(remaining, service.DaysBetween(date, deadline)+1)
+		days[i] = service.GoalDay{Date: date, Watched: progress, Target: target, Met: progress >= target}
+	}
+	return days
+}
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineDays(t *testing.T) {
+	first := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 三天的首次观看时长分别为 600 秒 (另有 100 秒重看)、0 和 300 秒，今天之后还剩 900 秒
+	watched := []WatchedSegmentResult{
+		{WatchedDuration: 700 * time.Second, RewatchedDuration: 100 * time.Second},
+		{},
+		{WatchedDuration: 300 * time.Second},
+	}
+	tests := []struct {
+		name       string
+		deadline   time.Time
+		remaining  time.Duration
+		wantTarget []time.Duration
+		wantMet    []bool
+	}{
+		{
+			name: "deadline today", deadline: first.AddDate(0, 0, 2), remaining: 900 * time.Second,
+			wantTarget: []time.Duration{600 * time.Second, 600 * time.Second, 1200 * time.Second},
+			wantMet:    []bool{true, false, false},
+		},
+		{
+			name: "deadline in the past", deadline: first.AddDate(0, 0, -1), remaining: 900 * time.Second,
+			wantTarget: []time.Duration{1800 * time.Second, 1200 * time.Second, 1200 * time.Second},
+			wantMet:    []bool{false, false, false},
+		},
+		{
+			name: "finished today", deadline: first.AddDate(0, 0, 9), remaining: 0,
+			wantTarget: []time.Duration{90 * time.Second, 34 * time.Second, 38 * time.Second},
+			wantMet:    []bool{true, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := deadlineDays(first, watched, tt.remaining, tt.deadline)
+			if len(days) != len(watched) {
+				t.Fatalf("got %d days, want %d", len(days), len(watched))
+			}
+			for i, d := range days {
+				if !d.Date.Equal(first.AddDate(0, 0, i)) || d.Target != tt.wantTarget[i] || d.Met != tt.wantMet[i] {
+					t.Errorf("day %d = %+v, want target %s, met %v", i, d, tt.wantTarget[i], tt.wantMet[i])
+				}
+			}
+			if days[0].Watched != 600*time.Second {
+				t.Errorf("first day counts %s, want only the 600s watched for the first time", days[0].Watched)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// MaxHeatmapDays 观看热力图一次最多统计的天数。
+const MaxHeatmapDays = 366
+
+// ErrInvalidHeatmapRange 表示观看热力图的时间范围无效。
+var ErrInvalidHeatmapRange = errors.New("invalid heatmap range")
+
+// HeatmapCell 热力图中一个 (星期, 小时) 格子的累计观看时长。
+type HeatmapCell struct {
+	Watched   time.Duration // 观看时长，包含重看时长
+	Rewatched time.Duration
+	Hours     int // 范围内该 (星期, 小时) 出现的次数，用于求平均值
+}
+
+// WatchHeatmap 按星期和一天中的小时汇总的观看时长。
+type WatchHeatmap struct {
+	Location *time.Location
+	Start    time.Time          // 对齐到整点后的开始时间
+	End      time.Time          // 对齐到整点后的结束时间
+	Videos   []*model.Video     // 参与统计的视频
+	Cells    [7][24]HeatmapCell // 按 time.Weekday (周日为 0) 和当地的小时索引
+	Total    time.Duration      // 所有格子的观看时长之和
+}
+
+// HeatmapService 应用服务，把观看时长按星期 × 小时汇总为热力图，回答 "一般在什么时候看"。
+type HeatmapService struct {
+	catalog     *VideoCatalogService
+	analytics   VideoAnalyticsService
+	aggregation *WatchTimeAggregationService
+}
+
+// NewHeatmapService 创建 HeatmapService 实例。
+func NewHeatmapService(catalog *VideoCatalogService, analytics VideoAnalyticsService, aggregation *WatchTimeAggregationService) *HeatmapService {
+	return &HeatmapService{catalog: catalog, analytics: analytics, aggregation: aggregation}
+}
+
+// GetHeatmap 统计 [start, end) 内的观看时长在 loc 时区的星期 × 小时分布，loc 为 nil 时使用聚合时区。
+// aidStr 和 bvidStr 都为空时统计目录中的所有视频。开始时间向前、结束时间向后对齐到当地整点，范围最多 MaxHeatmapDays 天。
+// 观看时长由 GetWatchedSegments 以 1 小时分段按比例归属计算，跨越多个小时的记录对按重叠时间拆分到每个小时。
+func (s *HeatmapService) GetHeatmap(ctx context.Context, aidStr, bvidStr string, start, end time.Time, loc *time.Location) (*WatchHeatmap, error) {
+	if loc == nil {
+		loc = s.aggregation.Location()
+	}
+	start = floorLocalHour(start, loc)
+	if floored := floorLocalHour(end, loc); floored.Before(end) {
+		end = floored.Add(time.Hour)
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidHeatmapRange)
+	}
+	if end.Sub(start) > MaxHeatmapDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", ErrInvalidHeatmapRange, MaxHeatmapDays)
+	}
+
+	var videos []*model.Video
+	if aidStr == "" && bvidStr == "" {
+		all, err := s.catalog.ListVideos(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取视频列表失败: %w", err)
+		}
+		videos = all
+	} else {
+		video, err := s.catalog.GetVideo(ctx, aidStr, bvidStr)
+		if err != nil {
+			return nil, fmt.Errorf("获取视频信息失败: %w", err)
+		}
+		videos = []*model.Video{video}
+	}
+
+	heatmap := &WatchHeatmap{Location: loc, Start: start.In(loc), End: end.In(loc), Videos: videos}
+	for t := heatmap.Start; t.Before(heatmap.End); t = t.Add(time.Hour) {
+		heatmap.Cells[t.Weekday()][t.Hour()].Hours++
+	}
+	for _, video := range videos {
+		result, err := s.analytics.GetWatchedSegments(ctx, strconv.FormatInt(video.AID, 10), "",
+			start, end, FixedInterval(time.Hour), loc, AttributionProportional, false)
+		if err != nil {
+			return nil, fmt.Errorf("计算视频 %s 的观看时长失败: %w", video.BVID, err)
+		}
+		for _, segment := range result.Segments {
+			t := segment.SegmentStartTime.In(loc)
+			cell := &heatmap.Cells[t.Weekday()][t.Hour()]
+			cell.Watched += segment.WatchedDuration
+			cell.Rewatched += segment.RewatchedDuration
+		}
+		heatmap.Total += result.TotalWatchedDuration
+	}
+	return heatmap, nil
+}
+
+// floorLocalHour 把 t 向前对齐到 loc 时区的整点 (对非整小时偏移的时区同样按当地时间对齐)。
+func floorLocalHour(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return local.Add(-time.Duration(local.Minute())*time.Minute - time.Duration(local.Second())*time.Second -
+		time.Duration(local.Nanosecond()))
+}
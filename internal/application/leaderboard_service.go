@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+const (
+	// MaxLeaderboardDays 排行榜一次最多统计的天数。
+	MaxLeaderboardDays = 366
+	// DefaultLeaderboardLimit 每个排行榜默认返回的条目数。
+	DefaultLeaderboardLimit = 10
+	// MaxLeaderboardLimit 每个排行榜最多返回的条目数。
+	MaxLeaderboardLimit = 100
+)
+
+// ErrInvalidLeaderboard 表示排行榜请求的参数无效。
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard request")
+
+// LeaderboardEntry 排行榜中的一项：一个视频、一个UP主或一个分区。
+type LeaderboardEntry struct {
+	ID         int64        // 视频为 AID，UP主为 mid，分区为 tid；目录中缺少该信息时为 0
+	Name       string       // 视频标题、UP主名称或分区名称
+	Video      *model.Video // 仅视频排行榜
+	VideoCount int          // 有观看时长的视频数
+	Watched    time.Duration
+	Rewatched  time.Duration
+	Share      float64 // 占所有视频观看时长的比例 (0-1)
+}
+
+// Leaderboard [Start, End) 内按观看时长排序的视频、UP主和分区排行榜，只包含有观看时长的条目。
+type Leaderboard struct {
+	Start, End     time.Time
+	Total          time.Duration // 所有视频的观看时长，包含重看
+	TotalRewatched time.Duration
+	Videos         []LeaderboardEntry
+	Owners         []LeaderboardEntry
+	Partitions     []LeaderboardEntry
+	// 截断前的条目数
+	VideoCount, OwnerCount, PartitionCount int
+}
+
+// LeaderboardService 应用服务，按观看时长对视频、UP主和分区排名，回答 "最近主要在看什么"。
+type LeaderboardService struct {
+	combined *CombinedAnalyticsService
+}
+
+// NewLeaderboardService 创建 LeaderboardService 实例。
+func NewLeaderboardService(combined *CombinedAnalyticsService) *LeaderboardService {
+	return &LeaderboardService{combined: combined}
+}
+
+// GetLeaderboard 统计目录中所有视频在 [start, end) 内的观看时长，按视频、UP主 (Video.OwnerMID) 和分区 (Video.TID) 排名。
+// 每个排行榜最多返回 limit 项 (为 0 时使用 DefaultLeaderboardLimit)；范围最多 MaxLeaderboardDays 天，参数无效时返回 ErrInvalidLeaderboard。
+// 目录刷新前没有 UP主 ID 或分区的视频按名称归组 (名称也为空时归为 ID 为 0 的一组)。
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context,
+	start, end time.Time,
+	attribution Attribution,
+	includeSuspicious bool,
+	limit int,
+) (*Leaderboard, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidLeaderboard)
+	}
+	if end.Sub(start) > MaxLeaderboardDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", ErrInvalidLeaderboard, MaxLeaderboardDays)
+	}
+	if limit == 0 {
+		limit = DefaultLeaderboardLimit
+	}
+	if limit < 0 || limit > MaxLeaderboardLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidLeaderboard, MaxLeaderboardLimit)
+	}
+
+	// 整个范围作为一个分段，只使用每个视频的合计
+	result, err := s.combined.GetCombinedSegments(ctx, nil, start, end, FixedInterval(end.Sub(start)), nil, attribution, includeSuspicious)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &Leaderboard{Start: start, End: end, Total: result.TotalWatched, TotalRewatched: result.TotalRewatched}
+	videos := make([]LeaderboardEntry, 0, len(result.VideoTotals))
+	owners := newLeaderboardGroups()
+	partitions := newLeaderboardGroups()
+	for _, total := range result.VideoTotals {
+		if total.Watched <= 0 {
+			continue
+		}
+		video := total.Video
+		videos = append(videos, LeaderboardEntry{ID: video.AID, Name: video.Title, Video: video, VideoCount: 1,
+			Watched: total.Watched, Rewatched: total.Rewatched})
+		owners.add(video.OwnerMID, video.OwnerName, total)
+		partitions.add(int64(video.TID), video.TName, total)
+	}
+	board.Videos, board.VideoCount = rankEntries(videos, board.Total, limit)
+	board.Owners, board.OwnerCount = rankEntries(owners.entries, board.Total, limit)
+	board.Partitions, board.PartitionCount = rankEntries(partitions.entries, board.Total, limit)
+	return board, nil
+}
+
+// leaderboardGroups 按 ID 归组的排行榜条目，ID 为 0 时按名称归组。
+type leaderboardGroups struct {
+	index   map[leaderboardGroupKey]int
+	entries []LeaderboardEntry
+}
+
+// leaderboardGroupKey 归组的键，ID 不为 0 时名称为空 (同一 UP主改名后仍是一组)。
+type leaderboardGroupKey struct {
+	id   int64
+	name string
+}
+
+// newLeaderboardGroups 创建空的 leaderboardGroups。
+func newLeaderboardGroups() *leaderboardGroups {
+	return &leaderboardGroups{index: make(map[leaderboardGroupKey]int)}
+}
+
+// add 把一个视频的观看时长累加到它所属的组。
+func (g *leaderboardGroups) add(id int64, name string, total VideoWatchTime) {
+	key := leaderboardGroupKey{id: id}
+	if id == 0 {
+		key.name = name
+	}
+	i, ok := g.index[key]
+	if !ok {
+		i = len(g.entries)
+		g.index[key] = i
+		g.entries = append(g.entries, LeaderboardEntry{ID: id, Name: name})
+	}
+	entry := &g.entries[i]
+	entry.VideoCount++
+	entry.Watched += total.Watched
+	entry.Rewatched += total.Rewatched
+}
+
+// rankEntries 按观看时长降序 (相同时按 ID、名称升序) 排列条目并计算占比，返回前 limit 项和截断前的条目数。
+func rankEntries(entries []LeaderboardEntry, total time.Duration, limit int) ([]LeaderboardEntry, int) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Watched != entries[j].Watched {
+			return entries[i].Watched > entries[j].Watched
+		}
+		if entries[i].ID != entries[j].ID {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	for i := range entries {
+		if total > 0 {
+			entries[i].Share = float64(entries[i].Watched) / float64(total)
+		}
+	}
+	return entries[:min(limit, len(entries))], len(entries)
+}
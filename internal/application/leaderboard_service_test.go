@@ -0,0 +1,122 @@
+package application
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// rankedEntry 排行榜条目中参与比较的字段。
+type rankedEntry struct {
+	id         int64
+	name       string
+	videoCount int
+	watched    time.Duration
+	share      float64
+}
+
+func ranked(entries []LeaderboardEntry) []rankedEntry {
+	got := make([]rankedEntry, 0, len(entries))
+	for _, e := range entries {
+		got = append(got, rankedEntry{id: e.ID, name: e.Name, videoCount: e.VideoCount, watched: e.Watched, share: e.Share})
+	}
+	return got
+}
+
+func TestRankEntries(t *testing.T) {
+	entry := func(id int64, name string, minutes int) LeaderboardEntry {
+		return LeaderboardEntry{ID: id, Name: name, VideoCount: 1, Watched: time.Duration(minutes) * time.Minute}
+	}
+	tests := []struct {
+		name      string
+		entries   []LeaderboardEntry
+		total     time.Duration
+		limit     int
+		want      []rankedEntry
+		wantCount int
+	}{
+		{name: "empty", total: time.Hour, limit: 10, want: []rankedEntry{}},
+		{
+			name:    "by watch time",
+			entries: []LeaderboardEntry{entry(1, "a", 10), entry(2, "b", 30), entry(3, "c", 20)},
+			total:   time.Hour, limit: 10, wantCount: 3,
+			want: []rankedEntry{{2, "b", 1, 30 * time.Minute, 0.5}, {3, "c", 1, 20 * time.Minute, 1.0 / 3}, {1, "a", 1, 10 * time.Minute, 1.0 / 6}},
+		},
+		{
+			name:    "ties by id",
+			entries: []LeaderboardEntry{entry(3, "a", 20), entry(1, "c", 20), entry(2, "b", 20)},
+			total:   time.Hour, limit: 10, wantCount: 3,
+			want: []rankedEntry{{1, "c", 1, 20 * time.Minute, 1.0 / 3}, {2, "b", 1, 20 * time.Minute, 1.0 / 3}, {3, "a", 1, 20 * time.Minute, 1.0 / 3}},
+		},
+		{
+			name:    "ties without id by name",
+			entries: []LeaderboardEntry{entry(0, "b", 30), entry(0, "", 30), entry(5, "a", 30), entry(0, "a", 30)},
+			total:   2 * time.Hour, limit: 10, wantCount: 4,
+			want: []rankedEntry{{0, "", 1, 30 * time.Minute, 0.25}, {0, "a", 1, 30 * time.Minute, 0.25}, {0, "b", 1, 30 * time.Minute, 0.25}, {5, "a", 1, 30 * time.Minute, 0.25}},
+		},
+		{
+			name:    "truncated after ranking",
+			entries: []LeaderboardEntry{entry(1, "a", 10), entry(2, "b", 30), entry(3, "c", 20), entry(4, "d", 20)},
+			total:   80 * time.Minute, limit: 2, wantCount: 4,
+			want: []rankedEntry{{2, "b", 1, 30 * time.Minute, 0.375}, {3, "c", 1, 20 * time.Minute, 0.25}},
+		},
+		{
+			name:    "no share without a total",
+			entries: []LeaderboardEntry{entry(1, "a", 10)},
+			limit:   1, wantCount: 1,
+			want: []rankedEntry{{1, "a", 1, 10 * time.Minute, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, count := rankEntries(tt.entries, tt.total, tt.limit)
+			if got := ranked(entries); !reflect.DeepEqual(got, tt.want) || count != tt.wantCount {
+				t.Errorf("rankEntries = %+v, %d, want %+v, %d", got, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestLeaderboardGroups(t *testing.T) {
+	type video struct {
+		id      int64
+		name    string
+		minutes int
+	}
+	tests := []struct {
+		name   string
+		videos []video
+		want   []rankedEntry
+	}{
+		{
+			name:   "same id under different names",
+			videos: []video{{7, "old name", 10}, {8, "other", 5}, {7, "new name", 20}},
+			want:   []rankedEntry{{7, "old name", 2, 30 * time.Minute, 30.0 / 35}, {8, "other", 1, 5 * time.Minute, 5.0 / 35}},
+		},
+		{
+			name:   "without id by name",
+			videos: []video{{0, "a", 10}, {0, "b", 10}, {0, "a", 5}, {3, "a", 5}},
+			want:   []rankedEntry{{0, "a", 2, 15 * time.Minute, 0.5}, {0, "b", 1, 10 * time.Minute, 1.0 / 3}, {3, "a", 1, 5 * time.Minute, 1.0 / 6}},
+		},
+		{
+			name:   "without id or name",
+			videos: []video{{0, "", 10}, {0, "", 10}, {1, "", 20}},
+			want:   []rankedEntry{{0, "", 2, 20 * time.Minute, 0.5}, {1, "", 1, 20 * time.Minute, 0.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := newLeaderboardGroups()
+			var total time.Duration
+			for _, v := range tt.videos {
+				watched := time.Duration(v.minutes) * time.Minute
+				groups.add(v.id, v.name, VideoWatchTime{Watched: watched})
+				total += watched
+			}
+			entries, count := rankEntries(groups.entries, total, MaxLeaderboardLimit)
+			if got := ranked(entries); !reflect.DeepEqual(got, tt.want) || count != len(tt.want) {
+				t.Errorf("groups = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// importBatchSize 是导入时每批写入仓库的记录数。
+const importBatchSize = 500
+
+// ImportResult 包含一次导入的统计结果。
+type ImportResult struct {
+	Read       int64   // 读取的记录数
+	Inserted   int64   // 实际插入的记录数
+	Duplicates int64   // 因 (aid, recorded_at) 已存在而跳过的记录数
+	AIDs       []int64 // 导入涉及的视频
+}
+
+// timeRange 记录导入记录的最早和最晚时间。
+type timeRange struct {
+	from, to time.Time
+}
+
+// relabel 重新计算范围内 (及其前后) 记录的分类，并把范围扩展到分类发生变化的记录，以便重建受影响的聚合。
+// 失败只记录日志，可之后通过 relabel-progress 命令补做。
+func (r *timeRange) relabel(ctx context.Context, labels *ProgressLabelService, aid int64) {
+	if labels == nil {
+		return
+	}
+	changes, err := labels.Relabel(ctx, aid, r.from, r.to)
+	if err != nil {
+		log.Printf("Labels: failed to relabel progress pairs for AID %d: %v", aid, err)
+		return
+	}
+	if changes.Changed == 0 {
+		return
+	}
+	if changes.From.Before(r.from) {
+		r.from = changes.From
+	}
+	if changes.To.After(r.to) {
+		r.to = changes.To
+	}
+}
+
+// refreshRanges 重新计算 aids 在各自范围内的记录对分类，并重建受影响的观看时长聚合，logPrefix 用于区分日志来源。
+// 失败只记录日志，可之后通过 relabel-progress 和 rebuild-aggregates 命令补做。
+func refreshRanges(ctx context.Context, labels *ProgressLabelService, aggregation *WatchTimeAggregationService,
+	aids []int64, ranges map[int64]*timeRange, logPrefix string) {
+	for _, aid := range aids {
+		ranges[aid].relabel(ctx, labels, aid)
+		if err := aggregation.RebuildAround(ctx, aid, ranges[aid].from, ranges[aid].to); err != nil {
+			log.Printf("%s: failed to rebuild aggregates for AID %d: %v", logPrefix, aid, err)
+		}
+	}
+}
+
+// ProgressExchangeService 应用服务，负责进度记录和观看分段的导出，以及进度记录的幂等导入。
+// 具体的编码格式由调用方传入的 ProgressEncoder / ProgressDecoder / SegmentEncoder 决定。
+type ProgressExchangeService struct {
+	progressRepo repository.VideoProgressRepository
+	catalog      *VideoCatalogService
+	analytics    VideoAnalyticsService
+	aggregation  *WatchTimeAggregationService
+	labels       *ProgressLabelService
+}
+
+// NewProgressExchangeService 创建 ProgressExchangeService 实例。
+func NewProgressExchangeService(
+	progressRepo repository.VideoProgressRepository,
+	catalog *VideoCatalogService,
+	analytics VideoAnalyticsService,
+	aggregation *WatchTimeAggregationService,
+	labels *ProgressLabelService,
+) *ProgressExchangeService {
+	return &ProgressExchangeService{
+		progressRepo: progressRepo,
+		catalog:      catalog,
+		analytics:    analytics,
+		aggregation:  aggregation,
+		labels:       labels,
+	}
+}
+
+// ExportProgress 按视频和时间范围 [start, end) 导出原始进度记录，返回导出的条数。
+// aidStr 和 bvidStr 都为空时导出所有视频；start、end 为零值时不限制。
+// 成功时会调用 enc.Close 写出格式结尾。
+func (s *ProgressExchangeService) ExportProgress(ctx context.Context, aidStr, bvidStr string, start, end time.Time, enc ProgressEncoder) (int64, error) {
+	filter := repository.ProgressFilter{Start: start, End: end}
+	if aidStr != "" {
+		aid, err := strconv.ParseInt(aidStr, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid aid %q: %w", aidStr, err)
+		}
+		filter.AID = aid
+	} else if bvidStr != "" {
+		video, err := s.catalog.GetVideo(ctx, "", bvidStr)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve bvid %s: %w", bvidStr, err)
+		}
+		filter.AID = video.AID
+	}
+
+	var count int64
+	err := s.progressRepo.Iterate(ctx, filter, func(progress *model.VideoProgress) error {
+		count++
+		return enc.Encode(progress)
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to export progress: %w", err)
+	}
+	return count, enc.Close()
+}
+
+// ExportSegments 计算并导出观看分段，参数含义与 VideoAnalyticsService.GetWatchedSegments 相同。
+// 返回导出的分段数，成功时会调用 enc.Close 写出格式结尾。
+func (s *ProgressExchangeService) ExportSegments(ctx context.Context,
+	aidStr, bvidStr string,
+	start, end time.Time,
+	interval SegmentInterval,
+	loc *time.Location,
+	attribution Attribution,
+	includeSuspicious bool,
+	enc SegmentEncoder,
+) (int, error) {
+	result, err := s.analytics.GetWatchedSegments(ctx, aidStr, bvidStr, start, end, interval, loc, attribution, includeSuspicious)
+	if err != nil {
+		return 0, err
+	}
+	for _, seg := range result.Segments {
+		if err := enc.Encode(seg); err != nil {
+			return 0, fmt.Errorf("failed to export segments: %w", err)
+		}
+	}
+	return len(result.Segments), enc.Close()
+}
+
+// ImportProgress 从 dec 读取进度记录并批量写入，(aid, recorded_at) 已存在的记录会被跳过，因此可以重复导入。
+// 导入结束后重新计算涉及的记录对分类，并重建涉及的时间范围内的观看时长聚合；重建失败只记录日志，可之后通过 rebuild-aggregates 命令补做。
+// 中途出错时之前的批次已经写入，同样会重新分类和重建：再次导入时这些记录作为重复记录被跳过，不会再触发重建。
+// 注意：早于视频汇总水位线的记录不会参与分析，因为该范围的原始记录已被清理。
+func (s *ProgressExchangeService) ImportProgress(ctx context.Context, dec ProgressDecoder) (result ImportResult, err error) {
+	ranges := make(map[int64]*timeRange)
+	batch := make([]*model.VideoProgress, 0, importBatchSize)
+	defer func() {
+		if result.Inserted > 0 {
+			refreshRanges(ctx, s.labels, s.aggregation, result.AIDs, ranges, "Import")
+		}
+	}()
+
+	flush := func() error {
+		inserted, err := s.progressRepo.InsertIgnoreDuplicates(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.Inserted += inserted
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		progress, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to decode record %d: %w", result.Read+1, err)
+		}
+		result.Read++
+		if progress.AID <= 0 || progress.RecordedAt.IsZero() {
+			return result, fmt.Errorf("record %d: aid and recorded_at are required", result.Read)
+		}
+		progress.ID = 0 // 使用目标实例的自增 ID
+
+		r, ok := ranges[progress.AID]
+		if !ok {
+			r = &timeRange{from: progress.RecordedAt, to: progress.RecordedAt}
+			ranges[progress.AID] = r
+			result.AIDs = append(result.AIDs, progress.AID)
+		}
+		if progress.RecordedAt.Before(r.from) {
+			r.from = progress.RecordedAt
+		}
+		if progress.RecordedAt.After(r.to) {
+			r.to = progress.RecordedAt
+		}
+
+		batch = append(batch, progress)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	result.Duplicates = result.Read - result.Inserted
+	log.Printf("Import: read %d records, inserted %d, skipped %d duplicates", result.Read, result.Inserted, result.Duplicates)
+	return result, nil
+}
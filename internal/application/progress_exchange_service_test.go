@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+// sliceDecoder 依次返回 records 中的记录，之后返回 io.EOF。
+type sliceDecoder struct {
+	records []*model.VideoProgress
+}
+
+func (d *sliceDecoder) Decode() (*model.VideoProgress, error) {
+	if len(d.records) == 0 {
+		return nil, io.EOF
+	}
+	record := *d.records[0] // 导入会修改记录 (ID)，每次返回副本
+	d.records = d.records[1:]
+	return &record, nil
+}
+
+// exchangeFixture 基于内存仓库的导入服务，视频 AID 1 只有一个很长的分P。
+type exchangeFixture struct {
+	service     *ProgressExchangeService
+	progress    repository.VideoProgressRepository
+	aggregates  repository.WatchTimeAggregateRepository
+	aggregation *WatchTimeAggregationService
+	labels      *ProgressLabelService
+}
+
+func newExchangeFixture(t *testing.T, from time.Time) exchangeFixture {
+	t.Helper()
+	ctx := context.Background()
+	videos := persistence.NewMemoryVideoRepository()
+	if err := videos.Save(ctx, &model.Video{AID: 1, BVID: "BV1", PageVersion: 1, RefreshedAt: from}); err != nil {
+		t.Fatal(err)
+	}
+	pages := []model.VideoPage{{Cid: 1, Duration: 1000000, Page: 1}}
+	if err := videos.SavePageList(ctx, &model.VideoPageList{AID: 1, Version: 1, EffectiveFrom: from, Pages: pages}); err != nil {
+		t.Fatal(err)
+	}
+	strategy, err := service.NewWatchTimeStrategy(service.StrategyForward, service.NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := persistence.NewMemoryVideoProgressRepository()
+	aggregates := persistence.NewMemoryWatchTimeAggregateRepository()
+	catalog := NewVideoCatalogService(videos, offlineClient{})
+	aggregation := NewWatchTimeAggregationService(progress, aggregates, catalog, strategy, time.UTC)
+	labels := NewProgressLabelService(progress, catalog, service.NewPairClassifier(strategy.MaxSpeed()))
+	analytics := NewVideoAnalyticsService(catalog, progress, aggregates, strategy, aggregation, AttributionStart)
+	return exchangeFixture{
+		service:     NewProgressExchangeService(progress, catalog, analytics, aggregation, labels),
+		progress:    progress,
+		aggregates:  aggregates,
+		aggregation: aggregation,
+		labels:      labels,
+	}
+}
+
+// importRecords 生成 n 条每分钟推进 60 秒的进度记录。
+func importRecords(start time.Time, n int) []*model.VideoProgress {
+	records := make([]*model.VideoProgress, 0, n)
+	for i := 0; i < n; i++ {
+		records = append(records, &model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: 1,
+			LastPlayTime: int64(i) * 60000, RecordedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+	return records
+}
+
+func TestImportProgressRebuildsWrittenBatchesOnError(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixture := newExchangeFixture(t, start)
+
+	// 第一批写入之后遇到缺少 aid 的记录
+	records := append(importRecords(start, importBatchSize+10), &model.VideoProgress{RecordedAt: start})
+	result, err := fixture.service.ImportProgress(ctx, &sliceDecoder{records: records})
+	if err == nil {
+		t.Fatal("expected an error for the record without aid")
+	}
+	if result.Inserted != importBatchSize {
+		t.Fatalf("inserted = %d, want %d", result.Inserted, importBatchSize)
+	}
+
+	written, err := fixture.progress.ListPage(ctx, repository.ProgressFilter{AID: 1}, repository.ProgressPageRequest{Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range written[1:] {
+		if p.PairLabel != model.PairLabelForward {
+			t.Fatalf("record at %s labelled %q, want %q", p.RecordedAt, p.PairLabel, model.PairLabelForward)
+		}
+	}
+	daily, err := fixture.aggregates.ListDaily(ctx, 1, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily) != 1 || daily[0].WatchedSeconds != (importBatchSize-1)*60 {
+		t.Errorf("daily aggregates = %+v, want %d seconds", daily, (importBatchSize-1)*60)
+	}
+}
+
+func TestImportProgressTwice(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixture := newExchangeFixture(t, start)
+	records := importRecords(start, 30)
+
+	first, err := fixture.service.ImportProgress(ctx, &sliceDecoder{records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Inserted != 30 {
+		t.Fatalf("first import inserted %d, want 30", first.Inserted)
+	}
+	listAggregates := func() ([]model.WatchTimeAggregate, []model.WatchTimeAggregate) {
+		hourly, err := fixture.aggregates.ListHourly(ctx, 1, start, start.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		daily, err := fixture.aggregates.ListDaily(ctx, 1, start, start.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hourly, daily
+	}
+	hourly, daily := listAggregates()
+	if len(daily) != 1 || daily[0].WatchedSeconds != 29*60 {
+		t.Fatalf("daily aggregates after the first import = %+v, want %d seconds", daily, 29*60)
+	}
+
+	second, err := fixture.service.ImportProgress(ctx, &sliceDecoder{records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Inserted != 0 || second.Duplicates != 30 {
+		t.Errorf("second import inserted %d, skipped %d, want 0 and 30", second.Inserted, second.Duplicates)
+	}
+	hourlyAgain, dailyAgain := listAggregates()
+	if !sameAggregates(hourly, hourlyAgain) || !sameAggregates(daily, dailyAgain) {
+		t.Errorf("aggregates changed after the second import: hourly %+v -> %+v, daily %+v -> %+v", hourly, hourlyAgain, daily, dailyAgain)
+	}
+}
+
+// sameAggregates 比较两组聚合，忽略桶开始时间的时区表示。
+func sameAggregates(a, b []model.WatchTimeAggregate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if !x.BucketStart.Equal(y.BucketStart) {
+			return false
+		}
+		x.BucketStart, y.BucketStart = time.Time{}, time.Time{}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// labelUpdateBatchSize 重新分类时每批写回的修改条数。
+const labelUpdateBatchSize = 1000
+
+// errStopRelabel 用于在重新分类越过范围后提前结束遍历。
+var errStopRelabel = errors.New("stop relabel")
+
+// LabelChanges 重新分类的结果。
+type LabelChanges struct {
+	Changed int       // 分类发生变化的记录数
+	From    time.Time // 分类发生变化的最早记录时间，Changed 为 0 时为零值
+	To      time.Time // 分类发生变化的最晚记录时间
+}
+
+// add 记录一条分类发生变化的记录。
+func (c *LabelChanges) add(recordedAt time.Time) {
+	if c.Changed == 0 || recordedAt.Before(c.From) {
+		c.From = recordedAt
+	}
+	if c.Changed == 0 || recordedAt.After(c.To) {
+		c.To = recordedAt
+	}
+	c.Changed++
+}
+
+// ProgressLabelService 应用服务，给相邻进度记录对分类 (service.PairClassifier) 并把分类保存在记录上。
+// 被标记为可疑的记录对不计入观看时长、聚合、覆盖和会话。
+type ProgressLabelService struct {
+	progressRepo repository.VideoProgressRepository
+	catalog      *VideoCatalogService
+	classifier   service.PairClassifier
+}
+
+// NewProgressLabelService 创建 ProgressLabelService 实例。
+func NewProgressLabelService(
+	progressRepo repository.VideoProgressRepository,
+	catalog *VideoCatalogService,
+	classifier service.PairClassifier,
+) *ProgressLabelService {
+	return &ProgressLabelService{progressRepo: progressRepo, catalog: catalog, classifier: classifier}
+}
+
+// LabelNew 在新记录保存之前根据同一视频最近的两条记录给它分类 (设置 progress.PairLabel)。
+// 新记录说明上一条记录是异常数据时，把上一条记录改为可疑并返回它，调用方需要重建包含它的聚合。
+func (s *ProgressLabelService) LabelNew(ctx context.Context, progress *model.VideoProgress) (*model.VideoProgress, error) {
+	recent, err := s.progressRepo.ListPage(ctx, repository.ProgressFilter{AID: progress.AID, End: progress.RecordedAt},
+		repository.ProgressPageRequest{Limit: 2, Descending: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent progress: %w", err)
+	}
+	if len(recent) == 0 {
+		progress.PairLabel = model.PairLabelNone
+		return nil, nil
+	}
+	history, err := s.catalog.EnsurePageHistory(ctx, progress.AID)
+	if err != nil {
+		return nil, err
+	}
+	prev := recent[0]
+	var before *model.VideoProgress
+	if len(recent) > 1 {
+		before = recent[1]
+	}
+	label, relabelPrev := s.classifier.Classify(history, before, prev, progress)
+	progress.PairLabel = label
+	if !relabelPrev || prev.PairLabel == model.PairLabelSuspicious {
+		return nil, nil
+	}
+	prev.PairLabel = model.PairLabelSuspicious
+	if err := s.progressRepo.UpdatePairLabels(ctx, []repository.PairLabelUpdate{{ID: prev.ID, Label: prev.PairLabel}}); err != nil {
+		return nil, err
+	}
+	log.Printf("Labels: AID %d record at %s relabeled as suspicious (returned to earlier position at %s)",
+		progress.AID, prev.RecordedAt, progress.RecordedAt)
+	return prev, nil
+}
+
+// Relabel 重新计算视频在 [first, last] 内 (零值表示不限) 的记录的分类，只写回发生变化的记录。
+// first 之前的两条记录作为上下文 (上一条也可能被改为可疑)，last 之后再处理两条记录 (它们的分类依赖前面的记录)。
+func (s *ProgressLabelService) Relabel(ctx context.Context, aid int64, first, last time.Time) (LabelChanges, error) {
+	var changes LabelChanges
+	history, err := s.catalog.EnsurePageHistory(ctx, aid)
+	if err != nil {
+		return changes, err
+	}
+
+	// window 保存最近两条记录 (before, prev) 及其原有分类，记录移出窗口时分类才确定
+	var window []*model.VideoProgress
+	original := make(map[uint]model.PairLabel)
+	if !first.IsZero() {
+		recent, err := s.progressRepo.ListPage(ctx, repository.ProgressFilter{AID: aid, End: first},
+			repository.ProgressPageRequest{Limit: 2, Descending: true})
+		if err != nil {
+			return changes, fmt.Errorf("failed to list progress before %s: %w", first, err)
+		}
+		for i := len(recent) - 1; i >= 0; i-- {
+			window = append(window, recent[i])
+			original[recent[i].ID] = recent[i].PairLabel
+		}
+	}
+
+	var updates []repository.PairLabelUpdate
+	finalize := func(p *model.VideoProgress) error {
+		if p.PairLabel != original[p.ID] {
+			updates = append(updates, repository.PairLabelUpdate{ID: p.ID, Label: p.PairLabel})
+			changes.add(p.RecordedAt)
+		}
+		delete(original, p.ID)
+		if len(updates) >= labelUpdateBatchSize {
+			if err := s.progressRepo.UpdatePairLabels(ctx, updates); err != nil {
+				return err
+			}
+			updates = updates[:0]
+		}
+		return nil
+	}
+
+	beyond := 0
+	err = s.progressRepo.Iterate(ctx, repository.ProgressFilter{AID: aid, Start: first}, func(curr *model.VideoProgress) error {
+		if !last.IsZero() && curr.RecordedAt.After(last) {
+			if beyond == 2 {
+				return errStopRelabel
+			}
+			beyond++
+		}
+		original[curr.ID] = curr.PairLabel
+		var before, prev *model.VideoProgress
+		if n := len(window); n > 0 {
+			prev = window[n-1]
+			if n > 1 {
+				before = window[n-2]
+			}
+		}
+		label, relabelPrev := s.classifier.Classify(history, before, prev, curr)
+		curr.PairLabel = label
+		if relabelPrev {
+			prev.PairLabel = model.PairLabelSuspicious
+		}
+		window = append(window, curr)
+		if len(window) > 2 {
+			if err := finalize(window[0]); err != nil {
+				return err
+			}
+			window = window[1:]
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopRelabel) {
+		return changes, fmt.Errorf("failed to relabel progress for aid %d: %w", aid, err)
+	}
+	for _, p := range window {
+		if err := finalize(p); err != nil {
+			return changes, err
+		}
+	}
+	if err := s.progressRepo.UpdatePairLabels(ctx, updates); err != nil {
+		return changes, err
+	}
+	return changes, nil
+}
+
+// RelabelAll 重新计算所有视频全部记录的分类，返回每个视频分类发生变化的记录。
+func (s *ProgressLabelService) RelabelAll(ctx context.Context) (map[int64]LabelChanges, error) {
+	aids, err := s.progressRepo.ListDistinctAIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked videos: %w", err)
+	}
+	result := make(map[int64]LabelChanges, len(aids))
+	for _, aid := range aids {
+		changes, err := s.Relabel(ctx, aid, time.Time{}, time.Time{})
+		if err != nil {
+			return result, err
+		}
+		if changes.Changed > 0 {
+			log.Printf("Labels: relabeled %d records of AID %d in [%s, %s]", changes.Changed, aid, changes.From, changes.To)
+		}
+		result[aid] = changes
+	}
+	return result, nil
+}
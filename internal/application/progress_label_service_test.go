@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+func TestProgressLabelServiceRelabel(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixture := newExchangeFixture(t, start)
+	points := []struct {
+		minute     int
+		positionMs int64
+		want       model.PairLabel
+	}{
+		{0, 100000, model.PairLabelNone},
+		{1, 160000, model.PairLabelForward},
+		{2, 900000, model.PairLabelSuspicious}, // 超出倍速上限的前跳
+		{3, 280000, model.PairLabelSuspicious}, // 又回到跳转前的位置之后，前跳是假的
+		{4, 340000, model.PairLabelForward},
+		{5, 0, model.PairLabelReset},
+		{6, 20000, model.PairLabelForward},
+		{7, 10000, model.PairLabelSeekBack},
+	}
+	records := make([]*model.VideoProgress, 0, len(points))
+	for _, p := range points {
+		records = append(records, &model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: 1, LastPlayTime: p.positionMs,
+			RecordedAt: start.Add(time.Duration(p.minute) * time.Minute)})
+	}
+	if _, err := fixture.progress.InsertIgnoreDuplicates(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := fixture.labels.Relabel(ctx, 1, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Changed != len(points)-1 {
+		t.Errorf("changed = %d, want %d", changes.Changed, len(points)-1)
+	}
+	labelled, err := fixture.progress.ListPage(ctx, repository.ProgressFilter{AID: 1}, repository.ProgressPageRequest{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range labelled {
+		if p.PairLabel != points[i].want {
+			t.Errorf("record at minute %d labelled %q, want %q", points[i].minute, p.PairLabel, points[i].want)
+		}
+	}
+
+	// 再次分类不应有变化
+	if changes, err = fixture.labels.Relabel(ctx, 1, start.Add(3*time.Minute), start.Add(5*time.Minute)); err != nil || changes.Changed != 0 {
+		t.Errorf("second relabel changed %d records (error %v), want 0", changes.Changed, err)
+	}
+}
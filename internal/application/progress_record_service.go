@@ -0,0 +1,123 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// ErrInvalidCursor 表示分页游标无法解析。
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	// defaultProgressPageSize 未指定条数时每页返回的记录数。
+	defaultProgressPageSize = 100
+	// maxProgressPageSize 每页最多返回的记录数，超过时按此值截断。
+	maxProgressPageSize = 1000
+)
+
+// ProgressRecordQuery 查询原始进度记录的条件。
+type ProgressRecordQuery struct {
+	BVID       string
+	CID        int64           // 只返回 LastPlayCID 为该分P的记录，0 表示不限
+	PairLabel  model.PairLabel // 只返回记录对为该分类的记录，空表示不限
+	Start      time.Time       // 开始时间 (含)，零值表示不限
+	End        time.Time       // 结束时间 (不含)，零值表示不限
+	Cursor     string          // 上一页返回的 NextCursor，为空表示第一页
+	Limit      int
+	Descending bool
+}
+
+// ProgressRecordPage 一页原始进度记录。
+type ProgressRecordPage struct {
+	Records []*model.VideoProgress
+	// NextCursor 为下一页的游标，没有更多记录时为空。
+	NextCursor string
+}
+
+// ProgressRecordService 应用服务，提供原始进度记录的分页查询。
+type ProgressRecordService struct {
+	catalog      *VideoCatalogService
+	progressRepo repository.VideoProgressRepository
+}
+
+// NewProgressRecordService 创建 ProgressRecordService 实例。
+func NewProgressRecordService(catalog *VideoCatalogService, progressRepo repository.VideoProgressRepository) *ProgressRecordService {
+	return &ProgressRecordService{catalog: catalog, progressRepo: progressRepo}
+}
+
+// ListRecords 按 (记录时间, ID) 顺序返回一页指定视频的原始进度记录。
+// Limit 不大于 0 时使用默认值，超过上限时按上限截断。
+func (s *ProgressRecordService) ListRecords(ctx context.Context, query ProgressRecordQuery) (ProgressRecordPage, error) {
+	var after *repository.ProgressCursor
+	if query.Cursor != "" {
+		cursor, err := decodeProgressCursor(query.Cursor)
+		if err != nil {
+			return ProgressRecordPage{}, err
+		}
+		after = cursor
+	}
+	video, err := s.catalog.GetVideo(ctx, "", query.BVID)
+	if err != nil {
+		return ProgressRecordPage{}, fmt.Errorf("获取视频信息失败: %w", err)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultProgressPageSize
+	}
+	if limit > maxProgressPageSize {
+		limit = maxProgressPageSize
+	}
+
+	// 多读取一条用于判断是否还有下一页
+	records, err := s.progressRepo.ListPage(ctx,
+		repository.ProgressFilter{AID: video.AID, CID: query.CID, PairLabel: query.PairLabel, Start: query.Start, End: query.End},
+		repository.ProgressPageRequest{After: after, Limit: limit + 1, Descending: query.Descending},
+	)
+	if err != nil {
+		return ProgressRecordPage{}, fmt.Errorf("列出进度记录失败: %w", err)
+	}
+
+	page := ProgressRecordPage{Records: records}
+	if len(records) > limit {
+		page.Records = records[:limit]
+		last := page.Records[limit-1]
+		page.NextCursor = encodeProgressCursor(repository.ProgressCursor{RecordedAt: last.RecordedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// encodeProgressCursor 把游标编码为不透明的字符串 (记录时间的 Unix 纳秒数和 ID)。
+func encodeProgressCursor(cursor repository.ProgressCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.RecordedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeProgressCursor 解析 encodeProgressCursor 生成的游标。
+func decodeProgressCursor(value string) (*repository.ProgressCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	nanosStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return &repository.ProgressCursor{RecordedAt: time.Unix(0, nanos).UTC(), ID: uint(id)}, nil
+}
@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+func TestProgressCursorRoundTrip(t *testing.T) {
+	for _, cursor := range []repository.ProgressCursor{
+		{RecordedAt: time.Date(2025, 3, 1, 8, 30, 0, 123456789, time.UTC), ID: 42},
+		{RecordedAt: time.Date(2025, 3, 1, 16, 30, 0, 0, time.FixedZone("CST", 8*3600)), ID: 1},
+		{RecordedAt: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), ID: 0}, // Unix 纳秒数为负
+	} {
+		encoded := encodeProgressCursor(cursor)
+		decoded, err := decodeProgressCursor(encoded)
+		if err != nil {
+			t.Fatalf("decode %q: %v", encoded, err)
+		}
+		if !decoded.RecordedAt.Equal(cursor.RecordedAt) || decoded.RecordedAt.Location() != time.UTC || decoded.ID != cursor.ID {
+			t.Errorf("round trip of %+v = %+v", cursor, decoded)
+		}
+	}
+}
+
+func TestDecodeProgressCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	for _, value := range []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("1:12")), // 带填充的标准编码
+		encode(""),
+		encode("1741000000000000000"),
+		encode(":1"),
+		encode("1741000000000000000:"),
+		encode("abc:1"),
+		encode("1741000000000000000:x"),
+		encode("1741000000000000000:-1"),
+		encode("1741000000000000000:1:2"),
+		encode("99999999999999999999:1"),
+	} {
+		if _, err := decodeProgressCursor(value); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decode %q: err = %v, want ErrInvalidCursor", value, err)
+		}
+	}
+}
+
+func TestListRecordsPagesThroughAllRecords(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	videos := persistence.NewMemoryVideoRepository()
+	if err := videos.Save(ctx, &model.Video{AID: 1, BVID: "BV1", PageVersion: 1, RefreshedAt: start}); err != nil {
+		t.Fatal(err)
+	}
+	progress := persistence.NewMemoryVideoProgressRepository()
+	// 每两条记录的时间相同，翻页需要用 ID 区分
+	const total = maxProgressPageSize + 5
+	for i := 0; i < total; i++ {
+		p := &model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: 1, LastPlayTime: int64(i), RecordedAt: start.Add(time.Duration(i/2) * time.Minute)}
+		if err := progress.Save(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records := NewProgressRecordService(NewVideoCatalogService(videos, offlineClient{}), progress)
+
+	if _, err := records.ListRecords(ctx, ProgressRecordQuery{BVID: "BV1", Cursor: "???"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("invalid cursor: err = %v, want ErrInvalidCursor", err)
+	}
+
+	for _, tt := range []struct {
+		limit int
+		sizes []int
+	}{
+		{limit: 0, sizes: []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 5}},
+		{limit: 400, sizes: []int{400, 400, 205}},
+		{limit: maxProgressPageSize, sizes: []int{maxProgressPageSize, 5}},
+		{limit: 5000, sizes: []int{maxProgressPageSize, 5}},
+		{limit: total, sizes: []int{maxProgressPageSize, 5}},
+	} {
+		var sizes []int
+		seen := make(map[int64]bool)
+		cursor := ""
+		for {
+			page, err := records.ListRecords(ctx, ProgressRecordQuery{BVID: "BV1", Cursor: cursor, Limit: tt.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sizes = append(sizes, len(page.Records))
+			for _, p := range page.Records {
+				if seen[p.LastPlayTime] {
+					t.Fatalf("limit %d: record %d returned twice", tt.limit, p.LastPlayTime)
+				}
+				seen[p.LastPlayTime] = true
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		if len(seen) != total || len(sizes) != len(tt.sizes) {
+			t.Errorf("limit %d: %d records in pages %v, want %d in %v", tt.limit, len(seen), sizes, total, tt.sizes)
+			continue
+		}
+		for i := range sizes {
+			if sizes[i] != tt.sizes[i] {
+				t.Errorf("limit %d: pages %v, want %v", tt.limit, sizes, tt.sizes)
+				break
+			}
+		}
+	}
+}
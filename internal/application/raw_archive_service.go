@@ -0,0 +1,134 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// ReprocessResult 包含一次从归档重新生成进度记录的统计结果。
+type ReprocessResult struct {
+	Responses int64   // 扫描的原始进度响应数
+	Progress  int64   // 解析出有效进度的响应数
+	Written   int64   // 写入的进度记录数 (不覆盖模式下不含已存在的记录)
+	Failed    int64   // 解析失败的响应数
+	AIDs      []int64 // 涉及的视频
+}
+
+// RawArchiveService 应用服务，负责保存 Bilibili API 原始响应，并从归档重新生成进度记录。
+// 它实现了 RawResponseArchiver，由 Bilibili 客户端在每次请求后调用。
+type RawArchiveService struct {
+	archiveRepo  repository.RawResponseRepository
+	progressRepo repository.VideoProgressRepository
+	parser       RawProgressParser
+	aggregation  *WatchTimeAggregationService
+	labels       *ProgressLabelService
+}
+
+// NewRawArchiveService 创建 RawArchiveService 实例。
+func NewRawArchiveService(
+	archiveRepo repository.RawResponseRepository,
+	progressRepo repository.VideoProgressRepository,
+	parser RawProgressParser,
+	aggregation *WatchTimeAggregationService,
+	labels *ProgressLabelService,
+) *RawArchiveService {
+	return &RawArchiveService{
+		archiveRepo:  archiveRepo,
+		progressRepo: progressRepo,
+		parser:       parser,
+		aggregation:  aggregation,
+		labels:       labels,
+	}
+}
+
+// ArchiveRawResponse 保存一条原始响应。失败只记录日志，不影响调用方。
+func (s *RawArchiveService) ArchiveRawResponse(ctx context.Context, response *model.RawResponse) {
+	if !json.Valid(response.Body) {
+		log.Printf("Archive: skipping invalid JSON %s response for AID %d", response.Kind, response.AID)
+		return
+	}
+	if err := s.archiveRepo.Save(ctx, response); err != nil {
+		log.Printf("Archive: failed to save raw %s response for AID %d: %v", response.Kind, response.AID, err)
+	}
+}
+
+// Reprocess 从归档中的原始进度响应重新生成 [start, end) 范围内的进度记录，aid 为 0 时处理所有视频。
+// 生成的记录以响应的获取时间作为记录时间。overwrite 为 false 时只补充缺失的记录，
+// 为 true 时覆盖已存在记录的分P和播放进度 (例如解析规则变化后)。处理结束后重新计算受影响范围的记录对分类并重建聚合，
+// 中途出错时已写入的批次同样会处理。
+func (s *RawArchiveService) Reprocess(ctx context.Context, aid int64, start, end time.Time, overwrite bool) (result ReprocessResult, err error) {
+	ranges := make(map[int64]*timeRange)
+	batch := make([]*model.VideoProgress, 0, importBatchSize)
+	defer func() {
+		if result.Written > 0 {
+			refreshRanges(ctx, s.labels, s.aggregation, result.AIDs, ranges, "Archive")
+		}
+	}()
+
+	flush := func() error {
+		if overwrite {
+			if err := s.progressRepo.UpsertBatch(ctx, batch); err != nil {
+				return err
+			}
+			result.Written += int64(len(batch))
+		} else {
+			inserted, err := s.progressRepo.InsertIgnoreDuplicates(ctx, batch)
+			if err != nil {
+				return err
+			}
+			result.Written += inserted
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	filter := repository.RawResponseFilter{Kind: model.RawResponseKindProgress, AID: aid, Start: start, End: end}
+	err = s.archiveRepo.Iterate(ctx, filter, func(response *model.RawResponse) error {
+		result.Responses++
+		dto, err := s.parser.ParseVideoProgress(response.Body)
+		if err != nil {
+			result.Failed++
+			log.Printf("Archive: failed to parse raw response %d (AID %d at %s): %v", response.ID, response.AID, response.FetchedAt, err)
+			return nil
+		}
+		if dto == nil {
+			return nil // 响应中没有有效进度，与获取时的行为一致
+		}
+		result.Progress++
+
+		r, ok := ranges[dto.AID]
+		if !ok {
+			r = &timeRange{from: response.FetchedAt, to: response.FetchedAt}
+			ranges[dto.AID] = r
+			result.AIDs = append(result.AIDs, dto.AID)
+		}
+		r.to = response.FetchedAt // 按获取时间升序遍历
+
+		batch = append(batch, &model.VideoProgress{
+			AID:          dto.AID,
+			BVID:         dto.BVID,
+			LastPlayCID:  dto.LastPlayCid,
+			LastPlayTime: dto.LastPlayTime,
+			RecordedAt:   response.FetchedAt.UTC(),
+		})
+		if len(batch) == importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to reprocess archive: %w", err)
+	}
+	if err := flush(); err != nil {
+		return result, fmt.Errorf("failed to reprocess archive: %w", err)
+	}
+	log.Printf("Archive: reprocessed %d raw responses, %d with progress, wrote %d records, %d failed",
+		result.Responses, result.Progress, result.Written, result.Failed)
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+// jsonProgressParser 把响应体直接解析为 VideoProgressDTO。
+type jsonProgressParser struct{}
+
+func (jsonProgressParser) ParseVideoProgress(body []byte) (*VideoProgressDTO, error) {
+	var dto VideoProgressDTO
+	if err := json.Unmarshal(body, &dto); err != nil {
+		return nil, err
+	}
+	return &dto, nil
+}
+
+// failingProgressRepository 在第 failAt 次批量插入时返回错误，其余调用交给内嵌的仓库。
+type failingProgressRepository struct {
+	repository.VideoProgressRepository
+	calls, failAt int
+}
+
+func (r *failingProgressRepository) InsertIgnoreDuplicates(ctx context.Context, records []*model.VideoProgress) (int64, error) {
+	r.calls++
+	if r.calls == r.failAt {
+		return 0, errors.New("insert failed")
+	}
+	return r.VideoProgressRepository.InsertIgnoreDuplicates(ctx, records)
+}
+
+func TestReprocessRebuildsWrittenBatchesOnError(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixture := newExchangeFixture(t, start)
+
+	archive := persistence.NewMemoryRawResponseRepository()
+	for _, p := range importRecords(start, importBatchSize+10) {
+		body, err := json.Marshal(VideoProgressDTO{AID: p.AID, BVID: p.BVID, LastPlayCid: p.LastPlayCID, LastPlayTime: p.LastPlayTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := archive.Save(ctx, &model.RawResponse{Kind: model.RawResponseKindProgress, AID: p.AID, BVID: p.BVID,
+			CID: p.LastPlayCID, FetchedAt: p.RecordedAt, Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一批写入成功，最后一批失败
+	progress := &failingProgressRepository{VideoProgressRepository: fixture.progress, failAt: 2}
+	archiveService := NewRawArchiveService(archive, progress, jsonProgressParser{}, fixture.aggregation, fixture.labels)
+	result, err := archiveService.Reprocess(ctx, 0, time.Time{}, time.Time{}, false)
+	if err == nil {
+		t.Fatal("expected the failed batch to be reported")
+	}
+	if result.Written != importBatchSize {
+		t.Fatalf("written = %d, want %d", result.Written, importBatchSize)
+	}
+
+	written, err := fixture.progress.ListPage(ctx, repository.ProgressFilter{AID: 1}, repository.ProgressPageRequest{Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range written[1:] {
+		if p.PairLabel != model.PairLabelForward {
+			t.Fatalf("record at %s labelled %q, want %q", p.RecordedAt, p.PairLabel, model.PairLabelForward)
+		}
+	}
+	daily, err := fixture.aggregates.ListDaily(ctx, 1, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily) != 1 || daily[0].WatchedSeconds != (importBatchSize-1)*60 {
+		t.Errorf("daily aggregates = %+v, want %d seconds", daily, (importBatchSize-1)*60)
+	}
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// RetentionService 应用服务，负责原始进度记录的保留策略。
+// 超过保留期的原始记录先确保已汇总为小时/天级观看时长聚合，再从 video_progress 中删除。
+type RetentionService struct {
+	progressRepo  repository.VideoProgressRepository
+	aggregateRepo repository.WatchTimeAggregateRepository
+	aggregation   *WatchTimeAggregationService
+	retention     time.Duration // 原始记录保留时长
+}
+
+// NewRetentionService 创建 RetentionService 实例。
+// retention: 原始记录保留时长，必须为正数。
+func NewRetentionService(
+	progressRepo repository.VideoProgressRepository,
+	aggregateRepo repository.WatchTimeAggregateRepository,
+	aggregation *WatchTimeAggregationService,
+	retention time.Duration,
+) *RetentionService {
+	return &RetentionService{
+		progressRepo:  progressRepo,
+		aggregateRepo: aggregateRepo,
+		aggregation:   aggregation,
+		retention:     retention,
+	}
+}
+
+// Run 对所有视频执行一次汇总和清理，now 之前超过保留期的原始记录会被处理。
+// 单个视频失败不会中断其他视频的处理，最后返回遇到的第一个错误。
+func (s *RetentionService) Run(ctx context.Context, now time.Time) error {
+	if s.retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	cutoff := now.Add(-s.retention)
+	aids, err := s.progressRepo.ListDistinctAIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked videos: %w", err)
+	}
+
+	var firstErr error
+	for _, aid := range aids {
+		if err := s.rollupVideo(ctx, aid, cutoff); err != nil {
+			log.Printf("Retention: failed to roll up AID %d before %s: %v", aid, cutoff, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// rollupVideo 汇总并清理单个视频在 cutoff 之前的原始记录。
+//
+// 新的水位线 H 为 cutoff 向下取整到聚合时区的整点：先从原始记录重建 [旧水位线, H) 的聚合，
+// 确保起点早于 H 的记录对都已计入聚合，然后推进水位线并删除 H 之前最后一条记录 (锚点) 之前的记录。
+// 锚点本身保留，作为跨越 H 的记录对的起点。整个过程持有视频的聚合锁，与新记录的增量累加串行执行。
+func (s *RetentionService) rollupVideo(ctx context.Context, aid int64, cutoff time.Time) error {
+	unlock, err := s.aggregation.lockVideo(ctx, aid)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	watermark, err := s.aggregateRepo.GetWatermark(ctx, aid)
+	if err != nil {
+		return err
+	}
+	newWatermark := s.aggregation.HourStart(cutoff)
+	if !newWatermark.After(watermark) {
+		return nil // 没有新的过期记录
+	}
+
+	from := watermark
+	if from.IsZero() {
+		from = time.Unix(0, 0) // 避免向数据库传入零值时间
+	}
+	if err := s.aggregation.rebuild(ctx, aid, from, newWatermark); err != nil {
+		return err
+	}
+	if err := s.aggregateRepo.SetWatermark(ctx, aid, newWatermark); err != nil {
+		return err
+	}
+
+	anchor, err := s.progressRepo.GetLatestByAIDBefore(ctx, aid, newWatermark)
+	if err != nil {
+		return err
+	}
+	if anchor == nil {
+		return nil
+	}
+	deleted, err := s.progressRepo.DeleteByAIDBefore(ctx, aid, anchor.RecordedAt)
+	if err != nil {
+		return err
+	}
+	log.Printf("Retention: AID %d watermark advanced to %s, pruned %d raw records before %s",
+		aid, newWatermark, deleted, anchor.RecordedAt)
+	return nil
+}
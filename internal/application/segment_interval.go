@@ -0,0 +1,88 @@
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// SegmentInterval 观看分段的间隔：固定时长，或按日历对齐的单位 (Unit 不为空时)。
+//
+// 固定时长的分段从开始时间起算，整天的时长在时区内按日历日推进 (夏令时切换当天为 23 或 25 小时)；
+// 日历单位的分段对齐到时区内的日、ISO 周、月、季度或年，每天从 CutoffHour 点开始，范围向外扩展到完整的周期。
+type SegmentInterval struct {
+	Duration   time.Duration
+	Unit       service.CalendarUnit
+	CutoffHour int // 日历单位下每天开始的整点 (0-23)，例如 4 表示凌晨 4 点前的观看计入前一天
+}
+
+// FixedInterval 返回固定时长的分段间隔。
+func FixedInterval(d time.Duration) SegmentInterval {
+	return SegmentInterval{Duration: d}
+}
+
+// CalendarInterval 返回按日历对齐的分段间隔。
+func CalendarInterval(unit service.CalendarUnit, cutoffHour int) SegmentInterval {
+	return SegmentInterval{Unit: unit, CutoffHour: cutoffHour}
+}
+
+// ParseSegmentInterval 解析分段间隔：日历单位名称 (day, week, month, quarter, year)，
+// 或固定时长 (time.ParseDuration 支持的格式，另外支持 'd' 表示天，如 1d)。cutoffHour 只用于日历单位。
+func ParseSegmentInterval(value string, cutoffHour int) (SegmentInterval, error) {
+	if unit, err := service.ParseCalendarUnit(value); err == nil {
+		interval := CalendarInterval(unit, cutoffHour)
+		return interval, interval.validate()
+	}
+	durationStr := value
+	if daysStr, ok := strings.CutSuffix(value, "d"); ok {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil {
+			return SegmentInterval{}, fmt.Errorf("invalid interval %q: %w", value, err)
+		}
+		durationStr = fmt.Sprintf("%dh", days*24)
+	}
+	d, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return SegmentInterval{}, fmt.Errorf("invalid interval %q: %w", value, err)
+	}
+	interval := FixedInterval(d)
+	return interval, interval.validate()
+}
+
+// IsCalendar 判断是否为按日历对齐的间隔。
+func (i SegmentInterval) IsCalendar() bool {
+	return i.Unit != ""
+}
+
+// String 返回间隔的文本表示。
+func (i SegmentInterval) String() string {
+	if i.IsCalendar() {
+		if i.CutoffHour != 0 {
+			return fmt.Sprintf("%s (cutoff %02d:00)", i.Unit, i.CutoffHour)
+		}
+		return string(i.Unit)
+	}
+	return i.Duration.String()
+}
+
+// validate 校验间隔是否有效。
+func (i SegmentInterval) validate() error {
+	if i.IsCalendar() {
+		if i.CutoffHour < 0 || i.CutoffHour > 23 {
+			return fmt.Errorf("day cutoff hour must be between 0 and 23, got %d", i.CutoffHour)
+		}
+		return nil
+	}
+	if i.Duration <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	return nil
+}
+
+// atLeastDay 判断每个分段是否都由整天组成 (按天的固定时长或日历单位)，只有这样分段才可能对齐天聚合。
+func (i SegmentInterval) atLeastDay() bool {
+	return i.IsCalendar() || i.Duration%(24*time.Hour) == 0
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// VideoSessions 一个视频在一段时间内识别出的观看会话。
+type VideoSessions struct {
+	Video    *model.Video
+	Pages    []model.VideoPage // 最新的分P列表，用于展示会话经过的分P
+	Sessions []service.ViewingSession
+	MaxSpeed float64 // 观看时长策略的最大倍速，推断会话倍速时作为上限
+}
+
+// SessionService 应用服务，把原始进度记录划分为观看会话。
+type SessionService struct {
+	catalog        *VideoCatalogService
+	progressRepo   repository.VideoProgressRepository
+	strategy       service.WatchTimeStrategy
+	defaultIdleGap time.Duration
+}
+
+// NewSessionService 创建 SessionService 实例。defaultIdleGap 为未指定空闲间隔时使用的值。
+func NewSessionService(
+	catalog *VideoCatalogService,
+	progressRepo repository.VideoProgressRepository,
+	strategy service.WatchTimeStrategy,
+	defaultIdleGap time.Duration,
+) *SessionService {
+	return &SessionService{catalog: catalog, progressRepo: progressRepo, strategy: strategy, defaultIdleGap: defaultIdleGap}
+}
+
+// DefaultIdleGap 返回未指定空闲间隔时使用的值。
+func (s *SessionService) DefaultIdleGap() time.Duration {
+	return s.defaultIdleGap
+}
+
+// GetSessions 返回视频在 [start, end) 范围内 (零值表示不限) 的观看会话。
+// 相邻两次有效观看之间超过 idleGap 时开始新的会话，idleGap 不大于 0 时使用默认值。
+// 超过保留期被清理的原始记录和可疑的记录对不参与计算。
+func (s *SessionService) GetSessions(ctx context.Context, bvid string, start, end time.Time, idleGap time.Duration) (*VideoSessions, error) {
+	if idleGap <= 0 {
+		idleGap = s.defaultIdleGap
+	}
+	video, err := s.catalog.GetVideo(ctx, "", bvid)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频信息失败: %w", err)
+	}
+	history, err := s.catalog.GetPageHistory(ctx, video.AID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频分P历史失败: %w", err)
+	}
+	latest, ok := history.Latest()
+	if !ok {
+		return nil, fmt.Errorf("视频没有分页信息")
+	}
+
+	// 范围之前到达过的位置决定范围内的向前播放是首次观看还是重看
+	reached, err := s.progressRepo.ReachedPositions(ctx, video.AID, start, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取分P最远播放位置失败: %w", err)
+	}
+	builder := service.NewSessionBuilder(s.strategy, idleGap, reached)
+	var prev *model.VideoProgress
+	filter := repository.ProgressFilter{AID: video.AID, Start: start, End: end}
+	err = s.progressRepo.Iterate(ctx, filter, func(progress *model.VideoProgress) error {
+		if progress.PairLabel == model.PairLabelSuspicious {
+			prev = progress
+			return nil
+		}
+		if prev != nil {
+			from, to := playbackPoint(prev), playbackPoint(progress)
+			// 无法计算的记录对 (后退、分P不存在等) 不计入会话，与观看分段一致
+			_ = withPairPages(history, prev, progress, func(pages []model.VideoPage) error {
+				return builder.AddPair(pages, from, to)
+			})
+		}
+		service.ReachedPositions(reached).Advance(playbackPoint(progress))
+		prev = progress
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历进度记录失败: %w", err)
+	}
+	return &VideoSessions{Video: video, Pages: latest.Pages, Sessions: builder.Sessions(), MaxSpeed: s.strategy.MaxSpeed()}, nil
+}
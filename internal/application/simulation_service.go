@@ -0,0 +1,194 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+const (
+	// MaxSimulationPoints 一次模拟最多的进度点数。
+	MaxSimulationPoints = 10000
+	// MaxSimulationPages 一次模拟最多的分P数。
+	MaxSimulationPages = 1000
+)
+
+// simulationAID / simulationBVID 模拟使用的虚拟视频，只存在于本次模拟的仓库中。
+const (
+	simulationAID  int64 = 1
+	simulationBVID       = "BVsimulation"
+)
+
+// ErrInvalidSimulation 表示模拟请求的参数无效。
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// SimulationRepositories 一次模拟使用的仓库，每次模拟都重新创建且互不共享 (通常为内存实现)，不会读写真实数据。
+type SimulationRepositories struct {
+	Videos     repository.VideoRepository
+	Progress   repository.VideoProgressRepository
+	Aggregates repository.WatchTimeAggregateRepository
+}
+
+// SimulatedPair 模拟中一对相邻进度点的计算结果，用于排查观看时长的归属。
+type SimulatedPair struct {
+	Start, End service.PlaybackPoint
+	Label      model.PairLabel            // 记录对的分类 (保存在终点上)
+	Breakdown  service.WatchTimeBreakdown // 按观看时长策略计算的结果，可疑的记录对同样计算以便对照
+	Err        error                      // 无法计算的原因，如分P不存在或位置超出分P时长
+}
+
+// SimulationResult 模拟的结果：与 GetWatchedSegments 相同的分段结果，以及每个记录对的明细。
+type SimulationResult struct {
+	Analytics VideoAnalyticsResult
+	Pairs     []SimulatedPair
+}
+
+// SimulationService 应用服务，用任意的进度点和分P列表走一遍完整的计算流程 (分类、聚合、分段)，
+// 不读写数据库，用于调试观看时长的计算和归属。
+type SimulationService struct {
+	newRepositories func() SimulationRepositories
+	strategy        service.WatchTimeStrategy
+	classifier      service.PairClassifier
+	attribution     Attribution
+	location        *time.Location
+}
+
+// NewSimulationService 创建 SimulationService 实例。
+// newRepositories 为每次模拟创建隔离的仓库；策略、分类器、默认归属方式和聚合时区应与正式服务一致，模拟结果才有参考意义。
+func NewSimulationService(
+	newRepositories func() SimulationRepositories,
+	strategy service.WatchTimeStrategy,
+	classifier service.PairClassifier,
+	attribution Attribution,
+	location *time.Location,
+) *SimulationService {
+	return &SimulationService{
+		newRepositories: newRepositories,
+		strategy:        strategy,
+		classifier:      classifier,
+		attribution:     attribution,
+		location:        location,
+	}
+}
+
+// Simulate 把 points 作为一个分P列表为 pages 的虚拟视频的进度记录，像正式数据一样分类并重建聚合，
+// 再调用 GetWatchedSegments 计算 [start, end) 内的观看分段 (其余参数与 GetWatchedSegments 相同)。
+// points 可以无序，但记录时间不能重复；参数无效时返回 ErrInvalidSimulation。
+func (s *SimulationService) Simulate(ctx context.Context,
+	pages []model.VideoPage,
+	points []service.PlaybackPoint,
+	start, end time.Time,
+	interval SegmentInterval,
+	loc *time.Location,
+	attribution Attribution,
+	includeSuspicious bool,
+) (*SimulationResult, error) {
+	if err := validateSimulation(pages, points); err != nil {
+		return nil, err
+	}
+	points = append([]service.PlaybackPoint(nil), points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].RecordedAt.Before(points[j].RecordedAt) })
+
+	// 在隔离的仓库中建立虚拟视频和进度记录
+	repos := s.newRepositories()
+	catalog := NewVideoCatalogService(repos.Videos, offlineClient{})
+	first, last := points[0].RecordedAt, points[len(points)-1].RecordedAt
+	if err := repos.Videos.Save(ctx, &model.Video{AID: simulationAID, BVID: simulationBVID, Title: "simulation",
+		PageVersion: 1, RefreshedAt: first}); err != nil {
+		return nil, fmt.Errorf("failed to save simulated video: %w", err)
+	}
+	if err := repos.Videos.SavePageList(ctx, &model.VideoPageList{AID: simulationAID, Version: 1,
+		EffectiveFrom: first, Pages: pages}); err != nil {
+		return nil, fmt.Errorf("failed to save simulated pages: %w", err)
+	}
+	records := make([]*model.VideoProgress, 0, len(points))
+	for _, p := range points {
+		records = append(records, &model.VideoProgress{AID: simulationAID, BVID: simulationBVID,
+			LastPlayCID: p.CID, LastPlayTime: p.PositionSec * 1000, RecordedAt: p.RecordedAt})
+	}
+	if _, err := repos.Progress.InsertIgnoreDuplicates(ctx, records); err != nil {
+		return nil, fmt.Errorf("failed to save simulated progress: %w", err)
+	}
+
+	// 与正式数据相同：分类记录对，重建聚合，再计算分段
+	labels := NewProgressLabelService(repos.Progress, catalog, s.classifier)
+	if _, err := labels.Relabel(ctx, simulationAID, time.Time{}, time.Time{}); err != nil {
+		return nil, fmt.Errorf("failed to label simulated progress: %w", err)
+	}
+	aggregation := NewWatchTimeAggregationService(repos.Progress, repos.Aggregates, catalog, s.strategy, s.location)
+	if err := aggregation.Rebuild(ctx, simulationAID, first, last.Add(time.Nanosecond)); err != nil {
+		return nil, fmt.Errorf("failed to aggregate simulated progress: %w", err)
+	}
+	analytics := NewVideoAnalyticsService(catalog, repos.Progress, repos.Aggregates, s.strategy, aggregation, s.attribution)
+	segments, err := analytics.GetWatchedSegments(ctx, "", simulationBVID, start, end, interval, loc, attribution, includeSuspicious)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{Analytics: segments, Pairs: make([]SimulatedPair, 0, len(points)-1)}
+	var prev *model.VideoProgress
+	reached := make(service.ReachedPositions) // 与分析结果一致，按各分P到达过的最远位置划分重看
+	err = repos.Progress.Iterate(ctx, repository.ProgressFilter{AID: simulationAID}, func(curr *model.VideoProgress) error {
+		if prev != nil {
+			pair := SimulatedPair{Start: playbackPoint(prev), End: playbackPoint(curr), Label: curr.PairLabel}
+			if pair.Breakdown, pair.Err = s.strategy.CalculateBetween(pages, pair.Start, pair.End); pair.Err == nil {
+				pair.Breakdown = reached.Split(pair.Breakdown, pages, pair.End)
+			}
+			result.Pairs = append(result.Pairs, pair)
+		}
+		if includeSuspicious || curr.PairLabel != model.PairLabelSuspicious {
+			reached.Advance(playbackPoint(curr))
+		}
+		prev = curr
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list simulated progress: %w", err)
+	}
+	return result, nil
+}
+
+// validateSimulation 校验分P列表和进度点。
+func validateSimulation(pages []model.VideoPage, points []service.PlaybackPoint) error {
+	if len(pages) == 0 || len(pages) > MaxSimulationPages {
+		return fmt.Errorf("%w: pages must contain between 1 and %d pages", ErrInvalidSimulation, MaxSimulationPages)
+	}
+	if len(points) < 2 || len(points) > MaxSimulationPoints {
+		return fmt.Errorf("%w: points must contain between 2 and %d points", ErrInvalidSimulation, MaxSimulationPoints)
+	}
+	cids := make(map[int64]bool, len(pages))
+	for _, page := range pages {
+		if cids[page.Cid] {
+			return fmt.Errorf("%w: duplicate page cid %d", ErrInvalidSimulation, page.Cid)
+		}
+		cids[page.Cid] = true
+	}
+	times := make(map[time.Time]bool, len(points))
+	for _, p := range points {
+		key := p.RecordedAt.UTC()
+		if times[key] {
+			return fmt.Errorf("%w: duplicate recorded_at %s", ErrInvalidSimulation, p.RecordedAt.Format(time.RFC3339Nano))
+		}
+		times[key] = true
+	}
+	return nil
+}
+
+// offlineClient 模拟使用的 BilibiliClient，视频目录中已经有虚拟视频，不应回源。
+type offlineClient struct{}
+
+// GetVideoProgress 始终返回错误。
+func (offlineClient) GetVideoProgress(context.Context, string, string, string) (*VideoProgressDTO, error) {
+	return nil, fmt.Errorf("bilibili client is not available in simulation")
+}
+
+// GetVideoView 始终返回错误。
+func (offlineClient) GetVideoView(context.Context, string, string) (*VideoViewDTO, error) {
+	return nil, fmt.Errorf("bilibili client is not available in simulation")
+}
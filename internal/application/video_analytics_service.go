@@ -2,9 +2,12 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
@@ -12,83 +15,489 @@ import (
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
 )
 
+// PartWatchTime 一个分P在某个分段 (或整个范围) 内的观看时长。
+type PartWatchTime struct {
+	CID               int64
+	Page              int    // 分P序号，分P列表中找不到该分P时为 0
+	Part              string // 分P标题
+	WatchedDuration   time.Duration
+	RewatchedDuration time.Duration
+}
+
 // WatchedSegmentResult 包含单个时间分段的计算结果。
 type WatchedSegmentResult struct {
-	SegmentStartTime time.Time
-	SegmentEndTime   time.Time
-	WatchedDuration  time.Duration
+	SegmentStartTime  time.Time
+	SegmentEndTime    time.Time
+	WatchedDuration   time.Duration   // 观看时长，包含重看时长
+	RewatchedDuration time.Duration   // 其中重看的时长 (分P之前到达过的最远位置之前的观看)
+	SkippedDuration   time.Duration   // 超出倍速上限、视为跳过的进度，不计入观看时长
+	PlaybackSpeed     float64         // 由连续播放的记录对推断的播放倍速 (不超过最大倍速的 1, 1.25, 1.5, 2, 3)，无法推断时为 0
+	Parts             []PartWatchTime // 观看时长按分P的分布，按分P序号排列，只包含有观看时长的分P
 }
 
 // VideoAnalyticsResult 包含视频分析的完整结果，包括分段和总时长。
 type VideoAnalyticsResult struct {
-	Segments             []WatchedSegmentResult
-	TotalWatchedDuration time.Duration
+	Segments               []WatchedSegmentResult
+	TotalWatchedDuration   time.Duration
+	TotalRewatchedDuration time.Duration
+	TotalSkippedDuration   time.Duration
+	AveragePlaybackSpeed   float64         // 整个范围内推断的平均播放倍速，无法推断时为 0
+	Parts                  []PartWatchTime // 整个范围内观看时长按分P的分布
+}
+
+// Attribution 一对相邻记录之间的观看时长归属到分段的方式。
+type Attribution string
+
+// 观看时长归属方式，对应请求参数 attribution 和配置 WATCH_TIME_ATTRIBUTION。
+const (
+	AttributionStart        Attribution = "start"        // 全部归属到记录对起点所在的分段
+	AttributionProportional Attribution = "proportional" // 按记录对与每个分段重叠的时间比例拆分 (默认)
+)
+
+// ParseAttribution 解析归属方式名称，名称为空时返回 AttributionProportional。
+func ParseAttribution(name string) (Attribution, error) {
+	switch attribution := Attribution(strings.TrimSpace(name)); attribution {
+	case "":
+		return AttributionProportional, nil
+	case AttributionStart, AttributionProportional:
+		return attribution, nil
+	default:
+		return "", fmt.Errorf("unknown attribution %q (expected %s or %s)", name, AttributionStart, AttributionProportional)
+	}
 }
 
 // VideoAnalyticsService 定义了视频分析相关的应用服务接口。
 type VideoAnalyticsService interface {
 	// GetWatchedSegments 计算并返回指定时间范围和间隔内的视频观看分段时长及总时长。
+	// 分段在 loc 时区内划分 (见 SegmentInterval；日历单位的分段对齐该时区的日历，范围向外扩展到完整的周期)，
+	// 结果中的时间也使用该时区；loc 为 nil 时使用聚合时区。
+	// attribution 为空时使用服务的默认归属方式；但分段边界都是聚合时区零点的查询 (见 isDayAligned) 此时直接读取
+	// 按起点归属的天聚合，只有显式指定 proportional 时才扫描原始记录按比例拆分。
+	// 可疑的记录对 (model.PairLabelSuspicious) 默认不计入，includeSuspicious 为 true 时计入原始记录中的可疑记录对
+	// (水位线之前的小时聚合在汇总时已排除可疑记录对，无法恢复)。
 	GetWatchedSegments(ctx context.Context,
 		aidStr, bvidStr string, // aid 和 bvid 提供一个
 		overallStartTime, overallEndTime time.Time,
-		interval time.Duration,
+		interval SegmentInterval,
+		loc *time.Location,
+		attribution Attribution,
+		includeSuspicious bool,
 	) (VideoAnalyticsResult, error)
 }
 
 // videoAnalyticsService 实现了 VideoAnalyticsService。
 type videoAnalyticsService struct {
-	biliClient   BilibiliClient                     // Bilibili 客户端接口，用于获取视频页面信息
-	progressRepo repository.VideoProgressRepository // 视频进度仓库接口，用于获取进度记录
-	calculator   service.WatchTimeCalculator        // 观看时长计算器服务
+	catalog       *VideoCatalogService                    // 视频目录服务，用于获取视频及历史分P信息
+	progressRepo  repository.VideoProgressRepository      // 视频进度仓库接口，用于获取进度记录
+	aggregateRepo repository.WatchTimeAggregateRepository // 观看时长聚合仓库，用于读取已汇总的历史数据
+	strategy      service.WatchTimeStrategy               // 观看时长计算策略
+	aggregation   *WatchTimeAggregationService            // 聚合服务，提供天聚合的日期边界
+	attribution   Attribution                             // 请求未指定时使用的归属方式
 }
 
 // NewVideoAnalyticsService 创建 VideoAnalyticsService 实例。
 func NewVideoAnalyticsService(
-	biliClient BilibiliClient,
+	catalog *VideoCatalogService,
 	progressRepo repository.VideoProgressRepository,
-	calculator service.WatchTimeCalculator,
+	aggregateRepo repository.WatchTimeAggregateRepository,
+	strategy service.WatchTimeStrategy,
+	aggregation *WatchTimeAggregationService,
+	attribution Attribution,
 ) VideoAnalyticsService {
 	return &videoAnalyticsService{
-		biliClient:   biliClient,
-		progressRepo: progressRepo,
-		calculator:   calculator,
+		catalog:       catalog,
+		progressRepo:  progressRepo,
+		aggregateRepo: aggregateRepo,
+		strategy:      strategy,
+		aggregation:   aggregation,
+		attribution:   attribution,
+	}
+}
+
+// segmentGrid 描述 [start, end) 内的分段边界，所有边界都在同一时区。
+// 固定时长为整天时按该时区的日历日推进，夏令时切换当天的分段为 23 或 25 小时；否则按固定时长推进。
+// 日历单位的分段对齐到该时区的周期边界。
+type segmentGrid struct {
+	starts []time.Time // 每个分段的开始时间，升序
+	end    time.Time   // 最后一个分段的结束时间
+}
+
+// newSegmentGrid 按间隔在 loc 时区内划分 [start, end)。日历单位的第一个和最后一个分段是包含 start 和 end 的完整周期。
+func newSegmentGrid(start, end time.Time, interval SegmentInterval, loc *time.Location) segmentGrid {
+	if interval.IsCalendar() {
+		clock := service.StudyDayClock{Location: loc, CutoffHour: interval.CutoffHour}
+		starts, last := clock.PeriodStarts(interval.Unit, start, end)
+		return segmentGrid{starts: starts, end: last.In(loc)}
+	}
+	grid := segmentGrid{end: end.In(loc)}
+	days := 0
+	if interval.Duration%(24*time.Hour) == 0 {
+		days = int(interval.Duration / (24 * time.Hour))
+	}
+	for t := start.In(loc); t.Before(grid.end); {
+		grid.starts = append(grid.starts, t)
+		if days > 0 {
+			t = t.AddDate(0, 0, days) // 保持当地时刻不变，跨越夏令时切换时长度随之变化
+		} else {
+			t = t.Add(interval.Duration)
+		}
+	}
+	return grid
+}
+
+// index 返回时间 t 所在分段的下标；t 不在 [start, end) 范围内时返回 false。
+func (g segmentGrid) index(t time.Time) (int, bool) {
+	if len(g.starts) == 0 || t.Before(g.starts[0]) || !t.Before(g.end) {
+		return 0, false
 	}
+	i := sort.Search(len(g.starts), func(i int) bool { return g.starts[i].After(t) })
+	return i - 1, true
 }
 
-// getSegmentStartTime 根据记录时间、整体开始时间和间隔，计算记录所属分段的开始时间。
-// 返回分段开始时间和是否有效（即记录时间是否在 [overallStartTime, overallEndTime) 范围内）。
-func getSegmentStartTime(recordTime, overallStartTime, overallEndTime time.Time, interval time.Duration) (time.Time, bool) {
-	// 确保记录时间在有效的查询范围内 [overallStartTime, overallEndTime)
-	if recordTime.Before(overallStartTime) || !recordTime.Before(overallEndTime) { // recordTime < overallStartTime || recordTime >= overallEndTime
-		return time.Time{}, false
+// segmentEnd 返回第 i 个分段的结束时间。
+func (g segmentGrid) segmentEnd(i int) time.Time {
+	if i+1 < len(g.starts) {
+		return g.starts[i+1]
 	}
+	return g.end
+}
 
-	// 计算自 overallStartTime 以来经过的时间
-	elapsed := recordTime.Sub(overallStartTime)
-	if elapsed < 0 {
-		// 理论上不应发生，因为上面有检查 recordTime.Before(overallStartTime)
-		log.Printf("[getSegmentStartTime Error] Negative elapsed time calculated for %s relative to %s", recordTime, overallStartTime)
-		return time.Time{}, false
+// spread 把 [from, to) 之间某个分P的观看秒数按与每个分段重叠的时间比例累加到 segmentSeconds，落在 grid 范围之外的部分被丢弃。
+// from 与 to 相同时全部归属到 from 所在的分段。
+func (g segmentGrid) spread(from, to time.Time, cid int64, seconds watchSeconds, segmentSeconds []partSeconds) {
+	if !to.After(from) {
+		if i, ok := g.index(from); ok {
+			segmentSeconds[i].add(cid, seconds)
+		}
+		return
+	}
+	first := 0
+	if i, ok := g.index(from); ok {
+		first = i
+	}
+	for i := first; i < len(g.starts) && g.starts[i].Before(to); i++ {
+		start, end := g.starts[i], g.segmentEnd(i)
+		if !end.After(from) {
+			continue
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		segmentSeconds[i].add(cid, seconds.portion(from, to, start, end))
 	}
+}
 
-	// 计算记录时间落在哪个分段索引 (向下取整)
-	segmentIndex := int64(elapsed / interval)
-	calculatedSegmentStart := overallStartTime.Add(time.Duration(segmentIndex) * interval)
+// playbackPoint 把进度记录转换为策略使用的播放位置 (毫秒转秒)。
+func playbackPoint(p *model.VideoProgress) service.PlaybackPoint {
+	return service.PlaybackPoint{CID: p.LastPlayCID, PositionSec: p.LastPlayTime / 1000, RecordedAt: p.RecordedAt}
+}
 
-	// 最终校验，确保计算出的开始时间不会意外地超出范围
-	if calculatedSegmentStart.Before(overallStartTime) || !calculatedSegmentStart.Before(overallEndTime) {
-		log.Printf("[getSegmentStartTime Warning] Calculated segment start %s is outside overall range [%s, %s). Record time: %s", calculatedSegmentStart, overallStartTime, overallEndTime, recordTime)
-		return time.Time{}, false
+// withPairPages 为一对相邻进度记录选择分P列表并调用 fn。
+// 优先使用 pNext 记录时有效的分P列表；如果两条记录跨越了分P列表版本且 fn 在该版本中找不到分P
+// (返回 ErrPageNotFound)，则退回使用 pCurr 记录时有效的版本再调用一次。
+func withPairPages(history model.VideoPageHistory, pCurr, pNext *model.VideoProgress, fn func(pages []model.VideoPage) error) error {
+	nextList, _ := history.At(pNext.RecordedAt)
+	err := fn(nextList.Pages)
+	if errors.Is(err, service.ErrPageNotFound) {
+		if currList, _ := history.At(pCurr.RecordedAt); currList.Version != nextList.Version {
+			return fn(currList.Pages)
+		}
 	}
+	return err
+}
 
-	return calculatedSegmentStart, true
+// calculatePairWatchTime 按策略计算两条相邻进度记录之间的观看时长，分P列表的选择见 withPairPages。
+// 首次观看与重看按 reached (pNext 之前的记录中各分P到达过的最远位置) 划分。
+func calculatePairWatchTime(strategy service.WatchTimeStrategy, history model.VideoPageHistory, pCurr, pNext *model.VideoProgress,
+	reached service.ReachedPositions) (service.WatchTimeBreakdown, error) {
+	start, end := playbackPoint(pCurr), playbackPoint(pNext)
+	var breakdown service.WatchTimeBreakdown
+	err := withPairPages(history, pCurr, pNext, func(pages []model.VideoPage) error {
+		var err error
+		if breakdown, err = strategy.CalculateBetween(pages, start, end); err == nil {
+			breakdown = reached.Split(breakdown, pages, end)
+		}
+		return err
+	})
+	return breakdown, err
+}
+
+// pairBucketWidth 返回仓库分桶使用的宽度：所有分段起点与第一个分段起点之差的最大公约数，
+// 因此所有分段边界都是桶的边界。等长的分段即为分段长度；按天或日历单位的分段跨越夏令时切换时
+// 长度会变化，宽度相应缩小 (最小为 15 分钟，所有时区偏移都是 15 分钟的整数倍)，再归入分段。
+func pairBucketWidth(grid segmentGrid) time.Duration {
+	if len(grid.starts) == 0 {
+		return time.Hour
+	}
+	width := grid.end.Sub(grid.starts[0])
+	if len(grid.starts) > 1 {
+		width = 0
+		for _, t := range grid.starts[1:] {
+			width = gcdDuration(width, t.Sub(grid.starts[0]))
+		}
+	}
+	return width
+}
+
+// gcdDuration 返回两个时长的最大公约数。
+func gcdDuration(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// pairBucketKey 标识一个桶内某个分P的观看时长。
+type pairBucketKey struct {
+	bucket int64
+	cid    int64
+}
+
+// watchSeconds 一个桶或分段内累计的观看秒数，watched 包含 rewatched；skipped 不计入 watched。
+// continuous 和 playback 只包含连续播放的记录对，用于推断倍速。
+type watchSeconds struct {
+	watched    int64
+	rewatched  int64
+	skipped    int64
+	continuous int64
+	playback   int64
+}
+
+// addDeltaSum 累加仓库求和的同一分P记录对，其中位于高水位线之前的部分计为重看。
+func (w *watchSeconds) addDeltaSum(sum model.ProgressDeltaSum) {
+	w.watched += sum.Seconds
+	w.rewatched += sum.RewatchedSeconds
+	w.skipped += sum.SkippedSeconds
+	w.continuous += sum.ContinuousSeconds
+	w.playback += sum.PlaybackSeconds
+}
+
+// addAggregate 累加一条小时或天聚合。
+func (w *watchSeconds) addAggregate(a model.WatchTimeAggregate) {
+	w.watched += a.WatchedSeconds
+	w.rewatched += a.RewatchedSeconds
+	w.skipped += a.SkippedSeconds
+	w.continuous += a.ContinuousSeconds
+	w.playback += a.PlaybackSeconds
+}
+
+// add 累加另一组观看秒数。
+func (w *watchSeconds) add(other watchSeconds) {
+	w.watched += other.watched
+	w.rewatched += other.rewatched
+	w.skipped += other.skipped
+	w.continuous += other.continuous
+	w.playback += other.playback
+}
+
+// portion 返回 [from, to) 内的观看秒数中 [a, b) 这一段按时间比例分到的部分。
+// 各项按累计比例取整，相邻片段之和与原值一致；首次观看和重看分别拆分，每个片段的重看时长不超过观看时长。
+func (w watchSeconds) portion(from, to, a, b time.Time) watchSeconds {
+	span := float64(to.Sub(from))
+	share := func(total int64) int64 {
+		upTo := func(t time.Time) int64 {
+			if !t.Before(to) {
+				return total
+			}
+			return int64(math.Floor(float64(total) * float64(t.Sub(from)) / span))
+		}
+		return upTo(b) - upTo(a)
+	}
+	rewatched := share(w.rewatched)
+	return watchSeconds{
+		watched:    share(w.watched-w.rewatched) + rewatched,
+		rewatched:  rewatched,
+		skipped:    share(w.skipped),
+		continuous: share(w.continuous),
+		playback:   share(w.playback),
+	}
+}
+
+// speed 返回推断的播放倍速，maxSpeed 为观看时长策略的最大倍速；没有连续播放的记录对时返回 0 (未知)。
+func (w watchSeconds) speed(maxSpeed float64) float64 {
+	return service.InferPlaybackSpeed(time.Duration(w.continuous)*time.Second, time.Duration(w.playback)*time.Second, maxSpeed)
+}
+
+// partSeconds 按分P CID 累计的观看秒数。
+type partSeconds map[int64]watchSeconds
+
+// newPartSeconds 创建 n 个空的 partSeconds，与分段一一对应。
+func newPartSeconds(n int) []partSeconds {
+	parts := make([]partSeconds, n)
+	for i := range parts {
+		parts[i] = make(partSeconds)
+	}
+	return parts
+}
+
+// add 累加某个分P的观看秒数。
+func (p partSeconds) add(cid int64, seconds watchSeconds) {
+	s := p[cid]
+	s.add(seconds)
+	p[cid] = s
+}
+
+// addAggregate 累加一条小时或天聚合。
+func (p partSeconds) addAggregate(a model.WatchTimeAggregate) {
+	s := p[a.CID]
+	s.addAggregate(a)
+	p[a.CID] = s
+}
+
+// merge 累加另一组按分P的观看秒数。
+func (p partSeconds) merge(other partSeconds) {
+	for cid, seconds := range other {
+		p.add(cid, seconds)
+	}
+}
+
+// total 返回所有分P的合计。
+func (p partSeconds) total() watchSeconds {
+	var total watchSeconds
+	for _, seconds := range p {
+		total.add(seconds)
+	}
+	return total
+}
+
+// parts 按分P序号返回有观看时长的分P，序号和标题取自分P列表历史 (优先最新版本)。
+func (p partSeconds) parts(history model.VideoPageHistory) []PartWatchTime {
+	parts := make([]PartWatchTime, 0, len(p))
+	for cid, seconds := range p {
+		if seconds.watched <= 0 {
+			continue
+		}
+		page, _ := history.Page(cid)
+		parts = append(parts, PartWatchTime{
+			CID:               cid,
+			Page:              page.Page,
+			Part:              page.Part,
+			WatchedDuration:   time.Duration(seconds.watched) * time.Second,
+			RewatchedDuration: time.Duration(seconds.rewatched) * time.Second,
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].Page != parts[j].Page {
+			return parts[i].Page < parts[j].Page
+		}
+		return parts[i].CID < parts[j].CID
+	})
+	return parts
+}
+
+// breakdownByPart 把一对记录的计算结果按分P拆分：观看及重看时长按 ByPage 分配，
+// 跳过的进度、连续播放的时长和播放时间记在记录对终点的分P上。
+func breakdownByPart(b service.WatchTimeBreakdown, endCID int64) partSeconds {
+	parts := partSeconds{endCID: {skipped: int64(b.Skipped / time.Second),
+		continuous: int64(b.Continuous / time.Second), playback: int64(b.Playback / time.Second)}}
+	for _, page := range b.ByPage {
+		parts.add(page.CID, watchSeconds{watched: int64(page.Duration / time.Second), rewatched: int64(page.Rewatch / time.Second)})
+	}
+	return parts
+}
+
+// aggregate 转换为某个视频分P在 bucketStart 时间桶的聚合。
+func (w watchSeconds) aggregate(aid, cid int64, bucketStart time.Time) model.WatchTimeAggregate {
+	return model.WatchTimeAggregate{AID: aid, CID: cid, BucketStart: bucketStart,
+		WatchedSeconds: w.watched, RewatchedSeconds: w.rewatched, SkippedSeconds: w.skipped,
+		ContinuousSeconds: w.continuous, PlaybackSeconds: w.playback}
+}
+
+// sumPairDurations 把仓库预先计算的记录对换算为每个 (桶, 分P) 的观看秒数，跨分P的记录对按 breakdownByPart 拆分到各分P。
+// 同一分P内向前推进的记录对已由仓库求和，只需确认分P存在且进度未超出分P时长 (否则整组不计入，
+// 与逐对计算时返回 ErrPageNotFound / ErrInvalidTime 一致)；逐条返回的记录对交给 calculatePairWatchTime 按策略计算。
+// 合计和逐条返回的记录对按时间顺序重放，从 deltas.Reached 开始推进各分P的高水位线，用来划分逐条返回的记录对中的重看
+// (与仓库求和时使用的高水位线一致)；includeSuspicious 与列出记录对时相同，决定可疑记录是否推进高水位线。
+// spread 不为 nil 时，逐条返回的记录对不计入桶，而是按分P交给 spread 按时间拆分。
+func sumPairDurations(strategy service.WatchTimeStrategy, history model.VideoPageHistory, deltas *model.PairDeltas, includeSuspicious bool,
+	spread func(pair *model.ProgressPair, cid int64, seconds watchSeconds)) map[pairBucketKey]watchSeconds {
+	seconds := make(map[pairBucketKey]watchSeconds)
+	skipped, skips := 0, 0
+	for _, sum := range deltas.Sums {
+		if !deltaSumWithinPage(history, sum) {
+			skipped += int(sum.Pairs)
+			continue
+		}
+		key := pairBucketKey{bucket: sum.Bucket, cid: sum.CID}
+		s := seconds[key]
+		s.addDeltaSum(sum)
+		seconds[key] = s
+	}
+
+	reached := make(service.ReachedPositions, len(deltas.Reached))
+	for cid, position := range deltas.Reached {
+		reached[cid] = position
+	}
+	// 合计之间不会夹着逐条返回的记录对 (见 model.ProgressDeltaSum)，按起点时间排序后即可与记录对交替重放
+	sums := append([]model.ProgressDeltaSum{}, deltas.Sums...)
+	sort.Slice(sums, func(i, j int) bool { return sums[i].FirstStartedAt.Before(sums[j].FirstStartedAt) })
+	next := 0
+	for i := range deltas.Pairs {
+		pair := &deltas.Pairs[i]
+		for ; next < len(sums) && sums[next].FirstStartedAt.Before(pair.Curr.RecordedAt); next++ {
+			reached.Advance(service.PlaybackPoint{CID: sums[next].CID, PositionSec: sums[next].MaxPosition})
+		}
+		if includeSuspicious || pair.Curr.PairLabel != model.PairLabelSuspicious {
+			reached.Advance(playbackPoint(&pair.Curr))
+		}
+		breakdown, err := calculatePairWatchTime(strategy, history, &pair.Curr, &pair.Next, reached)
+		reached.Advance(playbackPoint(&pair.Next))
+		if err != nil {
+			skipped++ // 回退 (向前策略)、找不到分P等情况不计入观看时长
+			continue
+		}
+		if breakdown.IsSkip() {
+			skips++
+		}
+		if breakdown.Total() <= 0 && !breakdown.IsSkip() {
+			continue
+		}
+		for cid, pairSeconds := range breakdownByPart(breakdown, pair.Next.LastPlayCID) {
+			if spread != nil {
+				spread(pair, cid, pairSeconds)
+				continue
+			}
+			key := pairBucketKey{bucket: pair.Bucket, cid: cid}
+			s := seconds[key]
+			s.add(pairSeconds)
+			seconds[key] = s
+		}
+	}
+	if skipped > 0 {
+		log.Printf("跳过 %d 对无法计算观看时长的记录 (后退、分P不存在或进度超出时长)", skipped)
+	}
+	if skips > 0 {
+		log.Printf("%d 对跨分P的记录超出倍速上限，超出部分记为跳过", skips)
+	}
+	return seconds
+}
+
+// deltaSumWithinPage 判断同一分P的合计是否有效：优先使用最后一对记录终点时有效的分P列表，找不到分P时退回最早起点时的版本。
+func deltaSumWithinPage(history model.VideoPageHistory, sum model.ProgressDeltaSum) bool {
+	list, ok := history.At(sum.LastEndedAt)
+	if !ok {
+		return false
+	}
+	page, found := list.Page(sum.CID)
+	if !found {
+		if list, ok = history.At(sum.FirstStartedAt); ok {
+			page, found = list.Page(sum.CID)
+		}
+	}
+	return found && sum.MaxPosition <= page.Duration
 }
 
 // GetWatchedSegments 实现获取观看分段的逻辑 (基于记录点迭代和归属)。
 func (s *videoAnalyticsService) GetWatchedSegments(ctx context.Context,
 	aidStr, bvidStr string,
 	overallStartTime, overallEndTime time.Time,
-	interval time.Duration,
+	interval SegmentInterval,
+	loc *time.Location,
+	attribution Attribution,
+	includeSuspicious bool,
 ) (VideoAnalyticsResult, error) {
 
 	emptyResult := VideoAnalyticsResult{Segments: []WatchedSegmentResult{}, TotalWatchedDuration: 0}
@@ -96,134 +505,201 @@ func (s *videoAnalyticsService) GetWatchedSegments(ctx context.Context,
 	if aidStr == "" && bvidStr == "" {
 		return emptyResult, fmt.Errorf("必须提供 aid 或 bvid")
 	}
-	if interval <= 0 {
-		return emptyResult, fmt.Errorf("interval 必须为正数")
+	if err := interval.validate(); err != nil {
+		return emptyResult, err
 	}
 	if overallEndTime.Before(overallStartTime) {
 		return emptyResult, fmt.Errorf("结束时间必须在开始时间之后")
 	}
+	if loc == nil {
+		loc = s.defaultLocation()
+	}
+	explicit := attribution != ""
+	if !explicit {
+		attribution = s.attribution
+	}
+	proportional := attribution == AttributionProportional
 
-	// 1. 获取视频页面信息
-	videoView, err := s.biliClient.GetVideoView(ctx, aidStr, bvidStr)
+	// 1. 从视频目录获取视频及其历史分P列表
+	video, err := s.catalog.GetVideo(ctx, aidStr, bvidStr)
 	if err != nil {
 		return emptyResult, fmt.Errorf("获取视频信息失败: %w", err)
 	}
-	if videoView == nil || len(videoView.Pages) == 0 {
+	pageHistory, err := s.catalog.GetPageHistory(ctx, video.AID)
+	if err != nil {
+		return emptyResult, fmt.Errorf("获取视频分P历史失败: %w", err)
+	}
+	if len(pageHistory) == 0 {
 		return emptyResult, fmt.Errorf("视频没有分页信息")
 	}
-	domainPages := make([]model.VideoPage, 0, len(videoView.Pages))
-	for _, dtoPage := range videoView.Pages {
-		domainPages = append(domainPages, model.VideoPage{
-			Cid: dtoPage.Cid, Duration: dtoPage.Duration, Part: dtoPage.Part, Page: dtoPage.Page,
-		})
+	actualAID := video.AID
+
+	// 在请求的时区内划分分段；日历单位的范围扩展到完整的周期
+	grid := newSegmentGrid(overallStartTime, overallEndTime, interval, loc)
+	if len(grid.starts) > 0 {
+		overallStartTime, overallEndTime = grid.starts[0], grid.end
 	}
-	actualAID := videoView.Aid
 
-	// 2. 获取时间范围内的所有进度记录，增加足够 buffer
-	queryStartTime := overallStartTime.Add(-interval * 2)
-	queryEndTime := overallEndTime.Add(interval)
-	log.Printf("查询 AID %d 在扩展时间范围 [%s, %s] 内的进度记录", actualAID, queryStartTime, queryEndTime)
-	progressRecords, err := s.progressRepo.ListByAIDAndTimestampRange(ctx, actualAID, queryStartTime, queryEndTime)
+	// 分段边界都落在聚合时区的零点时，直接读取天聚合，无需扫描原始记录。
+	// 天聚合按记录对起点归属且不含可疑记录对，显式要求按比例拆分或计入可疑记录对时仍需扫描原始记录；
+	// 未指定归属方式时按天的分段使用天聚合 (按比例拆分只影响跨越零点的那一对记录)
+	if (!proportional || !explicit) && !includeSuspicious && s.isDayAligned(grid, interval) {
+		return s.getSegmentsFromDaily(ctx, actualAID, grid, pageHistory)
+	}
+
+	// 水位线之前的原始记录已被汇总为小时聚合并清理，这部分时长从聚合表读取
+	watermark, err := s.aggregateRepo.GetWatermark(ctx, actualAID)
 	if err != nil {
-		return emptyResult, fmt.Errorf("列出进度记录失败: %w", err)
+		return emptyResult, fmt.Errorf("获取汇总水位线失败: %w", err)
 	}
 
-	if len(progressRecords) < 2 {
-		log.Printf("在扩展时间范围 [%s, %s] 内找到的记录少于2条 (共 %d 条)，无法计算观看时长", queryStartTime, queryEndTime, len(progressRecords))
-		results := make([]WatchedSegmentResult, 0)
-		segmentStart := overallStartTime
-		for segmentStart.Before(overallEndTime) {
-			segmentEnd := segmentStart.Add(interval)
-			if segmentEnd.After(overallEndTime) {
-				segmentEnd = overallEndTime
+	// 2. 初始化每个分段按分P的时长
+	segmentSeconds := newPartSeconds(len(grid.starts))
+
+	// 3. 由仓库配对相邻记录并按起点分桶 (起点在水位线之前的记录对已计入小时聚合)
+	rawStart := overallStartTime
+	if rawStart.Before(watermark) {
+		rawStart = watermark
+	}
+	if rawStart.Before(overallEndTime) {
+		pairFrom := rawStart
+		var spread func(pair *model.ProgressPair, cid int64, seconds watchSeconds)
+		if proportional {
+			// 按比例拆分时，起点在范围之前、终点在范围之内的记录对也有一部分属于第一个分段
+			if rawStart.After(watermark) {
+				if pairFrom, err = s.previousRecordedAt(ctx, actualAID, rawStart); err != nil {
+					return emptyResult, err
+				}
 			}
-			results = append(results, WatchedSegmentResult{
-				SegmentStartTime: segmentStart,
-				SegmentEndTime:   segmentEnd,
-				WatchedDuration:  0,
-			})
-			segmentStart = segmentEnd
-		}
-		return VideoAnalyticsResult{Segments: results, TotalWatchedDuration: 0}, nil
-	}
-
-	// 3. 初始化分段时长 map
-	segmentDurations := make(map[time.Time]time.Duration)
-	segmentStartMapKey := overallStartTime
-	for segmentStartMapKey.Before(overallEndTime) {
-		segmentDurations[segmentStartMapKey] = 0
-		segmentStartMapKey = segmentStartMapKey.Add(interval)
-	}
-
-	// 4. 迭代记录点，计算时长并归属到对应分段
-	for i := 0; i < len(progressRecords)-1; i++ {
-		pCurr := progressRecords[i]
-		pNext := progressRecords[i+1]
-
-		// 计算这两个记录点之间的观看时长
-		duration, calcErr := s.calculator.CalculateWatchTime(
-			domainPages,
-			pCurr.LastPlayCID, pCurr.LastPlayTime/1000, // 毫秒转秒
-			pNext.LastPlayCID, pNext.LastPlayTime/1000, // 毫秒转秒
-		)
-		if calcErr != nil {
-			log.Printf("计算观看时长出错 (记录 %d -> %d): %v. P_curr: %+v, P_next: %+v",
-				pCurr.ID, pNext.ID, calcErr, pCurr, pNext)
-			continue // 跳过这一对记录
-		}
-
-		if duration <= 0 {
-			continue // 没有时长变化，无需归属
-		}
-
-		// 确定时长发生区间的起始点 pCurr 属于哪个分段
-		segmentStartTimeForAttribution, isValidSegment := getSegmentStartTime(pCurr.RecordedAt, overallStartTime, overallEndTime, interval)
-
-		if isValidSegment {
-			// 将计算出的时长累加到对应的分段
-			if _, ok := segmentDurations[segmentStartTimeForAttribution]; ok {
-				log.Printf("[Attribution] Attributing duration %s (from record %d at %s to record %d at %s) to segment starting at %s",
-					duration, pCurr.ID, pCurr.RecordedAt, pNext.ID, pNext.RecordedAt, segmentStartTimeForAttribution)
-				segmentDurations[segmentStartTimeForAttribution] += duration
-			} else {
-				log.Printf("[Attribution Warning] Segment start time %s (from record %d at %s) not found in map.", segmentStartTimeForAttribution, pCurr.ID, pCurr.RecordedAt)
+			spread = func(pair *model.ProgressPair, cid int64, seconds watchSeconds) {
+				grid.spread(pair.Curr.RecordedAt, pair.Next.RecordedAt, cid, seconds, segmentSeconds)
 			}
-		} else {
-			log.Printf("[Attribution] Skipping duration %s starting at %s (record %d) because it falls outside the requested range [%s, %s)",
-				duration, pCurr.RecordedAt, pCurr.ID, overallStartTime, overallEndTime)
 		}
+		bucketing := model.PairBucketing{Origin: overallStartTime, Width: pairBucketWidth(grid),
+			MaxSpeed: s.strategy.MaxSpeed(), Split: proportional, IncludeSuspicious: includeSuspicious}
+		deltas, err := s.progressRepo.ListPairDeltas(ctx, actualAID, pairFrom, overallEndTime, bucketing)
+		if err != nil {
+			return emptyResult, fmt.Errorf("列出进度记录对失败: %w", err)
+		}
+
+		// 4. 计算跨分P的记录对并把每个桶的时长归属到包含桶开始时间的分段；按比例拆分时逐条返回的记录对由 spread 直接拆分到分段
+		for key, seconds := range sumPairDurations(s.strategy, pageHistory, deltas, includeSuspicious, spread) {
+			if segmentIndex, ok := grid.index(bucketing.BucketStart(key.bucket)); ok {
+				segmentSeconds[segmentIndex].add(key.cid, seconds)
+			}
+		}
+		log.Printf("AID %d 在 [%s, %s) 内: %d 组同分P记录对, %d 对跨分P或后退的记录对", actualAID, rawStart, overallEndTime, len(deltas.Sums), len(deltas.Pairs))
 	}
 
-	// 5. 从 map 生成最终结果列表并计算总时长
-	results := make([]WatchedSegmentResult, 0, len(segmentDurations))
-	var totalWatchedDuration time.Duration
-	segmentResultStart := overallStartTime
-	processedSegments := make(map[time.Time]bool)
+	// 5. 合并水位线之前的小时聚合，按小时开始时间归属到分段
+	if watermark.After(overallStartTime) {
+		aggregateEnd := watermark
+		if aggregateEnd.After(overallEndTime) {
+			aggregateEnd = overallEndTime
+		}
+		aggregates, err := s.aggregateRepo.ListHourly(ctx, actualAID, overallStartTime, aggregateEnd)
+		if err != nil {
+			return emptyResult, fmt.Errorf("列出小时聚合失败: %w", err)
+		}
+		for _, agg := range aggregates {
+			if segmentIndex, ok := grid.index(agg.BucketStart); ok {
+				segmentSeconds[segmentIndex].addAggregate(agg)
+			}
+		}
+	}
 
-	for segmentResultStart.Before(overallEndTime) {
-		segmentEnd := segmentResultStart.Add(interval)
-		if segmentEnd.After(overallEndTime) {
-			segmentEnd = overallEndTime
+	// 6. 生成最终结果列表并计算总时长
+	return buildSegmentResults(grid, segmentSeconds, pageHistory, s.strategy.MaxSpeed()), nil
+}
+
+// previousRecordedAt 返回 before 之前最后一条进度记录的时间，没有时返回 before。
+func (s *videoAnalyticsService) previousRecordedAt(ctx context.Context, aid int64, before time.Time) (time.Time, error) {
+	records, err := s.progressRepo.ListPage(ctx, repository.ProgressFilter{AID: aid, End: before},
+		repository.ProgressPageRequest{Limit: 1, Descending: true})
+	if err != nil {
+		return before, fmt.Errorf("获取范围之前的进度记录失败: %w", err)
+	}
+	if len(records) == 0 {
+		return before, nil
+	}
+	return records[0].RecordedAt, nil
+}
+
+// defaultLocation 返回未指定时区时使用的时区，与天聚合保持一致。
+func (s *videoAnalyticsService) defaultLocation() *time.Location {
+	if s.aggregation != nil {
+		return s.aggregation.Location()
+	}
+	return time.Local
+}
+
+// isDayAligned 判断所有分段边界 (含结束时间) 是否都是聚合时区的零点。
+func (s *videoAnalyticsService) isDayAligned(grid segmentGrid, interval SegmentInterval) bool {
+	if s.aggregation == nil || !interval.atLeastDay() || !s.aggregation.IsDayStart(grid.end) {
+		return false
+	}
+	for _, t := range grid.starts {
+		if !s.aggregation.IsDayStart(t) {
+			return false
 		}
+	}
+	return true
+}
 
-		if !processedSegments[segmentResultStart] {
-			duration := segmentDurations[segmentResultStart]
-			results = append(results, WatchedSegmentResult{
-				SegmentStartTime: segmentResultStart,
-				SegmentEndTime:   segmentEnd,
-				WatchedDuration:  duration,
-			})
-			totalWatchedDuration += duration // 累加到总时长
-			log.Printf("[Final Result] Segment [%s, %s]: Duration=%s", segmentResultStart, segmentEnd, duration)
-			processedSegments[segmentResultStart] = true
+// getSegmentsFromDaily 使用天聚合计算观看分段，天聚合包含所有已保存记录对的观看时长。
+func (s *videoAnalyticsService) getSegmentsFromDaily(ctx context.Context, aid int64, grid segmentGrid, history model.VideoPageHistory) (VideoAnalyticsResult, error) {
+	if len(grid.starts) == 0 {
+		return buildSegmentResults(grid, nil, history, s.strategy.MaxSpeed()), nil
+	}
+	aggregates, err := s.aggregateRepo.ListDaily(ctx, aid, grid.starts[0], grid.end)
+	if err != nil {
+		return VideoAnalyticsResult{Segments: []WatchedSegmentResult{}}, fmt.Errorf("列出天聚合失败: %w", err)
+	}
+	segmentSeconds := newPartSeconds(len(grid.starts))
+	for _, agg := range aggregates {
+		if segmentIndex, ok := grid.index(agg.BucketStart); ok {
+			segmentSeconds[segmentIndex].addAggregate(agg)
 		}
-		segmentResultStart = segmentEnd
 	}
+	log.Printf("使用 %d 条天聚合计算 AID %d 在 [%s, %s) 内的观看分段", len(aggregates), aid, grid.starts[0], grid.end)
+	return buildSegmentResults(grid, segmentSeconds, history, s.strategy.MaxSpeed()), nil
+}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].SegmentStartTime.Before(results[j].SegmentStartTime)
-	})
+// buildSegmentResults 按时间顺序生成每个分段的结果，并计算总时长。segmentSeconds 与 grid.starts 一一对应，
+// 分P的序号和标题取自 history，maxSpeed 用于推断倍速。
+func buildSegmentResults(grid segmentGrid, segmentSeconds []partSeconds, history model.VideoPageHistory, maxSpeed float64) VideoAnalyticsResult {
+	results := make([]WatchedSegmentResult, 0, len(grid.starts))
+	totalParts := make(partSeconds)
 
-	log.Printf("[Total Duration] Calculated for range [%s, %s]: %s", overallStartTime, overallEndTime, totalWatchedDuration)
-	return VideoAnalyticsResult{Segments: results, TotalWatchedDuration: totalWatchedDuration}, nil
+	for i, segmentStart := range grid.starts {
+		parts := make(partSeconds)
+		if i < len(segmentSeconds) {
+			parts = segmentSeconds[i]
+		}
+		seconds := parts.total()
+		segmentEnd := grid.segmentEnd(i)
+		results = append(results, WatchedSegmentResult{
+			SegmentStartTime:  segmentStart,
+			SegmentEndTime:    segmentEnd,
+			WatchedDuration:   time.Duration(seconds.watched) * time.Second,
+			RewatchedDuration: time.Duration(seconds.rewatched) * time.Second,
+			SkippedDuration:   time.Duration(seconds.skipped) * time.Second,
+			PlaybackSpeed:     seconds.speed(maxSpeed),
+			Parts:             parts.parts(history),
+		})
+		totalParts.merge(parts) // 累加到总时长
+	}
+
+	total := totalParts.total()
+	result := VideoAnalyticsResult{
+		Segments:               results,
+		TotalWatchedDuration:   time.Duration(total.watched) * time.Second,
+		TotalRewatchedDuration: time.Duration(total.rewatched) * time.Second,
+		TotalSkippedDuration:   time.Duration(total.skipped) * time.Second,
+		AveragePlaybackSpeed:   total.speed(maxSpeed),
+		Parts:                  totalParts.parts(history),
+	}
+	log.Printf("[Total Duration] Calculated for %d segments ending at %s: %s (rewatched %s, skipped %s, %gx)", len(results), grid.end,
+		result.TotalWatchedDuration, result.TotalRewatchedDuration, result.TotalSkippedDuration, result.AveragePlaybackSpeed)
+	return result
 }
@@ -0,0 +1,445 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/persistence"
+)
+
+// mustLoadLocation 加载 IANA 时区，失败时终止测试。
+func mustLoadLocation(t testing.TB, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+// mustParseTime 解析 RFC3339 时间，失败时终止测试。
+func mustParseTime(t testing.TB, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("parse time %s: %v", value, err)
+	}
+	return parsed
+}
+
+func TestNewSegmentGrid(t *testing.T) {
+	tests := []struct {
+		name       string
+		zone       string
+		interval   SegmentInterval
+		start, end string
+		wantHours  []float64 // 每个分段的长度
+		wantFirst  string    // 第一个分段的开始时间
+		wantLocal  string    // 第一个分段在 zone 中的当地时间
+	}{
+		{
+			name: "new york hours across fall back", zone: "America/New_York", interval: FixedInterval(time.Hour),
+			start: "2025-11-02T04:00:00Z", end: "2025-11-02T09:00:00Z",
+			wantHours: []float64{1, 1, 1, 1, 1}, wantFirst: "2025-11-02T04:00:00Z", wantLocal: "2025-11-02 00:00",
+		},
+		{
+			name: "berlin hours across spring forward", zone: "Europe/Berlin", interval: FixedInterval(time.Hour),
+			start: "2025-03-30T00:00:00Z", end: "2025-03-30T02:30:00Z",
+			wantHours: []float64{1, 1, 0.5}, wantFirst: "2025-03-30T00:00:00Z", wantLocal: "2025-03-30 01:00",
+		},
+		{
+			name: "new york days across spring forward", zone: "America/New_York", interval: FixedInterval(24 * time.Hour),
+			start: "2025-03-08T05:00:00Z", end: "2025-03-11T04:00:00Z",
+			wantHours: []float64{24, 23, 24}, wantFirst: "2025-03-08T05:00:00Z", wantLocal: "2025-03-08 00:00",
+		},
+		{
+			name: "new york days from noon across fall back", zone: "America/New_York", interval: FixedInterval(24 * time.Hour),
+			start: "2025-11-01T16:00:00Z", end: "2025-11-03T17:00:00Z",
+			wantHours: []float64{25, 24}, wantFirst: "2025-11-01T16:00:00Z", wantLocal: "2025-11-01 12:00",
+		},
+		{
+			name: "berlin two day segments across fall back", zone: "Europe/Berlin", interval: FixedInterval(48 * time.Hour),
+			start: "2025-10-24T22:00:00Z", end: "2025-10-28T23:00:00Z",
+			wantHours: []float64{49, 48}, wantFirst: "2025-10-24T22:00:00Z", wantLocal: "2025-10-25 00:00",
+		},
+		{
+			name: "kolkata days", zone: "Asia/Kolkata", interval: FixedInterval(24 * time.Hour),
+			start: "2025-03-07T18:30:00Z", end: "2025-03-09T18:30:00Z",
+			wantHours: []float64{24, 24}, wantFirst: "2025-03-07T18:30:00Z", wantLocal: "2025-03-08 00:00",
+		},
+		{
+			name: "berlin calendar days across fall back", zone: "Europe/Berlin", interval: CalendarInterval(service.CalendarDay, 0),
+			start: "2025-10-25T10:00:00Z", end: "2025-10-26T12:00:00Z",
+			wantHours: []float64{24, 25}, wantFirst: "2025-10-24T22:00:00Z", wantLocal: "2025-10-25 00:00",
+		},
+		{
+			name: "new york calendar days with cutoff across spring forward", zone: "America/New_York", interval: CalendarInterval(service.CalendarDay, 4),
+			start: "2025-03-08T12:00:00Z", end: "2025-03-09T12:00:00Z",
+			wantHours: []float64{23, 24}, wantFirst: "2025-03-08T09:00:00Z", wantLocal: "2025-03-08 04:00",
+		},
+		{
+			name: "berlin calendar weeks across spring forward", zone: "Europe/Berlin", interval: CalendarInterval(service.CalendarWeek, 0),
+			start: "2025-03-24T12:00:00Z", end: "2025-04-01T12:00:00Z",
+			wantHours: []float64{7*24 - 1, 7 * 24}, wantFirst: "2025-03-23T23:00:00Z", wantLocal: "2025-03-24 00:00",
+		},
+		{
+			name: "new york calendar months across fall back", zone: "America/New_York", interval: CalendarInterval(service.CalendarMonth, 0),
+			start: "2025-10-15T12:00:00Z", end: "2025-11-15T12:00:00Z",
+			wantHours: []float64{31 * 24, 30*24 + 1}, wantFirst: "2025-10-01T04:00:00Z", wantLocal: "2025-10-01 00:00",
+		},
+		{
+			name: "adelaide calendar months across spring forward", zone: "Australia/Adelaide", interval: CalendarInterval(service.CalendarMonth, 0),
+			start: "2025-10-01T00:00:00Z", end: "2025-10-02T00:00:00Z",
+			wantHours: []float64{31*24 - 1}, wantFirst: "2025-09-30T14:30:00Z", wantLocal: "2025-10-01 00:00",
+		},
+		{
+			name: "empty range", zone: "Europe/Berlin", interval: FixedInterval(time.Hour),
+			start: "2025-03-30T00:00:00Z", end: "2025-03-30T00:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoadLocation(t, tt.zone)
+			grid := newSegmentGrid(mustParseTime(t, tt.start), mustParseTime(t, tt.end), tt.interval, loc)
+			if len(grid.starts) != len(tt.wantHours) {
+				t.Fatalf("got %d segments %v, want %d", len(grid.starts), grid.starts, len(tt.wantHours))
+			}
+			if len(grid.starts) == 0 {
+				return
+			}
+			if want := mustParseTime(t, tt.wantFirst); !grid.starts[0].Equal(want) {
+				t.Errorf("first segment starts at %s, want %s", grid.starts[0].UTC(), want)
+			}
+			if local := grid.starts[0].Format("2006-01-02 15:04"); local != tt.wantLocal {
+				t.Errorf("first segment starts at local %s, want %s", local, tt.wantLocal)
+			}
+			for i, want := range tt.wantHours {
+				if got := grid.segmentEnd(i).Sub(grid.starts[i]).Hours(); got != want {
+					t.Errorf("segment %d (%s) lasts %gh, want %gh", i, grid.starts[i], got, want)
+				}
+				if grid.starts[i].Location() != loc {
+					t.Errorf("segment %d is in %s, want %s", i, grid.starts[i].Location(), loc)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentGridIndex(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+	grid := newSegmentGrid(mustParseTime(t, "2025-11-02T04:00:00Z"), mustParseTime(t, "2025-11-03T05:00:00Z"),
+		FixedInterval(24*time.Hour), loc)
+	tests := []struct {
+		at     string
+		want   int
+		wantOK bool
+	}{
+		{"2025-11-02T03:59:59Z", 0, false},
+		{"2025-11-02T04:00:00Z", 0, true},
+		{"2025-11-02T06:30:00Z", 0, true}, // 第二次 01:30 (EST)
+		{"2025-11-03T04:59:59Z", 0, true}, // 25 小时的最后一秒
+		{"2025-11-03T05:00:00Z", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := grid.index(mustParseTime(t, tt.at))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("index(%s) = %d, %v, want %d, %v", tt.at, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPairBucketWidth(t *testing.T) {
+	tests := []struct {
+		name       string
+		zone       string
+		interval   SegmentInterval
+		start, end string
+		want       time.Duration
+	}{
+		{"single day", "America/New_York", FixedInterval(24 * time.Hour), "2025-03-08T05:00:00Z", "2025-03-09T05:00:00Z", 24 * time.Hour},
+		{"days across spring forward", "America/New_York", FixedInterval(24 * time.Hour), "2025-03-08T05:00:00Z", "2025-03-11T04:00:00Z", time.Hour},
+		{"days without transition", "Asia/Kolkata", FixedInterval(24 * time.Hour), "2025-03-07T18:30:00Z", "2025-03-10T18:30:00Z", 24 * time.Hour},
+		{"hours", "Europe/Berlin", FixedInterval(time.Hour), "2025-03-30T00:00:00Z", "2025-03-30T04:00:00Z", time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := newSegmentGrid(mustParseTime(t, tt.start), mustParseTime(t, tt.end), tt.interval, mustLoadLocation(t, tt.zone))
+			if got := pairBucketWidth(grid); got != tt.want {
+				t.Errorf("pairBucketWidth = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSumPairDurationsMatchesPerPairReached(t *testing.T) {
+	ctx := context.Background()
+	start := mustParseTime(t, "2025-03-01T00:00:00Z")
+	pages := []model.VideoPage{{Cid: 1, Duration: 600, Page: 1}, {Cid: 2, Duration: 600, Page: 2}, {Cid: 3, Duration: 600, Page: 3}}
+	history := model.VideoPageHistory{{AID: 1, Version: 1, EffectiveFrom: start, Pages: pages}}
+	points := []struct {
+		minute   int
+		cid      int64
+		position int64 // 秒
+		label    model.PairLabel
+	}{
+		{0, 1, 0, model.PairLabelNone},
+		{5, 1, 300, model.PairLabelForward},
+		{10, 2, 60, model.PairLabelPartSwitch},
+		{15, 2, 300, model.PairLabelForward},
+		{20, 1, 200, model.PairLabelPartSwitch}, // 回到 P1 已看过的位置
+		{25, 1, 500, model.PairLabelForward},    // 其中 100 秒重看
+		{30, 2, 100, model.PairLabelPartSwitch}, // P2 的 100 秒重看
+		{35, 2, 400, model.PairLabelForward},
+		{40, 3, 500, model.PairLabelSuspicious}, // 不推进高水位线
+		{45, 3, 550, model.PairLabelForward},
+		{50, 3, 100, model.PairLabelSeekBack},
+		{55, 3, 400, model.PairLabelForward},
+	}
+	repo := persistence.NewMemoryVideoProgressRepository()
+	records := make([]*model.VideoProgress, 0, len(points))
+	for _, p := range points {
+		records = append(records, &model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: p.cid, LastPlayTime: p.position * 1000,
+			RecordedAt: start.Add(time.Duration(p.minute) * time.Minute), PairLabel: p.label})
+	}
+	if _, err := repo.InsertIgnoreDuplicates(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	strategy, err := service.NewWatchTimeStrategy(service.StrategyRewatch, service.NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, from := range []time.Time{start, start.Add(20 * time.Minute), start.Add(42 * time.Minute)} {
+		// 逐对计算，高水位线从第一条记录开始维护
+		want := make(partSeconds)
+		reached := make(service.ReachedPositions)
+		for i := 0; i+1 < len(records); i++ {
+			curr, next := records[i], records[i+1]
+			if curr.PairLabel != model.PairLabelSuspicious {
+				reached.Advance(playbackPoint(curr))
+			}
+			if next.PairLabel == model.PairLabelSuspicious || curr.RecordedAt.Before(from) {
+				continue
+			}
+			breakdown, err := calculatePairWatchTime(strategy, history, curr, next, reached)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want.merge(breakdownByPart(breakdown, next.LastPlayCID))
+		}
+		if total := want.total(); total.rewatched == 0 || total.rewatched == total.watched {
+			t.Fatalf("from %s: fixture should mix first-time and rewatched seconds, got %+v", from.Format("15:04"), total)
+		}
+
+		deltas, err := repo.ListPairDeltas(ctx, 1, from, start.Add(time.Hour),
+			model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: strategy.MaxSpeed()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(partSeconds)
+		for key, seconds := range sumPairDurations(strategy, history, deltas, false, nil) {
+			got.add(key.cid, seconds)
+		}
+		for _, cid := range []int64{1, 2, 3} {
+			if got[cid] != want[cid] {
+				t.Errorf("from %s: part %d = %+v, want %+v", from.Format("15:04"), cid, got[cid], want[cid])
+			}
+		}
+	}
+}
+
+func TestSegmentGridSpread(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		start    string // 第一个分段的开始时间，按天划分三个分段
+		from, to string // 记录对的起止时间
+		seconds  watchSeconds
+		want     []int64 // 每个分段分到的观看秒数
+	}{
+		{
+			name: "berlin spring forward", zone: "Europe/Berlin", start: "2025-03-28T23:00:00Z",
+			from: "2025-03-29T11:00:00Z", to: "2025-03-31T10:00:00Z", // 12 + 23 + 12 小时
+			seconds: watchSeconds{watched: 4700, rewatched: 470, skipped: 47}, want: []int64{1200, 2300, 1200},
+		},
+		{
+			name: "berlin fall back", zone: "Europe/Berlin", start: "2025-10-24T22:00:00Z",
+			from: "2025-10-25T10:00:00Z", to: "2025-10-27T11:00:00Z", // 12 + 25 + 12 小时
+			seconds: watchSeconds{watched: 4900, rewatched: 490, skipped: 49}, want: []int64{1200, 2500, 1200},
+		},
+		{
+			name: "uneven shares", zone: "UTC", start: "2025-03-01T00:00:00Z",
+			from: "2025-03-01T16:00:00Z", to: "2025-03-03T08:00:00Z", // 8 + 24 + 8 小时
+			seconds: watchSeconds{watched: 1001, rewatched: 7, skipped: 3, continuous: 997, playback: 499}, want: []int64{199, 601, 201},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoadLocation(t, tt.zone)
+			start := mustParseTime(t, tt.start).In(loc)
+			grid := newSegmentGrid(start, start.AddDate(0, 0, 3), FixedInterval(24*time.Hour), loc)
+			segmentSeconds := newPartSeconds(len(grid.starts))
+			grid.spread(mustParseTime(t, tt.from), mustParseTime(t, tt.to), 1, tt.seconds, segmentSeconds)
+
+			var total watchSeconds
+			for i, segment := range segmentSeconds {
+				got := segment[1]
+				if got.watched != tt.want[i] {
+					t.Errorf("segment %d watched = %d, want %d", i, got.watched, tt.want[i])
+				}
+				if got.rewatched > got.watched {
+					t.Errorf("segment %d rewatched %d exceeds watched %d", i, got.rewatched, got.watched)
+				}
+				total.add(got)
+			}
+			if total != tt.seconds {
+				t.Errorf("total = %+v, want %+v", total, tt.seconds)
+			}
+		})
+	}
+}
+
+func TestProportionalAttributionConservesTotal(t *testing.T) {
+	ctx := context.Background()
+	loc := mustLoadLocation(t, "Europe/Berlin")
+	// 柏林 2025-03-29 至 2025-03-31 三天，中间一天为 23 小时
+	gridStart := time.Date(2025, 3, 29, 0, 0, 0, 0, loc)
+	pages := []model.VideoPage{{Cid: 1, Duration: 40000, Page: 1}, {Cid: 2, Duration: 60000, Page: 2}}
+	history := model.VideoPageHistory{{AID: 1, Version: 1, EffectiveFrom: gridStart, Pages: pages}}
+
+	// 从 29 日 20:15 起每 30 分钟一条记录，每次推进 1200 秒，中途从 P1 切换到 P2，其间有一次后退
+	repo := persistence.NewMemoryVideoProgressRepository()
+	var records []*model.VideoProgress
+	var watched int64
+	for i := 0; i < 64; i++ {
+		cid, position := int64(1), watched
+		if watched >= 40000 {
+			cid, position = 2, watched-40000
+		}
+		label := model.PairLabelForward
+		if i == 40 {
+			position, label = position/2, model.PairLabelSeekBack
+		}
+		records = append(records, &model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: cid, LastPlayTime: position * 1000,
+			RecordedAt: gridStart.Add(20*time.Hour + 15*time.Minute + time.Duration(i)*30*time.Minute), PairLabel: label})
+		watched += 1200
+	}
+	if _, err := repo.InsertIgnoreDuplicates(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	strategy, err := service.NewWatchTimeStrategy(service.StrategyRewatch, service.NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid := newSegmentGrid(gridStart, gridStart.AddDate(0, 0, 3), FixedInterval(24*time.Hour), loc)
+	if got := grid.segmentEnd(1).Sub(grid.starts[1]); got != 23*time.Hour {
+		t.Fatalf("second segment = %s, want 23h", got)
+	}
+
+	attribute := func(proportional bool) []partSeconds {
+		segmentSeconds := newPartSeconds(len(grid.starts))
+		var spread func(pair *model.ProgressPair, cid int64, seconds watchSeconds)
+		if proportional {
+			spread = func(pair *model.ProgressPair, cid int64, seconds watchSeconds) {
+				grid.spread(pair.Curr.RecordedAt, pair.Next.RecordedAt, cid, seconds, segmentSeconds)
+			}
+		}
+		bucketing := model.PairBucketing{Origin: grid.starts[0], Width: pairBucketWidth(grid),
+			MaxSpeed: strategy.MaxSpeed(), Split: proportional}
+		deltas, err := repo.ListPairDeltas(ctx, 1, grid.starts[0], grid.end, bucketing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, seconds := range sumPairDurations(strategy, history, deltas, false, spread) {
+			if i, ok := grid.index(bucketing.BucketStart(key.bucket)); ok {
+				segmentSeconds[i].add(key.cid, seconds)
+			}
+		}
+		return segmentSeconds
+	}
+	total := func(segments []partSeconds) partSeconds {
+		sum := make(partSeconds)
+		for _, segment := range segments {
+			sum.merge(segment)
+		}
+		return sum
+	}
+
+	start, proportional := attribute(false), attribute(true)
+	wantTotal, gotTotal := total(start), total(proportional)
+	if wantTotal.total().rewatched == 0 || len(wantTotal) != 2 {
+		t.Fatalf("fixture should cover both parts and include rewatched seconds, got %+v", wantTotal)
+	}
+	for _, cid := range []int64{1, 2} {
+		if gotTotal[cid] != wantTotal[cid] {
+			t.Errorf("part %d proportional total = %+v, start total = %+v", cid, gotTotal[cid], wantTotal[cid])
+		}
+	}
+	// 按比例拆分时，29 日 23:45 至 30 日 00:15 的记录对有一半归属到 30 日
+	if got, want := proportional[0].total().watched, start[0].total().watched-600; got != want {
+		t.Errorf("first day proportional = %d, want %d", got, want)
+	}
+}
+
+func TestGetWatchedSegmentsReadsDailyAggregatesByDefault(t *testing.T) {
+	ctx := context.Background()
+	loc := mustLoadLocation(t, "Asia/Shanghai")
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, loc)
+	pages := []model.VideoPage{{Cid: 1, Duration: 3600, Page: 1}}
+
+	videos := persistence.NewMemoryVideoRepository()
+	if err := videos.Save(ctx, &model.Video{AID: 1, BVID: "BV1", PageVersion: 1, RefreshedAt: day}); err != nil {
+		t.Fatal(err)
+	}
+	if err := videos.SavePageList(ctx, &model.VideoPageList{AID: 1, Version: 1, EffectiveFrom: day, Pages: pages}); err != nil {
+		t.Fatal(err)
+	}
+	// 原始记录有 600 秒观看时长，天聚合故意写成 120 秒，以区分读取的是哪一份数据
+	progress := persistence.NewMemoryVideoProgressRepository()
+	records := []*model.VideoProgress{
+		{AID: 1, BVID: "BV1", LastPlayCID: 1, LastPlayTime: 0, RecordedAt: day.Add(10 * time.Hour)},
+		{AID: 1, BVID: "BV1", LastPlayCID: 1, LastPlayTime: 600000, RecordedAt: day.Add(10*time.Hour + 10*time.Minute), PairLabel: model.PairLabelForward},
+	}
+	if _, err := progress.InsertIgnoreDuplicates(ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	aggregates := persistence.NewMemoryWatchTimeAggregateRepository()
+	if err := aggregates.ReplaceDaily(ctx, 1, day, day.AddDate(0, 0, 1),
+		[]model.WatchTimeAggregate{{AID: 1, CID: 1, BucketStart: day, WatchedSeconds: 120}}); err != nil {
+		t.Fatal(err)
+	}
+
+	strategy, err := service.NewWatchTimeStrategy(service.StrategyForward, service.NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog := NewVideoCatalogService(videos, offlineClient{})
+	aggregation := NewWatchTimeAggregationService(progress, aggregates, catalog, strategy, loc)
+	analytics := NewVideoAnalyticsService(catalog, progress, aggregates, strategy, aggregation, AttributionProportional)
+
+	tests := []struct {
+		name        string
+		interval    SegmentInterval
+		attribution Attribution
+		want        time.Duration
+	}{
+		{name: "default attribution reads daily aggregates", interval: FixedInterval(24 * time.Hour), want: 120 * time.Second},
+		{name: "explicit start reads daily aggregates", interval: FixedInterval(24 * time.Hour), attribution: AttributionStart, want: 120 * time.Second},
+		{name: "explicit proportional scans raw records", interval: FixedInterval(24 * time.Hour), attribution: AttributionProportional, want: 600 * time.Second},
+		{name: "hourly segments scan raw records", interval: FixedInterval(time.Hour), want: 600 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analytics.GetWatchedSegments(ctx, "", "BV1", day, day.AddDate(0, 0, 1), tt.interval, loc, tt.attribution, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.TotalWatchedDuration != tt.want {
+				t.Errorf("total = %s, want %s", result.TotalWatchedDuration, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// VideoCatalogService 应用服务，维护持久化的视频目录 (元数据及分P列表历史)。
+// 调度器定期调用 RefreshVideo 刷新目录，分析服务从目录读取分P信息，而不是实时调用 Bilibili API。
+type VideoCatalogService struct {
+	repo   repository.VideoRepository
+	client BilibiliClient
+
+	// refreshLocks 按 AID 串行化 RefreshVideo 中读取最新版本到保存新版本的过程，
+	// 避免调度器刷新与 GetVideo 的回源刷新算出相同的版本号而违反唯一键。
+	refreshMu    sync.Mutex
+	refreshLocks map[int64]*sync.Mutex
+}
+
+// NewVideoCatalogService 创建 VideoCatalogService 实例。
+func NewVideoCatalogService(repo repository.VideoRepository, client BilibiliClient) *VideoCatalogService {
+	return &VideoCatalogService{
+		repo:         repo,
+		client:       client,
+		refreshLocks: make(map[int64]*sync.Mutex),
+	}
+}
+
+// lockAID 获取 aid 的刷新锁，返回解锁函数。
+func (s *VideoCatalogService) lockAID(aid int64) func() {
+	s.refreshMu.Lock()
+	mu, ok := s.refreshLocks[aid]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.refreshLocks[aid] = mu
+	}
+	s.refreshMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// RefreshVideo 从 Bilibili 获取视频信息并更新目录。
+// aidStr 和 bvidStr 必须提供一个。分P列表与最新版本不同时保存为新版本。
+// 同一视频的刷新按 AID 串行执行，并发刷新时后执行的一方会看到先执行一方保存的版本。
+func (s *VideoCatalogService) RefreshVideo(ctx context.Context, aidStr, bvidStr string) (*model.Video, error) {
+	view, err := s.client.GetVideoView(ctx, aidStr, bvidStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video view from bilibili client: %w", err)
+	}
+	if view == nil {
+		return nil, fmt.Errorf("received nil video view info from client")
+	}
+	if len(view.Pages) == 0 {
+		return nil, fmt.Errorf("video %s has no page information", view.Bvid)
+	}
+
+	now := time.Now()
+	pages := make([]model.VideoPage, 0, len(view.Pages))
+	for _, p := range view.Pages {
+		pages = append(pages, model.VideoPage{
+			Cid:      p.Cid,
+			Duration: p.Duration,
+			Part:     p.Part,
+			Page:     p.Page,
+		})
+	}
+
+	unlock := s.lockAID(view.Aid)
+	defer unlock()
+
+	history, err := s.repo.ListPageHistory(ctx, view.Aid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list page history: %w", err)
+	}
+	version := 0
+	latest, ok := history.Latest()
+	if ok {
+		version = latest.Version
+	}
+	if !ok || !latest.SamePages(pages) {
+		version++
+		if err := s.repo.SavePageList(ctx, &model.VideoPageList{
+			AID:           view.Aid,
+			Version:       version,
+			EffectiveFrom: now,
+			Pages:         pages,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save page list: %w", err)
+		}
+		log.Printf("Catalog: saved page list version %d for AID %d (BVID: %s, %d pages)", version, view.Aid, view.Bvid, len(pages))
+	}
+
+	video := &model.Video{
+		AID:         view.Aid,
+		BVID:        view.Bvid,
+		Title:       view.Title,
+		OwnerName:   view.OwnerName,
+		OwnerMID:    view.OwnerMid,
+		TID:         view.Tid,
+		TName:       view.Tname,
+		Duration:    view.Duration,
+		Pubdate:     view.Pubdate,
+		PageVersion: version,
+		RefreshedAt: now,
+	}
+	if err := s.repo.Save(ctx, video); err != nil {
+		return nil, fmt.Errorf("failed to save video: %w", err)
+	}
+	return video, nil
+}
+
+// GetVideo 从目录中查找视频，aidStr 和 bvidStr 必须提供一个。
+// 目录中不存在时回源刷新一次，以便首次查询尚未被调度器刷新的视频。
+func (s *VideoCatalogService) GetVideo(ctx context.Context, aidStr, bvidStr string) (*model.Video, error) {
+	var video *model.Video
+	var err error
+	if aidStr != "" {
+		aid, parseErr := strconv.ParseInt(aidStr, 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid aid %q: %w", aidStr, parseErr)
+		}
+		video, err = s.repo.FindByAID(ctx, aid)
+	} else {
+		video, err = s.repo.FindByBVID(ctx, bvidStr)
+	}
+	if errors.Is(err, repository.ErrVideoNotFound) {
+		log.Printf("Catalog: video (AID: '%s', BVID: '%s') not in catalog, refreshing from bilibili", aidStr, bvidStr)
+		return s.RefreshVideo(ctx, aidStr, bvidStr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find video in catalog: %w", err)
+	}
+	return video, nil
+}
+
+// GetPageHistory 获取视频所有版本的分P列表。
+func (s *VideoCatalogService) GetPageHistory(ctx context.Context, aid int64) (model.VideoPageHistory, error) {
+	history, err := s.repo.ListPageHistory(ctx, aid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list page history: %w", err)
+	}
+	return history, nil
+}
+
+// EnsurePageHistory 获取视频的分P列表历史，目录中没有时按 AID 回源刷新一次。
+// 没有任何分P信息时返回错误，因为此时无法计算观看时长。
+func (s *VideoCatalogService) EnsurePageHistory(ctx context.Context, aid int64) (model.VideoPageHistory, error) {
+	history, err := s.GetPageHistory(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		if _, err := s.RefreshVideo(ctx, strconv.FormatInt(aid, 10), ""); err != nil {
+			return nil, fmt.Errorf("no page information for aid %d: %w", aid, err)
+		}
+		if history, err = s.GetPageHistory(ctx, aid); err != nil {
+			return nil, err
+		}
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no page information for aid %d", aid)
+	}
+	return history, nil
+}
+
+// ListVideos 获取目录中的所有视频。
+func (s *VideoCatalogService) ListVideos(ctx context.Context) ([]*model.Video, error) {
+	return s.repo.ListAll(ctx)
+}
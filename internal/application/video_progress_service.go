@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
@@ -11,15 +12,19 @@ import (
 
 // VideoProgressService 应用服务，处理视频进度相关的用例。
 type VideoProgressService struct {
-	repo   repository.VideoProgressRepository
-	client BilibiliClient // 使用新的通用 Bilibili Client 接口
+	repo        repository.VideoProgressRepository
+	client      BilibiliClient               // 使用新的通用 Bilibili Client 接口
+	aggregation *WatchTimeAggregationService // 保存记录后增量更新观看时长聚合
+	labels      *ProgressLabelService        // 保存记录前给记录对分类
 }
 
 // NewVideoProgressService 创建 VideoProgressService 实例。
-func NewVideoProgressService(repo repository.VideoProgressRepository, client BilibiliClient) *VideoProgressService {
+func NewVideoProgressService(repo repository.VideoProgressRepository, client BilibiliClient, aggregation *WatchTimeAggregationService, labels *ProgressLabelService) *VideoProgressService {
 	return &VideoProgressService{
-		repo:   repo,
-		client: client,
+		repo:        repo,
+		client:      client,
+		aggregation: aggregation,
+		labels:      labels,
 	}
 }
 
@@ -57,16 +62,53 @@ func (s *VideoProgressService) FetchAndSaveVideoProgress(ctx context.Context, ai
 		BVID:         bvid,
 		LastPlayCID:  cid,
 		LastPlayTime: progressMs,
+		RecordedAt:   progressDTO.FetchedAt.UTC(), // 与原始响应归档的获取时间一致，便于从归档重新生成
+	}
+	if progressToSave.RecordedAt.IsZero() {
+		progressToSave.RecordedAt = time.Now().UTC() // 显式设置，聚合需要在保存后立即知道记录时间
 	}
 	log.Printf("Creating new progress record for AID %d, BVID %s", aid, bvid)
 
-	// 4. 保存到仓库
+	// 分类、保存和增量累加都在视频的聚合锁内进行：并发的重建或汇总要么看不到这条记录，要么看到已累加后的聚合
+	if s.aggregation != nil {
+		unlock, err := s.aggregation.lockVideo(ctx, aid)
+		if err != nil {
+			log.Printf("Error locking watch time aggregates for AID %d: %v", aid, err)
+			return fmt.Errorf("failed to save new video progress: %w", err)
+		}
+		defer unlock()
+	}
+
+	// 4. 与上一条记录组成的记录对分类。失败时不分类，可通过 relabel-progress 命令重新分类
+	var relabeled *model.VideoProgress
+	if s.labels != nil {
+		if relabeled, err = s.labels.LabelNew(ctx, progressToSave); err != nil {
+			log.Printf("Error labeling progress pair for AID %d: %v", aid, err)
+		} else if progressToSave.PairLabel == model.PairLabelSuspicious {
+			log.Printf("Progress pair for AID %d is suspicious and will be excluded from watch time", aid)
+		}
+	}
+
+	// 5. 保存到仓库
 	if err := s.repo.Save(ctx, progressToSave); err != nil {
 		log.Printf("Error saving new video progress for AID %d and BVID %s: %v", aid, bvid, err)
 		return fmt.Errorf("failed to save new video progress: %w", err)
 	}
 
 	log.Printf("Successfully saved new progress record for AID %d and BVID %s (ID: %d)", aid, bvid, progressToSave.ID)
+
+	// 6. 增量更新观看时长聚合。上一条记录被改为可疑时重建包含它的时段。
+	// 失败不影响记录本身，可通过 rebuild-aggregates 命令重建
+	if s.aggregation != nil {
+		if relabeled != nil {
+			err = s.aggregation.rebuildAround(ctx, aid, relabeled.RecordedAt, progressToSave.RecordedAt)
+		} else {
+			err = s.aggregation.onProgressSaved(ctx, progressToSave)
+		}
+		if err != nil {
+			log.Printf("Error updating watch time aggregates for AID %d: %v", aid, err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,241 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+// WatchTimeAggregationService 应用服务，维护小时级和天级观看时长聚合。
+//
+// 每保存一条新的进度记录，就把它与上一条记录之间的观看时长累加到聚合中；
+// 计算逻辑变化后可以通过 Rebuild 从原始记录重新生成。
+// 观看时长归属到记录对起点所在的小时 (聚合时区的整点)，天聚合始终等于当天所有小时聚合之和。
+// 同一视频的增量累加、重建和保留策略的汇总在视频的聚合锁 (WatchTimeAggregateRepository.LockVideo) 内串行执行。
+type WatchTimeAggregationService struct {
+	progressRepo  repository.VideoProgressRepository
+	aggregateRepo repository.WatchTimeAggregateRepository
+	catalog       *VideoCatalogService
+	strategy      service.WatchTimeStrategy
+	location      *time.Location // 天聚合的日期边界所在时区
+}
+
+// NewWatchTimeAggregationService 创建 WatchTimeAggregationService 实例。
+func NewWatchTimeAggregationService(
+	progressRepo repository.VideoProgressRepository,
+	aggregateRepo repository.WatchTimeAggregateRepository,
+	catalog *VideoCatalogService,
+	strategy service.WatchTimeStrategy,
+	location *time.Location,
+) *WatchTimeAggregationService {
+	return &WatchTimeAggregationService{
+		progressRepo:  progressRepo,
+		aggregateRepo: aggregateRepo,
+		catalog:       catalog,
+		strategy:      strategy,
+		location:      location,
+	}
+}
+
+// dayStart 返回 t 在聚合时区所在日期的零点。
+func (s *WatchTimeAggregationService) dayStart(t time.Time) time.Time {
+	y, m, d := t.In(s.location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, s.location)
+}
+
+// HourStart 返回 t 在聚合时区所在整点的开始时间，小时聚合的桶按当地整点对齐。
+func (s *WatchTimeAggregationService) HourStart(t time.Time) time.Time {
+	return service.HourStart(t, s.location)
+}
+
+// Location 返回天聚合的日期边界所在时区。
+func (s *WatchTimeAggregationService) Location() *time.Location {
+	return s.location
+}
+
+// IsDayStart 判断 t 是否恰好是聚合时区的某天零点。
+func (s *WatchTimeAggregationService) IsDayStart(t time.Time) bool {
+	return s.dayStart(t).Equal(t)
+}
+
+// lockVideo 获取视频的聚合锁，返回释放锁的函数。
+func (s *WatchTimeAggregationService) lockVideo(ctx context.Context, aid int64) (func(), error) {
+	unlock, err := s.aggregateRepo.LockVideo(ctx, aid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock aggregates of aid %d: %w", aid, err)
+	}
+	return unlock, nil
+}
+
+// OnProgressSaved 在新的进度记录保存后调用，把它与同一视频上一条记录之间的观看时长累加到聚合中。
+// 可疑的记录对 (model.PairLabelSuspicious) 不计入聚合。
+// 调用方应在持有聚合锁时保存记录并调用 onProgressSaved (见 VideoProgressService)；
+// 否则保存之后、累加之前执行的重建已经计入了该记录，累加会重复计算。
+func (s *WatchTimeAggregationService) OnProgressSaved(ctx context.Context, progress *model.VideoProgress) error {
+	unlock, err := s.lockVideo(ctx, progress.AID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.onProgressSaved(ctx, progress)
+}
+
+// onProgressSaved 是 OnProgressSaved 的实现，调用方需持有视频的聚合锁。
+func (s *WatchTimeAggregationService) onProgressSaved(ctx context.Context, progress *model.VideoProgress) error {
+	if progress.PairLabel == model.PairLabelSuspicious {
+		return nil
+	}
+	prev, err := s.progressRepo.GetLatestByAIDBefore(ctx, progress.AID, progress.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to get previous progress: %w", err)
+	}
+	if prev == nil {
+		return nil // 第一条记录，没有可计算的记录对
+	}
+	history, err := s.catalog.EnsurePageHistory(ctx, progress.AID)
+	if err != nil {
+		return err
+	}
+	// 这条记录之前 (含上一条) 各分P到达过的最远位置，与重建时仓库求和使用的高水位线一致
+	reached, err := s.progressRepo.ReachedPositions(ctx, progress.AID, progress.RecordedAt, false)
+	if err != nil {
+		return fmt.Errorf("failed to get reached positions: %w", err)
+	}
+	breakdown, err := calculatePairWatchTime(s.strategy, history, prev, progress, reached)
+	if err != nil || (breakdown.Total() <= 0 && !breakdown.IsSkip()) {
+		return nil // 回退 (向前策略)、找不到分P等情况不计入观看时长，与分析服务一致
+	}
+
+	hour := s.HourStart(prev.RecordedAt)
+	day := s.dayStart(hour)
+	var hourly, daily []model.WatchTimeAggregate
+	for cid, seconds := range breakdownByPart(breakdown, progress.LastPlayCID) {
+		hourly = append(hourly, seconds.aggregate(progress.AID, cid, hour))
+		daily = append(daily, seconds.aggregate(progress.AID, cid, day))
+	}
+	return s.aggregateRepo.AddWatchTime(ctx, hourly, daily)
+}
+
+// Rebuild 根据原始记录重建视频在 [from, to) 范围内的聚合。
+// 范围会向外扩展到聚合时区的整点；水位线之前的原始记录已被清理，这部分的小时聚合保持不变。
+func (s *WatchTimeAggregationService) Rebuild(ctx context.Context, aid int64, from, to time.Time) error {
+	unlock, err := s.lockVideo(ctx, aid)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.rebuild(ctx, aid, from, to)
+}
+
+// rebuild 是 Rebuild 的实现，调用方需持有视频的聚合锁。
+func (s *WatchTimeAggregationService) rebuild(ctx context.Context, aid int64, from, to time.Time) error {
+	watermark, err := s.aggregateRepo.GetWatermark(ctx, aid)
+	if err != nil {
+		return err
+	}
+	start := s.HourStart(from)
+	if start.Before(watermark) {
+		start = watermark
+	}
+	end := s.HourStart(to)
+	if end.Before(to) {
+		end = end.Add(time.Hour)
+	}
+	if !start.Before(end) {
+		return nil
+	}
+
+	history, err := s.catalog.EnsurePageHistory(ctx, aid)
+	if err != nil {
+		return err
+	}
+	bucketing := model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: s.strategy.MaxSpeed()}
+	deltas, err := s.progressRepo.ListPairDeltas(ctx, aid, start, end, bucketing)
+	if err != nil {
+		return err
+	}
+	sums := sumPairDurations(s.strategy, history, deltas, bucketing.IncludeSuspicious, nil)
+	hourly := make([]model.WatchTimeAggregate, 0, len(sums))
+	for k, seconds := range sums {
+		hourly = append(hourly, seconds.aggregate(aid, k.cid, bucketing.BucketStart(k.bucket)))
+	}
+	if err := s.aggregateRepo.ReplaceHourly(ctx, aid, start, end, hourly); err != nil {
+		return err
+	}
+
+	// 天聚合由小时聚合按天求和重建，覆盖所有受影响的整天
+	dayFrom := s.dayStart(start)
+	dayTo := s.dayStart(end.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+	if err := s.rebuildDaily(ctx, aid, dayFrom, dayTo); err != nil {
+		return err
+	}
+	log.Printf("Aggregation: rebuilt AID %d in [%s, %s) (%d hourly buckets)", aid, start, end, len(hourly))
+	return nil
+}
+
+// RebuildAll 重建所有存在进度记录的视频在 [from, to) 范围内的聚合。
+func (s *WatchTimeAggregationService) RebuildAll(ctx context.Context, from, to time.Time) error {
+	aids, err := s.progressRepo.ListDistinctAIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked videos: %w", err)
+	}
+	for _, aid := range aids {
+		if err := s.Rebuild(ctx, aid, from, to); err != nil {
+			return fmt.Errorf("failed to rebuild aggregates for aid %d: %w", aid, err)
+		}
+	}
+	return nil
+}
+
+// RebuildAround 在 [first, last] 范围内新增或修改原始记录后重建受影响的聚合。
+// 范围从 first 之前的那条记录开始 (以它为起点的记录对也发生了变化)，到 last 为止。
+func (s *WatchTimeAggregationService) RebuildAround(ctx context.Context, aid int64, first, last time.Time) error {
+	unlock, err := s.lockVideo(ctx, aid)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.rebuildAround(ctx, aid, first, last)
+}
+
+// rebuildAround 是 RebuildAround 的实现，调用方需持有视频的聚合锁。
+func (s *WatchTimeAggregationService) rebuildAround(ctx context.Context, aid int64, first, last time.Time) error {
+	from := first
+	prev, err := s.progressRepo.GetLatestByAIDBefore(ctx, aid, first)
+	if err != nil {
+		return err
+	}
+	if prev != nil {
+		from = prev.RecordedAt
+	}
+	return s.rebuild(ctx, aid, from, last.Add(time.Nanosecond))
+}
+
+// rebuildDaily 用小时聚合按天求和，替换 [from, to) 范围内的天聚合。
+func (s *WatchTimeAggregationService) rebuildDaily(ctx context.Context, aid int64, from, to time.Time) error {
+	hourly, err := s.aggregateRepo.ListHourly(ctx, aid, from, to)
+	if err != nil {
+		return err
+	}
+	type dayKey struct {
+		cid int64
+		day time.Time
+	}
+	sums := make(map[dayKey]watchSeconds)
+	for _, h := range hourly {
+		key := dayKey{cid: h.CID, day: s.dayStart(h.BucketStart)}
+		sum := sums[key]
+		sum.addAggregate(h)
+		sums[key] = sum
+	}
+	daily := make([]model.WatchTimeAggregate, 0, len(sums))
+	for k, seconds := range sums {
+		daily = append(daily, seconds.aggregate(aid, k.cid, k.day))
+	}
+	return s.aggregateRepo.ReplaceDaily(ctx, aid, from, to, daily)
+}
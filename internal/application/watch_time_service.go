@@ -3,7 +3,6 @@ package application
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
@@ -11,73 +10,73 @@ import (
 
 // WatchTimeService 定义了处理观看时长计算相关用例的应用服务接口。
 type WatchTimeService interface {
-	// CalculateWatchTimeBetweenPoints 计算指定视频在两个时间点之间的观看时长。
-	// aid 和 bvid 提供一个即可。
+	// CalculateWatchTimeBetweenPoints 计算指定视频在两个播放位置之间的观看时长 (按播放顺序的内容时长，不受倍速上限限制)。
+	// aid 和 bvid 提供一个即可。返回每个分P贡献的时长；分P不存在、位置无效或起点晚于终点时返回领域服务的错误
+	// (service.ErrPageNotFound、service.ErrInvalidTime、service.ErrStartAfterEnd)。
 	CalculateWatchTimeBetweenPoints(ctx context.Context,
 		aid, bvid string,
 		startCid int64,
 		startTimeInStartCidSec int64,
 		endCid int64,
 		endTimeInEndCidSec int64,
-	) (time.Duration, error)
+	) (*model.VideoPageList, service.PageWatchTimes, error)
 }
 
 // watchTimeService 实现了 WatchTimeService 接口。
 type watchTimeService struct {
-	client     BilibiliClient              // 依赖 Bilibili Client 获取视频信息
+	catalog    *VideoCatalogService        // 依赖视频目录获取分P列表
 	calculator service.WatchTimeCalculator // 依赖领域服务进行计算
 }
 
 // NewWatchTimeService 创建 WatchTimeService 实例。
-func NewWatchTimeService(client BilibiliClient, calculator service.WatchTimeCalculator) WatchTimeService {
+func NewWatchTimeService(catalog *VideoCatalogService, calculator service.WatchTimeCalculator) WatchTimeService {
 	return &watchTimeService{
-		client:     client,
+		catalog:    catalog,
 		calculator: calculator,
 	}
 }
 
 // CalculateWatchTimeBetweenPoints 实现计算逻辑。
+// 使用同时包含两个分P的最新分P列表版本 (都不包含时使用最新版本)，同时返回使用的版本。
 func (s *watchTimeService) CalculateWatchTimeBetweenPoints(ctx context.Context,
 	aid, bvid string,
 	startCid int64,
 	startTimeInStartCidSec int64,
 	endCid int64,
 	endTimeInEndCidSec int64,
-) (time.Duration, error) {
+) (*model.VideoPageList, service.PageWatchTimes, error) {
 
 	// 1. 输入验证 (基础)
 	if aid == "" && bvid == "" {
-		return 0, fmt.Errorf("either aid or bvid must be provided")
+		return nil, nil, fmt.Errorf("either aid or bvid must be provided")
 	}
 
-	// 2. 调用 Bilibili Client 获取视频视图信息 (包含 Pages)
-	videoViewDTO, err := s.client.GetVideoView(ctx, aid, bvid)
+	// 2. 从视频目录获取分P列表历史
+	video, err := s.catalog.GetVideo(ctx, aid, bvid)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get video view info: %w", err)
+		return nil, nil, fmt.Errorf("failed to get video info: %w", err)
 	}
-	if videoViewDTO == nil {
-		// 理论上 client 不会返回 nil DTO 和 nil error，但加个保护
-		return 0, fmt.Errorf("received nil video view info from client")
+	history, err := s.catalog.GetPageHistory(ctx, video.AID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get page history: %w", err)
 	}
-
-	// 3. 将应用层 DTO 的 Pages 映射到领域层模型 Pages
-	if len(videoViewDTO.Pages) == 0 {
+	pages, ok := history.Latest()
+	if !ok {
 		// 如果视频没有分P信息，无法计算
-		return 0, fmt.Errorf("video has no page information (pages is empty)")
+		return nil, nil, fmt.Errorf("video has no page information (pages is empty)")
 	}
-	domainPages := make([]model.VideoPage, 0, len(videoViewDTO.Pages))
-	for _, dtoPage := range videoViewDTO.Pages {
-		domainPages = append(domainPages, model.VideoPage{
-			Cid:      dtoPage.Cid,
-			Duration: dtoPage.Duration,
-			Part:     dtoPage.Part,
-			Page:     dtoPage.Page,
-		})
+	for i := len(history) - 1; i >= 0; i-- {
+		_, hasStart := history[i].Page(startCid)
+		_, hasEnd := history[i].Page(endCid)
+		if hasStart && hasEnd {
+			pages = &history[i]
+			break
+		}
 	}
 
-	// 4. 调用领域服务进行计算
-	watchDuration, err := s.calculator.CalculateWatchTime(
-		domainPages,
+	// 3. 调用领域服务进行计算
+	watched, err := s.calculator.CalculateWatchTime(
+		pages.Pages,
 		startCid,
 		startTimeInStartCidSec,
 		endCid,
@@ -85,8 +84,8 @@ func (s *watchTimeService) CalculateWatchTimeBetweenPoints(ctx context.Context,
 	)
 	if err != nil {
 		// 直接返回领域服务计算出的错误（例如 PageNotFound, InvalidTime 等）
-		return 0, err
+		return nil, nil, err
 	}
 
-	return watchDuration, nil
+	return pages, watched, nil
 }
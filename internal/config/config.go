@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	// "github.com/spf13/viper" // Removed Viper dependency
 )
 
@@ -15,6 +16,12 @@ type Config struct {
 	Database  DatabaseConfig
 	Bilibili  BilibiliConfig
 	Scheduler SchedulerConfig
+	Retention RetentionConfig
+	Aggregate AggregateConfig
+	WatchTime WatchTimeConfig
+	Goal      GoalConfig
+	Archive   ArchiveConfig
+	Demo      DemoConfig
 	GinMode   string
 }
 
@@ -44,6 +51,42 @@ type SchedulerConfig struct {
 	Cron string // Env: SCHEDULER_CRON (默认: "0 0 * * *")
 }
 
+// RetentionConfig 保存原始进度记录保留策略相关配置。
+type RetentionConfig struct {
+	RawDays int    // Env: RETENTION_RAW_DAYS (默认: 0，表示永久保留原始记录)
+	Cron    string // Env: RETENTION_CRON (默认: "0 30 3 * * *"，每天 03:30 执行汇总和清理)
+}
+
+// AggregateConfig 保存观看时长聚合相关配置。
+type AggregateConfig struct {
+	Timezone string         // Env: AGGREGATE_TIMEZONE (默认: "Local")，天聚合按该时区的零点划分，小时聚合按该时区的整点划分
+	Location *time.Location // 由 Timezone 解析得到
+}
+
+// WatchTimeConfig 保存观看时长计算相关配置。
+type WatchTimeConfig struct {
+	Strategy    string        // Env: WATCH_TIME_STRATEGY (默认: "forward")，forward 不计后退的记录对，rewatch 把后退后的播放计为重看
+	MaxSpeed    float64       // Env: WATCH_TIME_MAX_SPEED (默认: 2)，每对记录最多计入 经过时间 × 该倍速，超出部分视为跳过；0 表示不限制
+	Attribution string        // Env: WATCH_TIME_ATTRIBUTION (默认: "proportional")，分段接口未指定 attribution 时的归属方式，start 或 proportional (按天的分段仍读取天聚合)
+	IdleGap     time.Duration // Env: SESSION_IDLE_GAP (默认: 30m)，两次有效观看间隔超过该时长时划分为不同的观看会话
+}
+
+// GoalConfig 保存学习目标相关配置。
+type GoalConfig struct {
+	DayCutoffHour int // Env: GOAL_DAY_CUTOFF_HOUR (默认: 0)，学习日从聚合时区的该整点开始，之前的观看计入前一天
+}
+
+// ArchiveConfig 保存 Bilibili API 原始响应归档相关配置。
+type ArchiveConfig struct {
+	Enabled bool // Env: ARCHIVE_RAW_RESPONSES (默认: false)，开启后保存每次进度和视频信息请求的原始响应
+}
+
+// DemoConfig 保存演示模式相关配置。
+type DemoConfig struct {
+	Enabled  bool // 由命令行参数 --demo 开启
+	SeedDays int  // Env: DEMO_SEED_DAYS (默认: 14)，启动时生成多少天的历史进度
+}
+
 // LoadConfig 使用 os 包严格从环境变量加载配置。
 func LoadConfig() (*Config, error) {
 	cfg := &Config{}
@@ -83,6 +126,23 @@ func LoadConfig() (*Config, error) {
 	// --- 定时任务配置 ---
 	cfg.Scheduler.Cron = getEnv("SCHEDULER_CRON", "0 0 * * *")
 
+	// --- 保留策略配置 ---
+	if err := loadRetentionConfig(&cfg.Retention); err != nil {
+		return nil, err
+	}
+	if err := loadAggregateConfig(&cfg.Aggregate); err != nil {
+		return nil, err
+	}
+	if err := loadWatchTimeConfig(&cfg.WatchTime); err != nil {
+		return nil, err
+	}
+	if err := loadGoalConfig(&cfg.Goal); err != nil {
+		return nil, err
+	}
+	if err := loadArchiveConfig(&cfg.Archive); err != nil {
+		return nil, err
+	}
+
 	// --- Gin 模式 ---
 	cfg.GinMode = getEnv("GIN_MODE", "debug")
 
@@ -107,6 +167,118 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// LoadDemoConfig 加载演示模式配置。
+// 演示模式使用内存仓库和模拟 Bilibili 客户端，因此不要求数据库和 SESSDATA 相关环境变量。
+func LoadDemoConfig() (*Config, error) {
+	cfg := &Config{}
+	var err error
+
+	serverPortStr := getEnv("BACKEND_PORT", "8080")
+	cfg.Server.Port, err = strconv.Atoi(serverPortStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_PORT value %q: %w", serverPortStr, err)
+	}
+
+	// BVID 列表可选，未设置时由调用方使用全部模拟视频
+	if bvidStr := getEnvOrErr("BILIBILI_BVID"); bvidStr != "" {
+		for _, bvid := range strings.Split(bvidStr, ",") {
+			cfg.Bilibili.TargetBVIDs = append(cfg.Bilibili.TargetBVIDs, strings.TrimSpace(bvid))
+		}
+	}
+
+	// 演示模式默认每分钟轮询一次，便于快速看到数据变化
+	cfg.Scheduler.Cron = getEnv("SCHEDULER_CRON", "0 * * * * *")
+	if err := loadRetentionConfig(&cfg.Retention); err != nil {
+		return nil, err
+	}
+	if err := loadAggregateConfig(&cfg.Aggregate); err != nil {
+		return nil, err
+	}
+	if err := loadWatchTimeConfig(&cfg.WatchTime); err != nil {
+		return nil, err
+	}
+	if err := loadGoalConfig(&cfg.Goal); err != nil {
+		return nil, err
+	}
+	if err := loadArchiveConfig(&cfg.Archive); err != nil {
+		return nil, err
+	}
+
+	cfg.Demo.Enabled = true
+	seedDaysStr := getEnv("DEMO_SEED_DAYS", "14")
+	cfg.Demo.SeedDays, err = strconv.Atoi(seedDaysStr)
+	if err != nil || cfg.Demo.SeedDays < 0 {
+		return nil, fmt.Errorf("invalid DEMO_SEED_DAYS value %q", seedDaysStr)
+	}
+
+	cfg.GinMode = getEnv("GIN_MODE", "debug")
+	return cfg, nil
+}
+
+// loadRetentionConfig 从环境变量加载保留策略配置。
+func loadRetentionConfig(cfg *RetentionConfig) error {
+	rawDaysStr := getEnv("RETENTION_RAW_DAYS", "0")
+	rawDays, err := strconv.Atoi(rawDaysStr)
+	if err != nil || rawDays < 0 {
+		return fmt.Errorf("invalid RETENTION_RAW_DAYS value %q", rawDaysStr)
+	}
+	cfg.RawDays = rawDays
+	cfg.Cron = getEnv("RETENTION_CRON", "0 30 3 * * *")
+	return nil
+}
+
+// loadAggregateConfig 从环境变量加载聚合配置。
+func loadAggregateConfig(cfg *AggregateConfig) error {
+	cfg.Timezone = getEnv("AGGREGATE_TIMEZONE", "Local")
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid AGGREGATE_TIMEZONE value %q: %w", cfg.Timezone, err)
+	}
+	cfg.Location = location
+	return nil
+}
+
+// loadWatchTimeConfig 从环境变量加载观看时长计算配置，策略和归属方式名称在创建服务时校验。
+func loadWatchTimeConfig(cfg *WatchTimeConfig) error {
+	cfg.Strategy = strings.TrimSpace(getEnv("WATCH_TIME_STRATEGY", "forward"))
+	maxSpeedStr := getEnv("WATCH_TIME_MAX_SPEED", "2")
+	maxSpeed, err := strconv.ParseFloat(maxSpeedStr, 64)
+	if err != nil || (maxSpeed != 0 && maxSpeed < 1) {
+		return fmt.Errorf("invalid WATCH_TIME_MAX_SPEED value %q (expected 0 or a number >= 1)", maxSpeedStr)
+	}
+	cfg.MaxSpeed = maxSpeed
+	cfg.Attribution = strings.TrimSpace(getEnv("WATCH_TIME_ATTRIBUTION", "proportional"))
+	idleGapStr := getEnv("SESSION_IDLE_GAP", "30m")
+	idleGap, err := time.ParseDuration(idleGapStr)
+	if err != nil || idleGap <= 0 {
+		return fmt.Errorf("invalid SESSION_IDLE_GAP value %q (expected a positive duration such as 30m)", idleGapStr)
+	}
+	cfg.IdleGap = idleGap
+	return nil
+}
+
+// loadGoalConfig 从环境变量加载学习目标配置。
+func loadGoalConfig(cfg *GoalConfig) error {
+	cutoffStr := getEnv("GOAL_DAY_CUTOFF_HOUR", "0")
+	cutoff, err := strconv.Atoi(cutoffStr)
+	if err != nil || cutoff < 0 || cutoff > 23 {
+		return fmt.Errorf("invalid GOAL_DAY_CUTOFF_HOUR value %q (expected an hour between 0 and 23)", cutoffStr)
+	}
+	cfg.DayCutoffHour = cutoff
+	return nil
+}
+
+// loadArchiveConfig 从环境变量加载原始响应归档配置。
+func loadArchiveConfig(cfg *ArchiveConfig) error {
+	enabledStr := getEnv("ARCHIVE_RAW_RESPONSES", "false")
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return fmt.Errorf("invalid ARCHIVE_RAW_RESPONSES value %q: %w", enabledStr, err)
+	}
+	cfg.Enabled = enabled
+	return nil
+}
+
 // getEnv 获取环境变量，如果未设置则返回默认值。
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// CoursePlanItem 课程计划中的一个分P。
+type CoursePlanItem struct {
+	AID  int64  // 视频稿件 ID (AV 号)
+	BVID string // 视频 BV 号
+	CID  int64  // 分P ID
+}
+
+// CoursePlan 课程计划：按顺序观看的一组分P (可以来自多个视频)，以及开始日期和目标完成日期。
+// StartDate 和 TargetDate 是日历日 (只使用年月日，以 UTC 零点表示)，按配置的学习日划分解释。
+type CoursePlan struct {
+	ID          uint
+	Name        string
+	Items       []CoursePlanItem // 按观看顺序排列
+	StartDate   time.Time        // 计划开始的日期
+	TargetDate  time.Time        // 目标完成日期 (含)
+	GmtCreate   time.Time
+	GmtModified time.Time
+}
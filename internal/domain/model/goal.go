@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// GoalKind 学习目标的类型。
+type GoalKind string
+
+const (
+	GoalKindDaily    GoalKind = "daily"    // 每天至少观看 DailySeconds，如 "每天看 30 分钟"
+	GoalKindDeadline GoalKind = "deadline" // 在 Deadline 当天结束前看完整个视频，如 "2026-12-01 前看完"
+)
+
+// Goal 一个视频的学习目标。
+// StartDate 和 Deadline 是日历日 (只使用年月日，以 UTC 零点表示)，按配置的学习日划分解释。
+type Goal struct {
+	ID           uint
+	AID          int64  // 视频稿件 ID (AV 号)
+	BVID         string // 视频 BV 号
+	Kind         GoalKind
+	DailySeconds int64     // 每天的目标观看时长 (秒)，仅 daily 目标使用
+	StartDate    time.Time // 开始统计的日期，更早的观看不计入连续天数
+	Deadline     time.Time // 截止日期 (含)，仅 deadline 目标使用
+	GmtCreate    time.Time
+	GmtModified  time.Time
+}
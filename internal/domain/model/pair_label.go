@@ -0,0 +1,16 @@
+package model
+
+// PairLabel 一对相邻进度记录 (同一视频的上一条记录到本条记录) 的分类，保存在后一条记录上。
+type PairLabel string
+
+const (
+	PairLabelNone       PairLabel = ""            // 视频的第一条记录，或尚未分类的历史记录
+	PairLabelForward    PairLabel = "forward"     // 同一分P内进度不变或向前推进
+	PairLabelSeekBack   PairLabel = "seek_back"   // 同一分P内进度后退
+	PairLabelPartSwitch PairLabel = "part_switch" // 切换到另一个分P
+	PairLabelReset      PairLabel = "reset"       // 同一分P内进度从非零变为 0，可能是从头重播，也可能是登录失效
+	PairLabelSuspicious PairLabel = "suspicious"  // 数据不可信 (分P不存在、进度超出时长、跳转后又回到原处等)，默认不计入观看时长
+)
+
+// PairLabels 是所有可保存的分类 (不含 PairLabelNone)。
+var PairLabels = []PairLabel{PairLabelForward, PairLabelSeekBack, PairLabelPartSwitch, PairLabelReset, PairLabelSuspicious}
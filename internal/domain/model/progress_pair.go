@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// PairBucketing 描述把连续记录对按起点时间分桶的方式：桶序号 = floor((起点时间 - Origin) / Width)。
+// MaxSpeed 为单对记录观看时长上限使用的最大倍速 (见 LimitPairSeconds)，0 表示不限。
+// Split 为 true 时，终点超出起点所在的桶或超出查询范围 (to) 的记录对不参与求和，而是作为 ProgressPair 逐条返回，
+// 由调用方按经过时间拆分。
+// 终点记录被标记为可疑 (PairLabelSuspicious) 的记录对默认被排除，IncludeSuspicious 为 true 时照常计算。
+type PairBucketing struct {
+	Origin            time.Time
+	Width             time.Duration
+	MaxSpeed          float64
+	Split             bool
+	IncludeSuspicious bool
+}
+
+// BucketStart 返回桶序号对应的开始时间。
+func (b PairBucketing) BucketStart(bucket int64) time.Time {
+	return b.Origin.Add(time.Duration(bucket) * b.Width)
+}
+
+// SpansBucket 判断起点位于 bucket 的记录对在 end 结束时是否超出了该桶。
+func (b PairBucketing) SpansBucket(bucket int64, end time.Time) bool {
+	return end.After(b.BucketStart(bucket + 1))
+}
+
+// ProgressDeltaSum 一个桶内同一分P中向前推进的连续记录对的合计，由数据库直接求和。
+// 这类记录对的观看时长就是进度差 (受 LimitPairSeconds 限制)，不需要分P列表参与计算。
+// 逐条返回的记录对把合计分为不同的段 (Run)，每个合计覆盖的时间内没有逐条返回的记录对，调用方可以按时间顺序重放。
+type ProgressDeltaSum struct {
+	Bucket            int64
+	CID               int64     // 分P ID (记录对两端相同)
+	Run               int64     // 之前逐条返回的记录对数量
+	Seconds           int64     // 计入的观看时长之和 (秒)，每对不超过经过时间乘以最大倍速
+	RewatchedSeconds  int64     // 其中位于分P高水位线 (之前的记录中到达过的最远位置) 之前、计为重看的时长 (秒)
+	SkippedSeconds    int64     // 超出上限、视为跳过的进度差之和 (秒)
+	ContinuousSeconds int64     // 其中连续播放的记录对计入的时长之和 (秒)
+	PlaybackSeconds   int64     // 连续播放的记录对的经过时间之和 (秒)，与 ContinuousSeconds 一起推断播放倍速
+	MaxPosition       int64     // 记录对终点的最大进度 (秒)，用于校验是否超出分P时长
+	Pairs             int64     // 合并的记录对数量
+	FirstStartedAt    time.Time // 最早的记录对起点时间
+	LastEndedAt       time.Time // 最晚的记录对终点时间
+}
+
+// FreshSeconds 返回计入的 counted 秒 (以 position 秒结束) 中超过高水位线 reached 的部分，其余为重看。
+// 数据库实现中的 SQL 与此处的计算保持一致。
+func FreshSeconds(counted, position, reached int64) int64 {
+	return min(counted, max(position-reached, 0))
+}
+
+// LimitPairSeconds 按两条记录之间的经过时间限制一对记录的观看时长：
+// 最多计入 floor(经过时间 * maxSpeed) 秒，超出部分视为跳过 (例如两次轮询之间从 P1 拖到 P20)。
+// 计入的时长不少于经过时间 (整秒，且不为 0) 时为连续播放，playback 为经过时间，用于推断倍速；
+// 否则中途有暂停或停止，无法知道播放实际用去的时间，playback 为 0，这对记录不参与推断倍速。
+// maxSpeed 为 0 时不限制。数据库实现中的 SQL 与此处的计算保持一致。
+func LimitPairSeconds(seconds int64, elapsed time.Duration, maxSpeed float64) (counted, skipped, playback int64) {
+	counted = seconds
+	if maxSpeed > 0 {
+		if limit := int64(float64(elapsed.Microseconds()) * maxSpeed / 1e6); counted > limit {
+			counted = limit
+		}
+	}
+	if elapsedSec := elapsed.Microseconds() / 1e6; elapsedSec > 0 && counted >= elapsedSec {
+		playback = elapsedSec
+	}
+	return counted, seconds - counted, playback
+}
+
+// ProgressPair 一对相邻的进度记录 (跨分P、同一分P内后退，或 Split 时跨越桶边界)，观看时长需要结合分P列表计算。
+type ProgressPair struct {
+	Bucket int64
+	Curr   VideoProgress
+	Next   VideoProgress
+}
+
+// PairDeltas 某个视频在一段时间内所有相邻记录对的预计算结果。
+// Reached 为 from 之前的记录中每个分P到达过的最远位置 (秒)，与 Sums 的重看时长使用相同的高水位线，
+// 调用方按时间顺序重放 Sums 和 Pairs 时以它为起点划分逐条返回的记录对中的重看。
+type PairDeltas struct {
+	Sums    []ProgressDeltaSum
+	Pairs   []ProgressPair
+	Reached map[int64]int64
+}
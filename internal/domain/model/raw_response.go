@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RawResponseKind 表示归档的原始响应来自哪个 Bilibili 接口。
+type RawResponseKind string
+
+const (
+	RawResponseKindProgress RawResponseKind = "progress" // 播放进度接口 /x/player/wbi/v2
+	RawResponseKindView     RawResponseKind = "view"     // 视频信息接口 /x/web-interface/view
+)
+
+// RawResponse 是一次 Bilibili API 调用的原始响应体归档。
+// 保留完整响应，便于之后提取当时未解析的字段或重新生成进度记录。
+type RawResponse struct {
+	ID        uint
+	Kind      RawResponseKind
+	AID       int64     // 视频稿件 ID (AV 号)
+	BVID      string    // 视频 BV 号
+	CID       int64     // 请求的分P ID，仅进度接口有值
+	FetchedAt time.Time // 收到响应的时间，与由该响应生成的进度记录的 RecordedAt 相同
+	Body      []byte    // 未压缩的响应体 (JSON)
+	GmtCreate time.Time
+}
@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// Video 代表一个被追踪视频稿件的元数据 (视频目录)。
+// 由调度器定期从 Bilibili 刷新，分析时不再依赖实时 API。
+type Video struct {
+	ID          uint
+	AID         int64     // 视频稿件 ID (AV 号)
+	BVID        string    // 视频 BV 号
+	Title       string    // 视频标题
+	OwnerName   string    // UP主名称
+	OwnerMID    int64     // UP主 ID (mid)，目录刷新前为 0
+	TID         int       // 分区 ID，目录刷新前为 0
+	TName       string    // 分区名称
+	Duration    int64     // 总时长（单位：秒）
+	Pubdate     int64     // 发布时间戳
+	PageVersion int       // 当前分P列表的版本号，从 1 开始
+	RefreshedAt time.Time // 最近一次从 Bilibili 刷新的时间
+	GmtCreate   time.Time
+	GmtModified time.Time
+}
+
+// VideoPageList 代表某个版本的分P列表。
+// UP主调整分P (增删、替换、改时长) 后会产生新版本，旧版本保留用于解释历史进度。
+type VideoPageList struct {
+	AID           int64
+	Version       int         // 版本号，从 1 开始递增
+	EffectiveFrom time.Time   // 该版本开始生效的时间
+	Pages         []VideoPage // 按播放顺序排列
+}
+
+// SamePages 判断分P列表内容是否与给定列表一致 (CID、时长、标题、序号均相同)。
+func (l *VideoPageList) SamePages(pages []VideoPage) bool {
+	if len(l.Pages) != len(pages) {
+		return false
+	}
+	for i := range pages {
+		if l.Pages[i] != pages[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Page 返回列表中指定 CID 的分P。
+func (l *VideoPageList) Page(cid int64) (VideoPage, bool) {
+	for _, p := range l.Pages {
+		if p.Cid == cid {
+			return p, true
+		}
+	}
+	return VideoPage{}, false
+}
+
+// VideoPageHistory 是一个视频所有版本的分P列表，按版本号升序排列。
+type VideoPageHistory []VideoPageList
+
+// At 返回在时间 t 有效的分P列表版本。
+// 如果 t 早于第一个版本的生效时间，返回第一个版本 (目录建立前的记录按最早已知的分P解释)。
+func (h VideoPageHistory) At(t time.Time) (*VideoPageList, bool) {
+	if len(h) == 0 {
+		return nil, false
+	}
+	current := &h[0]
+	for i := 1; i < len(h); i++ {
+		if h[i].EffectiveFrom.After(t) {
+			break
+		}
+		current = &h[i]
+	}
+	return current, true
+}
+
+// Page 返回指定 CID 的分P，优先使用最新版本，已被移除的分P从更早的版本中查找。
+func (h VideoPageHistory) Page(cid int64) (VideoPage, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		if page, ok := h[i].Page(cid); ok {
+			return page, true
+		}
+	}
+	return VideoPage{}, false
+}
+
+// Latest 返回最新版本的分P列表。
+func (h VideoPageHistory) Latest() (*VideoPageList, bool) {
+	if len(h) == 0 {
+		return nil, false
+	}
+	return &h[len(h)-1], true
+}
@@ -10,11 +10,12 @@ import (
 // Note: gorm.Model is not used to avoid DeletedAt field, matching the schema.
 type VideoProgress struct {
 	ID            uint      `gorm:"primarykey;comment:主键 ID"`
-	AID           int64     `gorm:"column:aid;index;not null;default:0;comment:视频稿件 ID (AV 号)"`          // 显式列名
+	AID           int64     `gorm:"column:aid;uniqueIndex:uk_video_progress_aid_recorded_at,priority:1;index:idx_video_progress_aid_cid_recorded_at,priority:1;not null;default:0;comment:视频稿件 ID (AV 号)"`          // 显式列名
 	BVID          string    `gorm:"column:bvid;not null;default:'';comment:视频 BV 号"`                     // 显式列名
-	LastPlayCID   int64     `gorm:"column:last_play_cid;index;not null;default:0;comment:上次播放的视频分 P ID"` // 显式列名 & 重命名
+	LastPlayCID   int64     `gorm:"column:last_play_cid;index;index:idx_video_progress_aid_cid_recorded_at,priority:2;not null;default:0;comment:上次播放的视频分 P ID"` // 显式列名 & 重命名
 	LastPlayTime  int64     `gorm:"column:last_play_time;not null;default:0;comment:上次播放时间/进度 (毫秒)"`     // 重命名
-	RecordedAt    time.Time `gorm:"column:recorded_at;index;not null;default:CURRENT_TIMESTAMP(3);comment:记录时间"`
+	RecordedAt    time.Time `gorm:"column:recorded_at;index;uniqueIndex:uk_video_progress_aid_recorded_at,priority:2;index:idx_video_progress_aid_cid_recorded_at,priority:3;not null;default:CURRENT_TIMESTAMP(3);comment:记录时间"`
+	PairLabel     PairLabel `gorm:"column:pair_label;type:varchar(16);not null;default:'';comment:与上一条记录组成的记录对的分类"` // 见 PairLabel
 	GmtCreate     time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
 	GmtModified   time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
 	// DeletedAt gorm.DeletedAt `gorm:"index"` // Removed
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// WatchTimeAggregate 代表某个视频分P在一个时间桶 (小时或天) 内的累计观看时长。
+// 聚合随进度记录增量维护；原始进度记录超过保留期后被删除，长时间范围的分析通过聚合数据完成。
+type WatchTimeAggregate struct {
+	AID               int64     // 视频稿件 ID (AV 号)
+	CID               int64     // 分P ID
+	BucketStart       time.Time // 时间桶开始时间 (小时聚合为整点，天聚合为聚合时区的零点)
+	WatchedSeconds    int64     // 观看时长（单位：秒），包含重看时长
+	RewatchedSeconds  int64     // 其中重看的时长（单位：秒），即分P之前到达过的最远位置之前的观看
+	SkippedSeconds    int64     // 超出倍速上限、视为跳过的进度（单位：秒），不计入观看时长
+	ContinuousSeconds int64     // 其中连续播放 (进度差不少于经过时间) 的记录对计入的观看时长（单位：秒）
+	PlaybackSeconds   int64     // 连续播放的记录对的经过时间（单位：秒），ContinuousSeconds / PlaybackSeconds 即平均倍速
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ErrCoursePlanNotFound 表示不存在指定的课程计划。
+var ErrCoursePlanNotFound = errors.New("course plan not found")
+
+// CoursePlanRepository 定义课程计划的数据操作接口，计划与其分P一起保存和读取。
+type CoursePlanRepository interface {
+	// Create 保存一个新计划及其分P，并回填 ID 和创建时间。
+	Create(ctx context.Context, plan *model.CoursePlan) error
+
+	// FindByID 根据 ID 查找计划，分P按观看顺序排列。
+	// 如果未找到，应返回 ErrCoursePlanNotFound 错误。
+	FindByID(ctx context.Context, id uint) (*model.CoursePlan, error)
+
+	// ListAll 获取所有计划，按 ID 升序排序。
+	ListAll(ctx context.Context) ([]*model.CoursePlan, error)
+
+	// Delete 删除指定 ID 的计划及其分P。
+	// 如果未找到，应返回 ErrCoursePlanNotFound 错误。
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ErrGoalNotFound 表示不存在指定的学习目标。
+var ErrGoalNotFound = errors.New("goal not found")
+
+// GoalRepository 定义学习目标的数据操作接口。
+type GoalRepository interface {
+	// Create 保存一个新目标，并回填 ID 和创建时间。
+	Create(ctx context.Context, goal *model.Goal) error
+
+	// FindByID 根据 ID 查找目标。
+	// 如果未找到，应返回 ErrGoalNotFound 错误。
+	FindByID(ctx context.Context, id uint) (*model.Goal, error)
+
+	// ListAll 获取所有目标，按 ID 升序排序。
+	ListAll(ctx context.Context) ([]*model.Goal, error)
+
+	// Delete 删除指定 ID 的目标。
+	// 如果未找到，应返回 ErrGoalNotFound 错误。
+	Delete(ctx context.Context, id uint) error
+}
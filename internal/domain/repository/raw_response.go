@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// RawResponseFilter 按接口类型、视频和时间范围筛选原始响应归档的条件。
+type RawResponseFilter struct {
+	Kind  model.RawResponseKind // 接口类型，空字符串表示所有类型
+	AID   int64                 // 视频稿件 ID，0 表示所有视频
+	Start time.Time             // 开始时间 (含)，零值表示不限
+	End   time.Time             // 结束时间 (不含)，零值表示不限
+}
+
+// RawResponseRepository 定义原始响应归档的数据操作接口。
+type RawResponseRepository interface {
+	// Save 保存一条原始响应。
+	Save(ctx context.Context, response *model.RawResponse) error
+
+	// Iterate 按 (获取时间, ID) 升序逐条遍历满足条件的原始响应，不会一次性加载全部记录。
+	// fn 返回错误时停止遍历并返回该错误。
+	Iterate(ctx context.Context, filter RawResponseFilter, fn func(response *model.RawResponse) error) error
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ErrVideoNotFound 表示视频目录中不存在指定的视频。
+var ErrVideoNotFound = errors.New("video not found")
+
+// VideoRepository 定义视频目录 (视频元数据及分P列表历史) 的数据操作接口。
+type VideoRepository interface {
+	// Save 新增或更新视频元数据，以 AID 作为唯一标识。
+	Save(ctx context.Context, video *model.Video) error
+
+	// FindByAID 根据 AID 查找视频。
+	// 如果未找到，应返回 ErrVideoNotFound 错误。
+	FindByAID(ctx context.Context, aid int64) (*model.Video, error)
+
+	// FindByBVID 根据 BVID 查找视频。
+	// 如果未找到，应返回 ErrVideoNotFound 错误。
+	FindByBVID(ctx context.Context, bvid string) (*model.Video, error)
+
+	// ListAll 获取目录中的所有视频，按 AID 升序排序。
+	ListAll(ctx context.Context) ([]*model.Video, error)
+
+	// SavePageList 保存一个新版本的分P列表。
+	// 调用方负责分配版本号，同一视频的同一版本只能保存一次。
+	SavePageList(ctx context.Context, list *model.VideoPageList) error
+
+	// ListPageHistory 获取视频所有版本的分P列表，按版本号升序排序。
+	// 如果视频没有任何分P列表，返回空切片。
+	ListPageHistory(ctx context.Context, aid int64) (model.VideoPageHistory, error)
+}
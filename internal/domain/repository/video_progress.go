@@ -11,6 +11,35 @@ import (
 // ErrVideoProgressNotFound 表示未找到指定的视频进度记录。
 var ErrVideoProgressNotFound = errors.New("video progress not found")
 
+// ProgressFilter 按视频、分P和时间范围筛选进度记录的条件。
+type ProgressFilter struct {
+	AID   int64     // 视频稿件 ID，0 表示所有视频
+	CID   int64     // 上次播放的分P ID (LastPlayCID)，0 表示所有分P
+	Start time.Time // 开始时间 (含)，零值表示不限
+	End   time.Time // 结束时间 (不含)，零值表示不限
+	// PairLabel 只包含记录对分类为该值的记录，空字符串表示不限
+	PairLabel model.PairLabel
+}
+
+// ProgressCursor 键集分页的游标，表示一条记录在 (记录时间, ID) 排序中的位置。
+type ProgressCursor struct {
+	RecordedAt time.Time
+	ID         uint
+}
+
+// ProgressPageRequest 键集分页的参数。
+type ProgressPageRequest struct {
+	After      *ProgressCursor // 从该位置之后开始读取 (不含)，nil 表示从头开始
+	Limit      int             // 最多返回的条数
+	Descending bool            // 为 true 时按 (记录时间, ID) 降序，After 之后指更早的记录
+}
+
+// PairLabelUpdate 把一条进度记录的记录对分类改为 Label。
+type PairLabelUpdate struct {
+	ID    uint
+	Label model.PairLabel
+}
+
 // VideoProgressRepository 定义视频进度数据操作的接口。
 type VideoProgressRepository interface {
 	// Save 保存一条视频观看进度记录。
@@ -32,4 +61,42 @@ type VideoProgressRepository interface {
 
 	// ListByBVIDAndTimestampRange 获取指定 BVID 在给定时间范围内的所有进度记录，按记录时间升序排序。
 	ListByBVIDAndTimestampRange(ctx context.Context, bvid string, startTime, endTime time.Time) ([]*model.VideoProgress, error)
+
+	// GetLatestByAIDBefore 获取指定 AID 在 before 之前 (不含) 的最新一条进度记录。
+	// 如果不存在，返回 nil, nil。
+	GetLatestByAIDBefore(ctx context.Context, aid int64, before time.Time) (*model.VideoProgress, error)
+
+	// ListDistinctAIDs 获取所有存在进度记录的 AID。
+	ListDistinctAIDs(ctx context.Context) ([]int64, error)
+
+	// DeleteByAIDBefore 删除指定 AID 在 before 之前 (不含) 的所有进度记录，返回删除的条数。
+	DeleteByAIDBefore(ctx context.Context, aid int64, before time.Time) (int64, error)
+
+	// Iterate 按 (记录时间, ID) 升序逐条遍历满足条件的进度记录，不会一次性加载全部记录。
+	// fn 返回错误时停止遍历并返回该错误。
+	Iterate(ctx context.Context, filter ProgressFilter, fn func(progress *model.VideoProgress) error) error
+
+	// ListPage 按 (记录时间, ID) 排序返回一页满足条件的进度记录，使用键集分页，翻页代价与偏移量无关。
+	ListPage(ctx context.Context, filter ProgressFilter, page ProgressPageRequest) ([]*model.VideoProgress, error)
+
+	// ListPairDeltas 在存储端计算指定 AID 的相邻记录对 (按记录时间和 ID 排序)，只包含起点在 [from, to) 内的记录对，
+	// 终点可以在 to 之后。同一分P内向前推进的记录对按 (桶, 分P) 合并求和，其余记录对逐条返回。
+	// 终点记录被标记为可疑 (model.PairLabelSuspicious) 的记录对不返回，除非 bucketing.IncludeSuspicious。
+	// 合计中位于分P高水位线之前的时长计为重看，高水位线与 ReachedPositions 相同 (from 之前的部分见 PairDeltas.Reached)。
+	ListPairDeltas(ctx context.Context, aid int64, from, to time.Time, bucketing model.PairBucketing) (*model.PairDeltas, error)
+
+	// ReachedPositions 返回指定 AID 在 before 之前 (不含) 的记录中每个分P到达过的最远播放位置 (秒)，按 CID 索引。
+	// 被标记为可疑的记录不参与，除非 includeSuspicious。
+	ReachedPositions(ctx context.Context, aid int64, before time.Time, includeSuspicious bool) (map[int64]int64, error)
+
+	// InsertIgnoreDuplicates 批量插入进度记录，(aid, recorded_at) 已存在的记录被跳过。
+	// 返回实际插入的条数。用于幂等导入。
+	InsertIgnoreDuplicates(ctx context.Context, records []*model.VideoProgress) (int64, error)
+
+	// UpsertBatch 批量写入进度记录，(aid, recorded_at) 已存在时覆盖其余字段。
+	// 用于从原始响应归档重新生成记录。
+	UpsertBatch(ctx context.Context, records []*model.VideoProgress) error
+
+	// UpdatePairLabels 批量修改记录的记录对分类。
+	UpdatePairLabels(ctx context.Context, updates []PairLabelUpdate) error
 }
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// WatchTimeAggregateRepository 定义观看时长聚合数据及其汇总水位线的数据操作接口。
+//
+// 聚合分为小时级和天级两张表，每保存一条新的进度记录就增量累加一次；
+// 天级聚合始终可以由小时级聚合按天求和得到。
+//
+// 水位线 (watermark) 是一个整点时间：起点早于水位线的记录对只存在于聚合中 (原始记录已被清理)，
+// 起点不早于水位线的记录对仍可从原始记录计算。
+type WatchTimeAggregateRepository interface {
+	// AddWatchTime 在一个事务中把同一段观看时长 (每个分P一行) 分别累加到小时聚合和天聚合。
+	AddWatchTime(ctx context.Context, hourly, daily []model.WatchTimeAggregate) error
+
+	// ReplaceHourly 在一个事务中删除视频在 [start, end) 范围内的小时聚合并写入 rows，用于重建。
+	ReplaceHourly(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error
+
+	// ReplaceDaily 在一个事务中删除视频在 [start, end) 范围内的天聚合并写入 rows，用于重建。
+	ReplaceDaily(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error
+
+	// ListHourly 获取指定视频在 [start, end) 范围内的小时聚合，按时间桶升序排序。
+	ListHourly(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error)
+
+	// ListDaily 获取指定视频在 [start, end) 范围内的天聚合，按时间桶升序排序。
+	ListDaily(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error)
+
+	// GetWatermark 获取视频的汇总水位线，从未清理过原始记录时返回零值时间。
+	GetWatermark(ctx context.Context, aid int64) (time.Time, error)
+
+	// SetWatermark 更新视频的汇总水位线。
+	SetWatermark(ctx context.Context, aid int64, watermark time.Time) error
+
+	// LockVideo 获取视频聚合的排他锁，返回释放锁的函数。增量累加、重建和汇总都在持有锁时进行，
+	// 避免重建读取到新记录后，该记录的增量累加又执行一次。锁不可重入。
+	LockVideo(ctx context.Context, aid int64) (unlock func(), err error)
+}
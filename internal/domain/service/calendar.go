@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// CalendarUnit 按日历对齐的时间单位，周期的边界是学习日 (StudyDayClock) 的开始时间。
+type CalendarUnit string
+
+const (
+	CalendarDay     CalendarUnit = "day"
+	CalendarWeek    CalendarUnit = "week" // ISO 周，从周一开始
+	CalendarMonth   CalendarUnit = "month"
+	CalendarQuarter CalendarUnit = "quarter"
+	CalendarYear    CalendarUnit = "year"
+)
+
+// ParseCalendarUnit 解析日历单位名称。
+func ParseCalendarUnit(name string) (CalendarUnit, error) {
+	switch unit := CalendarUnit(name); unit {
+	case CalendarDay, CalendarWeek, CalendarMonth, CalendarQuarter, CalendarYear:
+		return unit, nil
+	default:
+		return "", fmt.Errorf("unknown calendar unit %q", name)
+	}
+}
+
+// Floor 返回日期 date (UTC 零点表示) 所在周期的第一天。
+func (u CalendarUnit) Floor(date time.Time) time.Time {
+	y, m, d := date.Date()
+	switch u {
+	case CalendarWeek:
+		return time.Date(y, m, d-(int(date.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case CalendarMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case CalendarQuarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case CalendarYear:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Add 返回 t 之后 n 个单位的时间 (n 为负数时向前)，在 t 的时区内按日历推进，保持当地时刻不变。
+func (u CalendarUnit) Add(t time.Time, n int) time.Time {
+	switch u {
+	case CalendarWeek:
+		return t.AddDate(0, 0, 7*n)
+	case CalendarMonth:
+		return t.AddDate(0, n, 0)
+	case CalendarQuarter:
+		return t.AddDate(0, 3*n, 0)
+	case CalendarYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// PeriodStarts 返回覆盖 [start, end) 的所有周期的开始时间，以及最后一个周期的结束时间 (范围为空时为 start)。
+// 第一个周期是包含 start 的周期，因此范围会向外扩展到完整的周期；周期按 c 的时区和学习日开始时刻对齐，
+// 夏令时切换当天的周期长度随之变化。
+func (c StudyDayClock) PeriodStarts(unit CalendarUnit, start, end time.Time) (starts []time.Time, last time.Time) {
+	if !start.Before(end) {
+		return nil, start
+	}
+	date := unit.Floor(c.Date(start))
+	for {
+		boundary := c.Start(date)
+		if !boundary.Before(end) {
+			return starts, boundary
+		}
+		starts = append(starts, boundary)
+		date = unit.Add(date, 1)
+	}
+}
+
+// HourStart 返回 t 在 loc 时区所在整点的开始时间。
+// 与 time.Truncate 按 UTC 整点截断不同，Asia/Kolkata (+05:30) 等非整小时时区也按当地的整点对齐。
+func HourStart(t time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(time.Hour).Add(-shift)
+}
+
+// CheckHourAlignment 检查 loc 从 year 年初到下一年末的 UTC 偏移是否只相差整小时。
+// 小时聚合的桶宽固定为一小时，只有这样每个桶才始终对应当地的一个整点；
+// 例如 Australia/Lord_Howe 在夏令时切换时偏移在 +10:30 和 +11:00 之间变化，无法对齐。
+func CheckHourAlignment(loc *time.Location, year int) error {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, first := start.In(loc).Zone()
+	for t := start; t.Year() <= year+1; t = t.Add(24 * time.Hour) {
+		if _, offset := t.In(loc).Zone(); (offset-first)%3600 != 0 {
+			return fmt.Errorf("timezone %s changes its UTC offset by a fraction of an hour around %s, hourly buckets cannot follow local hours",
+				loc, t.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
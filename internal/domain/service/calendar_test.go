@@ -0,0 +1,241 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// mustLoadLocation 加载 IANA 时区，失败时终止测试。
+func mustLoadLocation(t testing.TB, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+// utc 解析 RFC3339 时间，失败时终止测试。
+func utc(t testing.TB, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("parse time %s: %v", value, err)
+	}
+	return parsed
+}
+
+// day 返回以 UTC 零点表示的日期。
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStudyDayClockDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		zone   string
+		cutoff int
+		at     string
+		want   time.Time
+	}{
+		{"new york before midnight", "America/New_York", 0, "2025-03-09T04:59:59Z", day(2025, 3, 8)},
+		{"new york after spring forward", "America/New_York", 0, "2025-03-09T07:00:00Z", day(2025, 3, 9)},
+		{"new york repeated hour counts to previous day with cutoff", "America/New_York", 4, "2025-11-02T06:30:00Z", day(2025, 11, 1)},
+		{"new york repeated hour without cutoff", "America/New_York", 0, "2025-11-02T06:30:00Z", day(2025, 11, 2)},
+		{"berlin cutoff on spring forward day", "Europe/Berlin", 4, "2025-03-30T01:59:00Z", day(2025, 3, 29)},
+		{"berlin cutoff reached after spring forward", "Europe/Berlin", 4, "2025-03-30T02:00:00Z", day(2025, 3, 30)},
+		{"berlin second 02:30 on fall back day", "Europe/Berlin", 3, "2025-10-26T01:30:00Z", day(2025, 10, 25)},
+		{"kolkata half hour offset before midnight", "Asia/Kolkata", 0, "2025-01-01T18:29:59Z", day(2025, 1, 1)},
+		{"kolkata half hour offset at midnight", "Asia/Kolkata", 0, "2025-01-01T18:30:00Z", day(2025, 1, 2)},
+		{"adelaide half hour offset during daylight saving", "Australia/Adelaide", 4, "2025-01-14T17:29:00Z", day(2025, 1, 14)},
+		{"adelaide half hour offset cutoff", "Australia/Adelaide", 4, "2025-01-14T17:30:00Z", day(2025, 1, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, err := NewStudyDayClock(mustLoadLocation(t, tt.zone), tt.cutoff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := clock.Date(utc(t, tt.at)); !got.Equal(tt.want) {
+				t.Errorf("Date(%s) = %s, want %s", tt.at, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestStudyDayClockStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		cutoff    int
+		date      time.Time
+		wantStart string
+		wantHours float64 // 学习日的长度
+	}{
+		{"new york spring forward", "America/New_York", 0, day(2025, 3, 9), "2025-03-09T05:00:00Z", 23},
+		{"new york fall back", "America/New_York", 0, day(2025, 11, 2), "2025-11-02T04:00:00Z", 25},
+		{"new york cutoff day spans spring forward", "America/New_York", 4, day(2025, 3, 8), "2025-03-08T09:00:00Z", 23},
+		{"new york cutoff day after fall back", "America/New_York", 4, day(2025, 11, 2), "2025-11-02T09:00:00Z", 24},
+		{"new york skipped cutoff hour", "America/New_York", 2, day(2025, 3, 9), "2025-03-09T07:00:00Z", 23},
+		{"santiago skipped midnight", "America/Santiago", 0, day(2025, 9, 7), "2025-09-07T04:00:00Z", 23},
+		{"berlin spring forward", "Europe/Berlin", 0, day(2025, 3, 30), "2025-03-29T23:00:00Z", 23},
+		{"berlin fall back", "Europe/Berlin", 0, day(2025, 10, 26), "2025-10-25T22:00:00Z", 25},
+		{"berlin ordinary day", "Europe/Berlin", 4, day(2025, 7, 1), "2025-07-01T02:00:00Z", 24},
+		{"kolkata", "Asia/Kolkata", 0, day(2025, 3, 9), "2025-03-08T18:30:00Z", 24},
+		{"adelaide spring forward", "Australia/Adelaide", 0, day(2025, 10, 5), "2025-10-04T14:30:00Z", 23},
+		{"adelaide fall back", "Australia/Adelaide", 0, day(2025, 4, 6), "2025-04-05T13:30:00Z", 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, err := NewStudyDayClock(mustLoadLocation(t, tt.zone), tt.cutoff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := clock.Start(tt.date)
+			if want := utc(t, tt.wantStart); !start.Equal(want) {
+				t.Errorf("Start(%s) = %s, want %s", tt.date.Format(time.DateOnly), start.UTC(), want)
+			}
+			if hours := clock.Start(tt.date.AddDate(0, 0, 1)).Sub(start).Hours(); hours != tt.wantHours {
+				t.Errorf("day %s lasts %gh, want %gh", tt.date.Format(time.DateOnly), hours, tt.wantHours)
+			}
+			if got := clock.Date(start); !got.Equal(tt.date) {
+				t.Errorf("Date(Start(%s)) = %s", tt.date.Format(time.DateOnly), got.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestNewStudyDayClockRejectsInvalidCutoff(t *testing.T) {
+	for _, cutoff := range []int{-1, 24} {
+		if _, err := NewStudyDayClock(time.UTC, cutoff); err == nil {
+			t.Errorf("NewStudyDayClock(UTC, %d) succeeded, want error", cutoff)
+		}
+	}
+}
+
+func TestStudyDayClockPeriodStarts(t *testing.T) {
+	tests := []struct {
+		name       string
+		zone       string
+		cutoff     int
+		unit       CalendarUnit
+		start, end string
+		wantStarts []string
+		wantLast   string
+	}{
+		{
+			name: "new york days across spring forward", zone: "America/New_York", unit: CalendarDay,
+			start: "2025-03-08T12:00:00Z", end: "2025-03-10T04:00:00Z",
+			wantStarts: []string{"2025-03-08T05:00:00Z", "2025-03-09T05:00:00Z"},
+			wantLast:   "2025-03-10T04:00:00Z",
+		},
+		{
+			name: "new york days across fall back with cutoff", zone: "America/New_York", cutoff: 4, unit: CalendarDay,
+			start: "2025-11-01T08:00:00Z", end: "2025-11-03T00:00:00Z",
+			wantStarts: []string{"2025-11-01T08:00:00Z", "2025-11-02T09:00:00Z"},
+			wantLast:   "2025-11-03T09:00:00Z",
+		},
+		{
+			name: "berlin weeks across spring forward", zone: "Europe/Berlin", unit: CalendarWeek,
+			start: "2025-03-26T10:00:00Z", end: "2025-04-01T10:00:00Z",
+			wantStarts: []string{"2025-03-23T23:00:00Z", "2025-03-30T22:00:00Z"},
+			wantLast:   "2025-04-06T22:00:00Z",
+		},
+		{
+			name: "berlin months across fall back", zone: "Europe/Berlin", unit: CalendarMonth,
+			start: "2025-10-15T00:00:00Z", end: "2025-11-15T00:00:00Z",
+			wantStarts: []string{"2025-09-30T22:00:00Z", "2025-10-31T23:00:00Z"},
+			wantLast:   "2025-11-30T23:00:00Z",
+		},
+		{
+			name: "adelaide months across spring forward", zone: "Australia/Adelaide", unit: CalendarMonth,
+			start: "2025-09-30T14:30:00Z", end: "2025-10-31T13:30:00Z",
+			wantStarts: []string{"2025-09-30T14:30:00Z"},
+			wantLast:   "2025-10-31T13:30:00Z",
+		},
+		{
+			name: "empty range", zone: "Asia/Kolkata", unit: CalendarDay,
+			start: "2025-01-01T00:00:00Z", end: "2025-01-01T00:00:00Z",
+			wantLast: "2025-01-01T00:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := StudyDayClock{Location: mustLoadLocation(t, tt.zone), CutoffHour: tt.cutoff}
+			starts, last := clock.PeriodStarts(tt.unit, utc(t, tt.start), utc(t, tt.end))
+			if len(starts) != len(tt.wantStarts) {
+				t.Fatalf("PeriodStarts returned %d periods %v, want %v", len(starts), starts, tt.wantStarts)
+			}
+			for i, want := range tt.wantStarts {
+				if !starts[i].Equal(utc(t, want)) {
+					t.Errorf("period %d starts at %s, want %s", i, starts[i].UTC(), want)
+				}
+			}
+			if want := utc(t, tt.wantLast); !last.Equal(want) {
+				t.Errorf("last period ends at %s, want %s", last.UTC(), want)
+			}
+		})
+	}
+}
+
+func TestCalendarUnitFloor(t *testing.T) {
+	tests := []struct {
+		unit CalendarUnit
+		date time.Time
+		want time.Time
+	}{
+		{CalendarDay, day(2025, 3, 9), day(2025, 3, 9)},
+		{CalendarWeek, day(2025, 3, 9), day(2025, 3, 3)}, // 周日属于从周一开始的那一周
+		{CalendarWeek, day(2025, 3, 10), day(2025, 3, 10)},
+		{CalendarMonth, day(2025, 3, 31), day(2025, 3, 1)},
+		{CalendarQuarter, day(2025, 6, 30), day(2025, 4, 1)},
+		{CalendarYear, day(2025, 12, 31), day(2025, 1, 1)},
+	}
+	for _, tt := range tests {
+		if got := tt.unit.Floor(tt.date); !got.Equal(tt.want) {
+			t.Errorf("%s.Floor(%s) = %s, want %s", tt.unit, tt.date.Format(time.DateOnly),
+				got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestHourStart(t *testing.T) {
+	tests := []struct {
+		zone string
+		at   string
+		want string
+	}{
+		{"America/New_York", "2025-11-02T05:45:00Z", "2025-11-02T05:00:00Z"}, // 第一次 01:45 (EDT)
+		{"America/New_York", "2025-11-02T06:45:00Z", "2025-11-02T06:00:00Z"}, // 第二次 01:45 (EST)
+		{"Europe/Berlin", "2025-03-30T01:10:00Z", "2025-03-30T01:00:00Z"},
+		{"Asia/Kolkata", "2025-01-01T05:29:00Z", "2025-01-01T04:30:00Z"},
+		{"Australia/Adelaide", "2025-04-05T16:40:00Z", "2025-04-05T16:30:00Z"}, // 第一次 03:10 (ACDT)
+		{"Australia/Adelaide", "2025-04-05T17:40:00Z", "2025-04-05T17:30:00Z"}, // 第二次 03:10 (ACST)
+		{"Asia/Kathmandu", "2025-01-01T00:00:00Z", "2024-12-31T23:15:00Z"},
+	}
+	for _, tt := range tests {
+		got := HourStart(utc(t, tt.at), mustLoadLocation(t, tt.zone))
+		if want := utc(t, tt.want); !got.Equal(want) {
+			t.Errorf("HourStart(%s, %s) = %s, want %s", tt.at, tt.zone, got.UTC(), want)
+		}
+	}
+}
+
+func TestCheckHourAlignment(t *testing.T) {
+	tests := []struct {
+		zone    string
+		wantErr bool
+	}{
+		{"America/New_York", false},
+		{"Europe/Berlin", false},
+		{"Asia/Kolkata", false},
+		{"Australia/Adelaide", false},
+		{"Australia/Lord_Howe", true},
+	}
+	for _, tt := range tests {
+		err := CheckHourAlignment(mustLoadLocation(t, tt.zone), 2025)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckHourAlignment(%s) error = %v, want error %v", tt.zone, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// PositionRange 分P内的一段播放位置 [Start, End)，单位为秒。
+type PositionRange struct {
+	Start int64
+	End   int64
+}
+
+// Length 返回区间长度（秒）。
+func (r PositionRange) Length() int64 {
+	return r.End - r.Start
+}
+
+// IntervalSet 一组互不重叠、按起点升序排列的位置区间，相邻或重叠的区间在加入时合并。
+type IntervalSet struct {
+	ranges []PositionRange
+}
+
+// Add 加入区间 [start, end)，空区间被忽略。
+func (s *IntervalSet) Add(start, end int64) {
+	if end <= start {
+		return
+	}
+	// 第一个结束位置不早于 start 的区间，之后所有与 [start, end] 相交或相邻的区间都合并进来
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i].End >= start })
+	j := i
+	for j < len(s.ranges) && s.ranges[j].Start <= end {
+		if s.ranges[j].Start < start {
+			start = s.ranges[j].Start
+		}
+		if s.ranges[j].End > end {
+			end = s.ranges[j].End
+		}
+		j++
+	}
+	merged := append([]PositionRange{{Start: start, End: end}}, s.ranges[j:]...)
+	s.ranges = append(s.ranges[:i], merged...)
+}
+
+// Ranges 返回所有区间的副本。
+func (s *IntervalSet) Ranges() []PositionRange {
+	return append([]PositionRange{}, s.ranges...)
+}
+
+// Clip 返回限制在 [0, limit) 内的区间。
+func (s *IntervalSet) Clip(limit int64) []PositionRange {
+	clipped := make([]PositionRange, 0, len(s.ranges))
+	for _, r := range s.ranges {
+		if r.Start >= limit {
+			break
+		}
+		if r.End > limit {
+			r.End = limit
+		}
+		clipped = append(clipped, r)
+	}
+	return clipped
+}
+
+// PageCoverage 一个分P的观看覆盖情况。
+type PageCoverage struct {
+	Page           model.VideoPage
+	Covered        []PositionRange // 看过的位置区间
+	Gaps           []PositionRange // 没看过的位置区间
+	CoveredSeconds int64
+}
+
+// Percent 返回该分P的完成百分比 (0-100)。
+func (c PageCoverage) Percent() float64 {
+	return coveragePercent(c.CoveredSeconds, c.Page.Duration)
+}
+
+// CourseCoverage 整个视频 (课程) 的观看覆盖情况，Pages 按分P列表顺序排列。
+type CourseCoverage struct {
+	Pages          []PageCoverage
+	TotalSeconds   int64 // 所有分P的总时长
+	CoveredSeconds int64 // 所有分P看过的时长之和
+}
+
+// Percent 返回整个课程的完成百分比 (0-100)。
+func (c CourseCoverage) Percent() float64 {
+	return coveragePercent(c.CoveredSeconds, c.TotalSeconds)
+}
+
+// coveragePercent 计算百分比，总量为 0 时返回 0。
+func coveragePercent(covered, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// CoverageBuilder 根据连续的进度记录对构建每个分P看过的位置区间。
+//
+// 每对记录的观看时长由观看时长策略计算 (后退按重看处理，并受最大倍速限制)，
+// 这段时长视为一直播放到终点位置为止：按 WatchTimeBreakdown.ByPage，每个分P覆盖其末尾 (终点分P为终点位置之前) 的相应时长。
+// 发生跳转时只有终点之前可计入的部分被覆盖，被跳过的内容仍然是缺口。
+type CoverageBuilder struct {
+	strategy WatchTimeStrategy
+	covered  map[int64]*IntervalSet // 按分P CID 保存
+}
+
+// NewCoverageBuilder 创建 CoverageBuilder。maxSpeed 与观看时长策略的最大倍速含义相同。
+func NewCoverageBuilder(calculator WatchTimeCalculator, maxSpeed float64) (*CoverageBuilder, error) {
+	strategy, err := NewWatchTimeStrategy(StrategyRewatch, calculator, maxSpeed)
+	if err != nil {
+		return nil, err
+	}
+	return &CoverageBuilder{strategy: strategy, covered: make(map[int64]*IntervalSet)}, nil
+}
+
+// AddPair 加入一对相邻的进度记录，pages 为终点记录时有效的分P列表。
+// 错误与 WatchTimeStrategy 相同 (分P不存在、进度超出时长)，此时不覆盖任何位置。
+func (b *CoverageBuilder) AddPair(pages []model.VideoPage, start, end PlaybackPoint) error {
+	breakdown, err := b.strategy.CalculateBetween(pages, start, end)
+	if err != nil {
+		return err
+	}
+	for _, watched := range pageRanges(pages, breakdown.ByPage, end) {
+		b.rangesOf(watched.CID).Add(watched.Range.Start, watched.Range.End)
+	}
+	return nil
+}
+
+// rangesOf 返回分P的区间集合，不存在时创建。
+func (b *CoverageBuilder) rangesOf(cid int64) *IntervalSet {
+	set, ok := b.covered[cid]
+	if !ok {
+		set = &IntervalSet{}
+		b.covered[cid] = set
+	}
+	return set
+}
+
+// Coverage 按 pages (通常为最新的分P列表) 汇总覆盖情况，不在 pages 中的分P被忽略。
+func (b *CoverageBuilder) Coverage(pages []model.VideoPage) CourseCoverage {
+	course := CourseCoverage{Pages: make([]PageCoverage, 0, len(pages))}
+	for _, page := range pages {
+		coverage := PageCoverage{Page: page, Covered: []PositionRange{}, Gaps: []PositionRange{}}
+		if set, ok := b.covered[page.Cid]; ok {
+			coverage.Covered = set.Clip(page.Duration)
+		}
+		var next int64
+		for _, r := range coverage.Covered {
+			if r.Start > next {
+				coverage.Gaps = append(coverage.Gaps, PositionRange{Start: next, End: r.Start})
+			}
+			coverage.CoveredSeconds += r.Length()
+			next = r.End
+		}
+		if next < page.Duration {
+			coverage.Gaps = append(coverage.Gaps, PositionRange{Start: next, End: page.Duration})
+		}
+		course.Pages = append(course.Pages, coverage)
+		course.TotalSeconds += page.Duration
+		course.CoveredSeconds += coverage.CoveredSeconds
+	}
+	return course
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestIntervalSetAdd(t *testing.T) {
+	tests := []struct {
+		name  string
+		added [][2]int64
+		want  []PositionRange
+	}{
+		{name: "empty range ignored", added: [][2]int64{{10, 10}, {20, 5}}, want: []PositionRange{}},
+		{name: "disjoint ranges sorted", added: [][2]int64{{50, 60}, {0, 10}, {20, 30}}, want: []PositionRange{{0, 10}, {20, 30}, {50, 60}}},
+		{name: "overlapping", added: [][2]int64{{0, 30}, {20, 50}}, want: []PositionRange{{0, 50}}},
+		{name: "adjacent", added: [][2]int64{{0, 30}, {30, 50}}, want: []PositionRange{{0, 50}}},
+		{name: "adjacent before", added: [][2]int64{{30, 50}, {0, 30}}, want: []PositionRange{{0, 50}}},
+		{name: "contained", added: [][2]int64{{0, 100}, {20, 50}}, want: []PositionRange{{0, 100}}},
+		{name: "containing", added: [][2]int64{{20, 50}, {0, 100}}, want: []PositionRange{{0, 100}}},
+		{name: "bridging several", added: [][2]int64{{0, 10}, {20, 30}, {40, 50}, {70, 80}, {5, 45}}, want: []PositionRange{{0, 50}, {70, 80}}},
+		{name: "one apart stays separate", added: [][2]int64{{0, 10}, {11, 20}}, want: []PositionRange{{0, 10}, {11, 20}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var set IntervalSet
+			for _, r := range tt.added {
+				set.Add(r[0], r[1])
+			}
+			if got := set.Ranges(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ranges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntervalSetClip(t *testing.T) {
+	var set IntervalSet
+	set.Add(0, 100)
+	set.Add(200, 300)
+	set.Add(400, 500)
+	tests := []struct {
+		limit int64
+		want  []PositionRange
+	}{
+		{0, []PositionRange{}},
+		{50, []PositionRange{{0, 50}}},
+		{200, []PositionRange{{0, 100}}},
+		{250, []PositionRange{{0, 100}, {200, 250}}},
+		{500, []PositionRange{{0, 100}, {200, 300}, {400, 500}}},
+		{600, []PositionRange{{0, 100}, {200, 300}, {400, 500}}},
+	}
+	for _, tt := range tests {
+		if got := set.Clip(tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Clip(%d) = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+	if got := set.Ranges(); len(got) != 3 || got[2].End != 500 {
+		t.Errorf("Clip modified the set: %v", got)
+	}
+}
+
+func TestCoverageBuilder(t *testing.T) {
+	pages := []model.VideoPage{{Cid: 1, Duration: 600}, {Cid: 2, Duration: 300}}
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	point := func(cid, position int64, minutes int) PlaybackPoint {
+		return PlaybackPoint{CID: cid, PositionSec: position, RecordedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	builder, err := NewCoverageBuilder(NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := [][2]PlaybackPoint{
+		{point(1, 0, 0), point(1, 120, 2)},   // [0, 120)
+		{point(1, 120, 2), point(1, 500, 3)}, // 超出倍速上限，只覆盖 [380, 500)
+		{point(1, 500, 3), point(2, 60, 5)},  // P1 [500, 600) 与 P2 [0, 60)
+		{point(2, 60, 5), point(2, 400, 10)}, // 进度超出分P时长，不覆盖
+	}
+	for i, pair := range pairs {
+		err := builder.AddPair(pages, pair[0], pair[1])
+		if (err != nil) != (i == 3) {
+			t.Errorf("pair %d error = %v", i, err)
+		}
+	}
+	coverage := builder.Coverage(pages)
+	want := []struct {
+		covered, gaps []PositionRange
+		seconds       int64
+	}{
+		{covered: []PositionRange{{0, 120}, {380, 600}}, gaps: []PositionRange{{120, 380}}, seconds: 340},
+		{covered: []PositionRange{{0, 60}}, gaps: []PositionRange{{60, 300}}, seconds: 60},
+	}
+	for i, page := range coverage.Pages {
+		if !reflect.DeepEqual(page.Covered, want[i].covered) || !reflect.DeepEqual(page.Gaps, want[i].gaps) || page.CoveredSeconds != want[i].seconds {
+			t.Errorf("page %d = %+v, want %+v", page.Page.Cid, page, want[i])
+		}
+	}
+	if coverage.TotalSeconds != 900 || coverage.CoveredSeconds != 400 {
+		t.Errorf("course covered %d of %d seconds, want 400 of 900", coverage.CoveredSeconds, coverage.TotalSeconds)
+	}
+}
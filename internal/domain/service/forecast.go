@@ -0,0 +1,69 @@
+package service
+
+import (
+	"math"
+	"time"
+)
+
+// ForecastConfidence 完成预测置信区间的置信度，forecastZ 为对应的正态分布双侧分位数。
+const (
+	ForecastConfidence = 0.8
+	forecastZ          = 1.2816
+)
+
+// CompletionForecast 根据最近的每日进度预测看完剩余内容所需的天数。
+type CompletionForecast struct {
+	Remaining    time.Duration // 剩余未看的时长
+	DailyPace    time.Duration // 指数加权平均的每日进度
+	PaceStdDev   time.Duration // 每日进度的指数加权标准差
+	Completed    bool          // 已经没有剩余内容
+	Predictable  bool          // 每日进度大于 0，可以给出预测 (已完成时也为 true)
+	ExpectedDays float64       // 按平均进度还需的天数
+	LowerDays    float64       // 置信区间下界 (进度偏快时的天数)
+	UpperDays    float64       // 置信区间上界 (进度偏慢时的天数)，进度下界不大于 0 时为 +Inf
+}
+
+// ForecastCompletion 用指数加权移动平均 (平滑系数 alpha，越大越看重最近的日子) 计算每日进度，并预测看完 remaining 所需的天数。
+// daily 为按日期升序排列的每日进度，没有观看的日子为 0。
+// 置信区间假设每天的进度相互独立且近似正态：接下来 n 天的平均进度的标准差 (均值的标准误) 为 σ/√n，
+// n 取按平均进度还需的天数 ExpectedDays (不足 1 天按 1 天，单日的波动就是 σ)，
+// 平均进度落在 mean ± forecastZ·σ/√n 之间的概率为 ForecastConfidence，用 remaining 除以区间两端得到天数的区间。
+// σ 为 0 (每天进度相同) 时区间退化为 ExpectedDays 一个点。
+func ForecastCompletion(daily []time.Duration, remaining time.Duration, alpha float64) CompletionForecast {
+	forecast := CompletionForecast{Remaining: remaining}
+	if remaining <= 0 {
+		forecast.Remaining = 0
+		forecast.Completed = true
+		forecast.Predictable = true
+		return forecast
+	}
+	if len(daily) == 0 {
+		return forecast
+	}
+
+	// 指数加权的均值和方差 (West 增量公式)，以第一天作为初值
+	mean, variance := float64(daily[0]), 0.0
+	for _, d := range daily[1:] {
+		diff := float64(d) - mean
+		increment := alpha * diff
+		mean += increment
+		variance = (1 - alpha) * (variance + diff*increment)
+	}
+	forecast.DailyPace = time.Duration(mean)
+	forecast.PaceStdDev = time.Duration(math.Sqrt(variance))
+	if forecast.DailyPace <= 0 {
+		return forecast
+	}
+
+	forecast.Predictable = true
+	forecast.ExpectedDays = float64(remaining) / mean
+	// 平均进度的置信区间半宽：z·σ/√n
+	margin := forecastZ * math.Sqrt(variance) / math.Sqrt(math.Max(forecast.ExpectedDays, 1))
+	forecast.LowerDays = float64(remaining) / (mean + margin)
+	if slow := mean - margin; slow > 0 {
+		forecast.UpperDays = float64(remaining) / slow
+	} else {
+		forecast.UpperDays = math.Inf(1)
+	}
+	return forecast
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecastCompletion(t *testing.T) {
+	minutes := func(values ...float64) []time.Duration {
+		daily := make([]time.Duration, 0, len(values))
+		for _, v := range values {
+			daily = append(daily, time.Duration(v*float64(time.Minute)))
+		}
+		return daily
+	}
+	// [0, 20] 分钟、alpha 0.5：均值 10 分钟，方差 0.5·(20·10) = 100，σ 为 10 分钟
+	margin := forecastZ * 10 / math.Sqrt(4)
+	tests := []struct {
+		name      string
+		daily     []time.Duration
+		remaining time.Duration
+		alpha     float64
+		want      CompletionForecast
+	}{
+		{
+			name: "zero history", remaining: time.Hour, alpha: 0.5,
+			want: CompletionForecast{Remaining: time.Hour},
+		},
+		{
+			name: "no progress in the history", daily: minutes(0, 0, 0), remaining: time.Hour, alpha: 0.5,
+			want: CompletionForecast{Remaining: time.Hour},
+		},
+		{
+			name: "zero remaining", daily: minutes(10, 20), alpha: 0.5,
+			want: CompletionForecast{Completed: true, Predictable: true},
+		},
+		{
+			name: "negative remaining", daily: minutes(10), remaining: -time.Minute, alpha: 0.5,
+			want: CompletionForecast{Completed: true, Predictable: true},
+		},
+		{
+			name: "constant pace collapses the band", daily: minutes(10, 10, 10, 10), remaining: 100 * time.Minute, alpha: 0.4,
+			want: CompletionForecast{Remaining: 100 * time.Minute, DailyPace: 10 * time.Minute, Predictable: true, ExpectedDays: 10, LowerDays: 10, UpperDays: 10},
+		},
+		{
+			name: "varying pace", daily: minutes(0, 20), remaining: 40 * time.Minute, alpha: 0.5,
+			want: CompletionForecast{Remaining: 40 * time.Minute, DailyPace: 10 * time.Minute, PaceStdDev: 10 * time.Minute, Predictable: true,
+				ExpectedDays: 4, LowerDays: 40 / (10 + margin), UpperDays: 40 / (10 - margin)},
+		},
+		{
+			name: "slow end of the band at or below zero", daily: minutes(0, 20), remaining: 10 * time.Minute, alpha: 0.5,
+			want: CompletionForecast{Remaining: 10 * time.Minute, DailyPace: 10 * time.Minute, PaceStdDev: 10 * time.Minute, Predictable: true,
+				ExpectedDays: 1, LowerDays: 10 / (10 + forecastZ*10), UpperDays: math.Inf(1)},
+		},
+	}
+	near := func(a, b float64) bool {
+		return a == b || math.Abs(a-b) < 1e-9
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForecastCompletion(tt.daily, tt.remaining, tt.alpha)
+			w := tt.want
+			if got.Remaining != w.Remaining || got.DailyPace != w.DailyPace || got.PaceStdDev != w.PaceStdDev ||
+				got.Completed != w.Completed || got.Predictable != w.Predictable ||
+				!near(got.ExpectedDays, w.ExpectedDays) || !near(got.LowerDays, w.LowerDays) || !near(got.UpperDays, w.UpperDays) {
+				t.Errorf("ForecastCompletion = %+v, want %+v", got, w)
+			}
+		})
+	}
+}
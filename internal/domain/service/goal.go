@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// StudyDayClock 把时间划分为学习日：每个学习日从当天 CutoffHour 点开始，到次日 CutoffHour 点结束 (Location 时区)。
+// 例如 CutoffHour 为 4 时，凌晨 1 点的观看计入前一天。学习日的日期以 UTC 零点表示，与目标中的日历日一致。
+type StudyDayClock struct {
+	Location   *time.Location
+	CutoffHour int
+}
+
+// NewStudyDayClock 创建 StudyDayClock，cutoffHour 必须在 0-23 之间。
+func NewStudyDayClock(loc *time.Location, cutoffHour int) (StudyDayClock, error) {
+	if cutoffHour < 0 || cutoffHour > 23 {
+		return StudyDayClock{}, fmt.Errorf("day cutoff hour must be between 0 and 23, got %d", cutoffHour)
+	}
+	return StudyDayClock{Location: loc, CutoffHour: cutoffHour}, nil
+}
+
+// Date 返回时间 t 所在学习日的日期。
+func (c StudyDayClock) Date(t time.Time) time.Time {
+	local := t.In(c.Location)
+	y, m, d := local.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if local.Hour() < c.CutoffHour {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+// Start 返回日期为 date 的学习日的开始时间。
+// 开始时刻落在夏令时跳过的时段中时 (如 America/New_York 春季的 02:00)，学习日从跳过的时段结束时开始。
+func (c StudyDayClock) Start(date time.Time) time.Time {
+	y, m, d := date.Date()
+	start := time.Date(y, m, d, c.CutoffHour, 0, 0, 0, c.Location)
+	if c.Date(start).Before(date) {
+		_, end := start.ZoneBounds()
+		return end
+	}
+	return start
+}
+
+// DaysBetween 返回从日期 from 到日期 to 相隔的天数 (to 早于 from 时为负数)。
+func DaysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Round(time.Hour) / (24 * time.Hour))
+}
+
+// GoalDay 一个学习日相对目标的完成情况。
+type GoalDay struct {
+	Date    time.Time     // 学习日的日期
+	Watched time.Duration // 当天计入目标的观看时长
+	Target  time.Duration // 当天的目标时长
+	Met     bool          // 是否达到目标
+}
+
+// CountStreaks 统计按日期升序排列的连续学习日中，连续达成目标的天数。
+// current 为截至最后一天 (今天) 的连续天数：今天尚未达成时不中断，从昨天开始往前数；longest 为其中最长的连续天数。
+func CountStreaks(days []GoalDay) (current, longest int) {
+	run := 0
+	for _, day := range days {
+		if day.Met {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	for i := len(days) - 1; i >= 0; i-- {
+		if !days[i].Met {
+			if i == len(days)-1 {
+				continue // 今天还没结束
+			}
+			break
+		}
+		current++
+	}
+	return current, longest
+}
+
+// RequiredDailyPace 返回在剩余 daysLeft 个学习日 (含今天) 内看完 remaining 每天至少需要的时长，向上取整到秒。
+// daysLeft 不大于 0 (已过截止日期) 时返回 remaining。
+func RequiredDailyPace(remaining time.Duration, daysLeft int) time.Duration {
+	if remaining <= 0 {
+		return 0
+	}
+	if daysLeft <= 0 {
+		return remaining
+	}
+	pace := remaining / time.Duration(daysLeft)
+	if rounded := pace.Truncate(time.Second); rounded < pace {
+		pace = rounded + time.Second
+	}
+	return pace
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountStreaks(t *testing.T) {
+	const y, n = true, false
+	tests := []struct {
+		name                 string
+		met                  []bool // 按日期升序，最后一天为今天
+		wantCurrent, wantMax int
+	}{
+		{name: "no days"},
+		{name: "all met", met: []bool{y, y, y}, wantCurrent: 3, wantMax: 3},
+		{name: "broken by a zero day", met: []bool{y, y, y, n, y, y}, wantCurrent: 2, wantMax: 3},
+		{name: "today not met yet", met: []bool{y, y, n}, wantCurrent: 2, wantMax: 2},
+		{name: "yesterday missed", met: []bool{y, n, n}, wantCurrent: 0, wantMax: 1},
+		{name: "only today, not met", met: []bool{n}},
+		{name: "only today, met", met: []bool{n, y}, wantCurrent: 1, wantMax: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := make([]GoalDay, 0, len(tt.met))
+			for i, met := range tt.met {
+				days = append(days, GoalDay{Date: day(2025, 3, 1).AddDate(0, 0, i), Met: met})
+			}
+			current, longest := CountStreaks(days)
+			if current != tt.wantCurrent || longest != tt.wantMax {
+				t.Errorf("CountStreaks = %d, %d, want %d, %d", current, longest, tt.wantCurrent, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRequiredDailyPace(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		daysLeft  int
+		want      time.Duration
+	}{
+		{"nothing left", 0, 3, 0},
+		{"negative remaining", -time.Minute, 3, 0},
+		{"deadline today", 1000 * time.Second, 1, 1000 * time.Second},
+		{"deadline passed", 1000 * time.Second, 0, 1000 * time.Second},
+		{"long past deadline", 1000 * time.Second, -5, 1000 * time.Second},
+		{"even split", 900 * time.Second, 3, 300 * time.Second},
+		{"rounded up to a second", 1000 * time.Second, 3, 334 * time.Second},
+		{"sub-second remainder", 1500 * time.Millisecond, 1, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredDailyPace(tt.remaining, tt.daysLeft); got != tt.want {
+				t.Errorf("RequiredDailyPace(%s, %d) = %s, want %s", tt.remaining, tt.daysLeft, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{day(2025, 3, 1), day(2025, 3, 1), 0},
+		{day(2025, 3, 1), day(2025, 3, 31), 30},
+		{day(2025, 3, 31), day(2025, 3, 1), -30},
+		{day(2024, 2, 28), day(2024, 3, 1), 2},
+	}
+	for _, tt := range tests {
+		if got := DaysBetween(tt.from, tt.to); got != tt.want {
+			t.Errorf("DaysBetween(%s, %s) = %d, want %d", tt.from.Format(time.DateOnly), tt.to.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestStudyDayClockCutoffBoundary(t *testing.T) {
+	clock, err := NewStudyDayClock(mustLoadLocation(t, "Asia/Shanghai"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   string
+		want time.Time
+	}{
+		{"2025-03-01T19:59:59Z", day(2025, 3, 1)}, // 当地 03:59:59，计入前一天
+		{"2025-03-01T20:00:00Z", day(2025, 3, 2)}, // 当地 04:00，新的学习日
+		{"2025-03-01T16:00:00Z", day(2025, 3, 1)}, // 当地零点仍属于前一天
+	}
+	for _, tt := range tests {
+		at := utc(t, tt.at)
+		date := clock.Date(at)
+		if !date.Equal(tt.want) {
+			t.Errorf("Date(%s) = %s, want %s", tt.at, date.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+		if start := clock.Start(date); start.After(at) || !clock.Start(date.AddDate(0, 0, 1)).After(at) {
+			t.Errorf("%s is outside the study day starting at %s", tt.at, start)
+		}
+	}
+}
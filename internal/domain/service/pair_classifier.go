@@ -0,0 +1,95 @@
+package service
+
+import (
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// PairClassifier 按相邻进度记录之间的变化给记录对分类 (model.PairLabel)，用于识别登录失效、接口返回旧数据等造成的异常记录。
+//
+// 分类只依赖记录对及其之前的记录，新记录保存时即可确定；发现 "跳到别处后又回到原处" 时，
+// 说明上一条记录是异常数据，上一对记录也应改为可疑。
+type PairClassifier struct {
+	maxSpeed float64 // 与观看时长策略一致的最大倍速，0 表示不限 (此时不识别异常跳转)
+}
+
+// NewPairClassifier 创建 PairClassifier 实例。
+func NewPairClassifier(maxSpeed float64) PairClassifier {
+	return PairClassifier{maxSpeed: maxSpeed}
+}
+
+// Classify 返回记录对 (prev, curr) 的分类，prev 为 nil 时 (视频的第一条记录) 返回 PairLabelNone。
+// before 为 prev 之前的一条记录 (可以为 nil)。(before, prev) 的变化由两条记录的位置判断，不读取 prev.PairLabel：
+// 它可能已被改为可疑，只重新分类部分记录时结果也因此与全部重新分类一致。
+// relabelPrev 为 true 时 (before, prev) 也应改为可疑。
+//
+// 规则依次为：
+//   - 任一端的分P不存在 (包括登录失效时返回的 CID 0) 或进度超出分P时长：可疑；
+//   - 切换分P：part_switch；
+//   - 上一对是重置 (进度变为 0)，本条又回到重置前的位置之后，且按最大倍速可以从重置前的记录连续播放到这里：
+//     重置是假的，两对都可疑；
+//   - 上一对向前跳转超出了倍速上限，本条又回到跳转前的位置附近 (同样可以连续播放到这里)：跳转是假的，两对都可疑；
+//   - 同一分P内进度从非零变为 0：reset；进度后退：seek_back；否则 forward。
+func (c PairClassifier) Classify(history model.VideoPageHistory, before, prev, curr *model.VideoProgress) (label model.PairLabel, relabelPrev bool) {
+	if prev == nil {
+		return model.PairLabelNone, false
+	}
+	if !validProgress(history, prev) || !validProgress(history, curr) {
+		return model.PairLabelSuspicious, false
+	}
+	if prev.LastPlayCID != curr.LastPlayCID {
+		return model.PairLabelPartSwitch, false
+	}
+
+	// continues 保证 before 与 curr (也就是 prev) 在同一分P且 before 的位置不为 0
+	if before != nil && before.LastPlayCID == curr.LastPlayCID && c.continues(before, curr) {
+		switch {
+		case prev.LastPlayTime == 0: // (before, prev) 是重置
+			return model.PairLabelSuspicious, true
+		case c.maxSpeed > 0 && prev.LastPlayTime > before.LastPlayTime && curr.LastPlayTime < prev.LastPlayTime &&
+			exceedsSpeed(before, prev, c.maxSpeed): // (before, prev) 是超出倍速上限的前跳
+			return model.PairLabelSuspicious, true
+		}
+	}
+
+	switch {
+	case curr.LastPlayTime == 0 && prev.LastPlayTime > 0:
+		return model.PairLabelReset, false
+	case curr.LastPlayTime < prev.LastPlayTime:
+		return model.PairLabelSeekBack, false
+	default:
+		return model.PairLabelForward, false
+	}
+}
+
+// continues 判断 to 是否可以看作从 from 连续播放而来：同一分P内位置不早于 from，且推进的进度没有超出倍速上限。
+// from 的位置为 0 时不认为是连续播放 (无法区分重新开始和恢复)。
+func (c PairClassifier) continues(from, to *model.VideoProgress) bool {
+	if from.LastPlayTime <= 0 || to.LastPlayTime < from.LastPlayTime {
+		return false
+	}
+	return c.maxSpeed <= 0 || !exceedsSpeed(from, to, c.maxSpeed)
+}
+
+// exceedsSpeed 判断同一分P内从 from 到 to 推进的进度是否超出了 经过时间 × maxSpeed。
+func exceedsSpeed(from, to *model.VideoProgress, maxSpeed float64) bool {
+	_, skipped, _ := model.LimitPairSeconds(to.LastPlayTime/1000-from.LastPlayTime/1000, to.RecordedAt.Sub(from.RecordedAt), maxSpeed)
+	return skipped > 0
+}
+
+// validProgress 判断记录的分P存在 (优先使用记录时有效的分P列表，找不到时查找其他版本) 且进度没有超出分P时长。
+func validProgress(history model.VideoPageHistory, p *model.VideoProgress) bool {
+	if p.LastPlayCID == 0 || p.LastPlayTime < 0 {
+		return false
+	}
+	var page model.VideoPage
+	ok := false
+	if list, found := history.At(p.RecordedAt); found {
+		page, ok = list.Page(p.LastPlayCID)
+	}
+	if !ok {
+		if page, ok = history.Page(p.LastPlayCID); !ok {
+			return false
+		}
+	}
+	return p.LastPlayTime/1000 <= page.Duration
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestPairClassifierClassify(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 第 60 分钟起 P1 被删除，P3 新增
+	history := model.VideoPageHistory{
+		{AID: 1, Version: 1, EffectiveFrom: start, Pages: []model.VideoPage{{Cid: 1, Duration: 600}, {Cid: 2, Duration: 600}}},
+		{AID: 1, Version: 2, EffectiveFrom: start.Add(time.Hour), Pages: []model.VideoPage{{Cid: 2, Duration: 600}, {Cid: 3, Duration: 600}}},
+	}
+	record := func(cid, positionMs int64, minute int, label model.PairLabel) *model.VideoProgress {
+		return &model.VideoProgress{AID: 1, LastPlayCID: cid, LastPlayTime: positionMs,
+			RecordedAt: start.Add(time.Duration(minute) * time.Minute), PairLabel: label}
+	}
+	tests := []struct {
+		name            string
+		maxSpeed        float64
+		before, prev    *model.VideoProgress
+		curr            *model.VideoProgress
+		want            model.PairLabel
+		wantRelabelPrev bool
+	}{
+		{name: "first record", maxSpeed: 2, curr: record(1, 0, 0, ""), want: model.PairLabelNone},
+		{name: "forward", maxSpeed: 2, prev: record(1, 100000, 0, ""), curr: record(1, 160000, 1, ""), want: model.PairLabelForward},
+		{name: "unchanged position is forward", maxSpeed: 2, prev: record(1, 100000, 0, ""), curr: record(1, 100000, 1, ""), want: model.PairLabelForward},
+		{name: "forward beyond the speed cap is still forward", maxSpeed: 2, prev: record(1, 0, 0, ""), curr: record(1, 590000, 1, ""), want: model.PairLabelForward},
+		{name: "seek back by one millisecond", maxSpeed: 2, prev: record(1, 100000, 0, ""), curr: record(1, 99999, 1, ""), want: model.PairLabelSeekBack},
+		{name: "part switch", maxSpeed: 2, prev: record(1, 590000, 0, ""), curr: record(2, 0, 1, ""), want: model.PairLabelPartSwitch},
+		{name: "reset", maxSpeed: 2, prev: record(1, 1000, 0, ""), curr: record(1, 0, 1, ""), want: model.PairLabelReset},
+		{name: "zero to zero is forward", maxSpeed: 2, prev: record(1, 0, 0, ""), curr: record(1, 0, 1, ""), want: model.PairLabelForward},
+		{name: "cid 0 when logged out", maxSpeed: 2, prev: record(1, 100000, 0, ""), curr: record(0, 0, 1, ""), want: model.PairLabelSuspicious},
+		{name: "unknown part", maxSpeed: 2, prev: record(1, 100000, 0, ""), curr: record(9, 0, 1, ""), want: model.PairLabelSuspicious},
+		{name: "position at the part duration", maxSpeed: 2, prev: record(1, 500000, 0, ""), curr: record(1, 600999, 1, ""), want: model.PairLabelForward},
+		{name: "position beyond the part duration", maxSpeed: 2, prev: record(1, 500000, 0, ""), curr: record(1, 601000, 1, ""), want: model.PairLabelSuspicious},
+		{name: "negative position", maxSpeed: 2, prev: record(1, 500000, 0, ""), curr: record(1, -1, 1, ""), want: model.PairLabelSuspicious},
+		{name: "part removed in a later page list", maxSpeed: 2, prev: record(1, 100000, 70, ""), curr: record(1, 160000, 71, ""), want: model.PairLabelForward},
+		{
+			name: "return after a reset", maxSpeed: 2,
+			before: record(1, 300000, 0, ""), prev: record(1, 0, 1, model.PairLabelReset), curr: record(1, 400000, 2, ""),
+			want: model.PairLabelSuspicious, wantRelabelPrev: true,
+		},
+		{
+			name: "return after a reset at exactly the speed cap", maxSpeed: 2,
+			before: record(1, 300000, 0, ""), prev: record(1, 0, 1, model.PairLabelReset), curr: record(1, 540000, 2, ""),
+			want: model.PairLabelSuspicious, wantRelabelPrev: true,
+		},
+		{
+			name: "after a reset beyond what could be played since", maxSpeed: 2,
+			before: record(1, 300000, 0, ""), prev: record(1, 0, 1, model.PairLabelReset), curr: record(1, 541000, 2, ""),
+			want: model.PairLabelForward,
+		},
+		{
+			name: "after a reset before the earlier position", maxSpeed: 2,
+			before: record(1, 300000, 0, ""), prev: record(1, 0, 1, model.PairLabelReset), curr: record(1, 200000, 2, ""),
+			want: model.PairLabelForward,
+		},
+		{
+			name: "return after a jump beyond the speed cap", maxSpeed: 2,
+			before: record(1, 100000, 0, ""), prev: record(1, 590000, 1, model.PairLabelForward), curr: record(1, 160000, 2, ""),
+			want: model.PairLabelSuspicious, wantRelabelPrev: true,
+		},
+		{
+			name: "return after a jump already relabelled as suspicious", maxSpeed: 2,
+			before: record(1, 100000, 0, ""), prev: record(1, 590000, 1, model.PairLabelSuspicious), curr: record(1, 160000, 2, ""),
+			want: model.PairLabelSuspicious, wantRelabelPrev: true,
+		},
+		{
+			name: "seek back after a jump within the speed cap", maxSpeed: 2,
+			before: record(1, 100000, 0, ""), prev: record(1, 220000, 1, model.PairLabelForward), curr: record(1, 160000, 2, ""),
+			want: model.PairLabelSeekBack,
+		},
+		{
+			name: "jumps are not checked without a speed cap", maxSpeed: 0,
+			before: record(1, 100000, 0, ""), prev: record(1, 590000, 1, model.PairLabelForward), curr: record(1, 160000, 2, ""),
+			want: model.PairLabelSeekBack,
+		},
+		{
+			name: "earlier record in another part", maxSpeed: 2,
+			before: record(2, 100000, 0, ""), prev: record(1, 0, 1, model.PairLabelPartSwitch), curr: record(1, 0, 2, ""),
+			want: model.PairLabelForward,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			label, relabelPrev := NewPairClassifier(tt.maxSpeed).Classify(history, tt.before, tt.prev, tt.curr)
+			if label != tt.want || relabelPrev != tt.wantRelabelPrev {
+				t.Errorf("Classify = %q, %v, want %q, %v", label, relabelPrev, tt.want, tt.wantRelabelPrev)
+			}
+		})
+	}
+}
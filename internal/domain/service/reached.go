@@ -0,0 +1,62 @@
+package service
+
+import (
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ReachedPositions 每个分P (按 CID) 在此之前的记录中到达过的最远播放位置 (秒)，即高水位线。
+//
+// 向前播放时，位于高水位线之前的部分是回到看过的位置重新观看，计为重看；只有超过高水位线的部分计为首次观看。
+// 高水位线只由记录中的位置推进：跨分P的记录对中途经过的分P不会推进；被排除的可疑记录也不推进。
+// 已被清理的原始记录 (汇总水位线之前) 中的位置不再可见。
+type ReachedPositions map[int64]int64
+
+// Advance 用一条记录的位置推进其分P的高水位线。
+func (r ReachedPositions) Advance(p PlaybackPoint) {
+	if p.PositionSec > r[p.CID] {
+		r[p.CID] = p.PositionSec
+	}
+}
+
+// Split 按高水位线重新划分 b 的首次观看与重看：ByPage 中每个分P覆盖的位置区间 (与 CoverageBuilder 相同，
+// 终点分P为终点位置之前、其余分P为末尾的相应时长) 超过该分P高水位线的部分计为首次观看，其余计为重看。
+// pages 和 end 与计算 b 时使用的相同。总时长、Skipped 和 Playback 不变，ByPage 中各分P的 Rewatch 相应更新。
+func (r ReachedPositions) Split(b WatchTimeBreakdown, pages []model.VideoPage, end PlaybackPoint) WatchTimeBreakdown {
+	total := b.Total()
+	byPage := make(PageWatchTimes, 0, len(b.ByPage))
+	b.FirstTime = 0
+	for i, watched := range pageRanges(pages, b.ByPage, end) {
+		page := b.ByPage[i]
+		fresh := time.Duration(model.FreshSeconds(watched.Range.Length(), watched.Range.End, r[watched.CID])) * time.Second
+		page.Rewatch = page.Duration - fresh
+		b.FirstTime += fresh
+		byPage = append(byPage, page)
+	}
+	b.Rewatch = total - b.FirstTime
+	b.ByPage = byPage
+	return b
+}
+
+// pageRange 一段观看在某个分P中覆盖的位置区间。
+type pageRange struct {
+	CID   int64
+	Range PositionRange
+}
+
+// pageRanges 把一对记录的 ByPage 换算为各分P中覆盖的位置区间：观看一直持续到终点，
+// 终点分P (最后一项) 覆盖终点位置之前的相应时长，其余分P覆盖其末尾的相应时长。
+func pageRanges(pages []model.VideoPage, byPage PageWatchTimes, end PlaybackPoint) []pageRange {
+	ranges := make([]pageRange, 0, len(byPage))
+	for i, watched := range byPage {
+		position := end.PositionSec
+		if i < len(byPage)-1 || watched.CID != end.CID {
+			index, _ := findPageIndexByCid(pages, watched.CID)
+			position = pages[index].Duration
+		}
+		ranges = append(ranges, pageRange{CID: watched.CID,
+			Range: PositionRange{Start: position - int64(watched.Duration/time.Second), End: position}})
+	}
+	return ranges
+}
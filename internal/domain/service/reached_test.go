@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestReachedPositionsSplit(t *testing.T) {
+	pages := []model.VideoPage{{Cid: 1, Duration: 600}, {Cid: 2, Duration: 600}, {Cid: 3, Duration: 600}}
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	point := func(cid, position int64, minutes int) PlaybackPoint {
+		return PlaybackPoint{CID: cid, PositionSec: position, RecordedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	tests := []struct {
+		name        string
+		strategy    string
+		reached     ReachedPositions
+		from, to    PlaybackPoint
+		wantFirst   int64
+		wantRewatch int64
+		wantByPage  map[int64]int64 // 各分P的重看秒数
+	}{
+		{
+			name: "forward beyond reached", strategy: StrategyForward, reached: ReachedPositions{1: 100},
+			from: point(1, 100, 0), to: point(1, 400, 5), wantFirst: 300, wantByPage: map[int64]int64{1: 0},
+		},
+		{
+			name: "forward below reached", strategy: StrategyForward, reached: ReachedPositions{1: 500},
+			from: point(1, 100, 0), to: point(1, 400, 5), wantRewatch: 300, wantByPage: map[int64]int64{1: 300},
+		},
+		{
+			name: "forward across reached", strategy: StrategyForward, reached: ReachedPositions{1: 300},
+			from: point(1, 100, 0), to: point(1, 400, 5), wantFirst: 100, wantRewatch: 200, wantByPage: map[int64]int64{1: 200},
+		},
+		{
+			name: "across parts with reached start part only", strategy: StrategyForward, reached: ReachedPositions{1: 600, 2: 0},
+			from: point(1, 500, 0), to: point(2, 100, 5), wantFirst: 100, wantRewatch: 100, wantByPage: map[int64]int64{1: 100, 2: 0},
+		},
+		{
+			name: "capped pair keeps the part nearest the end", strategy: StrategyForward, reached: ReachedPositions{1: 550, 2: 50},
+			from: point(1, 0, 0), to: point(2, 100, 5), wantFirst: 100, wantRewatch: 500, wantByPage: map[int64]int64{1: 450, 2: 50},
+		},
+		{
+			name: "seek back into an unreached part", strategy: StrategyRewatch, reached: ReachedPositions{3: 300},
+			from: point(3, 300, 0), to: point(2, 200, 5), wantFirst: 300, wantByPage: map[int64]int64{1: 0, 2: 0},
+		},
+		{
+			name: "seek back within reached part", strategy: StrategyRewatch, reached: ReachedPositions{1: 500},
+			from: point(1, 500, 0), to: point(1, 200, 2), wantRewatch: 120, wantByPage: map[int64]int64{1: 120},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewWatchTimeStrategy(tt.strategy, NewWatchTimeCalculator(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			breakdown, err := strategy.CalculateBetween(pages, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total := breakdown.Total()
+			got := tt.reached.Split(breakdown, pages, tt.to)
+			if got.Total() != total {
+				t.Errorf("total = %s, want %s", got.Total(), total)
+			}
+			if got.FirstTime != time.Duration(tt.wantFirst)*time.Second || got.Rewatch != time.Duration(tt.wantRewatch)*time.Second {
+				t.Errorf("first time %s, rewatch %s, want %ds, %ds", got.FirstTime, got.Rewatch, tt.wantFirst, tt.wantRewatch)
+			}
+			for _, page := range got.ByPage {
+				if want, ok := tt.wantByPage[page.CID]; !ok || page.Rewatch != time.Duration(want)*time.Second {
+					t.Errorf("page %d rewatch = %s, want %ds", page.CID, page.Rewatch, want)
+				}
+			}
+		})
+	}
+}
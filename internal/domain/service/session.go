@@ -0,0 +1,97 @@
+package service
+
+import (
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// ViewingSession 一次连续的观看 (学习) 过程，由相邻的、有观看时长的记录对组成。
+// 开始和结束时间是第一对记录的起点和最后一对记录的终点，精度取决于轮询间隔。
+type ViewingSession struct {
+	StartedAt time.Time
+	EndedAt   time.Time
+	Start     PlaybackPoint // 会话开始时的播放位置
+	End       PlaybackPoint // 会话结束时的播放位置
+	Parts     []int64       // 经过的分P CID，按首次经过的顺序
+	WatchTimeBreakdown
+	Pairs int // 计入会话的记录对数量
+}
+
+// SessionBuilder 按时间顺序接收相邻的进度记录对，把间隔不超过 idleGap 的有效观看合并为会话。
+// 观看时长由观看时长策略计算，没有观看时长的记录对 (暂停、未播放) 不延长会话。
+// 首次观看与重看按 reached 划分，调用方在加入记录对的同时用每条计入的记录推进 reached。
+type SessionBuilder struct {
+	strategy WatchTimeStrategy
+	idleGap  time.Duration
+	reached  ReachedPositions
+	sessions []ViewingSession
+}
+
+// NewSessionBuilder 创建 SessionBuilder，reached 为第一对记录之前各分P到达过的最远位置。
+func NewSessionBuilder(strategy WatchTimeStrategy, idleGap time.Duration, reached ReachedPositions) *SessionBuilder {
+	return &SessionBuilder{strategy: strategy, idleGap: idleGap, reached: reached}
+}
+
+// AddPair 加入一对相邻的进度记录，pages 为终点记录时有效的分P列表。记录对必须按时间顺序加入。
+// 错误与 WatchTimeStrategy 相同，此时记录对不计入任何会话。
+func (b *SessionBuilder) AddPair(pages []model.VideoPage, start, end PlaybackPoint) error {
+	breakdown, err := b.strategy.CalculateBetween(pages, start, end)
+	if err != nil {
+		return err
+	}
+	if breakdown.Total() <= 0 {
+		return nil
+	}
+	breakdown = b.reached.Split(breakdown, pages, end)
+
+	var session *ViewingSession
+	if n := len(b.sessions); n > 0 && start.RecordedAt.Sub(b.sessions[n-1].EndedAt) <= b.idleGap {
+		session = &b.sessions[n-1]
+	} else {
+		b.sessions = append(b.sessions, ViewingSession{StartedAt: start.RecordedAt, Start: start})
+		session = &b.sessions[len(b.sessions)-1]
+	}
+	session.EndedAt = end.RecordedAt
+	session.End = end
+	session.WatchTimeBreakdown = session.WatchTimeBreakdown.Add(breakdown)
+	session.Pairs++
+	for _, cid := range partsBetween(pages, start.CID, end.CID) {
+		if !containsCID(session.Parts, cid) {
+			session.Parts = append(session.Parts, cid)
+		}
+	}
+	return nil
+}
+
+// Sessions 返回已识别的会话，按开始时间升序。
+func (b *SessionBuilder) Sessions() []ViewingSession {
+	return append([]ViewingSession{}, b.sessions...)
+}
+
+// partsBetween 返回从 startCID 到 endCID 播放经过的分P；后退时只包含两端的分P。
+func partsBetween(pages []model.VideoPage, startCID, endCID int64) []int64 {
+	startIndex, startFound := findPageIndexByCid(pages, startCID)
+	endIndex, endFound := findPageIndexByCid(pages, endCID)
+	if !startFound || !endFound || startIndex > endIndex {
+		if startCID == endCID {
+			return []int64{endCID}
+		}
+		return []int64{startCID, endCID}
+	}
+	cids := make([]int64, 0, endIndex-startIndex+1)
+	for i := startIndex; i <= endIndex; i++ {
+		cids = append(cids, pages[i].Cid)
+	}
+	return cids
+}
+
+// containsCID 判断 cids 中是否包含 cid。
+func containsCID(cids []int64, cid int64) bool {
+	for _, c := range cids {
+		if c == cid {
+			return true
+		}
+	}
+	return false
+}
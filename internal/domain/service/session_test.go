@@ -0,0 +1,76 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestSessionBuilder(t *testing.T) {
+	pages := []model.VideoPage{{Cid: 1, Duration: 600}, {Cid: 2, Duration: 600}, {Cid: 3, Duration: 600}}
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	point := func(cid, position int64, minutes int) PlaybackPoint {
+		return PlaybackPoint{CID: cid, PositionSec: position, RecordedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	type wantSession struct {
+		startMinute, endMinute int
+		parts                  []int64
+		watched                time.Duration
+		pairs                  int
+	}
+	tests := []struct {
+		name   string
+		points []PlaybackPoint
+		want   []wantSession
+	}{
+		{name: "single record", points: []PlaybackPoint{point(1, 0, 0)}, want: []wantSession{}},
+		{name: "single pair", points: []PlaybackPoint{point(1, 0, 0), point(1, 300, 5)},
+			want: []wantSession{{0, 5, []int64{1}, 300 * time.Second, 1}}},
+		{name: "paused only", points: []PlaybackPoint{point(1, 300, 0), point(1, 300, 5), point(1, 300, 10)}, want: []wantSession{}},
+		{
+			name:   "pause up to the idle gap keeps the session",
+			points: []PlaybackPoint{point(1, 0, 0), point(1, 300, 5), point(1, 300, 35), point(1, 600, 40)},
+			want:   []wantSession{{0, 40, []int64{1}, 600 * time.Second, 2}},
+		},
+		{
+			name:   "pause beyond the idle gap starts a new session",
+			points: []PlaybackPoint{point(1, 0, 0), point(1, 300, 5), point(1, 300, 36), point(1, 600, 41)},
+			want:   []wantSession{{0, 5, []int64{1}, 300 * time.Second, 1}, {36, 41, []int64{1}, 300 * time.Second, 1}},
+		},
+		{
+			name:   "part switch inside a session",
+			points: []PlaybackPoint{point(1, 500, 0), point(2, 100, 5), point(3, 50, 15), point(1, 60, 17)},
+			want:   []wantSession{{0, 17, []int64{1, 2, 3}, 200*time.Second + 550*time.Second + 60*time.Second, 3}},
+		},
+	}
+	strategy, err := NewWatchTimeStrategy(StrategyRewatch, NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewSessionBuilder(strategy, 30*time.Minute, make(ReachedPositions))
+			for i := 0; i+1 < len(tt.points); i++ {
+				if err := builder.AddPair(pages, tt.points[i], tt.points[i+1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sessions := builder.Sessions()
+			if len(sessions) != len(tt.want) {
+				t.Fatalf("got %d sessions, want %d: %+v", len(sessions), len(tt.want), sessions)
+			}
+			for i, s := range sessions {
+				w := tt.want[i]
+				if !s.StartedAt.Equal(start.Add(time.Duration(w.startMinute)*time.Minute)) ||
+					!s.EndedAt.Equal(start.Add(time.Duration(w.endMinute)*time.Minute)) {
+					t.Errorf("session %d = [%s, %s), want minutes [%d, %d)", i, s.StartedAt, s.EndedAt, w.startMinute, w.endMinute)
+				}
+				if !reflect.DeepEqual(s.Parts, w.parts) || s.Total() != w.watched || s.Pairs != w.pairs {
+					t.Errorf("session %d parts %v, watched %s, %d pairs, want %v, %s, %d", i, s.Parts, s.Total(), s.Pairs, w.parts, w.watched, w.pairs)
+				}
+			}
+		})
+	}
+}
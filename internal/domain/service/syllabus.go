@@ -0,0 +1,161 @@
+package service
+
+import "time"
+
+// syllabusSplitToleranceSec 分P在当天的份额之后剩余不超过该秒数时当天直接看完，份额只剩这么多时也不再开始新的分P，
+// 避免把几十秒的片段排到单独的一天。
+const syllabusSplitToleranceSec = 60
+
+// SyllabusPart 待排期的一个分P及其还没看过的位置区间。
+type SyllabusPart struct {
+	CID  int64
+	Gaps []PositionRange // 按位置升序排列、互不重叠
+}
+
+// SyllabusAssignment 一个学习日中要看的一个分P的一部分。
+type SyllabusAssignment struct {
+	Part     int             // 分P在排期输入中的下标
+	Ranges   []PositionRange // 要看的位置区间
+	Seconds  int64           // 区间长度之和
+	Finishes bool            // 看完这部分后该分P没有剩余内容
+}
+
+// SyllabusDay 一个学习日的任务。
+type SyllabusDay struct {
+	Date        time.Time // 学习日的日期
+	Assignments []SyllabusAssignment
+	Seconds     int64 // 当天要看的总时长
+}
+
+// PlanSyllabus 按顺序把 parts 中还没看过的内容排到从 first 开始的 days 个学习日中 (days 不大于 0 时全部排在 first 当天)。
+// 每天的份额为当天开始时的剩余时长平均分配到剩余的天数 (向上取整到秒)，分P按位置顺序切分，最后一天排完所有剩余内容。
+// 内容排完后不再返回后面的日子，没有剩余内容时返回空列表。
+func PlanSyllabus(parts []SyllabusPart, first time.Time, days int) []SyllabusDay {
+	var remaining int64
+	for _, part := range parts {
+		remaining += gapSeconds(part.Gaps)
+	}
+	days = max(days, 1)
+
+	result := []SyllabusDay{}
+	cursor := syllabusCursor{parts: parts}
+	for day := 0; remaining > 0; day++ {
+		quota := remaining
+		left := int64(days - day)
+		last := left <= 1
+		if !last {
+			quota = (remaining + left - 1) / left
+		}
+		planned := SyllabusDay{Date: first.AddDate(0, 0, day), Assignments: []SyllabusAssignment{}}
+		for quota > 0 && cursor.skipFinished() {
+			// 最后一天排完所有剩余内容，不受容差影响
+			if !last && len(planned.Assignments) > 0 && quota <= syllabusSplitToleranceSec {
+				break
+			}
+			assignment := cursor.take(quota)
+			planned.Assignments = append(planned.Assignments, assignment)
+			planned.Seconds += assignment.Seconds
+			quota -= assignment.Seconds
+			remaining -= assignment.Seconds
+		}
+		result = append(result, planned)
+	}
+	return result
+}
+
+// ScheduledThrough 返回 syllabus 中日期不晚于 date 的学习日要看的总时长。
+func ScheduledThrough(syllabus []SyllabusDay, date time.Time) int64 {
+	var total int64
+	for _, day := range syllabus {
+		if day.Date.After(date) {
+			break
+		}
+		total += day.Seconds
+	}
+	return total
+}
+
+// OverlapSeconds 返回 covered (按起点升序、互不重叠) 与 r 重叠的秒数。
+func OverlapSeconds(covered []PositionRange, r PositionRange) int64 {
+	var total int64
+	for _, c := range covered {
+		if c.Start >= r.End {
+			break
+		}
+		if start, end := max(c.Start, r.Start), min(c.End, r.End); end > start {
+			total += end - start
+		}
+	}
+	return total
+}
+
+// gapSeconds 返回区间长度之和。
+func gapSeconds(gaps []PositionRange) int64 {
+	var total int64
+	for _, g := range gaps {
+		total += g.Length()
+	}
+	return total
+}
+
+// syllabusCursor 排期的当前位置：第 part 个分P的第 gap 个区间中已排到 position。
+type syllabusCursor struct {
+	parts    []SyllabusPart
+	part     int
+	gap      int
+	position int64
+}
+
+// skipFinished 跳过没有剩余内容的分P，返回是否还有内容可排。
+func (c *syllabusCursor) skipFinished() bool {
+	for c.part < len(c.parts) && c.partLeft() == 0 {
+		c.nextPart()
+	}
+	return c.part < len(c.parts)
+}
+
+// partLeft 返回当前分P还没排的秒数。
+func (c *syllabusCursor) partLeft() int64 {
+	gaps := c.parts[c.part].Gaps
+	if c.gap >= len(gaps) {
+		return 0
+	}
+	first := gaps[c.gap]
+	return first.End - max(first.Start, c.position) + gapSeconds(gaps[c.gap+1:])
+}
+
+// take 从当前分P中排出最多 quota 秒 (剩余不超过 syllabusSplitToleranceSec 时排完该分P)。
+func (c *syllabusCursor) take(quota int64) SyllabusAssignment {
+	left := c.partLeft()
+	n := min(quota, left)
+	if left-n <= syllabusSplitToleranceSec {
+		n = left
+	}
+	assignment := SyllabusAssignment{Part: c.part}
+	gaps := c.parts[c.part].Gaps
+	for n > 0 {
+		g := gaps[c.gap]
+		start := max(g.Start, c.position)
+		length := min(g.End-start, n)
+		assignment.Ranges = append(assignment.Ranges, PositionRange{Start: start, End: start + length})
+		assignment.Seconds += length
+		n -= length
+		c.position = start + length
+		if c.position >= g.End {
+			c.gap++
+			c.position = 0
+		}
+	}
+	if c.gap >= len(gaps) {
+		assignment.Finishes = true
+		c.nextPart()
+	}
+	return assignment
+}
+
+// nextPart 移到下一个分P的开头。
+func (c *syllabusCursor) nextPart() {
+	c.part++
+	c.gap = 0
+	c.position = 0
+}
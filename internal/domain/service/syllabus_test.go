@@ -0,0 +1,143 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// whole 返回覆盖 [0, seconds) 的缺口列表。
+func whole(seconds int64) []PositionRange {
+	return []PositionRange{{Start: 0, End: seconds}}
+}
+
+func TestPlanSyllabus(t *testing.T) {
+	type planned struct {
+		part     int
+		ranges   []PositionRange
+		finishes bool
+	}
+	tests := []struct {
+		name  string
+		parts []SyllabusPart
+		days  int
+		want  [][]planned // 每天的任务
+	}{
+		{name: "no parts", days: 3, want: [][]planned{}},
+		{name: "nothing left to watch", parts: []SyllabusPart{{CID: 1}, {CID: 2, Gaps: []PositionRange{}}}, days: 3, want: [][]planned{}},
+		{
+			name: "even split", parts: []SyllabusPart{{CID: 1, Gaps: whole(1000)}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 500}}}},
+				{{part: 0, ranges: []PositionRange{{500, 1000}}, finishes: true}},
+			},
+		},
+		{
+			name: "part longer than the daily budget", parts: []SyllabusPart{{CID: 1, Gaps: whole(3000)}}, days: 3,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 1000}}}},
+				{{part: 0, ranges: []PositionRange{{1000, 2000}}}},
+				{{part: 0, ranges: []PositionRange{{2000, 3000}}, finishes: true}},
+			},
+		},
+		{
+			name: "split across gaps", parts: []SyllabusPart{{CID: 1, Gaps: []PositionRange{{0, 100}, {200, 1200}}}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 100}, {200, 650}}}},
+				{{part: 0, ranges: []PositionRange{{650, 1200}}, finishes: true}},
+			},
+		},
+		{
+			name: "remainder within 60s is finished the same day", parts: []SyllabusPart{{CID: 1, Gaps: whole(560)}, {CID: 2, Gaps: whole(440)}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 560}}, finishes: true}},
+				{{part: 1, ranges: []PositionRange{{0, 440}}, finishes: true}},
+			},
+		},
+		{
+			name: "remainder of 61s is left for the next day", parts: []SyllabusPart{{CID: 1, Gaps: whole(561)}, {CID: 2, Gaps: whole(439)}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 500}}}},
+				{{part: 0, ranges: []PositionRange{{500, 561}}, finishes: true}, {part: 1, ranges: []PositionRange{{0, 439}}, finishes: true}},
+			},
+		},
+		{
+			name: "no new part with 60s of budget left", parts: []SyllabusPart{{CID: 1, Gaps: whole(480)}, {CID: 2, Gaps: whole(600)}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 480}}, finishes: true}},
+				{{part: 1, ranges: []PositionRange{{0, 600}}, finishes: true}},
+			},
+		},
+		{
+			name: "new part with 61s of budget left", parts: []SyllabusPart{{CID: 1, Gaps: whole(479)}, {CID: 2, Gaps: whole(600)}}, days: 2,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 479}}, finishes: true}, {part: 1, ranges: []PositionRange{{0, 61}}}},
+				{{part: 1, ranges: []PositionRange{{61, 600}}, finishes: true}},
+			},
+		},
+		{
+			name: "no days puts everything on the first day", parts: []SyllabusPart{{CID: 1, Gaps: whole(300)}, {CID: 2, Gaps: whole(300)}}, days: 0,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 300}}, finishes: true}, {part: 1, ranges: []PositionRange{{0, 300}}, finishes: true}},
+			},
+		},
+		{
+			name: "last day takes a short part after a long one", parts: []SyllabusPart{{CID: 1, Gaps: whole(1000)}, {CID: 2, Gaps: whole(30)}}, days: 1,
+			want: [][]planned{
+				{{part: 0, ranges: []PositionRange{{0, 1000}}, finishes: true}, {part: 1, ranges: []PositionRange{{0, 30}}, finishes: true}},
+			},
+		},
+	}
+	first := day(2025, 3, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syllabus := PlanSyllabus(tt.parts, first, tt.days)
+			if syllabus == nil {
+				t.Fatal("PlanSyllabus returned nil, want an empty list")
+			}
+			if len(syllabus) != len(tt.want) {
+				t.Fatalf("planned %d days, want %d: %+v", len(syllabus), len(tt.want), syllabus)
+			}
+			for i, d := range syllabus {
+				if !d.Date.Equal(first.AddDate(0, 0, i)) {
+					t.Errorf("day %d date = %s", i, d.Date)
+				}
+				var got []planned
+				var seconds int64
+				for _, a := range d.Assignments {
+					got = append(got, planned{part: a.Part, ranges: a.Ranges, finishes: a.Finishes})
+					if a.Seconds != gapSeconds(a.Ranges) {
+						t.Errorf("day %d part %d seconds = %d, want %d", i, a.Part, a.Seconds, gapSeconds(a.Ranges))
+					}
+					seconds += a.Seconds
+				}
+				if d.Seconds != seconds {
+					t.Errorf("day %d seconds = %d, want %d", i, d.Seconds, seconds)
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("day %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSyllabusTerminates(t *testing.T) {
+	// 天数远多于内容时提前结束，不返回空的日子；所有内容都被排完
+	for _, days := range []int{-1, 0, 1, 7, 10, 1000} {
+		parts := []SyllabusPart{{CID: 1, Gaps: whole(100)}, {CID: 2, Gaps: []PositionRange{{0, 0}, {10, 30}}}, {CID: 3}}
+		syllabus := PlanSyllabus(parts, day(2025, 3, 1), days)
+		if len(syllabus) == 0 || len(syllabus) > max(days, 1) {
+			t.Errorf("days %d: planned %d days", days, len(syllabus))
+		}
+		var total int64
+		for _, d := range syllabus {
+			if d.Seconds == 0 {
+				t.Errorf("days %d: empty day %s", days, d.Date)
+			}
+			total += d.Seconds
+		}
+		if total != 120 {
+			t.Errorf("days %d: planned %d seconds, want 120", days, total)
+		}
+	}
+}
@@ -14,6 +14,60 @@ var (
 	ErrIdenticalPoints = errors.New("start and end points are identical")
 )
 
+// PageWatchTime 一段观看在某个分P中的时长。
+type PageWatchTime struct {
+	CID      int64
+	Duration time.Duration
+	Rewatch  time.Duration // 其中计为重看的时长，不超过 Duration
+}
+
+// PageWatchTimes 一段观看在各分P中的时长，按播放顺序排列，不包含时长为 0 的分P。
+type PageWatchTimes []PageWatchTime
+
+// Total 返回各分P时长之和。
+func (p PageWatchTimes) Total() time.Duration {
+	var total time.Duration
+	for _, page := range p {
+		total += page.Duration
+	}
+	return total
+}
+
+// Last 返回最后 d 时长对应的部分，即从末尾向前截取，离终点最近的观看被保留。
+func (p PageWatchTimes) Last(d time.Duration) PageWatchTimes {
+	var last PageWatchTimes
+	for i := len(p) - 1; i >= 0 && d > 0; i-- {
+		page := p[i]
+		if page.Duration > d {
+			page.Duration = d
+			page.Rewatch = min(page.Rewatch, d)
+		}
+		last = append(PageWatchTimes{page}, last...)
+		d -= page.Duration
+	}
+	return last
+}
+
+// Add 返回与 other 按分P合并后的结果，分P按首次出现的顺序排列。
+func (p PageWatchTimes) Add(other PageWatchTimes) PageWatchTimes {
+	merged := append(PageWatchTimes{}, p...)
+	for _, page := range other {
+		found := false
+		for i := range merged {
+			if merged[i].CID == page.CID {
+				merged[i].Duration += page.Duration
+				merged[i].Rewatch += page.Rewatch
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, page)
+		}
+	}
+	return merged
+}
+
 // WatchTimeCalculator 定义了计算视频观看时长的领域服务接口。
 type WatchTimeCalculator interface {
 	// CalculateWatchTime 计算从 startCid 的 startTimeInStartCidSec 到 endCid 的 endTimeInEndCidSec 之间的总观看时长。
@@ -22,7 +76,7 @@ type WatchTimeCalculator interface {
 	// startTimeInStartCidSec: 在 startCid 中开始观看的时间点（秒）。
 	// endCid: 结束观看的分P ID。
 	// endTimeInEndCidSec: 在 endCid 中结束观看的时间点（秒）。
-	// 返回计算出的总观看时长 (time.Duration) 和可能的错误。
+	// 返回每个分P贡献的观看时长 (总时长为 PageWatchTimes.Total()) 和可能的错误。
 	// 可能的错误包括：找不到页面、时间无效、开始点晚于结束点。
 	CalculateWatchTime(
 		pages []model.VideoPage,
@@ -30,5 +84,5 @@ type WatchTimeCalculator interface {
 		startTimeInStartCidSec int64,
 		endCid int64,
 		endTimeInEndCidSec int64,
-	) (time.Duration, error)
+	) (PageWatchTimes, error)
 }
@@ -1,7 +1,6 @@
 package service
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
@@ -25,63 +24,85 @@ func findPageIndexByCid(pages []model.VideoPage, cid int64) (int, bool) {
 	return -1, false
 }
 
-// CalculateWatchTime 计算观看时长。
+// CalculateWatchTime 计算观看时长，按分P返回。
 func (s *watchTimeCalculatorService) CalculateWatchTime(
 	pages []model.VideoPage,
 	startCid int64,
 	startTimeInStartCidSec int64,
 	endCid int64,
 	endTimeInEndCidSec int64,
-) (time.Duration, error) {
+) (PageWatchTimes, error) {
 
 	startIndex, startFound := findPageIndexByCid(pages, startCid)
 	endIndex, endFound := findPageIndexByCid(pages, endCid)
 
 	if !startFound || !endFound {
-		return 0, ErrPageNotFound
+		return nil, ErrPageNotFound
 	}
 
 	// 检查时间有效性
 	if startTimeInStartCidSec < 0 || startTimeInStartCidSec > pages[startIndex].Duration ||
 		endTimeInEndCidSec < 0 || endTimeInEndCidSec > pages[endIndex].Duration {
-		return 0, ErrInvalidTime
+		return nil, ErrInvalidTime
 	}
 
 	// 检查开始点是否在结束点之前或相同
 	if startIndex > endIndex || (startIndex == endIndex && startTimeInStartCidSec > endTimeInEndCidSec) {
-		return 0, ErrStartAfterEnd
+		return nil, ErrStartAfterEnd
 	}
 
 	// 检查开始点和结束点是否完全相同
 	if startIndex == endIndex && startTimeInStartCidSec == endTimeInEndCidSec {
-		// return 0, ErrIdenticalPoints // 返回0时长更符合逻辑
-		return 0, nil
+		// return nil, ErrIdenticalPoints // 返回0时长更符合逻辑
+		return nil, nil
 	}
 
-	var totalDurationSeconds int64 = 0
+	var watched PageWatchTimes
+	add := func(cid int64, seconds int64) {
+		if seconds > 0 {
+			watched = append(watched, PageWatchTime{CID: cid, Duration: time.Duration(seconds) * time.Second})
+		}
+	}
 
 	if startIndex == endIndex {
 		// 开始和结束在同一个分P
-		totalDurationSeconds = endTimeInEndCidSec - startTimeInStartCidSec
+		add(startCid, endTimeInEndCidSec-startTimeInStartCidSec)
 	} else {
 		// 开始和结束在不同的分P
 
 		// 1. 计算在开始分P观看的时间
-		totalDurationSeconds += pages[startIndex].Duration - startTimeInStartCidSec
+		add(startCid, pages[startIndex].Duration-startTimeInStartCidSec)
 
 		// 2. 计算中间完整观看的分P时间
 		for i := startIndex + 1; i < endIndex; i++ {
-			totalDurationSeconds += pages[i].Duration
+			add(pages[i].Cid, pages[i].Duration)
 		}
 
 		// 3. 计算在结束分P观看的时间
-		totalDurationSeconds += endTimeInEndCidSec
+		add(endCid, endTimeInEndCidSec)
 	}
 
-	if totalDurationSeconds < 0 {
-		// 理论上不应发生，但作为健壮性检查
-		return 0, fmt.Errorf("internal calculation error: negative duration calculated")
-	}
+	return watched, nil
+}
 
-	return time.Duration(totalDurationSeconds) * time.Second, nil
+// watchedBefore 返回一直播放到 pages[endIndex] 的 endPosition 为止、共 seconds 秒的观看在各分P中的分布：
+// 从终点沿播放顺序向前回溯，最多回溯到第一个分P的开头。各分P的时长都计为重看。
+func watchedBefore(pages []model.VideoPage, endIndex int, endPosition int64, seconds int64) PageWatchTimes {
+	var watched PageWatchTimes
+	position := endPosition
+	for i := endIndex; i >= 0 && seconds > 0; i-- {
+		if i < endIndex {
+			position = pages[i].Duration
+		}
+		taken := position
+		if taken > seconds {
+			taken = seconds
+		}
+		if taken > 0 {
+			duration := time.Duration(taken) * time.Second
+			watched = append(PageWatchTimes{{CID: pages[i].Cid, Duration: duration, Rewatch: duration}}, watched...)
+		}
+		seconds -= taken
+	}
+	return watched
 }
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// 观看时长策略名称，对应配置 WATCH_TIME_STRATEGY。
+const (
+	StrategyForward = "forward" // 只计算向前的播放，后退的记录对不计入 (默认)
+	StrategyRewatch = "rewatch" // 后退视为一次新的观看，计入重看时长
+)
+
+// PlaybackPoint 一条进度记录对应的播放位置。
+type PlaybackPoint struct {
+	CID         int64     // 分P ID
+	PositionSec int64     // 在该分P中的播放位置（秒）
+	RecordedAt  time.Time // 记录时间
+}
+
+// standardPlaybackSpeeds Bilibili 播放器提供的不低于 1 倍的播放倍速，推断的倍速取其中不超过最大倍速且最接近的一档。
+var standardPlaybackSpeeds = []float64{1, 1.25, 1.5, 2, 3}
+
+// WatchTimeBreakdown 两条相邻进度记录之间的观看时长，区分首次观看和重看。
+type WatchTimeBreakdown struct {
+	FirstTime  time.Duration  // 首次观看的时长 (经 ReachedPositions.Split 划分后为超过各分P高水位线的部分)
+	Rewatch    time.Duration  // 重看的时长 (策略计算时为后退后重新观看的时长，Split 之后为高水位线之前的部分)
+	Skipped    time.Duration  // 超出 经过时间 × 最大倍速 的进度差，视为跳过，不计入观看时长
+	Continuous time.Duration  // 连续播放 (计入的时长不少于经过时间) 时计入的时长，中途有暂停或停止时为 0
+	Playback   time.Duration  // 连续播放时的经过时间，与 Continuous 一起推断倍速
+	ByPage     PageWatchTimes // 计入的观看时长 (首次观看与重看) 在各分P中的分布
+}
+
+// Add 返回与 other 相加的结果，ByPage 按分P合并。
+func (b WatchTimeBreakdown) Add(other WatchTimeBreakdown) WatchTimeBreakdown {
+	return WatchTimeBreakdown{
+		FirstTime:  b.FirstTime + other.FirstTime,
+		Rewatch:    b.Rewatch + other.Rewatch,
+		Skipped:    b.Skipped + other.Skipped,
+		Continuous: b.Continuous + other.Continuous,
+		Playback:   b.Playback + other.Playback,
+		ByPage:     b.ByPage.Add(other.ByPage),
+	}
+}
+
+// Total 返回首次观看与重看的总时长。
+func (b WatchTimeBreakdown) Total() time.Duration {
+	return b.FirstTime + b.Rewatch
+}
+
+// IsSkip 判断这对记录之间是否发生了超出倍速上限的跳转。
+func (b WatchTimeBreakdown) IsSkip() bool {
+	return b.Skipped > 0
+}
+
+// Speed 返回推断的播放倍速，maxSpeed 为计算时使用的最大倍速；没有连续播放的记录对时返回 0 (未知)。
+func (b WatchTimeBreakdown) Speed(maxSpeed float64) float64 {
+	return InferPlaybackSpeed(b.Continuous, b.Playback, maxSpeed)
+}
+
+// InferPlaybackSpeed 根据连续播放的内容时长和播放用去的时间推断播放倍速，
+// 取不超过 maxSpeed (0 表示不限制) 的标准倍速 (1x, 1.25x, 1.5x, 2x, 3x) 中最接近的一档。
+// 两者都只应包含连续播放的记录对 (见 model.LimitPairSeconds)；playback 为 0 (没有连续播放的记录对) 时无法推断，返回 0 表示未知。
+func InferPlaybackSpeed(continuous, playback time.Duration, maxSpeed float64) float64 {
+	if playback <= 0 {
+		return 0
+	}
+	ratio := float64(continuous) / float64(playback)
+	best := standardPlaybackSpeeds[0]
+	for _, speed := range standardPlaybackSpeeds[1:] {
+		if maxSpeed > 0 && speed > maxSpeed {
+			break
+		}
+		if math.Abs(ratio-speed) < math.Abs(ratio-best) {
+			best = speed
+		}
+	}
+	return best
+}
+
+// WatchTimeStrategy 定义计算两条相邻进度记录之间观看时长的策略。
+type WatchTimeStrategy interface {
+	// CalculateBetween 计算从 start 到 end 之间的观看时长，pages 为按播放顺序排列的分P列表。
+	// 错误与 WatchTimeCalculator 相同。
+	// 计入的时长不超过两条记录之间的经过时间乘以 MaxSpeed，超出部分记为 Skipped。
+	CalculateBetween(pages []model.VideoPage, start, end PlaybackPoint) (WatchTimeBreakdown, error)
+	// MaxSpeed 返回限制观看时长使用的最大播放倍速，0 表示不限制。
+	MaxSpeed() float64
+}
+
+// NewWatchTimeStrategy 根据名称创建观看时长策略，名称为空时使用 StrategyForward。
+// maxSpeed 为最大播放倍速，必须为 0 (不限制) 或不小于 1。
+func NewWatchTimeStrategy(name string, calculator WatchTimeCalculator, maxSpeed float64) (WatchTimeStrategy, error) {
+	if maxSpeed != 0 && maxSpeed < 1 {
+		return nil, fmt.Errorf("max playback speed must be 0 or at least 1, got %g", maxSpeed)
+	}
+	limit := speedLimit{maxSpeed: maxSpeed}
+	switch name {
+	case "", StrategyForward:
+		return &forwardStrategy{speedLimit: limit, calculator: calculator}, nil
+	case StrategyRewatch:
+		return &rewatchStrategy{speedLimit: limit, calculator: calculator}, nil
+	default:
+		return nil, fmt.Errorf("unknown watch time strategy %q (expected %s or %s)", name, StrategyForward, StrategyRewatch)
+	}
+}
+
+// speedLimit 按最大播放倍速限制一对记录的观看时长，各策略共用。
+type speedLimit struct {
+	maxSpeed float64
+}
+
+// MaxSpeed 返回最大播放倍速。
+func (l speedLimit) MaxSpeed() float64 {
+	return l.maxSpeed
+}
+
+// apply 用 model.LimitPairSeconds 限制首次观看或重看时长 (同一对记录只会有其中一种)，并填充 Skipped、Continuous 和 Playback。
+// 超出上限时 ByPage 只保留离终点最近的部分。
+func (l speedLimit) apply(b WatchTimeBreakdown, start, end PlaybackPoint) WatchTimeBreakdown {
+	elapsed := end.RecordedAt.Sub(start.RecordedAt)
+	watched := &b.FirstTime
+	if b.Rewatch > 0 {
+		watched = &b.Rewatch
+	}
+	counted, skipped, playback := model.LimitPairSeconds(int64(*watched/time.Second), elapsed, l.maxSpeed)
+	*watched = time.Duration(counted) * time.Second
+	b.Skipped = time.Duration(skipped) * time.Second
+	b.Playback = time.Duration(playback) * time.Second
+	if playback > 0 {
+		b.Continuous = *watched
+	}
+	b.ByPage = b.ByPage.Last(*watched)
+	return b
+}
+
+// forwardStrategy 直接使用 WatchTimeCalculator，后退时返回 ErrStartAfterEnd。
+type forwardStrategy struct {
+	speedLimit
+	calculator WatchTimeCalculator
+}
+
+// CalculateBetween 计算向前播放的时长。
+func (s *forwardStrategy) CalculateBetween(pages []model.VideoPage, start, end PlaybackPoint) (WatchTimeBreakdown, error) {
+	watched, err := s.calculator.CalculateWatchTime(pages, start.CID, start.PositionSec, end.CID, end.PositionSec)
+	if err != nil {
+		return WatchTimeBreakdown{}, err
+	}
+	return s.apply(WatchTimeBreakdown{FirstTime: watched.Total(), ByPage: watched}, start, end), nil
+}
+
+// rewatchStrategy 在向前播放时与 forwardStrategy 相同；后退 (回看或从头重播) 时，
+// 认为用户跳回了 end 之前的某个位置并一直看到 end：重看时长不超过两条记录之间的实际经过时间，
+// 也不超过 end 距离整个视频开头的播放时长，ByPage 为从 end 向前回溯经过的分P。
+// 两种策略都只看一对记录本身；调用方再用 ReachedPositions.Split 按各分P之前到达过的最远位置划分首次观看与重看，
+// 因此后退之后向前播放、回到之前看过的位置时同样计为重看。
+type rewatchStrategy struct {
+	speedLimit
+	calculator WatchTimeCalculator
+}
+
+// CalculateBetween 计算观看时长，后退时计为重看。
+func (s *rewatchStrategy) CalculateBetween(pages []model.VideoPage, start, end PlaybackPoint) (WatchTimeBreakdown, error) {
+	watched, err := s.calculator.CalculateWatchTime(pages, start.CID, start.PositionSec, end.CID, end.PositionSec)
+	if err == nil {
+		return s.apply(WatchTimeBreakdown{FirstTime: watched.Total(), ByPage: watched}, start, end), nil
+	}
+	if !errors.Is(err, ErrStartAfterEnd) {
+		return WatchTimeBreakdown{}, err
+	}
+
+	endIndex, _ := findPageIndexByCid(pages, end.CID) // 分P存在且时间有效已由 CalculateWatchTime 校验
+	reachable := end.PositionSec
+	for i := 0; i < endIndex; i++ {
+		reachable += pages[i].Duration
+	}
+	elapsed := end.RecordedAt.Sub(start.RecordedAt).Truncate(time.Second)
+	if elapsed <= 0 {
+		return WatchTimeBreakdown{}, nil
+	}
+	rewatch := time.Duration(reachable) * time.Second
+	if elapsed < rewatch {
+		rewatch = elapsed
+	}
+	byPage := watchedBefore(pages, endIndex, end.PositionSec, int64(rewatch/time.Second))
+	breakdown := s.apply(WatchTimeBreakdown{Rewatch: rewatch, ByPage: byPage}, start, end)
+	// 重看时长本身由经过时间推算而来，不能用来推断倍速
+	breakdown.Continuous, breakdown.Playback = 0, 0
+	return breakdown, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestInferPlaybackSpeed(t *testing.T) {
+	tests := []struct {
+		name                 string
+		continuous, playback time.Duration
+		maxSpeed             float64
+		want                 float64
+	}{
+		{name: "no continuous pairs", continuous: 300 * time.Second, maxSpeed: 2, want: 0},
+		{name: "normal speed", continuous: 300 * time.Second, playback: 300 * time.Second, maxSpeed: 2, want: 1},
+		{name: "nearest standard speed", continuous: 440 * time.Second, playback: 300 * time.Second, maxSpeed: 2, want: 1.5},
+		{name: "3x is beyond the cap", continuous: 3 * time.Second, playback: time.Second, maxSpeed: 2, want: 2},
+		{name: "3x without a cap", continuous: 3 * time.Second, playback: time.Second, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferPlaybackSpeed(tt.continuous, tt.playback, tt.maxSpeed); got != tt.want {
+				t.Errorf("InferPlaybackSpeed(%s, %s, %g) = %g, want %g", tt.continuous, tt.playback, tt.maxSpeed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchTimeBreakdownSpeed(t *testing.T) {
+	pages := []model.VideoPage{{Cid: 1, Duration: 3600}}
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	point := func(position int64, elapsed time.Duration) PlaybackPoint {
+		return PlaybackPoint{CID: 1, PositionSec: position, RecordedAt: start.Add(elapsed)}
+	}
+	tests := []struct {
+		name     string
+		from, to PlaybackPoint
+		want     float64
+	}{
+		{name: "continuous at 2x", from: point(0, 0), to: point(600, 5*time.Minute), want: 2},
+		{name: "paused in between", from: point(0, 0), to: point(120, 5*time.Minute), want: 0},
+		{name: "skip capped at max speed", from: point(0, 0), to: point(3000, 5*time.Minute), want: 2},
+		{name: "rounded elapsed time stays within the cap", from: point(0, 0), to: point(3, 1900*time.Millisecond), want: 2},
+		{name: "seek back", from: point(600, 0), to: point(300, 2*time.Minute), want: 0},
+	}
+	strategy, err := NewWatchTimeStrategy(StrategyRewatch, NewWatchTimeCalculator(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := strategy.CalculateBetween(pages, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := breakdown.Speed(strategy.MaxSpeed()); got != tt.want {
+				t.Errorf("speed = %g, want %g (breakdown %+v)", got, tt.want, breakdown)
+			}
+		})
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 )
 
 // Base URLs - Can be extended if needed
@@ -21,6 +25,7 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    *url.URL
 	sessData   string
+	archiver   application.RawResponseArchiver // 可选，保存原始响应
 }
 
 // NewClient 创建一个新的 Bilibili API 客户端实例。
@@ -34,11 +39,40 @@ func NewClient(sessData string) *Client {
 	}
 }
 
+// SetArchiver 设置原始响应归档器，设置后每次成功的进度和视频信息请求都会归档响应体。
+func (c *Client) SetArchiver(archiver application.RawResponseArchiver) {
+	c.archiver = archiver
+}
+
+// archive 归档一次请求的原始响应，未设置归档器时不做任何操作。
+func (c *Client) archive(ctx context.Context, kind model.RawResponseKind, aid int64, bvid string, cid int64, fetchedAt time.Time, body []byte) {
+	if c.archiver == nil {
+		return
+	}
+	c.archiver.ArchiveRawResponse(ctx, &model.RawResponse{
+		Kind:      kind,
+		AID:       aid,
+		BVID:      bvid,
+		CID:       cid,
+		FetchedAt: fetchedAt,
+		Body:      body,
+	})
+}
+
 // Get 发送一个 GET 请求到指定的 API 路径，并将 JSON 响应解码到 target 中。
 // path: 相对于 baseURL 的 API 路径 (例如 "/x/web-interface/view")。
 // params: URL 查询参数。
 // target: 用于解码 JSON 响应体的目标结构体指针。
 func (c *Client) Get(ctx context.Context, path string, params url.Values, target interface{}) error {
+	body, err := c.getRaw(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(path, body, target)
+}
+
+// getRaw 发送一个 GET 请求到指定的 API 路径，返回 HTTP 200 响应的原始响应体。
+func (c *Client) getRaw(ctx context.Context, path string, params url.Values) ([]byte, error) {
 	// 构建完整的请求 URL
 	requestURL := c.baseURL.ResolveReference(&url.URL{Path: path})
 	if params != nil {
@@ -48,7 +82,7 @@ func (c *Client) Get(ctx context.Context, path string, params url.Values, target
 	// 创建 HTTP GET 请求
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to create GET request for %s: %w", path, err)
+		return nil, fmt.Errorf("failed to create GET request for %s: %w", path, err)
 	}
 
 	// 设置通用请求头
@@ -60,14 +94,14 @@ func (c *Client) Get(ctx context.Context, path string, params url.Values, target
 	// 发送请求
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send GET request to %s: %w", path, err)
+		return nil, fmt.Errorf("failed to send GET request to %s: %w", path, err)
 	}
 	defer resp.Body.Close()
 
 	// 读取响应体
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body from %s: %w", path, err)
+		return nil, fmt.Errorf("failed to read response body from %s: %w", path, err)
 	}
 
 	// 检查 HTTP 状态码
@@ -79,13 +113,18 @@ func (c *Client) Get(ctx context.Context, path string, params url.Values, target
 		}
 		jsonErr := json.Unmarshal(body, &baseResp)
 		if jsonErr == nil && baseResp.Message != "" {
-			return fmt.Errorf("unexpected status code %d from %s. Bilibili error: code=%d, message=%s",
+			return nil, fmt.Errorf("unexpected status code %d from %s. Bilibili error: code=%d, message=%s",
 				resp.StatusCode, path, baseResp.Code, baseResp.Message)
 		}
 		// 如果无法解析为 Bilibili 错误，返回通用 HTTP 错误
-		return fmt.Errorf("unexpected status code %d from %s, body: %s", resp.StatusCode, path, string(body))
+		return nil, fmt.Errorf("unexpected status code %d from %s, body: %s", resp.StatusCode, path, string(body))
 	}
 
+	return body, nil
+}
+
+// decodeResponse 将 JSON 响应体解码到 target 中，target 为 nil 时不做任何操作。
+func decodeResponse(path string, body []byte, target interface{}) error {
 	// 解析 JSON 响应到目标结构体
 	if target != nil {
 		if err := json.Unmarshal(body, target); err != nil {
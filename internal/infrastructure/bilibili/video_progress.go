@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 )
 
 // VideoProgressData 定义 Bilibili 视频进度 API 响应中 data 字段的结构体。
@@ -153,6 +155,9 @@ type ElecHighLevel struct {
 
 // -----------------------------------------
 
+// videoProgressPath 是播放进度接口的路径。
+const videoProgressPath = "/x/player/wbi/v2"
+
 // VideoProgressResponse 定义 Bilibili 视频进度 API 的响应结构体。
 type VideoProgressResponse struct {
 	Code    int               `json:"code"`
@@ -166,7 +171,7 @@ type VideoProgressResponse struct {
 // aidStr (视频稿件 avid) 和 bvidStr (视频稿件 bvid) 必须提供一个。
 // cidStr (视频分P的 ID) 必须提供。
 func (c *Client) GetVideoProgress(ctx context.Context, aidStr, bvidStr, cidStr string) (*application.VideoProgressDTO, error) {
-	const path = "/x/player/wbi/v2"
+	const path = videoProgressPath
 
 	var finalAidStr string
 
@@ -196,13 +201,34 @@ func (c *Client) GetVideoProgress(ctx context.Context, aidStr, bvidStr, cidStr s
 	params.Set("aid", finalAidStr)
 	params.Set("cid", cidStr)
 
-	var resp VideoProgressResponse
-	err := c.Get(ctx, path, params, &resp) // Pass ctx to c.Get
+	body, err := c.getRaw(ctx, path, params)
 	if err != nil {
-		if apiErrResp, ok := err.(interface{ GetResponse() *VideoProgressResponse }); ok && apiErrResp.GetResponse() != nil {
-			return nil, fmt.Errorf("bilibili api error: code=%d, message=%s, underlying error: %w",
-				apiErrResp.GetResponse().Code, apiErrResp.GetResponse().Message, err)
-		}
+		return nil, err
+	}
+	fetchedAt := time.Now()
+	aid, _ := strconv.ParseInt(finalAidStr, 10, 64)
+	cid, _ := strconv.ParseInt(cidStr, 10, 64)
+	c.archive(ctx, model.RawResponseKindProgress, aid, bvidStr, cid, fetchedAt, body)
+
+	dto, err := parseVideoProgressResponse(body)
+	if dto != nil {
+		dto.FetchedAt = fetchedAt
+	}
+	return dto, err
+}
+
+// ResponseParser 实现 application.RawProgressParser，用与 GetVideoProgress 相同的规则解析归档的原始响应。
+type ResponseParser struct{}
+
+// ParseVideoProgress 解析进度接口的响应体，没有有效进度时返回 nil, nil。
+func (ResponseParser) ParseVideoProgress(body []byte) (*application.VideoProgressDTO, error) {
+	return parseVideoProgressResponse(body)
+}
+
+// parseVideoProgressResponse 将进度接口的响应体解析为应用层 DTO，FetchedAt 由调用方设置。
+func parseVideoProgressResponse(body []byte) (*application.VideoProgressDTO, error) {
+	var resp VideoProgressResponse
+	if err := decodeResponse(videoProgressPath, body, &resp); err != nil {
 		return nil, err
 	}
 
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 )
 
 // --- 响应结构体定义 ---
@@ -185,17 +187,16 @@ func (c *Client) GetVideoView(ctx context.Context, aid, bvid string) (*applicati
 		params.Set("bvid", bvid)
 	}
 
-	var resp VideoViewResponse
-	err := c.Get(ctx, path, params, &resp)
+	body, err := c.getRaw(ctx, path, params)
 	if err != nil {
-		// 底层 Get 方法已处理 HTTP 和解码错误
-		// 尝试提取 Bilibili API 的特定错误信息
-		if apiErrResp, ok := err.(interface{ GetResponse() *VideoViewResponse }); ok && apiErrResp.GetResponse() != nil {
-			return nil, fmt.Errorf("bilibili api error: code=%d, message=%s, underlying error: %w",
-				apiErrResp.GetResponse().Code, apiErrResp.GetResponse().Message, err)
-		}
 		return nil, err
 	}
+	fetchedAt := time.Now()
+	var resp VideoViewResponse
+	if err := decodeResponse(path, body, &resp); err != nil {
+		return nil, err
+	}
+	c.archive(ctx, model.RawResponseKindView, resp.Data.Aid, resp.Data.Bvid, 0, fetchedAt, body)
 
 	// 检查 Bilibili API 返回的业务状态码
 	if resp.Code != 0 {
@@ -229,6 +230,9 @@ func (c *Client) GetVideoView(ctx context.Context, aid, bvid string) (*applicati
 		Pubdate:   resp.Data.Pubdate,
 		Duration:  resp.Data.Duration,
 		OwnerName: resp.Data.Owner.Name,
+		OwnerMid:  resp.Data.Owner.Mid,
+		Tid:       resp.Data.Tid,
+		Tname:     resp.Data.Tname,
 		Pages:     pagesDTO, // 填充 Pages DTO
 	}
 
@@ -0,0 +1,161 @@
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// Client 是 application.BilibiliClient 的模拟实现。
+// 它不访问网络，而是根据确定性的观看日程生成视频信息和观看进度。
+type Client struct {
+	origin time.Time        // 模拟观看行为的起始时间
+	now    func() time.Time // 当前时间，便于生成历史数据
+
+	archiver application.RawResponseArchiver // 可选，归档模拟的原始响应
+}
+
+// NewClient 创建一个新的模拟 Bilibili 客户端。
+// origin: 模拟观看开始的时间，早于该时间的进度均为空。
+func NewClient(origin time.Time) *Client {
+	return &Client{
+		origin: origin,
+		now:    time.Now,
+	}
+}
+
+// SetArchiver 设置原始响应归档器。模拟响应使用与真实 API 相同的 JSON 结构，
+// 因此可以用 bilibili.ResponseParser 从归档重新生成进度记录。
+func (c *Client) SetArchiver(archiver application.RawResponseArchiver) {
+	c.archiver = archiver
+}
+
+// archive 以 Bilibili API 的响应格式归档模拟数据，未设置归档器时不做任何操作。
+func (c *Client) archive(ctx context.Context, kind model.RawResponseKind, v *demoVideo, cid int64, fetchedAt time.Time, data any) {
+	if c.archiver == nil {
+		return
+	}
+	body, err := json.Marshal(map[string]any{"code": 0, "message": "0", "ttl": 1, "data": data})
+	if err != nil {
+		log.Printf("Demo: failed to encode raw %s response: %v", kind, err)
+		return
+	}
+	c.archiver.ArchiveRawResponse(ctx, &model.RawResponse{
+		Kind:      kind,
+		AID:       v.view.Aid,
+		BVID:      v.view.Bvid,
+		CID:       cid,
+		FetchedAt: fetchedAt,
+		Body:      body,
+	})
+}
+
+// findVideo 根据 aid 或 bvid 查找模拟视频。
+func findVideo(aid, bvid string) (*demoVideo, error) {
+	for i := range demoVideos {
+		v := &demoVideos[i]
+		if (aid != "" && strconv.FormatInt(v.view.Aid, 10) == aid) || (bvid != "" && v.view.Bvid == bvid) {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("demo video not found (aid: %q, bvid: %q)", aid, bvid)
+}
+
+// GetVideoView 返回模拟视频的详细信息。
+func (c *Client) GetVideoView(ctx context.Context, aid, bvid string) (*application.VideoViewDTO, error) {
+	if aid == "" && bvid == "" {
+		return nil, fmt.Errorf("either aid or bvid must be provided for GetVideoView")
+	}
+	v, err := findVideo(aid, bvid)
+	if err != nil {
+		return nil, err
+	}
+	view := v.view
+	view.Pages = append([]application.VideoViewPageDTO(nil), v.view.Pages...)
+	c.archive(ctx, model.RawResponseKindView, v, 0, c.now(), map[string]any{
+		"aid":      view.Aid,
+		"bvid":     view.Bvid,
+		"title":    view.Title,
+		"desc":     view.Desc,
+		"pubdate":  view.Pubdate,
+		"duration": view.Duration,
+		"tid":      view.Tid,
+		"tname":    view.Tname,
+		"owner":    map[string]any{"mid": view.OwnerMid, "name": view.OwnerName},
+		"pages":    view.Pages,
+	})
+	return &view, nil
+}
+
+// GetVideoProgress 返回模拟视频在当前时间的观看进度。
+// 与真实 API 一致，尚无观看记录时返回 nil, nil。
+func (c *Client) GetVideoProgress(ctx context.Context, aid, bvid, cid string) (*application.VideoProgressDTO, error) {
+	if aid == "" && bvid == "" {
+		return nil, fmt.Errorf("GetVideoProgress requires either aid or bvid")
+	}
+	if cid == "" {
+		return nil, fmt.Errorf("GetVideoProgress requires cid")
+	}
+	v, err := findVideo(aid, bvid)
+	if err != nil {
+		return nil, err
+	}
+	fetchedAt := c.now()
+	lastPlayCid, lastPlayTime, ok := v.positionAt(c.origin, fetchedAt)
+	requestedCid, _ := strconv.ParseInt(cid, 10, 64)
+	c.archive(ctx, model.RawResponseKindProgress, v, requestedCid, fetchedAt, map[string]any{
+		"aid":            v.view.Aid,
+		"bvid":           v.view.Bvid,
+		"cid":            requestedCid,
+		"last_play_cid":  lastPlayCid,
+		"last_play_time": lastPlayTime,
+		"now_time":       fetchedAt.Unix(),
+	})
+	if !ok {
+		return nil, nil
+	}
+	return &application.VideoProgressDTO{
+		AID:          v.view.Aid,
+		BVID:         v.view.Bvid,
+		LastPlayTime: lastPlayTime,
+		LastPlayCid:  lastPlayCid,
+		FetchedAt:    fetchedAt,
+	}, nil
+}
+
+// Seed 按 step 间隔为所有模拟视频生成 [from, to) 范围内的历史进度记录并写入仓库，
+// 模拟调度器在过去一段时间内的轮询结果。返回写入的记录数。
+func (c *Client) Seed(ctx context.Context, repo repository.VideoProgressRepository, from, to time.Time, step time.Duration) (int, error) {
+	if step <= 0 {
+		return 0, fmt.Errorf("seed step must be positive")
+	}
+	saved := 0
+	for i := range demoVideos {
+		v := &demoVideos[i]
+		for t := from; t.Before(to); t = t.Add(step) {
+			lastPlayCid, lastPlayTime, ok := v.positionAt(c.origin, t)
+			if !ok {
+				continue
+			}
+			if err := repo.Save(ctx, &model.VideoProgress{
+				AID:          v.view.Aid,
+				BVID:         v.view.Bvid,
+				LastPlayCID:  lastPlayCid,
+				LastPlayTime: lastPlayTime,
+				RecordedAt:   t,
+			}); err != nil {
+				return saved, fmt.Errorf("failed to save demo progress for %s at %s: %w", v.view.Bvid, t, err)
+			}
+			saved++
+		}
+	}
+	log.Printf("Demo: seeded %d progress records in [%s, %s) with step %s", saved, from, to, step)
+	return saved, nil
+}
@@ -0,0 +1,141 @@
+package demo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// viewingSession 一次模拟的观看行为。
+type viewingSession struct {
+	start    time.Time     // 开始观看的时间
+	duration time.Duration // 实际经过的墙钟时长
+	speed    float64       // 播放倍速
+}
+
+// 模拟观看通常发生的整点时刻 (本地时间)
+var sessionStartHours = []int{7, 9, 12, 14, 19, 20, 21, 22}
+
+// 模拟观看时可能使用的播放倍速
+var sessionSpeeds = []float64{1, 1, 1, 1.25, 1.5, 2}
+
+// dayStart 返回 t 所在自然日 (本地时区) 的零点。
+func dayStart(t time.Time) time.Time {
+	y, m, d := t.In(time.Local).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// dayTotals 缓存每个自然日完整观看的内容秒数，避免每次查询都从 origin 重放所有会话。
+type dayTotals struct {
+	mu     sync.Mutex
+	totals map[dayTotalKey]float64
+}
+
+// dayTotalKey 缓存的键：模拟起始时间和自然日的零点 (Unix 秒)。
+type dayTotalKey struct {
+	origin int64
+	day    int64
+}
+
+// sessionsOn 返回某个视频在指定日期的模拟观看会话，按开始时间升序排列。
+// 结果只由 BVID 和日期决定，因此同一时间点多次查询得到的进度保持一致。
+// 会话结束时间不晚于下一次会话的开始时间，避免重叠的会话重复推进进度。
+func (v *demoVideo) sessionsOn(day time.Time) []viewingSession {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s", v.view.Bvid, day.Format("2006-01-02"))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	count := rng.Intn(v.sessionsPerDay + 1) // 可能一整天都不看
+	sessions := make([]viewingSession, 0, count)
+	usedHours := make(map[int]bool)
+	for i := 0; i < count; i++ {
+		hour := sessionStartHours[rng.Intn(len(sessionStartHours))]
+		if usedHours[hour] {
+			continue
+		}
+		usedHours[hour] = true
+		sessions = append(sessions, viewingSession{
+			start:    day.Add(time.Duration(hour)*time.Hour + time.Duration(rng.Intn(45))*time.Minute),
+			duration: time.Duration(10+rng.Intn(70)) * time.Minute,
+			speed:    sessionSpeeds[rng.Intn(len(sessionSpeeds))],
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].start.Before(sessions[j].start) })
+	for i := 0; i+1 < len(sessions); i++ {
+		if gap := sessions[i+1].start.Sub(sessions[i].start); sessions[i].duration > gap {
+			sessions[i].duration = gap
+		}
+	}
+	return sessions
+}
+
+// watchedSecondsAt 计算从 origin 到 t 为止累计观看的视频内容秒数 (已考虑倍速)。
+// 会话最晚在次日凌晨结束，因此早于 t 所在日前一天的日子按缓存的整日时长累加，只有最后两天逐个会话计算。
+func (v *demoVideo) watchedSecondsAt(origin, t time.Time) int64 {
+	if !t.After(origin) {
+		return 0
+	}
+	var watched float64
+	for day := dayStart(origin); !day.After(t); day = day.AddDate(0, 0, 1) {
+		if !t.Before(day.AddDate(0, 0, 2)) {
+			watched += v.dayTotal(origin, day)
+			continue
+		}
+		watched += v.watchedOn(origin, day, t)
+	}
+	return int64(watched)
+}
+
+// dayTotal 返回 day 当天开始的会话全部看完时观看的内容秒数，结果按 (origin, day) 缓存。
+func (v *demoVideo) dayTotal(origin, day time.Time) float64 {
+	key := dayTotalKey{origin: origin.Unix(), day: day.Unix()}
+	v.dayTotals.mu.Lock()
+	total, ok := v.dayTotals.totals[key]
+	v.dayTotals.mu.Unlock()
+	if ok {
+		return total
+	}
+
+	total = v.watchedOn(origin, day, day.AddDate(0, 0, 2))
+	v.dayTotals.mu.Lock()
+	v.dayTotals.totals[key] = total
+	v.dayTotals.mu.Unlock()
+	return total
+}
+
+// watchedOn 返回 day 当天开始、不早于 origin 的会话到 t 为止观看的内容秒数。
+func (v *demoVideo) watchedOn(origin, day, t time.Time) float64 {
+	var watched float64
+	for _, s := range v.sessionsOn(day) {
+		if s.start.Before(origin) || !s.start.Before(t) {
+			continue
+		}
+		elapsed := t.Sub(s.start)
+		if elapsed > s.duration {
+			elapsed = s.duration
+		}
+		watched += elapsed.Seconds() * s.speed
+	}
+	return watched
+}
+
+// positionAt 将累计观看秒数映射为播放位置 (分P CID 及分P内的毫秒进度)。
+// 看完整个视频后从第一P重新开始，模拟重刷课程。
+func (v *demoVideo) positionAt(origin, t time.Time) (cid int64, positionMs int64, ok bool) {
+	watched := v.watchedSecondsAt(origin, t)
+	if watched == 0 || v.view.Duration == 0 {
+		return 0, 0, false
+	}
+	offset := watched % v.view.Duration
+	for _, p := range v.view.Pages {
+		if offset < p.Duration {
+			return p.Cid, offset * 1000, true
+		}
+		offset -= p.Duration
+	}
+	last := v.view.Pages[len(v.view.Pages)-1]
+	return last.Cid, last.Duration * 1000, true
+}
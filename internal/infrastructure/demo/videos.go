@@ -0,0 +1,77 @@
+package demo
+
+import "github.com/krisxia0506/bilibili-watcher/internal/application"
+
+// demoVideo 描述一个用于演示的模拟视频稿件。
+type demoVideo struct {
+	view application.VideoViewDTO
+	// sessionsPerDay 每天最多的观看次数，用于区分“热门”与“冷门”课程
+	sessionsPerDay int
+	// dayTotals 每天观看内容秒数的缓存
+	dayTotals *dayTotals
+}
+
+// demoOwner 模拟视频的UP主和分区。
+type demoOwner struct {
+	mid   int64
+	name  string
+	tid   int
+	tname string
+}
+
+// newDemoVideo 根据分P时长 (秒) 构造模拟视频，CID 由 AID 派生以保证唯一。
+func newDemoVideo(aid int64, bvid, title string, owner demoOwner, sessionsPerDay int, parts []string, durations []int64) demoVideo {
+	pages := make([]application.VideoViewPageDTO, 0, len(parts))
+	var total int64
+	for i, part := range parts {
+		pages = append(pages, application.VideoViewPageDTO{
+			Cid:      aid*1000 + int64(i+1),
+			Part:     part,
+			Duration: durations[i],
+			Page:     i + 1,
+		})
+		total += durations[i]
+	}
+	return demoVideo{
+		view: application.VideoViewDTO{
+			Bvid:      bvid,
+			Aid:       aid,
+			Title:     title,
+			Desc:      "演示模式生成的模拟视频",
+			Pubdate:   1735689600, // 2025-01-01 00:00:00 UTC
+			Duration:  total,
+			OwnerName: owner.name,
+			OwnerMid:  owner.mid,
+			Tid:       owner.tid,
+			Tname:     owner.tname,
+			Pages:     pages,
+		},
+		sessionsPerDay: sessionsPerDay,
+		dayTotals:      &dayTotals{totals: make(map[dayTotalKey]float64)},
+	}
+}
+
+// demoVideos 演示模式下可用的模拟视频列表。
+var demoVideos = []demoVideo{
+	newDemoVideo(100000001, "BV1Demo4Go1x", "Go 语言从入门到实战", demoOwner{90000001, "演示UP主-码农老张", 231, "计算机技术"}, 3,
+		[]string{"课程介绍", "环境搭建", "基础语法", "函数与方法", "接口", "并发编程", "标准库", "项目实战"},
+		[]int64{420, 960, 2280, 1860, 2040, 2760, 1920, 3480},
+	),
+	newDemoVideo(100000002, "BV1Demo4DDDx", "领域驱动设计精讲", demoOwner{90000002, "演示UP主-架构师小王", 231, "计算机技术"}, 2,
+		[]string{"战略设计", "限界上下文", "聚合与实体", "领域事件", "分层架构"},
+		[]int64{2700, 3120, 2880, 2460, 3300},
+	),
+	newDemoVideo(100000003, "BV1Demo4MySQ", "MySQL 性能优化", demoOwner{90000003, "演示UP主-DBA阿强", 201, "科学科普"}, 1,
+		[]string{"索引原理", "执行计划", "慢查询分析"},
+		[]int64{1980, 2220, 1740},
+	),
+}
+
+// BVIDs 返回演示模式下所有模拟视频的 BVID。
+func BVIDs() []string {
+	bvids := make([]string, 0, len(demoVideos))
+	for _, v := range demoVideos {
+		bvids = append(bvids, v.view.Bvid)
+	}
+	return bvids
+}
@@ -0,0 +1,180 @@
+package exchange
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// recordCodec 描述一种记录类型 T 在交换格式中的表示。
+// JSON 格式直接序列化 record 结构体，CSV 格式使用 header 和 toRow / fromRow 转换。
+type recordCodec[T any, R any] struct {
+	header     []string
+	toRecord   func(T) R
+	fromRecord func(R) (T, error)
+	toRow      func(R) []string
+	fromRow    func(map[string]string) (R, error)
+}
+
+// encoder 是通用的流式编码器，逐条写出记录，不在内存中保留已写出的数据。
+type encoder[T any, R any] struct {
+	codec  recordCodec[T, R]
+	format Format
+	buf    *bufio.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+	count  int
+}
+
+func newEncoder[T any, R any](w io.Writer, format Format, codec recordCodec[T, R]) *encoder[T, R] {
+	e := &encoder[T, R]{codec: codec, format: format, buf: bufio.NewWriter(w)}
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(e.buf)
+	default:
+		e.json = json.NewEncoder(e.buf)
+	}
+	return e
+}
+
+// Encode 写出一条记录。
+func (e *encoder[T, R]) Encode(v T) error {
+	rec := e.codec.toRecord(v)
+	defer func() { e.count++ }()
+	switch e.format {
+	case FormatCSV:
+		if e.count == 0 {
+			if err := e.csv.Write(e.codec.header); err != nil {
+				return err
+			}
+		}
+		return e.csv.Write(e.codec.toRow(rec))
+	case FormatJSON:
+		sep := ",\n"
+		if e.count == 0 {
+			sep = "[\n"
+		}
+		if _, err := e.buf.WriteString(sep); err != nil {
+			return err
+		}
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		_, err = e.buf.Write(b)
+		return err
+	default:
+		return e.json.Encode(rec) // json.Encoder 在每个值后追加换行
+	}
+}
+
+// Close 写出格式结尾并刷新缓冲区。没有任何记录时 CSV 仍会写出表头，JSON 写出空数组。
+func (e *encoder[T, R]) Close() error {
+	switch e.format {
+	case FormatCSV:
+		if e.count == 0 {
+			if err := e.csv.Write(e.codec.header); err != nil {
+				return err
+			}
+		}
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	case FormatJSON:
+		end := "\n]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		if _, err := e.buf.WriteString(end); err != nil {
+			return err
+		}
+	}
+	return e.buf.Flush()
+}
+
+// decoder 是通用的流式解码器。
+type decoder[T any, R any] struct {
+	codec   recordCodec[T, R]
+	format  Format
+	csv     *csv.Reader
+	columns []string
+	json    *json.Decoder
+	started bool
+	line    int
+}
+
+func newDecoder[T any, R any](r io.Reader, format Format, codec recordCodec[T, R]) *decoder[T, R] {
+	d := &decoder[T, R]{codec: codec, format: format}
+	if format == FormatCSV {
+		d.csv = csv.NewReader(bufio.NewReader(r))
+		d.csv.ReuseRecord = true
+	} else {
+		d.json = json.NewDecoder(bufio.NewReader(r))
+	}
+	return d
+}
+
+// Decode 读取下一条记录，没有更多记录时返回 io.EOF。
+func (d *decoder[T, R]) Decode() (T, error) {
+	var zero T
+	rec, err := d.next()
+	if err != nil {
+		return zero, err
+	}
+	v, err := d.codec.fromRecord(rec)
+	if err != nil {
+		return zero, fmt.Errorf("record %d: %w", d.line, err)
+	}
+	return v, nil
+}
+
+func (d *decoder[T, R]) next() (R, error) {
+	var rec R
+	d.line++
+	switch d.format {
+	case FormatCSV:
+		if d.columns == nil {
+			header, err := d.csv.Read()
+			if err != nil {
+				return rec, err
+			}
+			d.columns = append([]string(nil), header...)
+		}
+		row, err := d.csv.Read()
+		if err != nil {
+			return rec, err
+		}
+		fields := make(map[string]string, len(d.columns))
+		for i, name := range d.columns {
+			if i < len(row) {
+				fields[name] = row[i]
+			}
+		}
+		return d.codec.fromRow(fields)
+	case FormatJSON:
+		if !d.started {
+			tok, err := d.json.Token()
+			if err != nil {
+				return rec, err
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return rec, fmt.Errorf("expected JSON array, got %v", tok)
+			}
+			d.started = true
+		}
+		if !d.json.More() {
+			if _, err := d.json.Token(); err != nil { // 读取右括号
+				return rec, err
+			}
+			return rec, io.EOF
+		}
+		err := d.json.Decode(&rec)
+		return rec, err
+	default:
+		err := d.json.Decode(&rec)
+		return rec, err
+	}
+}
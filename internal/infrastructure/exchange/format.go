@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// Format 表示数据交换格式。
+type Format string
+
+const (
+	FormatCSV    Format = "csv"    // 带表头的 CSV
+	FormatNDJSON Format = "ndjson" // 每行一个 JSON 对象
+	FormatJSON   Format = "json"   // 单个 JSON 数组
+)
+
+// ParseFormat 解析格式名称 (不区分大小写)。
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCSV, FormatNDJSON, FormatJSON:
+		return f, nil
+	case "jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (supported: csv, ndjson, json)", name)
+	}
+}
+
+// FormatFromFilename 根据文件扩展名推断格式。
+func FormatFromFilename(name string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot infer format from file name %q", name)
+	}
+	return ParseFormat(ext)
+}
+
+// FormatFromContentType 根据 HTTP Content-Type 推断格式。
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON, nil
+	case "application/json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+}
+
+// ContentType 返回格式对应的 HTTP Content-Type。
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
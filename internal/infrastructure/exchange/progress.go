@@ -0,0 +1,101 @@
+package exchange
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+// progressRecord 是进度记录在交换格式中的表示。
+// 不包含数据库 ID 和 gmt_* 字段，导入到其他实例时由目标实例生成。
+type progressRecord struct {
+	AID            int64     `json:"aid"`
+	BVID           string    `json:"bvid"`
+	LastPlayCID    int64     `json:"last_play_cid"`
+	LastPlayTimeMs int64     `json:"last_play_time_ms"` // 播放进度 (毫秒)
+	RecordedAt     time.Time `json:"recorded_at"`       // RFC3339 格式
+}
+
+var progressHeader = []string{"aid", "bvid", "last_play_cid", "last_play_time_ms", "recorded_at"}
+
+var progressCodec = recordCodec[*model.VideoProgress, progressRecord]{
+	header: progressHeader,
+	toRecord: func(p *model.VideoProgress) progressRecord {
+		return progressRecord{
+			AID:            p.AID,
+			BVID:           p.BVID,
+			LastPlayCID:    p.LastPlayCID,
+			LastPlayTimeMs: p.LastPlayTime,
+			RecordedAt:     p.RecordedAt.UTC(),
+		}
+	},
+	fromRecord: func(r progressRecord) (*model.VideoProgress, error) {
+		if r.AID <= 0 {
+			return nil, fmt.Errorf("aid must be positive")
+		}
+		if r.RecordedAt.IsZero() {
+			return nil, fmt.Errorf("recorded_at is required")
+		}
+		return &model.VideoProgress{
+			AID:          r.AID,
+			BVID:         r.BVID,
+			LastPlayCID:  r.LastPlayCID,
+			LastPlayTime: r.LastPlayTimeMs,
+			RecordedAt:   r.RecordedAt.UTC(), // 统一以 UTC 保存
+		}, nil
+	},
+	toRow: func(r progressRecord) []string {
+		return []string{
+			strconv.FormatInt(r.AID, 10),
+			r.BVID,
+			strconv.FormatInt(r.LastPlayCID, 10),
+			strconv.FormatInt(r.LastPlayTimeMs, 10),
+			r.RecordedAt.Format(time.RFC3339Nano),
+		}
+	},
+	fromRow: func(fields map[string]string) (progressRecord, error) {
+		var r progressRecord
+		var err error
+		if r.AID, err = parseIntField(fields, "aid"); err != nil {
+			return r, err
+		}
+		r.BVID = fields["bvid"]
+		if r.LastPlayCID, err = parseIntField(fields, "last_play_cid"); err != nil {
+			return r, err
+		}
+		if r.LastPlayTimeMs, err = parseIntField(fields, "last_play_time_ms"); err != nil {
+			return r, err
+		}
+		if r.RecordedAt, err = time.Parse(time.RFC3339Nano, fields["recorded_at"]); err != nil {
+			return r, fmt.Errorf("invalid recorded_at: %w", err)
+		}
+		return r, nil
+	},
+}
+
+// NewProgressEncoder 创建按指定格式写出进度记录的编码器。
+func NewProgressEncoder(w io.Writer, format Format) application.ProgressEncoder {
+	return newEncoder(w, format, progressCodec)
+}
+
+// NewProgressDecoder 创建按指定格式读取进度记录的解码器。
+func NewProgressDecoder(r io.Reader, format Format) application.ProgressDecoder {
+	return newDecoder(r, format, progressCodec)
+}
+
+// parseIntField 解析 CSV 行中的整数列，缺失或为空时返回 0。
+func parseIntField(fields map[string]string, name string) (int64, error) {
+	value := fields[name]
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
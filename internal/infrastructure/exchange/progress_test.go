@@ -0,0 +1,59 @@
+package exchange
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestProgressRoundTrip(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	records := []*model.VideoProgress{
+		{AID: 1, BVID: "BV1xx411c7mD", LastPlayCID: 101, LastPlayTime: 0, RecordedAt: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{AID: 1, BVID: "BV1xx411c7mD", LastPlayCID: 102, LastPlayTime: 61500, RecordedAt: time.Date(2025, 3, 1, 16, 30, 0, 123456789, shanghai)},
+		{AID: 2, BVID: "BV1, \"quoted\"", LastPlayCID: 201, LastPlayTime: 3600000, RecordedAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, format := range []Format{FormatCSV, FormatNDJSON, FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			for _, want := range [][]*model.VideoProgress{records, nil} {
+				var buf bytes.Buffer
+				enc := NewProgressEncoder(&buf, format)
+				for _, p := range want {
+					if err := enc.Encode(p); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := enc.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				dec := NewProgressDecoder(&buf, format)
+				var got []*model.VideoProgress
+				for {
+					p, err := dec.Decode()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("decode %d: %v", len(got)+1, err)
+					}
+					got = append(got, p)
+				}
+				if len(got) != len(want) {
+					t.Fatalf("decoded %d records, want %d", len(got), len(want))
+				}
+				for i, p := range got {
+					w := want[i]
+					if p.AID != w.AID || p.BVID != w.BVID || p.LastPlayCID != w.LastPlayCID || p.LastPlayTime != w.LastPlayTime ||
+						!p.RecordedAt.Equal(w.RecordedAt) || p.RecordedAt.Location() != time.UTC {
+						t.Errorf("record %d = %+v, want %+v (in UTC)", i, p, w)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+)
+
+// segmentRecord 是观看分段在交换格式中的表示，字段名与 watch-segments 接口的响应一致。
+// 分P明细 (Parts) 只出现在 JSON 和 NDJSON 中，CSV 每行只有分段合计。
+type segmentRecord struct {
+	SegmentStartTime     time.Time           `json:"segment_start_time"`
+	SegmentEndTime       time.Time           `json:"segment_end_time"`
+	WatchedDurationSec   int64               `json:"watched_duration_seconds"`
+	RewatchedDurationSec int64               `json:"rewatched_duration_seconds"`
+	SkippedDurationSec   int64               `json:"skipped_duration_seconds"`
+	PlaybackSpeed        float64             `json:"playback_speed"`
+	Parts                []segmentPartRecord `json:"parts"`
+}
+
+// segmentPartRecord 是分段内一个分P的观看时长。
+type segmentPartRecord struct {
+	CID                  int64  `json:"cid"`
+	Page                 int    `json:"page"`
+	Part                 string `json:"part"`
+	WatchedDurationSec   int64  `json:"watched_duration_seconds"`
+	RewatchedDurationSec int64  `json:"rewatched_duration_seconds"`
+}
+
+var segmentCodec = recordCodec[application.WatchedSegmentResult, segmentRecord]{
+	header: []string{"segment_start_time", "segment_end_time", "watched_duration_seconds", "rewatched_duration_seconds",
+		"skipped_duration_seconds", "playback_speed"},
+	toRecord: func(s application.WatchedSegmentResult) segmentRecord {
+		record := segmentRecord{
+			SegmentStartTime:     s.SegmentStartTime,
+			SegmentEndTime:       s.SegmentEndTime,
+			WatchedDurationSec:   int64(s.WatchedDuration.Seconds()),
+			RewatchedDurationSec: int64(s.RewatchedDuration.Seconds()),
+			SkippedDurationSec:   int64(s.SkippedDuration.Seconds()),
+			PlaybackSpeed:        s.PlaybackSpeed,
+			Parts:                make([]segmentPartRecord, 0, len(s.Parts)),
+		}
+		for _, p := range s.Parts {
+			record.Parts = append(record.Parts, segmentPartRecord{
+				CID:                  p.CID,
+				Page:                 p.Page,
+				Part:                 p.Part,
+				WatchedDurationSec:   int64(p.WatchedDuration.Seconds()),
+				RewatchedDurationSec: int64(p.RewatchedDuration.Seconds()),
+			})
+		}
+		return record
+	},
+	toRow: func(r segmentRecord) []string {
+		return []string{
+			r.SegmentStartTime.Format(time.RFC3339),
+			r.SegmentEndTime.Format(time.RFC3339),
+			strconv.FormatInt(r.WatchedDurationSec, 10),
+			strconv.FormatInt(r.RewatchedDurationSec, 10),
+			strconv.FormatInt(r.SkippedDurationSec, 10),
+			strconv.FormatFloat(r.PlaybackSpeed, 'f', -1, 64),
+		}
+	},
+}
+
+// NewSegmentEncoder 创建按指定格式写出观看分段的编码器。分段只支持导出。
+func NewSegmentEncoder(w io.Writer, format Format) application.SegmentEncoder {
+	return newEncoder(w, format, segmentCodec)
+}
@@ -0,0 +1,175 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// gormCoursePlanRepository 是 CoursePlanRepository 的 GORM 实现。
+type gormCoursePlanRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCoursePlanRepository 创建一个新的 GORM CoursePlanRepository 实例。
+func NewGormCoursePlanRepository(db *gorm.DB) repository.CoursePlanRepository {
+	return &gormCoursePlanRepository{db: db}
+}
+
+// coursePlanGorm 对应 course_plan 表。日历日与学习目标一样以 YYYY-MM-DD 字符串保存。
+type coursePlanGorm struct {
+	ID          uint      `gorm:"primaryKey;comment:主键 ID"`
+	Name        string    `gorm:"column:name;type:varchar(255);not null;default:'';comment:计划名称"`
+	StartDate   string    `gorm:"column:start_date;type:varchar(10);not null;default:'';comment:计划开始的日期 (YYYY-MM-DD)"`
+	TargetDate  string    `gorm:"column:target_date;type:varchar(10);not null;default:'';comment:目标完成日期 (YYYY-MM-DD，含)"`
+	GmtCreate   time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (coursePlanGorm) TableName() string {
+	return "course_plan"
+}
+
+// coursePlanItemGorm 对应 course_plan_item 表，position 为分P在计划中的顺序。
+type coursePlanItemGorm struct {
+	ID          uint      `gorm:"primaryKey;comment:主键 ID"`
+	PlanID      uint      `gorm:"column:plan_id;uniqueIndex:uk_course_plan_item_plan_position,priority:1;not null;default:0;comment:课程计划 ID"`
+	Position    int       `gorm:"column:position;uniqueIndex:uk_course_plan_item_plan_position,priority:2;not null;default:0;comment:分P在计划中的顺序 (从 0 开始)"`
+	AID         int64     `gorm:"column:aid;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	BVID        string    `gorm:"column:bvid;type:varchar(255);not null;default:'';comment:视频 BV 号"`
+	CID         int64     `gorm:"column:cid;not null;default:0;comment:分P ID"`
+	GmtCreate   time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (coursePlanItemGorm) TableName() string {
+	return "course_plan_item"
+}
+
+// toDomain 将 GORM 模型转换为领域模型，items 已按 position 排序。
+func (p *coursePlanGorm) toDomain(items []coursePlanItemGorm) (*model.CoursePlan, error) {
+	startDate, err := parseGoalDate(p.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date of course plan %d: %w", p.ID, err)
+	}
+	targetDate, err := parseGoalDate(p.TargetDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_date of course plan %d: %w", p.ID, err)
+	}
+	plan := &model.CoursePlan{
+		ID:          p.ID,
+		Name:        p.Name,
+		Items:       make([]model.CoursePlanItem, 0, len(items)),
+		StartDate:   startDate,
+		TargetDate:  targetDate,
+		GmtCreate:   p.GmtCreate,
+		GmtModified: p.GmtModified,
+	}
+	for _, item := range items {
+		plan.Items = append(plan.Items, model.CoursePlanItem{AID: item.AID, BVID: item.BVID, CID: item.CID})
+	}
+	return plan, nil
+}
+
+// Create 在一个事务中保存计划及其分P。
+func (r *gormCoursePlanRepository) Create(ctx context.Context, plan *model.CoursePlan) error {
+	p := &coursePlanGorm{
+		Name:       plan.Name,
+		StartDate:  formatGoalDate(plan.StartDate),
+		TargetDate: formatGoalDate(plan.TargetDate),
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		items := make([]coursePlanItemGorm, 0, len(plan.Items))
+		for i, item := range plan.Items {
+			items = append(items, coursePlanItemGorm{PlanID: p.ID, Position: i, AID: item.AID, BVID: item.BVID, CID: item.CID})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		log.Printf("Database error creating course plan %q: %v", plan.Name, err)
+		return fmt.Errorf("database error creating course plan: %w", err)
+	}
+	plan.ID = p.ID
+	plan.GmtCreate = p.GmtCreate
+	plan.GmtModified = p.GmtModified
+	return nil
+}
+
+// FindByID 根据 ID 查找计划及其分P。
+func (r *gormCoursePlanRepository) FindByID(ctx context.Context, id uint) (*model.CoursePlan, error) {
+	var p coursePlanGorm
+	err := r.db.WithContext(ctx).First(&p, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrCoursePlanNotFound
+		}
+		log.Printf("Database error finding course plan %d: %v", id, err)
+		return nil, fmt.Errorf("database error finding course plan: %w", err)
+	}
+	var items []coursePlanItemGorm
+	if err := r.db.WithContext(ctx).Where("plan_id = ?", id).Order("position ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("database error listing items of course plan %d: %w", id, err)
+	}
+	return p.toDomain(items)
+}
+
+// ListAll 获取所有计划及其分P。
+func (r *gormCoursePlanRepository) ListAll(ctx context.Context) ([]*model.CoursePlan, error) {
+	var ps []coursePlanGorm
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&ps).Error; err != nil {
+		return nil, fmt.Errorf("database error listing course plans: %w", err)
+	}
+	var items []coursePlanItemGorm
+	if err := r.db.WithContext(ctx).Order("plan_id ASC, position ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("database error listing course plan items: %w", err)
+	}
+	byPlan := make(map[uint][]coursePlanItemGorm, len(ps))
+	for _, item := range items {
+		byPlan[item.PlanID] = append(byPlan[item.PlanID], item)
+	}
+	plans := make([]*model.CoursePlan, 0, len(ps))
+	for i := range ps {
+		plan, err := ps[i].toDomain(byPlan[ps[i].ID])
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Delete 在一个事务中删除计划及其分P。
+func (r *gormCoursePlanRepository) Delete(ctx context.Context, id uint) error {
+	var affected int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", id).Delete(&coursePlanItemGorm{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&coursePlanGorm{}, id)
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		log.Printf("Database error deleting course plan %d: %v", id, err)
+		return fmt.Errorf("database error deleting course plan: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrCoursePlanNotFound
+	}
+	return nil
+}
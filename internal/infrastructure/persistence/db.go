@@ -21,7 +21,9 @@ func NewDatabaseConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	if cfg.User == "" || cfg.Password == "" || cfg.Host == "" || cfg.DBName == "" {
 		return nil, fmt.Errorf("mysql config incomplete: user, password, host, and dbname are required")
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	// 所有时间以 UTC 存储：loc=UTC 使驱动按 UTC 读写 DATETIME，
+	// time_zone='+00:00' 使 CURRENT_TIMESTAMP 等数据库默认值也是 UTC，与容器时区无关
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
 		cfg.User,
 		cfg.Password,
 		cfg.Host,
@@ -43,15 +45,32 @@ func NewDatabaseConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 
 	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: newLogger,
+		NowFunc: func() time.Time {
+			return time.Now().UTC() // gmt_create / gmt_modified 同样使用 UTC
+		},
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := prepareVideoProgressMigration(db); err != nil {
+		return nil, err
+	}
+
 	// 自动迁移领域模型
 	err = db.AutoMigrate(
 		&model.VideoProgress{},
+		&videoGorm{},
+		&videoPageGorm{},
+		&watchTimeHourlyGorm{},
+		&watchTimeDailyGorm{},
+		&rawResponseGorm{},
+		&progressRollupStateGorm{},
+		&goalGorm{},
+		&coursePlanGorm{},
+		&coursePlanItemGorm{},
+		&schemaMigrationGorm{},
 		// 如果需要，在此添加其他模型
 	)
 	if err != nil {
@@ -61,3 +80,30 @@ func NewDatabaseConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	log.Println("Database connection established and migrations completed.")
 	return db, nil
 }
+
+// prepareVideoProgressMigration 为 AutoMigrate 准备已有的 video_progress 表：
+// 唯一索引 uk_video_progress_aid_recorded_at 尚未创建时，先删除 (aid, recorded_at) 重复的记录 (保留 ID 最小的一条)，
+// 否则创建唯一索引会失败；并删除已被唯一索引覆盖的单列索引 idx_video_progress_aid (AutoMigrate 不会删除索引)。
+func prepareVideoProgressMigration(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.VideoProgress{}) {
+		return nil
+	}
+	if !migrator.HasIndex(&model.VideoProgress{}, "uk_video_progress_aid_recorded_at") {
+		result := db.Exec("DELETE p FROM video_progress p JOIN video_progress q " +
+			"ON p.aid = q.aid AND p.recorded_at = q.recorded_at AND p.id > q.id")
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove duplicate progress records: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Migration: removed %d progress records with duplicate (aid, recorded_at)", result.RowsAffected)
+		}
+	}
+	if migrator.HasIndex(&model.VideoProgress{}, "idx_video_progress_aid") {
+		if err := migrator.DropIndex(&model.VideoProgress{}, "idx_video_progress_aid"); err != nil {
+			return fmt.Errorf("failed to drop redundant index idx_video_progress_aid: %w", err)
+		}
+		log.Println("Migration: dropped index idx_video_progress_aid (covered by uk_video_progress_aid_recorded_at)")
+	}
+	return nil
+}
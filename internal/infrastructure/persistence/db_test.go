@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/config"
+)
+
+// openTestDB 连接 TEST_DATABASE_* 环境变量 (与 DATABASE_* 含义相同) 指定的 MySQL 数据库，未设置 TEST_DATABASE_DBNAME 时跳过测试。
+// 测试会清空其中的表，只能指向专用于测试的数据库。
+func openTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dbName := os.Getenv("TEST_DATABASE_DBNAME")
+	if dbName == "" {
+		t.Skip("TEST_DATABASE_DBNAME not set, skipping MySQL test")
+	}
+	port, _ := strconv.Atoi(os.Getenv("TEST_DATABASE_PORT"))
+	if port == 0 {
+		port = 3306
+	}
+	db, err := NewDatabaseConnection(&config.DatabaseConfig{
+		Host:     os.Getenv("TEST_DATABASE_HOST"),
+		Port:     port,
+		User:     os.Getenv("TEST_DATABASE_USER"),
+		Password: os.Getenv("TEST_DATABASE_PASSWORD"),
+		DBName:   dbName,
+	})
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	db.Logger = db.Logger.LogMode(logger.Silent)
+	truncateTestTables(t, db)
+	t.Cleanup(func() { truncateTestTables(t, db) })
+	return db
+}
+
+// truncateTestTables 清空测试使用的表。
+func truncateTestTables(t testing.TB, db *gorm.DB) {
+	t.Helper()
+	for _, table := range []string{"video_progress", "watch_time_hourly", "watch_time_daily", "progress_rollup_state", "schema_migration"} {
+		if err := db.Exec("TRUNCATE TABLE " + table).Error; err != nil {
+			t.Fatalf("truncate %s: %v", table, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// goalDateLayout 目标中日历日字段的存储格式。
+const goalDateLayout = time.DateOnly
+
+// gormGoalRepository 是 GoalRepository 的 GORM 实现。
+type gormGoalRepository struct {
+	db *gorm.DB
+}
+
+// NewGormGoalRepository 创建一个新的 GORM GoalRepository 实例。
+func NewGormGoalRepository(db *gorm.DB) repository.GoalRepository {
+	return &gormGoalRepository{db: db}
+}
+
+// goalGorm 对应 study_goal 表。日历日以 YYYY-MM-DD 字符串保存，不适用的字段为空字符串。
+type goalGorm struct {
+	ID           uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID          int64     `gorm:"column:aid;index:idx_study_goal_aid;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	BVID         string    `gorm:"column:bvid;type:varchar(255);not null;default:'';comment:视频 BV 号"`
+	Kind         string    `gorm:"column:kind;type:varchar(16);not null;default:'';comment:目标类型 (daily, deadline)"`
+	DailySeconds int64     `gorm:"column:daily_seconds;not null;default:0;comment:每天的目标观看时长 (秒)"`
+	StartDate    string    `gorm:"column:start_date;type:varchar(10);not null;default:'';comment:开始统计的日期 (YYYY-MM-DD)"`
+	Deadline     string    `gorm:"column:deadline;type:varchar(10);not null;default:'';comment:截止日期 (YYYY-MM-DD，含)"`
+	GmtCreate    time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified  time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (goalGorm) TableName() string {
+	return "study_goal"
+}
+
+// formatGoalDate 把日历日格式化为存储格式，零值保存为空字符串。
+func formatGoalDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(goalDateLayout)
+}
+
+// parseGoalDate 解析存储的日历日，空字符串解析为零值。
+func parseGoalDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(goalDateLayout, s)
+}
+
+// toDomain 将 GORM 模型转换为领域模型。
+func (g *goalGorm) toDomain() (*model.Goal, error) {
+	startDate, err := parseGoalDate(g.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date of goal %d: %w", g.ID, err)
+	}
+	deadline, err := parseGoalDate(g.Deadline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline of goal %d: %w", g.ID, err)
+	}
+	return &model.Goal{
+		ID:           g.ID,
+		AID:          g.AID,
+		BVID:         g.BVID,
+		Kind:         model.GoalKind(g.Kind),
+		DailySeconds: g.DailySeconds,
+		StartDate:    startDate,
+		Deadline:     deadline,
+		GmtCreate:    g.GmtCreate,
+		GmtModified:  g.GmtModified,
+	}, nil
+}
+
+// Create 保存一个新目标。
+func (r *gormGoalRepository) Create(ctx context.Context, goal *model.Goal) error {
+	g := &goalGorm{
+		AID:          goal.AID,
+		BVID:         goal.BVID,
+		Kind:         string(goal.Kind),
+		DailySeconds: goal.DailySeconds,
+		StartDate:    formatGoalDate(goal.StartDate),
+		Deadline:     formatGoalDate(goal.Deadline),
+	}
+	if err := r.db.WithContext(ctx).Create(g).Error; err != nil {
+		log.Printf("Database error creating goal for AID %d: %v", goal.AID, err)
+		return fmt.Errorf("database error creating goal: %w", err)
+	}
+	goal.ID = g.ID
+	goal.GmtCreate = g.GmtCreate
+	goal.GmtModified = g.GmtModified
+	return nil
+}
+
+// FindByID 根据 ID 查找目标。
+func (r *gormGoalRepository) FindByID(ctx context.Context, id uint) (*model.Goal, error) {
+	var g goalGorm
+	err := r.db.WithContext(ctx).First(&g, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrGoalNotFound
+		}
+		log.Printf("Database error finding goal %d: %v", id, err)
+		return nil, fmt.Errorf("database error finding goal: %w", err)
+	}
+	return g.toDomain()
+}
+
+// ListAll 获取所有目标。
+func (r *gormGoalRepository) ListAll(ctx context.Context) ([]*model.Goal, error) {
+	var gs []goalGorm
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&gs).Error; err != nil {
+		return nil, fmt.Errorf("database error listing goals: %w", err)
+	}
+	goals := make([]*model.Goal, 0, len(gs))
+	for i := range gs {
+		goal, err := gs[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, nil
+}
+
+// Delete 删除指定 ID 的目标。
+func (r *gormGoalRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&goalGorm{}, id)
+	if result.Error != nil {
+		log.Printf("Database error deleting goal %d: %v", id, result.Error)
+		return fmt.Errorf("database error deleting goal: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrGoalNotFound
+	}
+	return nil
+}
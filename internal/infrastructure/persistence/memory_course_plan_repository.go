@@ -0,0 +1,80 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// memoryCoursePlanRepository 是 CoursePlanRepository 的内存实现，供演示模式使用。
+type memoryCoursePlanRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	plans  map[uint]*model.CoursePlan
+}
+
+// NewMemoryCoursePlanRepository 创建一个新的内存 CoursePlanRepository 实例。
+func NewMemoryCoursePlanRepository() repository.CoursePlanRepository {
+	return &memoryCoursePlanRepository{nextID: 1, plans: make(map[uint]*model.CoursePlan)}
+}
+
+// copyCoursePlan 复制计划及其分P，避免调用方修改仓库中保存的数据。
+func copyCoursePlan(plan *model.CoursePlan) *model.CoursePlan {
+	c := *plan
+	c.Items = append([]model.CoursePlanItem(nil), plan.Items...)
+	return &c
+}
+
+// Create 保存一个新计划。
+func (r *memoryCoursePlanRepository) Create(ctx context.Context, plan *model.CoursePlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	plan.ID = r.nextID
+	plan.GmtCreate = now
+	plan.GmtModified = now
+	r.nextID++
+	r.plans[plan.ID] = copyCoursePlan(plan)
+	return nil
+}
+
+// FindByID 根据 ID 查找计划。
+func (r *memoryCoursePlanRepository) FindByID(ctx context.Context, id uint) (*model.CoursePlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if p, ok := r.plans[id]; ok {
+		return copyCoursePlan(p), nil
+	}
+	return nil, repository.ErrCoursePlanNotFound
+}
+
+// ListAll 获取所有计划，按 ID 升序排序。
+func (r *memoryCoursePlanRepository) ListAll(ctx context.Context) ([]*model.CoursePlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plans := make([]*model.CoursePlan, 0, len(r.plans))
+	for _, p := range r.plans {
+		plans = append(plans, copyCoursePlan(p))
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return plans, nil
+}
+
+// Delete 删除指定 ID 的计划。
+func (r *memoryCoursePlanRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plans[id]; !ok {
+		return repository.ErrCoursePlanNotFound
+	}
+	delete(r.plans, id)
+	return nil
+}
@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// memoryGoalRepository 是 GoalRepository 的内存实现，供演示模式使用。
+type memoryGoalRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	goals  map[uint]*model.Goal
+}
+
+// NewMemoryGoalRepository 创建一个新的内存 GoalRepository 实例。
+func NewMemoryGoalRepository() repository.GoalRepository {
+	return &memoryGoalRepository{nextID: 1, goals: make(map[uint]*model.Goal)}
+}
+
+// Create 保存一个新目标。
+func (r *memoryGoalRepository) Create(ctx context.Context, goal *model.Goal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	goal.ID = r.nextID
+	goal.GmtCreate = now
+	goal.GmtModified = now
+	r.nextID++
+	stored := *goal
+	r.goals[goal.ID] = &stored
+	return nil
+}
+
+// FindByID 根据 ID 查找目标。
+func (r *memoryGoalRepository) FindByID(ctx context.Context, id uint) (*model.Goal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if g, ok := r.goals[id]; ok {
+		c := *g
+		return &c, nil
+	}
+	return nil, repository.ErrGoalNotFound
+}
+
+// ListAll 获取所有目标，按 ID 升序排序。
+func (r *memoryGoalRepository) ListAll(ctx context.Context) ([]*model.Goal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	goals := make([]*model.Goal, 0, len(r.goals))
+	for _, g := range r.goals {
+		c := *g
+		goals = append(goals, &c)
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals, nil
+}
+
+// Delete 删除指定 ID 的目标。
+func (r *memoryGoalRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.goals[id]; !ok {
+		return repository.ErrGoalNotFound
+	}
+	delete(r.goals, id)
+	return nil
+}
@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// memoryRawResponseRepository 是 RawResponseRepository 的内存实现，供演示模式使用。
+// 响应体不做压缩。
+type memoryRawResponseRepository struct {
+	mu        sync.RWMutex
+	nextID    uint
+	responses []*model.RawResponse // 按 FetchedAt 升序保存
+}
+
+// NewMemoryRawResponseRepository 创建一个新的内存 RawResponseRepository 实例。
+func NewMemoryRawResponseRepository() repository.RawResponseRepository {
+	return &memoryRawResponseRepository{nextID: 1}
+}
+
+// Save 保存一条原始响应。
+func (r *memoryRawResponseRepository) Save(ctx context.Context, response *model.RawResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	response.ID = r.nextID
+	response.GmtCreate = time.Now()
+	r.nextID++
+
+	stored := *response
+	stored.Body = append([]byte(nil), response.Body...)
+	idx := sort.Search(len(r.responses), func(i int) bool {
+		return r.responses[i].FetchedAt.After(stored.FetchedAt)
+	})
+	r.responses = append(r.responses, nil)
+	copy(r.responses[idx+1:], r.responses[idx:])
+	r.responses[idx] = &stored
+	return nil
+}
+
+// Iterate 按 (获取时间, ID) 升序遍历满足条件的原始响应。
+func (r *memoryRawResponseRepository) Iterate(ctx context.Context, filter repository.RawResponseFilter, fn func(response *model.RawResponse) error) error {
+	r.mu.RLock()
+	matched := make([]*model.RawResponse, 0)
+	for _, resp := range r.responses {
+		if (filter.Kind == "" || resp.Kind == filter.Kind) &&
+			(filter.AID == 0 || resp.AID == filter.AID) &&
+			(filter.Start.IsZero() || !resp.FetchedAt.Before(filter.Start)) &&
+			(filter.End.IsZero() || resp.FetchedAt.Before(filter.End)) {
+			c := *resp
+			c.Body = append([]byte(nil), resp.Body...)
+			matched = append(matched, &c)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, resp := range matched {
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// memoryVideoProgressRepository 是 VideoProgressRepository 的内存实现。
+// 主要用于演示模式和本地开发，进程退出后数据即丢失。
+type memoryVideoProgressRepository struct {
+	mu      sync.RWMutex
+	nextID  uint
+	records []*model.VideoProgress // 按 RecordedAt 升序 (相同时间按 ID 升序) 保存
+}
+
+// NewMemoryVideoProgressRepository 创建一个新的内存 VideoProgressRepository 实例。
+func NewMemoryVideoProgressRepository() repository.VideoProgressRepository {
+	return &memoryVideoProgressRepository{nextID: 1}
+}
+
+// Save 保存一条视频观看进度记录。
+// 与数据库默认值保持一致：未设置 RecordedAt 时使用当前时间。
+func (r *memoryVideoProgressRepository) Save(ctx context.Context, progress *model.VideoProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertLocked(progress)
+	return nil
+}
+
+// insertLocked 按记录时间顺序插入一条记录，调用方需持有写锁。
+func (r *memoryVideoProgressRepository) insertLocked(progress *model.VideoProgress) {
+	now := time.Now()
+	if progress.RecordedAt.IsZero() {
+		progress.RecordedAt = now
+	}
+	progress.ID = r.nextID
+	progress.GmtCreate = now
+	progress.GmtModified = now
+	r.nextID++
+
+	stored := *progress
+	// 插入到第一个晚于该记录时间的位置，保持有序
+	idx := sort.Search(len(r.records), func(i int) bool {
+		return r.records[i].RecordedAt.After(stored.RecordedAt)
+	})
+	r.records = append(r.records, nil)
+	copy(r.records[idx+1:], r.records[idx:])
+	r.records[idx] = &stored
+}
+
+// GetLatestByAIDAndCID 获取指定视频 (稿件+分P) 的最新一条进度记录。
+func (r *memoryVideoProgressRepository) GetLatestByAIDAndCID(ctx context.Context, aid, lastPlayCID int64) (*model.VideoProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.records) - 1; i >= 0; i-- {
+		p := r.records[i]
+		if p.AID == aid && p.LastPlayCID == lastPlayCID {
+			return copyProgress(p), nil
+		}
+	}
+	return nil, nil
+}
+
+// ListByDateRange 获取指定日期范围内的所有进度记录。
+func (r *memoryVideoProgressRepository) ListByDateRange(ctx context.Context, start, end time.Time) ([]*model.VideoProgress, error) {
+	return r.filter(func(p *model.VideoProgress) bool {
+		return !p.RecordedAt.Before(start) && p.RecordedAt.Before(end)
+	}), nil
+}
+
+// FindByAID 根据 AID 查找视频进度记录。
+func (r *memoryVideoProgressRepository) FindByAID(ctx context.Context, aid int64) (*model.VideoProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.records {
+		if p.AID == aid {
+			return copyProgress(p), nil
+		}
+	}
+	return nil, repository.ErrVideoProgressNotFound
+}
+
+// ListByAIDAndTimestampRange 获取指定 AID 在给定时间范围内的所有进度记录，按记录时间升序排序。
+func (r *memoryVideoProgressRepository) ListByAIDAndTimestampRange(ctx context.Context, aid int64, startTime, endTime time.Time) ([]*model.VideoProgress, error) {
+	return r.filter(func(p *model.VideoProgress) bool {
+		return p.AID == aid && !p.RecordedAt.Before(startTime) && !p.RecordedAt.After(endTime)
+	}), nil
+}
+
+// ListByBVIDAndTimestampRange 获取指定 BVID 在给定时间范围内的所有进度记录，按记录时间升序排序。
+func (r *memoryVideoProgressRepository) ListByBVIDAndTimestampRange(ctx context.Context, bvid string, startTime, endTime time.Time) ([]*model.VideoProgress, error) {
+	return r.filter(func(p *model.VideoProgress) bool {
+		return p.BVID == bvid && !p.RecordedAt.Before(startTime) && !p.RecordedAt.After(endTime)
+	}), nil
+}
+
+// GetLatestByAIDBefore 获取指定 AID 在 before 之前 (不含) 的最新一条进度记录。
+func (r *memoryVideoProgressRepository) GetLatestByAIDBefore(ctx context.Context, aid int64, before time.Time) (*model.VideoProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.records) - 1; i >= 0; i-- {
+		p := r.records[i]
+		if p.AID == aid && p.RecordedAt.Before(before) {
+			return copyProgress(p), nil
+		}
+	}
+	return nil, nil
+}
+
+// ListDistinctAIDs 获取所有存在进度记录的 AID，按升序排序。
+func (r *memoryVideoProgressRepository) ListDistinctAIDs(ctx context.Context) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[int64]bool)
+	aids := make([]int64, 0)
+	for _, p := range r.records {
+		if !seen[p.AID] {
+			seen[p.AID] = true
+			aids = append(aids, p.AID)
+		}
+	}
+	sort.Slice(aids, func(i, j int) bool { return aids[i] < aids[j] })
+	return aids, nil
+}
+
+// DeleteByAIDBefore 删除指定 AID 在 before 之前 (不含) 的所有进度记录。
+func (r *memoryVideoProgressRepository) DeleteByAIDBefore(ctx context.Context, aid int64, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.records[:0]
+	var deleted int64
+	for _, p := range r.records {
+		if p.AID == aid && p.RecordedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, p)
+	}
+	r.records = kept
+	return deleted, nil
+}
+
+// Iterate 按 (记录时间, ID) 升序遍历满足条件的进度记录。
+func (r *memoryVideoProgressRepository) Iterate(ctx context.Context, filter repository.ProgressFilter, fn func(progress *model.VideoProgress) error) error {
+	for _, p := range r.filter(matchesProgressFilter(filter)) {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPage 按 (RecordedAt, ID) 排序返回一页满足条件的进度记录。
+func (r *memoryVideoProgressRepository) ListPage(ctx context.Context, filter repository.ProgressFilter, page repository.ProgressPageRequest) ([]*model.VideoProgress, error) {
+	records := r.filter(matchesProgressFilter(filter))
+	if page.Descending {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+	if after := page.After; after != nil {
+		// 找到第一条排在游标之后的记录
+		start := sort.Search(len(records), func(i int) bool {
+			p := records[i]
+			if page.Descending {
+				return p.RecordedAt.Before(after.RecordedAt) || (p.RecordedAt.Equal(after.RecordedAt) && p.ID < after.ID)
+			}
+			return p.RecordedAt.After(after.RecordedAt) || (p.RecordedAt.Equal(after.RecordedAt) && p.ID > after.ID)
+		})
+		records = records[start:]
+	}
+	if page.Limit > 0 && len(records) > page.Limit {
+		records = records[:page.Limit]
+	}
+	return records, nil
+}
+
+// ListPairDeltas 与 GORM 实现的 SQL 语义一致：配对相邻记录，同一分P内向前推进的记录对按 (桶, 分P, 段) 求和
+// (Split 时跨越桶边界的记录对除外)，重看按遍历过程中维护的高水位线计算。
+func (r *memoryVideoProgressRepository) ListPairDeltas(ctx context.Context, aid int64, from, to time.Time, bucketing model.PairBucketing) (*model.PairDeltas, error) {
+	prefix, err := r.ReachedPositions(ctx, aid, from, bucketing.IncludeSuspicious)
+	if err != nil {
+		return nil, err
+	}
+	reached := make(map[int64]int64, len(prefix))
+	for cid, position := range prefix {
+		reached[cid] = position
+	}
+	records := r.filter(func(p *model.VideoProgress) bool {
+		return p.AID == aid && !p.RecordedAt.Before(from)
+	})
+	type sumKey struct {
+		bucket int64
+		cid    int64
+		run    int64
+	}
+	sums := make(map[sumKey]*model.ProgressDeltaSum)
+	deltas := &model.PairDeltas{Sums: []model.ProgressDeltaSum{}, Pairs: []model.ProgressPair{}, Reached: prefix}
+	for i := 0; i+1 < len(records) && records[i].RecordedAt.Before(to); i++ {
+		curr, next := records[i], records[i+1]
+		if bucketing.IncludeSuspicious || curr.PairLabel != model.PairLabelSuspicious {
+			if position := curr.LastPlayTime / 1000; position > reached[curr.LastPlayCID] {
+				reached[curr.LastPlayCID] = position
+			}
+		}
+		if next.PairLabel == model.PairLabelSuspicious && !bucketing.IncludeSuspicious {
+			continue
+		}
+		bucket := int64(curr.RecordedAt.Sub(bucketing.Origin) / bucketing.Width)
+		if curr.RecordedAt.Before(bucketing.Origin) && curr.RecordedAt.Sub(bucketing.Origin)%bucketing.Width != 0 {
+			bucket-- // 向下取整
+		}
+		if curr.LastPlayCID != next.LastPlayCID || next.LastPlayTime < curr.LastPlayTime ||
+			(bucketing.Split && (bucketing.SpansBucket(bucket, next.RecordedAt) || next.RecordedAt.After(to))) {
+			deltas.Pairs = append(deltas.Pairs, model.ProgressPair{Bucket: bucket, Curr: *curr, Next: *next})
+			continue
+		}
+		key := sumKey{bucket: bucket, cid: next.LastPlayCID, run: int64(len(deltas.Pairs))}
+		sum, ok := sums[key]
+		if !ok {
+			sum = &model.ProgressDeltaSum{Bucket: bucket, CID: next.LastPlayCID, Run: key.run, FirstStartedAt: curr.RecordedAt}
+			sums[key] = sum
+		}
+		position := next.LastPlayTime / 1000
+		counted, skipped, playback := model.LimitPairSeconds(position-curr.LastPlayTime/1000,
+			next.RecordedAt.Sub(curr.RecordedAt), bucketing.MaxSpeed)
+		sum.Seconds += counted
+		sum.RewatchedSeconds += counted - model.FreshSeconds(counted, position, reached[next.LastPlayCID])
+		sum.SkippedSeconds += skipped
+		if playback > 0 {
+			sum.ContinuousSeconds += counted
+			sum.PlaybackSeconds += playback
+		}
+		if position > sum.MaxPosition {
+			sum.MaxPosition = position
+		}
+		sum.Pairs++
+		sum.LastEndedAt = next.RecordedAt
+	}
+	for _, sum := range sums {
+		deltas.Sums = append(deltas.Sums, *sum)
+	}
+	sort.Slice(deltas.Sums, func(i, j int) bool {
+		a, b := deltas.Sums[i], deltas.Sums[j]
+		if a.Bucket != b.Bucket {
+			return a.Bucket < b.Bucket
+		}
+		if a.CID != b.CID {
+			return a.CID < b.CID
+		}
+		return a.Run < b.Run
+	})
+	return deltas, nil
+}
+
+// ReachedPositions 按分P取 before 之前记录的最大进度 (毫秒截断为秒)。
+func (r *memoryVideoProgressRepository) ReachedPositions(ctx context.Context, aid int64, before time.Time, includeSuspicious bool) (map[int64]int64, error) {
+	records := r.filter(func(p *model.VideoProgress) bool {
+		return p.AID == aid && p.RecordedAt.Before(before) && (includeSuspicious || p.PairLabel != model.PairLabelSuspicious)
+	})
+	reached := make(map[int64]int64)
+	for _, p := range records {
+		if position, ok := reached[p.LastPlayCID]; !ok || p.LastPlayTime/1000 > position {
+			reached[p.LastPlayCID] = p.LastPlayTime / 1000
+		}
+	}
+	return reached, nil
+}
+
+// matchesProgressFilter 返回判断记录是否满足 filter 的函数。
+func matchesProgressFilter(filter repository.ProgressFilter) func(p *model.VideoProgress) bool {
+	return func(p *model.VideoProgress) bool {
+		return (filter.AID == 0 || p.AID == filter.AID) &&
+			(filter.CID == 0 || p.LastPlayCID == filter.CID) &&
+			(filter.Start.IsZero() || !p.RecordedAt.Before(filter.Start)) &&
+			(filter.End.IsZero() || p.RecordedAt.Before(filter.End)) &&
+			(filter.PairLabel == "" || p.PairLabel == filter.PairLabel)
+	}
+}
+
+// InsertIgnoreDuplicates 批量插入进度记录，(aid, recorded_at) 已存在的记录被跳过。
+// 整批在同一把写锁内检查并插入，并发写入相同记录时只有一条生效。
+func (r *memoryVideoProgressRepository) InsertIgnoreDuplicates(ctx context.Context, records []*model.VideoProgress) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var inserted int64
+	for _, p := range records {
+		if r.findLocked(p.AID, p.RecordedAt) != nil {
+			continue
+		}
+		r.insertLocked(p)
+		inserted++
+	}
+	return inserted, nil
+}
+
+// UpsertBatch 批量写入进度记录，(aid, recorded_at) 已存在时覆盖其余字段。
+// 与 InsertIgnoreDuplicates 一样在同一把写锁内完成查找和写入。
+func (r *memoryVideoProgressRepository) UpsertBatch(ctx context.Context, records []*model.VideoProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range records {
+		if existing := r.findLocked(p.AID, p.RecordedAt); existing != nil {
+			existing.BVID = p.BVID
+			existing.LastPlayCID = p.LastPlayCID
+			existing.LastPlayTime = p.LastPlayTime
+			existing.GmtModified = time.Now()
+			continue
+		}
+		r.insertLocked(p)
+	}
+	return nil
+}
+
+// findLocked 返回相同 (aid, recorded_at) 的记录，不存在时返回 nil，调用方需持有锁。
+func (r *memoryVideoProgressRepository) findLocked(aid int64, recordedAt time.Time) *model.VideoProgress {
+	idx := sort.Search(len(r.records), func(i int) bool {
+		return !r.records[i].RecordedAt.Before(recordedAt)
+	})
+	for ; idx < len(r.records) && r.records[idx].RecordedAt.Equal(recordedAt); idx++ {
+		if r.records[idx].AID == aid {
+			return r.records[idx]
+		}
+	}
+	return nil
+}
+
+// filter 按记录时间升序返回满足条件的记录副本，避免调用方修改内部状态。
+func (r *memoryVideoProgressRepository) filter(match func(p *model.VideoProgress) bool) []*model.VideoProgress {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*model.VideoProgress, 0)
+	for _, p := range r.records {
+		if match(p) {
+			result = append(result, copyProgress(p))
+		}
+	}
+	return result
+}
+
+// copyProgress 返回进度记录的浅拷贝。
+func copyProgress(p *model.VideoProgress) *model.VideoProgress {
+	c := *p
+	return &c
+}
+
+// UpdatePairLabels 批量修改记录的记录对分类。
+func (r *memoryVideoProgressRepository) UpdatePairLabels(ctx context.Context, updates []repository.PairLabelUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	labels := make(map[uint]model.PairLabel, len(updates))
+	for _, u := range updates {
+		labels[u.ID] = u.Label
+	}
+	now := time.Now()
+	for _, p := range r.records {
+		if label, ok := labels[p.ID]; ok {
+			p.PairLabel = label
+			p.GmtModified = now
+		}
+	}
+	return nil
+}
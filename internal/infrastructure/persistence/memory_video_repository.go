@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// memoryVideoRepository 是 VideoRepository 的内存实现，供演示模式使用。
+type memoryVideoRepository struct {
+	mu        sync.RWMutex
+	nextID    uint
+	videos    map[int64]*model.Video           // key: AID
+	pageLists map[int64]model.VideoPageHistory // key: AID，按版本号升序
+}
+
+// NewMemoryVideoRepository 创建一个新的内存 VideoRepository 实例。
+func NewMemoryVideoRepository() repository.VideoRepository {
+	return &memoryVideoRepository{
+		nextID:    1,
+		videos:    make(map[int64]*model.Video),
+		pageLists: make(map[int64]model.VideoPageHistory),
+	}
+}
+
+// Save 新增或更新视频元数据。
+func (r *memoryVideoRepository) Save(ctx context.Context, video *model.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := r.videos[video.AID]; ok {
+		video.ID = existing.ID
+		video.GmtCreate = existing.GmtCreate
+	} else {
+		video.ID = r.nextID
+		video.GmtCreate = now
+		r.nextID++
+	}
+	video.GmtModified = now
+	stored := *video
+	r.videos[video.AID] = &stored
+	return nil
+}
+
+// FindByAID 根据 AID 查找视频。
+func (r *memoryVideoRepository) FindByAID(ctx context.Context, aid int64) (*model.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if v, ok := r.videos[aid]; ok {
+		c := *v
+		return &c, nil
+	}
+	return nil, repository.ErrVideoNotFound
+}
+
+// FindByBVID 根据 BVID 查找视频。
+func (r *memoryVideoRepository) FindByBVID(ctx context.Context, bvid string) (*model.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.videos {
+		if v.BVID == bvid {
+			c := *v
+			return &c, nil
+		}
+	}
+	return nil, repository.ErrVideoNotFound
+}
+
+// ListAll 获取目录中的所有视频，按 AID 升序排序。
+func (r *memoryVideoRepository) ListAll(ctx context.Context) ([]*model.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	videos := make([]*model.Video, 0, len(r.videos))
+	for _, v := range r.videos {
+		c := *v
+		videos = append(videos, &c)
+	}
+	sort.Slice(videos, func(i, j int) bool { return videos[i].AID < videos[j].AID })
+	return videos, nil
+}
+
+// SavePageList 保存一个新版本的分P列表。
+func (r *memoryVideoRepository) SavePageList(ctx context.Context, list *model.VideoPageList) error {
+	if len(list.Pages) == 0 {
+		return fmt.Errorf("page list for aid %d version %d is empty", list.AID, list.Version)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.pageLists[list.AID] {
+		if existing.Version == list.Version {
+			return fmt.Errorf("page list for aid %d version %d already exists", list.AID, list.Version)
+		}
+	}
+	stored := *list
+	stored.Pages = append([]model.VideoPage(nil), list.Pages...)
+	history := append(r.pageLists[list.AID], stored)
+	sort.Slice(history, func(i, j int) bool { return history[i].Version < history[j].Version })
+	r.pageLists[list.AID] = history
+	return nil
+}
+
+// ListPageHistory 获取视频所有版本的分P列表。
+func (r *memoryVideoRepository) ListPageHistory(ctx context.Context, aid int64) (model.VideoPageHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := make(model.VideoPageHistory, 0, len(r.pageLists[aid]))
+	for _, list := range r.pageLists[aid] {
+		c := list
+		c.Pages = append([]model.VideoPage(nil), list.Pages...)
+		history = append(history, c)
+	}
+	return history, nil
+}
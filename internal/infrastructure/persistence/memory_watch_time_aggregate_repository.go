@@ -0,0 +1,181 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// aggregateKey 聚合在内存中的唯一键。
+type aggregateKey struct {
+	aid         int64
+	cid         int64
+	bucketStart int64 // UnixNano，避免 time.Time 的时区信息影响比较
+}
+
+// aggregateSeconds 一条内存聚合的累计时长。
+type aggregateSeconds struct {
+	watched    int64
+	rewatched  int64
+	skipped    int64
+	continuous int64
+	playback   int64
+}
+
+// aggregateTable 一张内存聚合表 (小时或天)。
+type aggregateTable map[aggregateKey]aggregateSeconds
+
+// add 累加一条聚合。
+func (t aggregateTable) add(a model.WatchTimeAggregate) {
+	key := aggregateKey{aid: a.AID, cid: a.CID, bucketStart: a.BucketStart.UnixNano()}
+	sum := t[key]
+	sum.watched += a.WatchedSeconds
+	sum.rewatched += a.RewatchedSeconds
+	sum.skipped += a.SkippedSeconds
+	sum.continuous += a.ContinuousSeconds
+	sum.playback += a.PlaybackSeconds
+	t[key] = sum
+}
+
+// replace 删除 [start, end) 范围内的聚合并写入 rows。
+func (t aggregateTable) replace(aid int64, start, end time.Time, rows []model.WatchTimeAggregate) {
+	for k := range t {
+		if k.aid == aid && k.bucketStart >= start.UnixNano() && k.bucketStart < end.UnixNano() {
+			delete(t, k)
+		}
+	}
+	for _, row := range rows {
+		t.add(row)
+	}
+}
+
+// list 返回 [start, end) 范围内的聚合，按时间桶和分P升序排序。
+func (t aggregateTable) list(aid int64, start, end time.Time) []model.WatchTimeAggregate {
+	aggregates := make([]model.WatchTimeAggregate, 0)
+	for k, seconds := range t {
+		if k.aid != aid || k.bucketStart < start.UnixNano() || k.bucketStart >= end.UnixNano() {
+			continue
+		}
+		aggregates = append(aggregates, model.WatchTimeAggregate{
+			AID:               k.aid,
+			CID:               k.cid,
+			BucketStart:       time.Unix(0, k.bucketStart),
+			WatchedSeconds:    seconds.watched,
+			RewatchedSeconds:  seconds.rewatched,
+			SkippedSeconds:    seconds.skipped,
+			ContinuousSeconds: seconds.continuous,
+			PlaybackSeconds:   seconds.playback,
+		})
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if !aggregates[i].BucketStart.Equal(aggregates[j].BucketStart) {
+			return aggregates[i].BucketStart.Before(aggregates[j].BucketStart)
+		}
+		return aggregates[i].CID < aggregates[j].CID
+	})
+	return aggregates
+}
+
+// memoryWatchTimeAggregateRepository 是 WatchTimeAggregateRepository 的内存实现，供演示模式使用。
+type memoryWatchTimeAggregateRepository struct {
+	mu         sync.RWMutex
+	hourly     aggregateTable
+	daily      aggregateTable
+	watermarks map[int64]time.Time
+
+	locksMu sync.Mutex
+	locks   map[int64]*sync.Mutex // 每个视频的聚合锁
+}
+
+// NewMemoryWatchTimeAggregateRepository 创建一个新的内存 WatchTimeAggregateRepository 实例。
+func NewMemoryWatchTimeAggregateRepository() repository.WatchTimeAggregateRepository {
+	return &memoryWatchTimeAggregateRepository{
+		hourly:     make(aggregateTable),
+		daily:      make(aggregateTable),
+		watermarks: make(map[int64]time.Time),
+		locks:      make(map[int64]*sync.Mutex),
+	}
+}
+
+// LockVideo 获取视频聚合的排他锁 (进程内)。
+func (r *memoryWatchTimeAggregateRepository) LockVideo(ctx context.Context, aid int64) (func(), error) {
+	r.locksMu.Lock()
+	mu, ok := r.locks[aid]
+	if !ok {
+		mu = &sync.Mutex{}
+		r.locks[aid] = mu
+	}
+	r.locksMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock, nil
+}
+
+// AddWatchTime 把同一段观看时长累加到小时聚合和天聚合。
+func (r *memoryWatchTimeAggregateRepository) AddWatchTime(ctx context.Context, hourly, daily []model.WatchTimeAggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, row := range hourly {
+		r.hourly.add(row)
+	}
+	for _, row := range daily {
+		r.daily.add(row)
+	}
+	return nil
+}
+
+// ReplaceHourly 删除 [start, end) 范围内的小时聚合并写入 rows。
+func (r *memoryWatchTimeAggregateRepository) ReplaceHourly(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hourly.replace(aid, start, end, rows)
+	return nil
+}
+
+// ReplaceDaily 删除 [start, end) 范围内的天聚合并写入 rows。
+func (r *memoryWatchTimeAggregateRepository) ReplaceDaily(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.daily.replace(aid, start, end, rows)
+	return nil
+}
+
+// ListHourly 获取指定视频在 [start, end) 范围内的小时聚合。
+func (r *memoryWatchTimeAggregateRepository) ListHourly(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.hourly.list(aid, start, end), nil
+}
+
+// ListDaily 获取指定视频在 [start, end) 范围内的天聚合。
+func (r *memoryWatchTimeAggregateRepository) ListDaily(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.daily.list(aid, start, end), nil
+}
+
+// GetWatermark 获取视频的汇总水位线。
+func (r *memoryWatchTimeAggregateRepository) GetWatermark(ctx context.Context, aid int64) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.watermarks[aid], nil
+}
+
+// SetWatermark 更新视频的汇总水位线。
+func (r *memoryWatchTimeAggregateRepository) SetWatermark(ctx context.Context, aid int64, watermark time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.watermarks[aid] = watermark
+	return nil
+}
@@ -0,0 +1,145 @@
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// gormRawResponseRepository 是 RawResponseRepository 的 GORM 实现，响应体以 gzip 压缩后保存。
+type gormRawResponseRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRawResponseRepository 创建一个新的 GORM RawResponseRepository 实例。
+func NewGormRawResponseRepository(db *gorm.DB) repository.RawResponseRepository {
+	return &gormRawResponseRepository{db: db}
+}
+
+// rawResponseGorm 对应 raw_response 表。
+type rawResponseGorm struct {
+	ID        uint      `gorm:"primaryKey;comment:主键 ID"`
+	Kind      string    `gorm:"column:kind;type:varchar(16);index:idx_raw_response_aid_kind_fetched,priority:2;not null;default:'';comment:接口类型 (progress, view)"`
+	AID       int64     `gorm:"column:aid;index:idx_raw_response_aid_kind_fetched,priority:1;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	BVID      string    `gorm:"column:bvid;type:varchar(255);not null;default:'';comment:视频 BV 号"`
+	CID       int64     `gorm:"column:cid;not null;default:0;comment:请求的分P ID"`
+	FetchedAt time.Time `gorm:"column:fetched_at;type:datetime(3);index:idx_raw_response_aid_kind_fetched,priority:3;index;not null;comment:收到响应的时间"`
+	BodyGzip  []byte    `gorm:"column:body_gzip;type:mediumblob;not null;comment:gzip 压缩的响应体"`
+	GmtCreate time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (rawResponseGorm) TableName() string {
+	return "raw_response"
+}
+
+// toDomain 将 GORM 模型转换为领域模型，解压响应体。
+func (g *rawResponseGorm) toDomain() (*model.RawResponse, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(g.BodyGzip))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress raw response %d: %w", g.ID, err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress raw response %d: %w", g.ID, err)
+	}
+	return &model.RawResponse{
+		ID:        g.ID,
+		Kind:      model.RawResponseKind(g.Kind),
+		AID:       g.AID,
+		BVID:      g.BVID,
+		CID:       g.CID,
+		FetchedAt: g.FetchedAt,
+		Body:      body,
+		GmtCreate: g.GmtCreate,
+	}, nil
+}
+
+// rawResponseFromDomain 将领域模型转换为 GORM 模型，压缩响应体。
+func rawResponseFromDomain(d *model.RawResponse) (*rawResponseGorm, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(d.Body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &rawResponseGorm{
+		ID:        d.ID,
+		Kind:      string(d.Kind),
+		AID:       d.AID,
+		BVID:      d.BVID,
+		CID:       d.CID,
+		FetchedAt: d.FetchedAt,
+		BodyGzip:  buf.Bytes(),
+	}, nil
+}
+
+// Save 保存一条原始响应。
+func (r *gormRawResponseRepository) Save(ctx context.Context, response *model.RawResponse) error {
+	g, err := rawResponseFromDomain(response)
+	if err != nil {
+		return fmt.Errorf("failed to compress raw response: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Create(g).Error; err != nil {
+		log.Printf("Database error saving raw %s response for AID %d: %v", response.Kind, response.AID, err)
+		return fmt.Errorf("database error saving raw response: %w", err)
+	}
+	response.ID = g.ID
+	response.GmtCreate = g.GmtCreate
+	return nil
+}
+
+// Iterate 按 (fetched_at, id) 升序逐批读取满足条件的原始响应。
+func (r *gormRawResponseRepository) Iterate(ctx context.Context, filter repository.RawResponseFilter, fn func(response *model.RawResponse) error) error {
+	var lastFetchedAt time.Time
+	var lastID uint
+	for {
+		query := r.db.WithContext(ctx).Model(&rawResponseGorm{})
+		if filter.Kind != "" {
+			query = query.Where("kind = ?", string(filter.Kind))
+		}
+		if filter.AID != 0 {
+			query = query.Where("aid = ?", filter.AID)
+		}
+		if !filter.Start.IsZero() {
+			query = query.Where("fetched_at >= ?", filter.Start)
+		}
+		if !filter.End.IsZero() {
+			query = query.Where("fetched_at < ?", filter.End)
+		}
+		if lastID != 0 {
+			query = query.Where("fetched_at > ? OR (fetched_at = ? AND id > ?)", lastFetchedAt, lastFetchedAt, lastID)
+		}
+
+		var batch []rawResponseGorm
+		if err := query.Order("fetched_at ASC, id ASC").Limit(iterateBatchSize).Find(&batch).Error; err != nil {
+			log.Printf("Database error iterating raw responses (filter %+v): %v", filter, err)
+			return fmt.Errorf("database error iterating raw responses: %w", err)
+		}
+		for i := range batch {
+			response, err := batch[i].toDomain()
+			if err != nil {
+				return err
+			}
+			if err := fn(response); err != nil {
+				return err
+			}
+		}
+		if len(batch) < iterateBatchSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastFetchedAt, lastID = last.FetchedAt, last.ID
+	}
+}
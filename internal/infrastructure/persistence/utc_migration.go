@@ -0,0 +1,244 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// localTimesToUTCMigration 是 MigrateLocalTimesToUTC 在 schema_migration 表中的名称。
+const localTimesToUTCMigration = "local_times_to_utc"
+
+// ErrMigrationApplied 表示一次性迁移已经执行过。
+var ErrMigrationApplied = errors.New("migration already applied")
+
+// schemaMigrationGorm 对应 schema_migration 表，记录已执行的一次性数据迁移。
+type schemaMigrationGorm struct {
+	ID          uint      `gorm:"primaryKey;comment:主键 ID"`
+	Name        string    `gorm:"column:name;type:varchar(64);uniqueIndex:uk_schema_migration_name;not null;default:'';comment:迁移名称"`
+	AppliedAt   time.Time `gorm:"column:applied_at;type:datetime(3);not null;comment:执行时间"`
+	GmtCreate   time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (schemaMigrationGorm) TableName() string {
+	return "schema_migration"
+}
+
+// localTimeTable 旧版本按容器时区保存时间的一张表。
+type localTimeTable struct {
+	name    string
+	group   []string // 与 primary 组成唯一键的列，为空表示 primary 不在唯一键中
+	primary string   // 主要的时间列
+	others  []string // 其他时间列
+}
+
+// localTimeTables 是改为 UTC 存储之前就已存在的表，之后新增的表始终以 UTC 保存。
+var localTimeTables = []localTimeTable{
+	{name: "video_progress", group: []string{"aid"}, primary: "recorded_at", others: []string{"gmt_create", "gmt_modified"}},
+	{name: "video", primary: "refreshed_at", others: []string{"gmt_create", "gmt_modified"}},
+	{name: "video_page", primary: "effective_from", others: []string{"gmt_create", "gmt_modified"}},
+	{name: "watch_time_hourly", group: []string{"aid", "cid"}, primary: "hour_start", others: []string{"gmt_create", "gmt_modified"}},
+	{name: "watch_time_daily", group: []string{"aid", "cid"}, primary: "day_start", others: []string{"gmt_create", "gmt_modified"}},
+	{name: "raw_response", primary: "fetched_at", others: []string{"gmt_create"}},
+	{name: "progress_rollup_state", primary: "rolled_up_until", others: []string{"gmt_create", "gmt_modified"}},
+}
+
+// LocalTimeTableNames 返回 MigrateLocalTimesToUTC 转换的表名，按转换顺序排列。
+func LocalTimeTableNames() []string {
+	names := make([]string, len(localTimeTables))
+	for i, table := range localTimeTables {
+		names[i] = table.name
+	}
+	return names
+}
+
+// localTimeRow 一行的时间列 (times[0] 为 primary) 及其转换结果。
+type localTimeRow struct {
+	id        int64
+	group     []int64
+	times     []time.Time
+	converted []time.Time
+}
+
+// MigrateLocalTimesToUTC 把旧版本按 loc 当地时间保存的时间列转换为 UTC，用于从以容器时区存储的版本升级。
+//
+// 与 SQL 的 CONVERT_TZ 加固定偏移不同，每个时间按当时的偏移转换，夏令时前后的记录都能得到正确的 UTC 时间；
+// 夏令时回拨时重复的当地时间按同一视频 (分P) 内的记录顺序依次对应到较早和较晚的时刻。
+// 所有表在一个事务中转换，并在 schema_migration 表中记录，重复执行返回 ErrMigrationApplied。
+// dryRun 为 true 时只统计需要转换的行数，不写入。返回每张表转换的行数。
+func MigrateLocalTimesToUTC(ctx context.Context, db *gorm.DB, loc *time.Location, dryRun bool) (map[string]int64, error) {
+	counts := make(map[string]int64, len(localTimeTables))
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var applied int64
+		if err := tx.Model(&schemaMigrationGorm{}).Where("name = ?", localTimesToUTCMigration).Count(&applied).Error; err != nil {
+			return fmt.Errorf("failed to check migration state: %w", err)
+		}
+		if applied > 0 {
+			return ErrMigrationApplied
+		}
+		for _, table := range localTimeTables {
+			n, err := migrateLocalTimeTable(tx, table, loc, dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to convert %s: %w", table.name, err)
+			}
+			counts[table.name] = n
+		}
+		if dryRun {
+			return nil
+		}
+		return tx.Create(&schemaMigrationGorm{Name: localTimesToUTCMigration, AppliedAt: time.Now().UTC()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// migrateLocalTimeTable 转换一张表的时间列，返回转换的行数。
+func migrateLocalTimeTable(tx *gorm.DB, table localTimeTable, loc *time.Location, dryRun bool) (int64, error) {
+	if !tx.Migrator().HasTable(table.name) {
+		return 0, nil
+	}
+	rows, err := loadLocalTimeRows(tx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	// 同一分组内按写入顺序 (ID) 转换：夏令时回拨后的记录写入较晚，即使当地时间较早也对应到较晚的时刻
+	sort.Slice(rows, func(i, j int) bool {
+		if c := compareGroups(rows[i].group, rows[j].group); c != 0 {
+			return c < 0
+		}
+		return rows[i].id < rows[j].id
+	})
+	earlier, later := false, false
+	for i := range rows {
+		row := &rows[i]
+		row.converted = make([]time.Time, len(row.times))
+		for k, t := range row.times {
+			var after time.Time
+			if k == 0 && len(table.group) > 0 && i > 0 && compareGroups(rows[i-1].group, row.group) == 0 {
+				after = rows[i-1].converted[0]
+			}
+			row.converted[k] = localToUTC(t, loc, after)
+		}
+		earlier = earlier || row.converted[0].Before(row.times[0])
+		later = later || row.converted[0].After(row.times[0])
+	}
+	if dryRun || len(rows) == 0 {
+		return int64(len(rows)), nil
+	}
+
+	// 唯一键中的时间整体向前 (或向后) 移动时，按转换后的时间沿同一方向的顺序更新，避免与尚未转换的行暂时冲突
+	if len(table.group) > 0 {
+		if earlier && later {
+			return 0, fmt.Errorf("timezone %s has both positive and negative UTC offsets, cannot convert %s in place", loc, table.primary)
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if later {
+				return rows[i].converted[0].After(rows[j].converted[0])
+			}
+			return rows[i].converted[0].Before(rows[j].converted[0])
+		})
+	}
+	columns := append([]string{table.primary}, table.others...)
+	for _, row := range rows {
+		updates := make(map[string]any, len(columns))
+		for k, column := range columns {
+			updates[column] = row.converted[k]
+		}
+		if err := tx.Table(table.name).Where("id = ?", row.id).UpdateColumns(updates).Error; err != nil {
+			return 0, err
+		}
+	}
+	log.Printf("Migration: converted %d rows of %s from %s to UTC", len(rows), table.name, loc)
+	return int64(len(rows)), nil
+}
+
+// loadLocalTimeRows 读取一张表所有行的 ID、分组列和时间列。时间按 UTC 读出，即保存的当地时间本身。
+func loadLocalTimeRows(tx *gorm.DB, table localTimeTable) ([]localTimeRow, error) {
+	columns := append(append([]string{"id"}, table.group...), table.primary)
+	columns = append(columns, table.others...)
+	cursor, err := tx.Table(table.name).Select(strings.Join(columns, ", ")).Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var rows []localTimeRow
+	for cursor.Next() {
+		row := localTimeRow{group: make([]int64, len(table.group)), times: make([]time.Time, 1+len(table.others))}
+		dest := []any{&row.id}
+		for k := range row.group {
+			dest = append(dest, &row.group[k])
+		}
+		for k := range row.times {
+			dest = append(dest, &row.times[k])
+		}
+		if err := cursor.Scan(dest...); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, cursor.Err()
+}
+
+// compareGroups 按字典序比较两个分组键。
+func compareGroups(a, b []int64) int {
+	for k := range a {
+		if a[k] != b[k] {
+			if a[k] < b[k] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// localToUTC 把以 UTC 表示的 loc 当地时间 wall (年月日时分秒即当地时间) 转换为实际的时刻。
+// 夏令时回拨时同一当地时间对应两个时刻：after 非零时选择晚于 after 的最早一个 (都不晚于 after 时选较晚的一个)，
+// 否则选择较早的一个。落在夏令时跳过的时段中的当地时间 (正常写入不会出现) 按跳过之前的偏移转换。
+func localToUTC(wall time.Time, loc *time.Location, after time.Time) time.Time {
+	var candidates []time.Time
+	for _, probe := range []time.Time{wall.Add(-24 * time.Hour), wall.Add(24 * time.Hour)} {
+		_, offset := probe.In(loc).Zone()
+		t := wall.Add(-time.Duration(offset) * time.Second)
+		if !sameWallClock(t.In(loc), wall) {
+			continue
+		}
+		if len(candidates) == 0 || !candidates[0].Equal(t) {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		_, offset := wall.Add(-24 * time.Hour).In(loc).Zone()
+		return wall.Add(-time.Duration(offset) * time.Second).UTC()
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	if after.IsZero() {
+		return candidates[0].UTC()
+	}
+	for _, t := range candidates {
+		if t.After(after) {
+			return t.UTC()
+		}
+	}
+	return candidates[len(candidates)-1].UTC()
+}
+
+// sameWallClock 判断 local 的当地日期和时刻是否与 wall 的 (UTC 表示的) 日期和时刻相同。
+func sameWallClock(local, wall time.Time) bool {
+	y1, m1, d1 := local.Date()
+	y2, m2, d2 := wall.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 && local.Hour() == wall.Hour() && local.Minute() == wall.Minute() &&
+		local.Second() == wall.Second() && local.Nanosecond() == wall.Nanosecond()
+}
@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+)
+
+func TestLocalToUTC(t *testing.T) {
+	tests := []struct {
+		name  string
+		zone  string
+		wall  string // 保存的当地时间 (以 UTC 表示)
+		after string // 同一分组内上一条记录转换后的时间，为空表示没有
+		want  string
+	}{
+		{"new york standard time", "America/New_York", "2025-01-15T10:00:00Z", "", "2025-01-15T15:00:00Z"},
+		{"new york daylight time", "America/New_York", "2025-07-15T10:00:00Z", "", "2025-07-15T14:00:00Z"},
+		{"new york before spring forward", "America/New_York", "2025-03-09T01:59:00Z", "", "2025-03-09T06:59:00Z"},
+		{"new york after spring forward", "America/New_York", "2025-03-09T03:00:00Z", "", "2025-03-09T07:00:00Z"},
+		{"new york skipped hour", "America/New_York", "2025-03-09T02:30:00Z", "", "2025-03-09T07:30:00Z"},
+		{"new york repeated hour first pass", "America/New_York", "2025-11-02T01:30:00Z", "2025-11-02T05:20:00Z", "2025-11-02T05:30:00Z"},
+		{"new york repeated hour second pass", "America/New_York", "2025-11-02T01:10:00Z", "2025-11-02T05:50:00Z", "2025-11-02T06:10:00Z"},
+		{"new york repeated hour without previous record", "America/New_York", "2025-11-02T01:10:00Z", "", "2025-11-02T05:10:00Z"},
+		{"new york after repeated hour", "America/New_York", "2025-11-02T02:00:00Z", "2025-11-02T06:50:00Z", "2025-11-02T07:00:00Z"},
+		{"berlin winter", "Europe/Berlin", "2025-01-15T10:00:00Z", "", "2025-01-15T09:00:00Z"},
+		{"berlin summer", "Europe/Berlin", "2025-07-15T10:00:00Z", "", "2025-07-15T08:00:00Z"},
+		{"berlin skipped hour", "Europe/Berlin", "2025-03-30T02:30:00Z", "", "2025-03-30T01:30:00Z"},
+		{"berlin after spring forward", "Europe/Berlin", "2025-03-30T03:00:00Z", "", "2025-03-30T01:00:00Z"},
+		{"berlin repeated hour first pass", "Europe/Berlin", "2025-10-26T02:30:00Z", "2025-10-26T00:20:00Z", "2025-10-26T00:30:00Z"},
+		{"berlin repeated hour second pass", "Europe/Berlin", "2025-10-26T02:10:00Z", "2025-10-26T00:50:00Z", "2025-10-26T01:10:00Z"},
+		{"berlin previous record later than both candidates", "Europe/Berlin", "2025-10-26T02:10:00Z", "2025-10-26T02:00:00Z", "2025-10-26T01:10:00Z"},
+		{"kolkata half hour offset", "Asia/Kolkata", "2025-01-01T00:15:00Z", "", "2024-12-31T18:45:00Z"},
+		{"adelaide daylight time", "Australia/Adelaide", "2025-01-15T10:00:00Z", "", "2025-01-14T23:30:00Z"},
+		{"adelaide repeated hour second pass", "Australia/Adelaide", "2025-04-06T02:10:00Z", "2025-04-05T16:50:00Z", "2025-04-05T16:40:00Z"},
+		{"adelaide repeated hour first pass", "Australia/Adelaide", "2025-04-06T02:10:00Z", "", "2025-04-05T15:40:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var after time.Time
+			if tt.after != "" {
+				after = mustParse(t, tt.after)
+			}
+			got := localToUTC(mustParse(t, tt.wall), loc, after)
+			if want := mustParse(t, tt.want); !got.Equal(want) {
+				t.Errorf("localToUTC(%s, %s) = %s, want %s", tt.wall, tt.zone, got, want)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("localToUTC returned location %s, want UTC", got.Location())
+			}
+		})
+	}
+}
+
+// mustParse 解析 RFC3339 时间，失败时终止测试。
+func mustParse(t testing.TB, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("parse time %s: %v", value, err)
+	}
+	return parsed
+}
+
+func TestMigrateLocalTimesToUTC(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本按纽约当地时间保存：每小时一条 (转换后与未转换的当地时间重叠)，以及夏令时回拨前后各一遍的 01:xx
+	walls := []string{
+		"2025-01-15T00:00:00Z", "2025-01-15T01:00:00Z", "2025-01-15T02:00:00Z", "2025-01-15T03:00:00Z",
+		"2025-01-15T04:00:00Z", "2025-01-15T05:00:00Z", "2025-01-15T06:00:00Z",
+		"2025-11-02T01:20:00Z", "2025-11-02T01:40:00Z", "2025-11-02T01:10:00Z", "2025-11-02T01:30:00Z", "2025-11-02T02:00:00Z",
+	}
+	want := []string{
+		"2025-01-15T05:00:00Z", "2025-01-15T06:00:00Z", "2025-01-15T07:00:00Z", "2025-01-15T08:00:00Z",
+		"2025-01-15T09:00:00Z", "2025-01-15T10:00:00Z", "2025-01-15T11:00:00Z",
+		"2025-11-02T05:20:00Z", "2025-11-02T05:40:00Z", "2025-11-02T06:10:00Z", "2025-11-02T06:30:00Z", "2025-11-02T07:00:00Z",
+	}
+	for _, wall := range walls {
+		recordedAt := mustParse(t, wall)
+		if err := db.Create(&model.VideoProgress{AID: 1, BVID: "BV1", LastPlayCID: 10, RecordedAt: recordedAt}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if recordedAt.Minute() == 0 {
+			if err := db.Create(&watchTimeHourlyGorm{AID: 1, CID: 10, HourStart: recordedAt, WatchedSeconds: 60}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	counts, err := MigrateLocalTimesToUTC(ctx, db, newYork, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["video_progress"] != int64(len(walls)) {
+		t.Errorf("dry run counted %d video_progress rows, want %d", counts["video_progress"], len(walls))
+	}
+	var unchanged model.VideoProgress
+	if err := db.Order("id").First(&unchanged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !unchanged.RecordedAt.Equal(mustParse(t, walls[0])) {
+		t.Fatalf("dry run changed recorded_at to %s", unchanged.RecordedAt)
+	}
+
+	if _, err := MigrateLocalTimesToUTC(ctx, db, newYork, false); err != nil {
+		t.Fatal(err)
+	}
+	var progress []model.VideoProgress
+	if err := db.Order("id").Find(&progress).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range progress {
+		if w := mustParse(t, want[i]); !p.RecordedAt.Equal(w) {
+			t.Errorf("record %d: recorded_at = %s, want %s", i, p.RecordedAt, w)
+		}
+	}
+	var hourly []watchTimeHourlyGorm
+	if err := db.Order("hour_start").Find(&hourly).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 8 || !hourly[0].HourStart.Equal(mustParse(t, "2025-01-15T05:00:00Z")) ||
+		!hourly[7].HourStart.Equal(mustParse(t, "2025-11-02T07:00:00Z")) {
+		t.Errorf("hourly buckets after migration: %+v", hourly)
+	}
+
+	if _, err := MigrateLocalTimesToUTC(ctx, db, newYork, false); !errors.Is(err, ErrMigrationApplied) {
+		t.Errorf("second migration error = %v, want ErrMigrationApplied", err)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
 	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
@@ -177,3 +180,362 @@ func (r *gormVideoProgressRepository) ListByBVIDAndTimestampRange(ctx context.Co
 
 	return domainProgresses, nil
 }
+
+// GetLatestByAIDBefore 获取指定 AID 在 before 之前 (不含) 的最新一条进度记录。
+func (r *gormVideoProgressRepository) GetLatestByAIDBefore(ctx context.Context, aid int64, before time.Time) (*model.VideoProgress, error) {
+	var progress model.VideoProgress
+	err := r.db.WithContext(ctx).
+		Where("aid = ? AND recorded_at < ?", aid, before).
+		Order("recorded_at DESC, id DESC").
+		First(&progress).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Database error finding latest progress for AID %d before %s: %v", aid, before, err)
+		return nil, fmt.Errorf("database error finding latest progress before time: %w", err)
+	}
+	return &progress, nil
+}
+
+// ListDistinctAIDs 获取所有存在进度记录的 AID。
+func (r *gormVideoProgressRepository) ListDistinctAIDs(ctx context.Context) ([]int64, error) {
+	var aids []int64
+	err := r.db.WithContext(ctx).
+		Model(&model.VideoProgress{}).
+		Distinct("aid").
+		Order("aid ASC").
+		Pluck("aid", &aids).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error listing distinct aids: %w", err)
+	}
+	return aids, nil
+}
+
+// DeleteByAIDBefore 删除指定 AID 在 before 之前 (不含) 的所有进度记录。
+func (r *gormVideoProgressRepository) DeleteByAIDBefore(ctx context.Context, aid int64, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("aid = ? AND recorded_at < ?", aid, before).
+		Delete(&model.VideoProgress{})
+	if result.Error != nil {
+		log.Printf("Database error deleting progress for AID %d before %s: %v", aid, before, result.Error)
+		return 0, fmt.Errorf("database error deleting progress: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// iterateBatchSize 是 Iterate 每次从数据库读取的记录数。
+const iterateBatchSize = 1000
+
+// Iterate 按 (recorded_at, id) 键集分页逐批读取满足条件的进度记录并逐条回调。
+func (r *gormVideoProgressRepository) Iterate(ctx context.Context, filter repository.ProgressFilter, fn func(progress *model.VideoProgress) error) error {
+	page := repository.ProgressPageRequest{Limit: iterateBatchSize}
+	for {
+		batch, err := r.ListPage(ctx, filter, page)
+		if err != nil {
+			return err
+		}
+		for _, p := range batch {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if len(batch) < iterateBatchSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		page.After = &repository.ProgressCursor{RecordedAt: last.RecordedAt, ID: last.ID}
+	}
+}
+
+// ListPage 按 (recorded_at, id) 键集分页读取一页进度记录。
+// 按 aid (及 last_play_cid) 过滤时可使用 idx_video_progress_aid_cid_recorded_at 索引顺序扫描。
+func (r *gormVideoProgressRepository) ListPage(ctx context.Context, filter repository.ProgressFilter, page repository.ProgressPageRequest) ([]*model.VideoProgress, error) {
+	query := r.db.WithContext(ctx).Model(&model.VideoProgress{})
+	if filter.AID != 0 {
+		query = query.Where("aid = ?", filter.AID)
+	}
+	if filter.CID != 0 {
+		query = query.Where("last_play_cid = ?", filter.CID)
+	}
+	if !filter.Start.IsZero() {
+		query = query.Where("recorded_at >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		query = query.Where("recorded_at < ?", filter.End)
+	}
+	if filter.PairLabel != "" {
+		query = query.Where("pair_label = ?", filter.PairLabel)
+	}
+	order := "recorded_at ASC, id ASC"
+	if page.Descending {
+		order = "recorded_at DESC, id DESC"
+	}
+	if after := page.After; after != nil {
+		if page.Descending {
+			query = query.Where("recorded_at < ? OR (recorded_at = ? AND id < ?)", after.RecordedAt, after.RecordedAt, after.ID)
+		} else {
+			query = query.Where("recorded_at > ? OR (recorded_at = ? AND id > ?)", after.RecordedAt, after.RecordedAt, after.ID)
+		}
+	}
+
+	var records []*model.VideoProgress
+	if err := query.Order(order).Limit(page.Limit).Find(&records).Error; err != nil {
+		log.Printf("Database error listing video progress page (filter %+v): %v", filter, err)
+		return nil, fmt.Errorf("database error listing progress page: %w", err)
+	}
+	return records, nil
+}
+
+// pairDeltasCTE 用 LAG 窗口函数把每条记录与前一条记录配对，并按记录对起点计算桶序号、进度差 (秒)、经过时间 (微秒)
+// 和终点相对分桶原点的偏移 (微秒)；同时计算终点分P的高水位线 (秒)：范围内之前的记录与 reached_before 中的较大值。
+// is_summed 标记参与求和的记录对，run 为之前逐条返回的记录对数量。
+// %s 为 reached_before 的查询 (见 reachedBeforeSQL)，其参数在最前面；之后的参数依次为：
+// 是否计入可疑记录、aid、from、aid、to、to (记录范围截止到 to 之后的第一条记录，使最后一个记录对完整)、
+// 分桶原点、桶宽 (微秒)、分桶原点、to、是否计入可疑记录、是否拆分、桶宽 (微秒)、to。
+const pairDeltasCTE = `
+WITH reached_before (reached_cid, reached_position) AS (%s),
+pairs AS (
+	SELECT
+		LAG(id) OVER w AS prev_id,
+		LAG(recorded_at) OVER w AS prev_recorded_at,
+		LAG(last_play_cid) OVER w AS prev_cid,
+		LAG(last_play_time) OVER w AS prev_play_time,
+		LAG(pair_label) OVER w AS prev_label,
+		MAX(CASE WHEN ? OR pair_label <> 'suspicious' THEN last_play_time END) OVER (
+			PARTITION BY last_play_cid ORDER BY recorded_at, id ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+		) AS reached_in_range,
+		id, aid, bvid, recorded_at, last_play_cid AS cid, last_play_time AS play_time, pair_label
+	FROM video_progress
+	WHERE aid = ? AND recorded_at >= ?
+		AND recorded_at <= COALESCE((SELECT MIN(recorded_at) FROM video_progress WHERE aid = ? AND recorded_at >= ?), ?)
+	WINDOW w AS (ORDER BY recorded_at, id)
+), bucketed AS (
+	SELECT pairs.*,
+		CAST(FLOOR(TIMESTAMPDIFF(MICROSECOND, ?, prev_recorded_at) / ?) AS SIGNED) AS bucket,
+		(prev_cid = cid AND play_time >= prev_play_time) AS is_forward,
+		play_time DIV 1000 - prev_play_time DIV 1000 AS delta,
+		GREATEST(COALESCE(reached_in_range DIV 1000, 0), COALESCE(reached_position, 0)) AS reached,
+		TIMESTAMPDIFF(MICROSECOND, prev_recorded_at, recorded_at) AS elapsed_us,
+		TIMESTAMPDIFF(MICROSECOND, ?, recorded_at) AS end_offset_us
+	FROM pairs LEFT JOIN reached_before ON reached_cid = cid
+	WHERE prev_recorded_at IS NOT NULL AND prev_recorded_at < ? AND (? OR pair_label <> 'suspicious')
+), classified AS (
+	SELECT bucketed.*,
+		(is_forward = 1 AND NOT (? AND (end_offset_us > (bucket + 1) * ? OR recorded_at > ?))) AS is_summed
+	FROM bucketed
+), runs AS (
+	SELECT classified.*,
+		SUM(1 - is_summed) OVER (ORDER BY prev_recorded_at, prev_id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS run
+	FROM classified
+)`
+
+// reachedBeforeSQL 把 from 之前的高水位线 (按 CID 排序) 写成 reached_before 的查询，没有时返回一行不影响结果的 (0, 0)。
+func reachedBeforeSQL(reached map[int64]int64) (string, []interface{}) {
+	if len(reached) == 0 {
+		return "SELECT 0, 0", nil
+	}
+	cids := make([]int64, 0, len(reached))
+	for cid := range reached {
+		cids = append(cids, cid)
+	}
+	sort.Slice(cids, func(i, j int) bool { return cids[i] < cids[j] })
+	selects := make([]string, 0, len(cids))
+	args := make([]interface{}, 0, 2*len(cids))
+	for _, cid := range cids {
+		selects = append(selects, "SELECT ?, ?")
+		args = append(args, cid, reached[cid])
+	}
+	return strings.Join(selects, " UNION ALL "), args
+}
+
+// progressDeltaSumRow 对应同一分P内向前推进的记录对的合计行。
+type progressDeltaSumRow struct {
+	Bucket            int64
+	CID               int64
+	Run               int64
+	Seconds           int64
+	RewatchedSeconds  int64
+	SkippedSeconds    int64
+	ContinuousSeconds int64
+	PlaybackSeconds   int64
+	MaxPosition       int64
+	Pairs             int64
+	FirstStartedAt    time.Time
+	LastEndedAt       time.Time
+}
+
+// progressPairRow 对应逐条返回的记录对。
+type progressPairRow struct {
+	Bucket         int64
+	PrevID         uint
+	PrevRecordedAt time.Time
+	PrevCID        int64
+	PrevPlayTime   int64
+	PrevLabel      model.PairLabel
+	ID             uint
+	AID            int64
+	BVID           string
+	RecordedAt     time.Time
+	CID            int64
+	PlayTime       int64
+	PairLabel      model.PairLabel
+}
+
+// ListPairDeltas 用窗口函数在数据库中配对相邻记录并分桶 (需要 MySQL 8)。
+// 同一分P内向前推进的记录对直接在 SQL 中求和 (进度按毫秒截断为秒后相减，与 WatchTimeCalculator 一致；
+// 每对的上限、跳过和播放时间与 model.LimitPairSeconds 一致，重看与 model.FreshSeconds 一致)，
+// 只有跨分P或后退的记录对 (bucketing.Split 时还有终点超出起点所在桶或超出 to 的记录对) 会逐条返回，
+// 传输和计算量与记录数无关，只与桶数和跨分P次数有关。
+// 可疑记录对在配对之后排除，因此不会把可疑记录前后的两条记录错误地配成一对。
+func (r *gormVideoProgressRepository) ListPairDeltas(ctx context.Context, aid int64, from, to time.Time, bucketing model.PairBucketing) (*model.PairDeltas, error) {
+	reached, err := r.ReachedPositions(ctx, aid, from, bucketing.IncludeSuspicious)
+	if err != nil {
+		return nil, err
+	}
+	reachedSQL, reachedArgs := reachedBeforeSQL(reached)
+	cte := fmt.Sprintf(pairDeltasCTE, reachedSQL)
+	width := bucketing.Width.Microseconds()
+	args := append(append([]interface{}{}, reachedArgs...), bucketing.IncludeSuspicious, aid, from, aid, to, to,
+		bucketing.Origin, width, bucketing.Origin, to, bucketing.IncludeSuspicious, bucketing.Split, width, to)
+	db := r.db.WithContext(ctx)
+
+	var sumRows []progressDeltaSumRow
+	sumArgs := append(append([]interface{}{}, args...), bucketing.MaxSpeed, bucketing.MaxSpeed)
+	err = db.Raw(cte+`
+SELECT bucket, cid, run,
+	CAST(SUM(counted) AS SIGNED) AS seconds,
+	CAST(SUM(counted - LEAST(counted, GREATEST(play_time DIV 1000 - reached, 0))) AS SIGNED) AS rewatched_seconds,
+	CAST(SUM(delta - counted) AS SIGNED) AS skipped_seconds,
+	CAST(SUM(CASE WHEN elapsed_us DIV 1000000 > 0 AND counted >= elapsed_us DIV 1000000 THEN counted ELSE 0 END) AS SIGNED) AS continuous_seconds,
+	CAST(SUM(CASE WHEN elapsed_us DIV 1000000 > 0 AND counted >= elapsed_us DIV 1000000 THEN elapsed_us DIV 1000000 ELSE 0 END) AS SIGNED) AS playback_seconds,
+	MAX(play_time DIV 1000) AS max_position,
+	COUNT(*) AS pairs,
+	MIN(prev_recorded_at) AS first_started_at,
+	MAX(recorded_at) AS last_ended_at
+FROM (
+	SELECT runs.*,
+		CASE WHEN ? > 0 THEN LEAST(delta, CAST(FLOOR(elapsed_us * ? / 1000000) AS SIGNED)) ELSE delta END AS counted
+	FROM runs
+	WHERE is_summed = 1
+) AS forward
+GROUP BY bucket, cid, run
+ORDER BY bucket, cid, run`, sumArgs...).Scan(&sumRows).Error
+	if err != nil {
+		log.Printf("Database error summing progress deltas for AID %d in [%s, %s): %v", aid, from, to, err)
+		return nil, fmt.Errorf("database error summing progress deltas: %w", err)
+	}
+
+	var pairRows []progressPairRow
+	err = db.Raw(cte+`
+SELECT bucket, prev_id, prev_recorded_at, prev_cid, prev_play_time, prev_label, id, aid, bvid, recorded_at, cid, play_time, pair_label
+FROM classified
+WHERE is_summed = 0
+ORDER BY prev_recorded_at, prev_id`, args...).Scan(&pairRows).Error
+	if err != nil {
+		log.Printf("Database error listing progress pairs for AID %d in [%s, %s): %v", aid, from, to, err)
+		return nil, fmt.Errorf("database error listing progress pairs: %w", err)
+	}
+
+	deltas := &model.PairDeltas{
+		Sums:    make([]model.ProgressDeltaSum, 0, len(sumRows)),
+		Pairs:   make([]model.ProgressPair, 0, len(pairRows)),
+		Reached: reached,
+	}
+	for _, row := range sumRows {
+		deltas.Sums = append(deltas.Sums, model.ProgressDeltaSum(row))
+	}
+	for _, row := range pairRows {
+		deltas.Pairs = append(deltas.Pairs, model.ProgressPair{
+			Bucket: row.Bucket,
+			Curr: model.VideoProgress{ID: row.PrevID, AID: row.AID, BVID: row.BVID,
+				LastPlayCID: row.PrevCID, LastPlayTime: row.PrevPlayTime, RecordedAt: row.PrevRecordedAt, PairLabel: row.PrevLabel},
+			Next: model.VideoProgress{ID: row.ID, AID: row.AID, BVID: row.BVID,
+				LastPlayCID: row.CID, LastPlayTime: row.PlayTime, RecordedAt: row.RecordedAt, PairLabel: row.PairLabel},
+		})
+	}
+	return deltas, nil
+}
+
+// reachedPositionRow 对应一个分P的高水位线。
+type reachedPositionRow struct {
+	CID      int64
+	Position int64
+}
+
+// ReachedPositions 按分P取 before 之前记录的最大进度 (毫秒截断为秒)，使用 (aid, recorded_at) 索引。
+func (r *gormVideoProgressRepository) ReachedPositions(ctx context.Context, aid int64, before time.Time, includeSuspicious bool) (map[int64]int64, error) {
+	var rows []reachedPositionRow
+	err := r.db.WithContext(ctx).Raw(`
+SELECT last_play_cid AS cid, MAX(last_play_time) DIV 1000 AS position
+FROM video_progress
+WHERE aid = ? AND recorded_at < ? AND (? OR pair_label <> 'suspicious')
+GROUP BY last_play_cid`, aid, before, includeSuspicious).Scan(&rows).Error
+	if err != nil {
+		log.Printf("Database error getting reached positions for AID %d before %s: %v", aid, before, err)
+		return nil, fmt.Errorf("database error getting reached positions: %w", err)
+	}
+	reached := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		reached[row.CID] = row.Position
+	}
+	return reached, nil
+}
+
+// InsertIgnoreDuplicates 批量插入进度记录，依赖 (aid, recorded_at) 唯一索引跳过已存在的记录。
+func (r *gormVideoProgressRepository) InsertIgnoreDuplicates(ctx context.Context, records []*model.VideoProgress) (int64, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(records, 500)
+	if result.Error != nil {
+		log.Printf("Database error importing %d video progress records: %v", len(records), result.Error)
+		return 0, fmt.Errorf("database error importing progress: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// UpsertBatch 批量写入进度记录，依赖 (aid, recorded_at) 唯一索引，冲突时覆盖 bvid、分P和播放进度。
+func (r *gormVideoProgressRepository) UpsertBatch(ctx context.Context, records []*model.VideoProgress) error {
+	if len(records) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"bvid", "last_play_cid", "last_play_time", "gmt_modified"}),
+		}).
+		CreateInBatches(records, 500).Error
+	if err != nil {
+		log.Printf("Database error upserting %d video progress records: %v", len(records), err)
+		return fmt.Errorf("database error upserting progress: %w", err)
+	}
+	return nil
+}
+
+// UpdatePairLabels 按分类分组批量修改记录的记录对分类，所有修改在同一事务中完成。
+func (r *gormVideoProgressRepository) UpdatePairLabels(ctx context.Context, updates []repository.PairLabelUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	idsByLabel := make(map[model.PairLabel][]uint)
+	for _, u := range updates {
+		idsByLabel[u.Label] = append(idsByLabel[u.Label], u.ID)
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for label, ids := range idsByLabel {
+			err := tx.Model(&model.VideoProgress{}).Where("id IN ?", ids).
+				UpdateColumns(map[string]interface{}{"pair_label": label, "gmt_modified": time.Now().UTC()}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Database error updating %d pair labels: %v", len(updates), err)
+		return fmt.Errorf("database error updating pair labels: %w", err)
+	}
+	return nil
+}
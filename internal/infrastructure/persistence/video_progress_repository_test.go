@@ -0,0 +1,341 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+)
+
+const fixtureAID = 42
+
+// fixturePages 是 pairDeltasFixture 使用的分P列表。
+var fixturePages = []model.VideoPage{
+	{Cid: 101, Duration: 200000, Page: 1},
+	{Cid: 102, Duration: 200000, Page: 2},
+	{Cid: 103, Duration: 200000, Page: 3},
+}
+
+// pairDeltasFixture 生成 n 条大约每 10 分钟一条的进度记录 (毫秒精度，与 datetime(3) 一致)：
+// 大多数同一分P内向前推进 (部分超过 1 倍速)，夹杂超出 2 倍速上限的跳转、后退、切换分P、长时间空闲和可疑记录。
+func pairDeltasFixture(start time.Time, n int) []*model.VideoProgress {
+	rng := rand.New(rand.NewSource(1))
+	records := make([]*model.VideoProgress, 0, n)
+	at, part, position := start, 0, int64(0)
+	for i := 0; i < n; i++ {
+		at = at.Add(10*time.Minute + time.Duration(rng.Intn(120000)-60000)*time.Millisecond)
+		label := model.PairLabelForward
+		switch r := rng.Intn(20); {
+		case i == 0:
+			label = model.PairLabelNone
+		case r == 0:
+			part = (part + 1) % len(fixturePages)
+			position = rng.Int63n(300000)
+			label = model.PairLabelPartSwitch
+		case r == 1:
+			position -= min(position, rng.Int63n(600000))
+			label = model.PairLabelSeekBack
+		case r == 2:
+			position += 1500000 + rng.Int63n(600000)
+		case r == 3:
+			at = at.Add(3*time.Hour + time.Duration(rng.Intn(3600000))*time.Millisecond)
+			position += rng.Int63n(700000)
+		default:
+			position += rng.Int63n(700000)
+		}
+		if i > 0 && rng.Intn(25) == 0 {
+			label = model.PairLabelSuspicious
+		}
+		records = append(records, &model.VideoProgress{
+			AID:          fixtureAID,
+			BVID:         "BVfixture",
+			LastPlayCID:  fixturePages[part].Cid,
+			LastPlayTime: position,
+			RecordedAt:   at,
+			PairLabel:    label,
+		})
+	}
+	return records
+}
+
+// seedFixture 把记录按顺序写入仓库，两个仓库中的 ID 因此相同。
+func seedFixture(t testing.TB, repo repository.VideoProgressRepository, records []*model.VideoProgress) {
+	t.Helper()
+	copies := make([]*model.VideoProgress, len(records))
+	for i, p := range records {
+		clone := *p
+		copies[i] = &clone
+	}
+	if _, err := repo.InsertIgnoreDuplicates(context.Background(), copies); err != nil {
+		t.Fatalf("seed fixture: %v", err)
+	}
+}
+
+// pairDeltasCase 一组 ListPairDeltas 参数。
+type pairDeltasCase struct {
+	name      string
+	from, to  time.Time
+	bucketing model.PairBucketing
+}
+
+// pairDeltasCases 覆盖倍速上限、可疑记录对、Split 和分桶原点晚于 from (负的桶序号)。
+func pairDeltasCases(start, end time.Time) []pairDeltasCase {
+	middle := start.Add(end.Sub(start) / 2).Truncate(time.Millisecond)
+	return []pairDeltasCase{
+		{"hourly capped", start, end, model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: 2}},
+		{"hourly uncapped", start, end, model.PairBucketing{Origin: start, Width: time.Hour}},
+		{"hourly capped with suspicious", start, end, model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: 2, IncludeSuspicious: true}},
+		{"daily split up to middle", start, middle, model.PairBucketing{Origin: start, Width: 24 * time.Hour, MaxSpeed: 2, Split: true}},
+		{"quarter hours split from middle with earlier records", middle, end,
+			model.PairBucketing{Origin: middle.Add(90 * time.Minute), Width: 15 * time.Minute, MaxSpeed: 1.5, Split: true, IncludeSuspicious: true}},
+	}
+}
+
+func TestListPairDeltasSQLMatchesMemory(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	records := pairDeltasFixture(start, 2000)
+	end := records[len(records)-1].RecordedAt.Add(time.Hour)
+
+	sqlRepo := NewGormVideoProgressRepository(db)
+	memoryRepo := NewMemoryVideoProgressRepository()
+	seedFixture(t, sqlRepo, records)
+	seedFixture(t, memoryRepo, records)
+
+	for _, tc := range pairDeltasCases(start, end) {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := memoryRepo.ListPairDeltas(ctx, fixtureAID, tc.from, tc.to, tc.bucketing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := sqlRepo.ListPairDeltas(ctx, fixtureAID, tc.from, tc.to, tc.bucketing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(want.Sums) == 0 || len(want.Pairs) == 0 {
+				t.Fatalf("fixture produced %d sums and %d pairs, want both", len(want.Sums), len(want.Pairs))
+			}
+			comparePairDeltas(t, got, want)
+		})
+	}
+}
+
+// comparePairDeltas 逐项比较两个实现的结果。
+func comparePairDeltas(t *testing.T, got, want *model.PairDeltas) {
+	t.Helper()
+	if len(got.Sums) != len(want.Sums) {
+		t.Fatalf("got %d sums, want %d", len(got.Sums), len(want.Sums))
+	}
+	for i := range want.Sums {
+		g, w := got.Sums[i], want.Sums[i]
+		if g.Bucket != w.Bucket || g.CID != w.CID || g.Run != w.Run || g.Seconds != w.Seconds ||
+			g.RewatchedSeconds != w.RewatchedSeconds || g.SkippedSeconds != w.SkippedSeconds ||
+			g.ContinuousSeconds != w.ContinuousSeconds || g.PlaybackSeconds != w.PlaybackSeconds || g.MaxPosition != w.MaxPosition || g.Pairs != w.Pairs ||
+			!g.FirstStartedAt.Equal(w.FirstStartedAt) || !g.LastEndedAt.Equal(w.LastEndedAt) {
+			t.Errorf("sum %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if len(got.Pairs) != len(want.Pairs) {
+		t.Fatalf("got %d pairs, want %d", len(got.Pairs), len(want.Pairs))
+	}
+	for i := range want.Pairs {
+		g, w := got.Pairs[i], want.Pairs[i]
+		if g.Bucket != w.Bucket || !sameProgress(g.Curr, w.Curr) || !sameProgress(g.Next, w.Next) {
+			t.Errorf("pair %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if len(got.Reached) != len(want.Reached) {
+		t.Errorf("reached = %v, want %v", got.Reached, want.Reached)
+	}
+	for cid, position := range want.Reached {
+		if got.Reached[cid] != position {
+			t.Errorf("reached = %v, want %v", got.Reached, want.Reached)
+			break
+		}
+	}
+}
+
+// sameProgress 比较记录对中使用的字段。
+func sameProgress(a, b model.VideoProgress) bool {
+	return a.ID == b.ID && a.AID == b.AID && a.BVID == b.BVID && a.LastPlayCID == b.LastPlayCID &&
+		a.LastPlayTime == b.LastPlayTime && a.RecordedAt.Equal(b.RecordedAt) && a.PairLabel == b.PairLabel
+}
+
+func TestMemoryListPairDeltas(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewMemoryVideoProgressRepository()
+	minute := func(m int) time.Time { return start.Add(time.Duration(m) * time.Minute) }
+	seedFixture(t, repo, []*model.VideoProgress{
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 0, RecordedAt: minute(0)},
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 300999, RecordedAt: minute(10), PairLabel: model.PairLabelForward},     // 300 秒
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3000000, RecordedAt: minute(20), PairLabel: model.PairLabelForward},    // 2700 秒，上限 1200 秒
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3600000, RecordedAt: minute(30), PairLabel: model.PairLabelSuspicious}, // 排除
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3000000, RecordedAt: minute(40), PairLabel: model.PairLabelSeekBack},
+		{AID: fixtureAID, LastPlayCID: 102, LastPlayTime: 60000, RecordedAt: minute(70), PairLabel: model.PairLabelPartSwitch},
+		{AID: fixtureAID, LastPlayCID: 102, LastPlayTime: 120000, RecordedAt: minute(80), PairLabel: model.PairLabelForward},
+	})
+
+	deltas, err := repo.ListPairDeltas(ctx, fixtureAID, start, minute(120), model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSums := []model.ProgressDeltaSum{
+		{Bucket: 0, CID: 101, Seconds: 300 + 1200, SkippedSeconds: 1500, ContinuousSeconds: 1200, PlaybackSeconds: 600, MaxPosition: 3000,
+			Pairs: 2, FirstStartedAt: minute(0), LastEndedAt: minute(20)},
+		{Bucket: 1, CID: 102, Run: 2, Seconds: 60, MaxPosition: 120, Pairs: 1, FirstStartedAt: minute(70), LastEndedAt: minute(80)},
+	}
+	comparePairDeltas(t, deltas, &model.PairDeltas{Sums: wantSums, Pairs: []model.ProgressPair{
+		{Bucket: 0, Curr: model.VideoProgress{ID: 4, AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3600000, RecordedAt: minute(30), PairLabel: model.PairLabelSuspicious},
+			Next: model.VideoProgress{ID: 5, AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3000000, RecordedAt: minute(40), PairLabel: model.PairLabelSeekBack}},
+		{Bucket: 0, Curr: model.VideoProgress{ID: 5, AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 3000000, RecordedAt: minute(40), PairLabel: model.PairLabelSeekBack},
+			Next: model.VideoProgress{ID: 6, AID: fixtureAID, LastPlayCID: 102, LastPlayTime: 60000, RecordedAt: minute(70), PairLabel: model.PairLabelPartSwitch}},
+	}, Reached: map[int64]int64{}})
+
+	// 计入可疑记录对后，第 3 对 (600 秒) 也被求和；Split 时第 5 对跨越桶边界，逐条返回
+	deltas, err = repo.ListPairDeltas(ctx, fixtureAID, start, minute(120),
+		model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: 2, IncludeSuspicious: true, Split: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas.Sums) != 2 || deltas.Sums[0].Seconds != 300+1200+600 || deltas.Sums[0].Pairs != 3 {
+		t.Errorf("sums with suspicious pairs = %+v", deltas.Sums)
+	}
+	if len(deltas.Pairs) != 2 || deltas.Pairs[1].Next.ID != 6 {
+		t.Errorf("pairs with split = %+v", deltas.Pairs)
+	}
+}
+
+func TestMemoryListPairDeltasRewatch(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewMemoryVideoProgressRepository()
+	minute := func(m int) time.Time { return start.Add(time.Duration(m) * time.Minute) }
+	seedFixture(t, repo, []*model.VideoProgress{
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 0, RecordedAt: minute(0)},
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 600000, RecordedAt: minute(10), PairLabel: model.PairLabelForward},
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 900000, RecordedAt: minute(15), PairLabel: model.PairLabelSuspicious}, // 不推进高水位线
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 100000, RecordedAt: minute(20), PairLabel: model.PairLabelSeekBack},
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 400000, RecordedAt: minute(25), PairLabel: model.PairLabelForward},  // 300 秒都是重看
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 700000, RecordedAt: minute(30), PairLabel: model.PairLabelForward},  // 200 秒重看，100 秒首次观看
+		{AID: fixtureAID, LastPlayCID: 101, LastPlayTime: 1000000, RecordedAt: minute(35), PairLabel: model.PairLabelForward}, // 首次观看
+	})
+
+	// 从 20 分开始：之前的非可疑记录到达过 600 秒
+	bucketing := model.PairBucketing{Origin: start, Width: time.Hour}
+	deltas, err := repo.ListPairDeltas(ctx, fixtureAID, minute(20), minute(60), bucketing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comparePairDeltas(t, deltas, &model.PairDeltas{
+		Sums: []model.ProgressDeltaSum{{CID: 101, Seconds: 900, RewatchedSeconds: 500, ContinuousSeconds: 900, PlaybackSeconds: 900, MaxPosition: 1000,
+			Pairs: 3, FirstStartedAt: minute(20), LastEndedAt: minute(35)}},
+		Pairs:   []model.ProgressPair{},
+		Reached: map[int64]int64{101: 600},
+	})
+
+	// 计入可疑记录时 900 秒也是到达过的位置
+	bucketing.IncludeSuspicious = true
+	deltas, err = repo.ListPairDeltas(ctx, fixtureAID, minute(20), minute(60), bucketing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas.Sums) != 1 || deltas.Sums[0].RewatchedSeconds != 300+300+200 || deltas.Reached[101] != 900 {
+		t.Errorf("deltas with suspicious records = %+v", deltas)
+	}
+}
+
+// BenchmarkPairWatchTime 比较两种计算方式：逐条读取原始记录并在 Go 中逐对计算 (ListPairDeltas 之前的做法)，
+// 与由仓库配对并求和、只逐对计算跨分P和后退的记录对 (ListPairDeltas)。MySQL 只在设置 TEST_DATABASE_DBNAME 时运行。
+func BenchmarkPairWatchTime(b *testing.B) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	strategy, err := service.NewWatchTimeStrategy(service.StrategyForward, service.NewWatchTimeCalculator(), 2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, n := range []int{1000, 10000} {
+		records := pairDeltasFixture(start, n)
+		end := records[len(records)-1].RecordedAt.Add(time.Hour)
+		bucketing := model.PairBucketing{Origin: start, Width: time.Hour, MaxSpeed: 2}
+		repos := []struct {
+			name string
+			open func(b *testing.B) repository.VideoProgressRepository
+		}{
+			{"memory", func(b *testing.B) repository.VideoProgressRepository { return NewMemoryVideoProgressRepository() }},
+			{"mysql", func(b *testing.B) repository.VideoProgressRepository {
+				return NewGormVideoProgressRepository(openTestDB(b))
+			}},
+		}
+		for _, r := range repos {
+			b.Run(fmt.Sprintf("%s/records=%d", r.name, n), func(b *testing.B) {
+				repo := r.open(b)
+				seedFixture(b, repo, records)
+				b.Run("per-record-loop", func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						sumPerRecord(b, repo, strategy, start, end, bucketing)
+					}
+				})
+				b.Run("list-pair-deltas", func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						sumPairDeltas(b, repo, strategy, start, end, bucketing)
+					}
+				})
+			})
+		}
+	}
+}
+
+// sumPerRecord 读取所有原始记录并逐对按策略计算，返回每个桶的观看秒数。
+func sumPerRecord(b *testing.B, repo repository.VideoProgressRepository, strategy service.WatchTimeStrategy,
+	from, to time.Time, bucketing model.PairBucketing) map[int64]int64 {
+	records, err := repo.ListByAIDAndTimestampRange(context.Background(), fixtureAID, from, to)
+	if err != nil {
+		b.Fatal(err)
+	}
+	seconds := make(map[int64]int64)
+	for i := 0; i+1 < len(records); i++ {
+		curr, next := records[i], records[i+1]
+		if next.PairLabel == model.PairLabelSuspicious {
+			continue
+		}
+		breakdown, err := strategy.CalculateBetween(fixturePages, benchmarkPoint(curr), benchmarkPoint(next))
+		if err != nil {
+			continue
+		}
+		seconds[int64(curr.RecordedAt.Sub(bucketing.Origin)/bucketing.Width)] += int64(breakdown.Total().Seconds())
+	}
+	return seconds
+}
+
+// sumPairDeltas 由仓库配对并求和，只逐对计算返回的记录对，返回每个桶的观看秒数。
+func sumPairDeltas(b *testing.B, repo repository.VideoProgressRepository, strategy service.WatchTimeStrategy,
+	from, to time.Time, bucketing model.PairBucketing) map[int64]int64 {
+	deltas, err := repo.ListPairDeltas(context.Background(), fixtureAID, from, to, bucketing)
+	if err != nil {
+		b.Fatal(err)
+	}
+	seconds := make(map[int64]int64)
+	for _, sum := range deltas.Sums {
+		seconds[sum.Bucket] += sum.Seconds
+	}
+	for i := range deltas.Pairs {
+		pair := &deltas.Pairs[i]
+		breakdown, err := strategy.CalculateBetween(fixturePages, benchmarkPoint(&pair.Curr), benchmarkPoint(&pair.Next))
+		if err != nil {
+			continue
+		}
+		seconds[pair.Bucket] += int64(breakdown.Total().Seconds())
+	}
+	return seconds
+}
+
+// benchmarkPoint 把进度记录转换为策略使用的播放位置。
+func benchmarkPoint(p *model.VideoProgress) service.PlaybackPoint {
+	return service.PlaybackPoint{CID: p.LastPlayCID, PositionSec: p.LastPlayTime / 1000, RecordedAt: p.RecordedAt}
+}
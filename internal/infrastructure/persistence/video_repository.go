@@ -0,0 +1,229 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// gormVideoRepository 是 VideoRepository 的 GORM 实现。
+type gormVideoRepository struct {
+	db *gorm.DB
+}
+
+// NewGormVideoRepository 创建一个新的 GORM VideoRepository 实例。
+func NewGormVideoRepository(db *gorm.DB) repository.VideoRepository {
+	return &gormVideoRepository{db: db}
+}
+
+// videoGorm 是 Video 领域模型对应的 GORM 数据模型。
+type videoGorm struct {
+	ID          uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID         int64     `gorm:"column:aid;uniqueIndex:uk_video_aid;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	BVID        string    `gorm:"column:bvid;type:varchar(255);uniqueIndex:uk_video_bvid;not null;default:'';comment:视频 BV 号"`
+	Title       string    `gorm:"column:title;type:varchar(512);not null;default:'';comment:视频标题"`
+	OwnerName   string    `gorm:"column:owner_name;type:varchar(255);not null;default:'';comment:UP主名称"`
+	OwnerMID    int64     `gorm:"column:owner_mid;not null;default:0;comment:UP主 ID (mid)"`
+	TID         int       `gorm:"column:tid;not null;default:0;comment:分区 ID"`
+	TName       string    `gorm:"column:tname;type:varchar(255);not null;default:'';comment:分区名称"`
+	Duration    int64     `gorm:"column:duration;not null;default:0;comment:总时长 (秒)"`
+	Pubdate     int64     `gorm:"column:pubdate;not null;default:0;comment:发布时间戳"`
+	PageVersion int       `gorm:"column:page_version;not null;default:0;comment:当前分P列表版本号"`
+	RefreshedAt time.Time `gorm:"column:refreshed_at;type:datetime(3);not null;comment:最近刷新时间"`
+	GmtCreate   time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (videoGorm) TableName() string {
+	return "video"
+}
+
+// videoPageGorm 是分P列表中单个分P对应的 GORM 数据模型。
+// 同一版本的所有分P共享 version 和 effective_from。
+type videoPageGorm struct {
+	ID            uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID           int64     `gorm:"column:aid;uniqueIndex:uk_video_page_aid_version_page,priority:1;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	Version       int       `gorm:"column:version;uniqueIndex:uk_video_page_aid_version_page,priority:2;not null;default:0;comment:分P列表版本号"`
+	Page          int       `gorm:"column:page;uniqueIndex:uk_video_page_aid_version_page,priority:3;not null;default:0;comment:分P序号"`
+	CID           int64     `gorm:"column:cid;index;not null;default:0;comment:分P ID"`
+	Part          string    `gorm:"column:part;type:varchar(512);not null;default:'';comment:分P标题"`
+	Duration      int64     `gorm:"column:duration;not null;default:0;comment:分P时长 (秒)"`
+	EffectiveFrom time.Time `gorm:"column:effective_from;type:datetime(3);not null;comment:该版本生效时间"`
+	GmtCreate     time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified   time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (videoPageGorm) TableName() string {
+	return "video_page"
+}
+
+// toDomain 将 GORM 模型转换为领域模型。
+func (g *videoGorm) toDomain() *model.Video {
+	return &model.Video{
+		ID:          g.ID,
+		AID:         g.AID,
+		BVID:        g.BVID,
+		Title:       g.Title,
+		OwnerName:   g.OwnerName,
+		OwnerMID:    g.OwnerMID,
+		TID:         g.TID,
+		TName:       g.TName,
+		Duration:    g.Duration,
+		Pubdate:     g.Pubdate,
+		PageVersion: g.PageVersion,
+		RefreshedAt: g.RefreshedAt,
+		GmtCreate:   g.GmtCreate,
+		GmtModified: g.GmtModified,
+	}
+}
+
+// videoFromDomain 将领域模型转换为 GORM 模型。
+func videoFromDomain(d *model.Video) *videoGorm {
+	return &videoGorm{
+		ID:          d.ID,
+		AID:         d.AID,
+		BVID:        d.BVID,
+		Title:       d.Title,
+		OwnerName:   d.OwnerName,
+		OwnerMID:    d.OwnerMID,
+		TID:         d.TID,
+		TName:       d.TName,
+		Duration:    d.Duration,
+		Pubdate:     d.Pubdate,
+		PageVersion: d.PageVersion,
+		RefreshedAt: d.RefreshedAt,
+		GmtCreate:   d.GmtCreate,
+		GmtModified: d.GmtModified,
+	}
+}
+
+// Save 新增或更新视频元数据 (按 aid 查找已有记录，存在则整体更新)。
+func (r *gormVideoRepository) Save(ctx context.Context, video *model.Video) error {
+	g := videoFromDomain(video)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing videoGorm
+		err := tx.Where("aid = ?", video.AID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(g).Error
+		}
+		if err != nil {
+			return err
+		}
+		g.ID = existing.ID
+		g.GmtCreate = existing.GmtCreate
+		return tx.Save(g).Error
+	})
+	if err != nil {
+		log.Printf("Database error saving video AID %d: %v", video.AID, err)
+		return fmt.Errorf("database error saving video: %w", err)
+	}
+	video.ID = g.ID
+	video.GmtCreate = g.GmtCreate
+	video.GmtModified = g.GmtModified
+	return nil
+}
+
+// FindByAID 根据 AID 查找视频。
+func (r *gormVideoRepository) FindByAID(ctx context.Context, aid int64) (*model.Video, error) {
+	return r.findOne(ctx, "aid = ?", aid)
+}
+
+// FindByBVID 根据 BVID 查找视频。
+func (r *gormVideoRepository) FindByBVID(ctx context.Context, bvid string) (*model.Video, error) {
+	return r.findOne(ctx, "bvid = ?", bvid)
+}
+
+// findOne 按条件查找单个视频，未找到时返回 ErrVideoNotFound。
+func (r *gormVideoRepository) findOne(ctx context.Context, query string, arg interface{}) (*model.Video, error) {
+	var g videoGorm
+	err := r.db.WithContext(ctx).Where(query, arg).First(&g).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrVideoNotFound
+		}
+		log.Printf("Database error finding video (%s, %v): %v", query, arg, err)
+		return nil, fmt.Errorf("database error finding video: %w", err)
+	}
+	return g.toDomain(), nil
+}
+
+// ListAll 获取目录中的所有视频。
+func (r *gormVideoRepository) ListAll(ctx context.Context) ([]*model.Video, error) {
+	var gs []videoGorm
+	if err := r.db.WithContext(ctx).Order("aid ASC").Find(&gs).Error; err != nil {
+		return nil, fmt.Errorf("database error listing videos: %w", err)
+	}
+	videos := make([]*model.Video, 0, len(gs))
+	for i := range gs {
+		videos = append(videos, gs[i].toDomain())
+	}
+	return videos, nil
+}
+
+// SavePageList 在一个事务中保存某个版本的全部分P。
+func (r *gormVideoRepository) SavePageList(ctx context.Context, list *model.VideoPageList) error {
+	if len(list.Pages) == 0 {
+		return fmt.Errorf("page list for aid %d version %d is empty", list.AID, list.Version)
+	}
+	rows := make([]videoPageGorm, 0, len(list.Pages))
+	for _, p := range list.Pages {
+		rows = append(rows, videoPageGorm{
+			AID:           list.AID,
+			Version:       list.Version,
+			Page:          p.Page,
+			CID:           p.Cid,
+			Part:          p.Part,
+			Duration:      p.Duration,
+			EffectiveFrom: list.EffectiveFrom,
+		})
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		log.Printf("Database error saving page list for AID %d version %d: %v", list.AID, list.Version, err)
+		return fmt.Errorf("database error saving page list: %w", err)
+	}
+	return nil
+}
+
+// ListPageHistory 获取视频所有版本的分P列表。
+func (r *gormVideoRepository) ListPageHistory(ctx context.Context, aid int64) (model.VideoPageHistory, error) {
+	var rows []videoPageGorm
+	err := r.db.WithContext(ctx).
+		Where("aid = ?", aid).
+		Order("version ASC, page ASC").
+		Find(&rows).Error
+	if err != nil {
+		log.Printf("Database error listing page history for AID %d: %v", aid, err)
+		return nil, fmt.Errorf("database error listing page history: %w", err)
+	}
+
+	history := make(model.VideoPageHistory, 0)
+	for _, row := range rows {
+		if len(history) == 0 || history[len(history)-1].Version != row.Version {
+			history = append(history, model.VideoPageList{
+				AID:           row.AID,
+				Version:       row.Version,
+				EffectiveFrom: row.EffectiveFrom,
+			})
+		}
+		current := &history[len(history)-1]
+		current.Pages = append(current.Pages, model.VideoPage{
+			Cid:      row.CID,
+			Duration: row.Duration,
+			Part:     row.Part,
+			Page:     row.Page,
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,291 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+)
+
+// gormWatchTimeAggregateRepository 是 WatchTimeAggregateRepository 的 GORM 实现。
+type gormWatchTimeAggregateRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWatchTimeAggregateRepository 创建一个新的 GORM WatchTimeAggregateRepository 实例。
+func NewGormWatchTimeAggregateRepository(db *gorm.DB) repository.WatchTimeAggregateRepository {
+	return &gormWatchTimeAggregateRepository{db: db}
+}
+
+// watchTimeHourlyGorm 对应 watch_time_hourly 表，每个视频分P每小时一行。
+type watchTimeHourlyGorm struct {
+	ID                uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID               int64     `gorm:"column:aid;uniqueIndex:uk_watch_time_hourly_aid_cid_hour,priority:1;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	CID               int64     `gorm:"column:cid;uniqueIndex:uk_watch_time_hourly_aid_cid_hour,priority:2;not null;default:0;comment:分P ID"`
+	HourStart         time.Time `gorm:"column:hour_start;type:datetime(3);uniqueIndex:uk_watch_time_hourly_aid_cid_hour,priority:3;not null;comment:小时开始时间"`
+	WatchedSeconds    int64     `gorm:"column:watched_seconds;not null;default:0;comment:观看时长 (秒)"`
+	RewatchedSeconds  int64     `gorm:"column:rewatched_seconds;not null;default:0;comment:其中重看时长 (秒)"`
+	SkippedSeconds    int64     `gorm:"column:skipped_seconds;not null;default:0;comment:跳过的进度 (秒)"`
+	ContinuousSeconds int64     `gorm:"column:continuous_seconds;not null;default:0;comment:连续播放的观看时长 (秒)"`
+	PlaybackSeconds   int64     `gorm:"column:playback_seconds;not null;default:0;comment:连续播放的实际播放时间 (秒)"`
+	GmtCreate         time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified       time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (watchTimeHourlyGorm) TableName() string {
+	return "watch_time_hourly"
+}
+
+// watchTimeDailyGorm 对应 watch_time_daily 表，每个视频分P每天一行。
+// day_start 为聚合时区当天零点对应的时间点。
+type watchTimeDailyGorm struct {
+	ID                uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID               int64     `gorm:"column:aid;uniqueIndex:uk_watch_time_daily_aid_cid_day,priority:1;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	CID               int64     `gorm:"column:cid;uniqueIndex:uk_watch_time_daily_aid_cid_day,priority:2;not null;default:0;comment:分P ID"`
+	DayStart          time.Time `gorm:"column:day_start;type:datetime(3);uniqueIndex:uk_watch_time_daily_aid_cid_day,priority:3;not null;comment:当天零点 (聚合时区)"`
+	WatchedSeconds    int64     `gorm:"column:watched_seconds;not null;default:0;comment:观看时长 (秒)"`
+	RewatchedSeconds  int64     `gorm:"column:rewatched_seconds;not null;default:0;comment:其中重看时长 (秒)"`
+	SkippedSeconds    int64     `gorm:"column:skipped_seconds;not null;default:0;comment:跳过的进度 (秒)"`
+	ContinuousSeconds int64     `gorm:"column:continuous_seconds;not null;default:0;comment:连续播放的观看时长 (秒)"`
+	PlaybackSeconds   int64     `gorm:"column:playback_seconds;not null;default:0;comment:连续播放的实际播放时间 (秒)"`
+	GmtCreate         time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified       time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (watchTimeDailyGorm) TableName() string {
+	return "watch_time_daily"
+}
+
+// progressRollupStateGorm 对应 progress_rollup_state 表，记录每个视频的汇总水位线。
+type progressRollupStateGorm struct {
+	ID            uint      `gorm:"primaryKey;comment:主键 ID"`
+	AID           int64     `gorm:"column:aid;uniqueIndex:uk_progress_rollup_state_aid;not null;default:0;comment:视频稿件 ID (AV 号)"`
+	RolledUpUntil time.Time `gorm:"column:rolled_up_until;type:datetime(3);not null;comment:原始记录已汇总到的时间点"`
+	GmtCreate     time.Time `gorm:"column:gmt_create;type:datetime(3);not null;autoCreateTime;comment:创建时间"`
+	GmtModified   time.Time `gorm:"column:gmt_modified;type:datetime(3);not null;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定 GORM 应使用的表名。
+func (progressRollupStateGorm) TableName() string {
+	return "progress_rollup_state"
+}
+
+// accumulate 返回按唯一键冲突时累加各时长列的 upsert 子句。
+func accumulate(bucketColumn string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns: []clause.Column{{Name: "aid"}, {Name: "cid"}, {Name: bucketColumn}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"watched_seconds":    gorm.Expr("watched_seconds + VALUES(watched_seconds)"),
+			"rewatched_seconds":  gorm.Expr("rewatched_seconds + VALUES(rewatched_seconds)"),
+			"skipped_seconds":    gorm.Expr("skipped_seconds + VALUES(skipped_seconds)"),
+			"continuous_seconds": gorm.Expr("continuous_seconds + VALUES(continuous_seconds)"),
+			"playback_seconds":   gorm.Expr("playback_seconds + VALUES(playback_seconds)"),
+			"gmt_modified":       gorm.Expr("VALUES(gmt_modified)"),
+		}),
+	}
+}
+
+// AddWatchTime 把同一段观看时长累加到小时聚合和天聚合。
+func (r *gormWatchTimeAggregateRepository) AddWatchTime(ctx context.Context, hourly, daily []model.WatchTimeAggregate) error {
+	if len(hourly) == 0 && len(daily) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, row := range hourly {
+			h := watchTimeHourlyGorm{AID: row.AID, CID: row.CID, HourStart: row.BucketStart,
+				WatchedSeconds: row.WatchedSeconds, RewatchedSeconds: row.RewatchedSeconds,
+				SkippedSeconds: row.SkippedSeconds, ContinuousSeconds: row.ContinuousSeconds, PlaybackSeconds: row.PlaybackSeconds}
+			if err := tx.Clauses(accumulate("hour_start")).Create(&h).Error; err != nil {
+				return err
+			}
+		}
+		for _, row := range daily {
+			d := watchTimeDailyGorm{AID: row.AID, CID: row.CID, DayStart: row.BucketStart,
+				WatchedSeconds: row.WatchedSeconds, RewatchedSeconds: row.RewatchedSeconds,
+				SkippedSeconds: row.SkippedSeconds, ContinuousSeconds: row.ContinuousSeconds, PlaybackSeconds: row.PlaybackSeconds}
+			if err := tx.Clauses(accumulate("day_start")).Create(&d).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Database error adding watch time (%d hourly, %d daily rows): %v", len(hourly), len(daily), err)
+		return fmt.Errorf("database error adding watch time: %w", err)
+	}
+	return nil
+}
+
+// ReplaceHourly 删除 [start, end) 范围内的小时聚合并写入 rows。
+func (r *gormWatchTimeAggregateRepository) ReplaceHourly(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("aid = ? AND hour_start >= ? AND hour_start < ?", aid, start, end).Delete(&watchTimeHourlyGorm{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		gs := make([]watchTimeHourlyGorm, 0, len(rows))
+		for _, row := range rows {
+			gs = append(gs, watchTimeHourlyGorm{AID: row.AID, CID: row.CID, HourStart: row.BucketStart,
+				WatchedSeconds: row.WatchedSeconds, RewatchedSeconds: row.RewatchedSeconds,
+				SkippedSeconds: row.SkippedSeconds, ContinuousSeconds: row.ContinuousSeconds, PlaybackSeconds: row.PlaybackSeconds})
+		}
+		return tx.CreateInBatches(&gs, 500).Error
+	})
+	if err != nil {
+		log.Printf("Database error replacing hourly aggregates for AID %d in [%s, %s): %v", aid, start, end, err)
+		return fmt.Errorf("database error replacing hourly aggregates: %w", err)
+	}
+	return nil
+}
+
+// ReplaceDaily 删除 [start, end) 范围内的天聚合并写入 rows。
+func (r *gormWatchTimeAggregateRepository) ReplaceDaily(ctx context.Context, aid int64, start, end time.Time, rows []model.WatchTimeAggregate) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("aid = ? AND day_start >= ? AND day_start < ?", aid, start, end).Delete(&watchTimeDailyGorm{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		gs := make([]watchTimeDailyGorm, 0, len(rows))
+		for _, row := range rows {
+			gs = append(gs, watchTimeDailyGorm{AID: row.AID, CID: row.CID, DayStart: row.BucketStart,
+				WatchedSeconds: row.WatchedSeconds, RewatchedSeconds: row.RewatchedSeconds,
+				SkippedSeconds: row.SkippedSeconds, ContinuousSeconds: row.ContinuousSeconds, PlaybackSeconds: row.PlaybackSeconds})
+		}
+		return tx.CreateInBatches(&gs, 500).Error
+	})
+	if err != nil {
+		log.Printf("Database error replacing daily aggregates for AID %d in [%s, %s): %v", aid, start, end, err)
+		return fmt.Errorf("database error replacing daily aggregates: %w", err)
+	}
+	return nil
+}
+
+// ListHourly 获取指定视频在 [start, end) 范围内的小时聚合。
+func (r *gormWatchTimeAggregateRepository) ListHourly(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error) {
+	var rows []watchTimeHourlyGorm
+	err := r.db.WithContext(ctx).
+		Where("aid = ? AND hour_start >= ? AND hour_start < ?", aid, start, end).
+		Order("hour_start ASC, cid ASC").
+		Find(&rows).Error
+	if err != nil {
+		log.Printf("Database error listing hourly aggregates for AID %d in [%s, %s): %v", aid, start, end, err)
+		return nil, fmt.Errorf("database error listing hourly aggregates: %w", err)
+	}
+	aggregates := make([]model.WatchTimeAggregate, 0, len(rows))
+	for _, row := range rows {
+		aggregates = append(aggregates, model.WatchTimeAggregate{
+			AID:               row.AID,
+			CID:               row.CID,
+			BucketStart:       row.HourStart,
+			WatchedSeconds:    row.WatchedSeconds,
+			RewatchedSeconds:  row.RewatchedSeconds,
+			SkippedSeconds:    row.SkippedSeconds,
+			ContinuousSeconds: row.ContinuousSeconds,
+			PlaybackSeconds:   row.PlaybackSeconds,
+		})
+	}
+	return aggregates, nil
+}
+
+// ListDaily 获取指定视频在 [start, end) 范围内的天聚合。
+func (r *gormWatchTimeAggregateRepository) ListDaily(ctx context.Context, aid int64, start, end time.Time) ([]model.WatchTimeAggregate, error) {
+	var rows []watchTimeDailyGorm
+	err := r.db.WithContext(ctx).
+		Where("aid = ? AND day_start >= ? AND day_start < ?", aid, start, end).
+		Order("day_start ASC, cid ASC").
+		Find(&rows).Error
+	if err != nil {
+		log.Printf("Database error listing daily aggregates for AID %d in [%s, %s): %v", aid, start, end, err)
+		return nil, fmt.Errorf("database error listing daily aggregates: %w", err)
+	}
+	aggregates := make([]model.WatchTimeAggregate, 0, len(rows))
+	for _, row := range rows {
+		aggregates = append(aggregates, model.WatchTimeAggregate{
+			AID:               row.AID,
+			CID:               row.CID,
+			BucketStart:       row.DayStart,
+			WatchedSeconds:    row.WatchedSeconds,
+			RewatchedSeconds:  row.RewatchedSeconds,
+			SkippedSeconds:    row.SkippedSeconds,
+			ContinuousSeconds: row.ContinuousSeconds,
+			PlaybackSeconds:   row.PlaybackSeconds,
+		})
+	}
+	return aggregates, nil
+}
+
+// GetWatermark 获取视频的汇总水位线。
+func (r *gormWatchTimeAggregateRepository) GetWatermark(ctx context.Context, aid int64) (time.Time, error) {
+	var state progressRollupStateGorm
+	err := r.db.WithContext(ctx).Where("aid = ?", aid).First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("database error getting rollup watermark: %w", err)
+	}
+	return state.RolledUpUntil, nil
+}
+
+// SetWatermark 更新视频的汇总水位线。
+func (r *gormWatchTimeAggregateRepository) SetWatermark(ctx context.Context, aid int64, watermark time.Time) error {
+	state := progressRollupStateGorm{AID: aid, RolledUpUntil: watermark}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "aid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rolled_up_until", "gmt_modified"}),
+	}).Create(&state).Error
+	if err != nil {
+		log.Printf("Database error setting rollup watermark for AID %d to %s: %v", aid, watermark, err)
+		return fmt.Errorf("database error setting rollup watermark: %w", err)
+	}
+	return nil
+}
+
+// videoLockTimeoutSec 等待视频聚合锁的最长秒数。
+const videoLockTimeoutSec = 60
+
+// LockVideo 用 MySQL 的 GET_LOCK 获取视频聚合的排他锁，对同一数据库上的所有进程 (服务和子命令) 生效。
+// 命名锁属于数据库会话，因此在释放前独占一个连接。
+func (r *gormWatchTimeAggregateRepository) LockVideo(ctx context.Context, aid int64) (func(), error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	name := fmt.Sprintf("watch_time_aggregate:%d", aid)
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, videoLockTimeoutSec).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("database error acquiring aggregate lock for aid %d: %w", aid, err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, fmt.Errorf("timed out acquiring aggregate lock for aid %d", aid)
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name); err != nil {
+			log.Printf("Database error releasing aggregate lock for AID %d: %v", aid, err)
+			// 释放失败时丢弃该连接，会话关闭后锁随之释放
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
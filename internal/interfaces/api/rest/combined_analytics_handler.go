@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// CombinedAnalyticsHandler 处理多个视频观看分段汇总相关的 API 请求。
+type CombinedAnalyticsHandler struct {
+	appService *application.CombinedAnalyticsService
+}
+
+// NewCombinedAnalyticsHandler 创建 CombinedAnalyticsHandler 实例。
+func NewCombinedAnalyticsHandler(appService *application.CombinedAnalyticsService) *CombinedAnalyticsHandler {
+	return &CombinedAnalyticsHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册多个视频观看分段汇总相关的路由。
+func (h *CombinedAnalyticsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/video/watch-segments/combined", h.GetCombinedSegments)
+}
+
+// GetCombinedSegments 处理汇总多个视频观看分段的请求。
+// @Summary 汇总多个视频的观看分段
+// @Description 并发计算 bvids 指定的视频 (为空时为所有视频) 的观看分段，返回每个分段内所有视频的合计和每个视频的时长 (用于堆叠图)，以及整个范围的合计。
+// @Description 参数含义与 /video/watch-segments 相同。
+// @Tags VideoAnalytics
+// @Accept json
+// @Produce json
+// @Param request body dto.GetCombinedSegmentsRequest true "查询参数"
+// @Success 200 {object} response.APIResponse{data=dto.GetCombinedSegmentsResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/video/watch-segments/combined [post]
+func (h *CombinedAnalyticsHandler) GetCombinedSegments(c *gin.Context) {
+	var req dto.GetCombinedSegmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	interval, err := application.ParseSegmentInterval(req.Interval, req.DayCutoffHour)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	result, err := h.appService.GetCombinedSegments(c.Request.Context(), req.BVIDs, startTime, endTime, interval, loc,
+		application.Attribution(req.Attribution), req.IncludeSuspicious)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate combined segments: %v", err))
+		return
+	}
+
+	respData := dto.GetCombinedSegmentsResponse{
+		Videos:                    make([]dto.VideoSummary, 0, len(result.Videos)),
+		Segments:                  make([]dto.CombinedSegment, 0, len(result.Segments)),
+		VideoTotals:               toVideoWatchedDurations(result.VideoTotals),
+		TotalWatchedDurationSec:   int64(result.TotalWatched.Seconds()),
+		TotalRewatchedDurationSec: int64(result.TotalRewatched.Seconds()),
+		TotalSkippedDurationSec:   int64(result.TotalSkipped.Seconds()),
+	}
+	for _, video := range result.Videos {
+		respData.Videos = append(respData.Videos, dto.VideoSummary{AID: video.AID, BVID: video.BVID, Title: video.Title})
+	}
+	for _, seg := range result.Segments {
+		respData.Segments = append(respData.Segments, dto.CombinedSegment{
+			SegmentStartTime:     seg.SegmentStartTime,
+			SegmentEndTime:       seg.SegmentEndTime,
+			WatchedDurationSec:   int64(seg.Watched.Seconds()),
+			RewatchedDurationSec: int64(seg.Rewatched.Seconds()),
+			SkippedDurationSec:   int64(seg.Skipped.Seconds()),
+			Videos:               toVideoWatchedDurations(seg.Videos),
+		})
+	}
+	response.Success(c, respData)
+}
+
+// toVideoWatchedDurations 把每个视频的观看时长转换为 DTO。
+func toVideoWatchedDurations(videos []application.VideoWatchTime) []dto.VideoWatchedDuration {
+	result := make([]dto.VideoWatchedDuration, 0, len(videos))
+	for _, v := range videos {
+		result = append(result, dto.VideoWatchedDuration{
+			AID:                  v.Video.AID,
+			BVID:                 v.Video.BVID,
+			WatchedDurationSec:   int64(v.Watched.Seconds()),
+			RewatchedDurationSec: int64(v.Rewatched.Seconds()),
+			SkippedDurationSec:   int64(v.Skipped.Seconds()),
+		})
+	}
+	return result
+}
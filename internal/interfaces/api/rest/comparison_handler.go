@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// ComparisonHandler 处理观看时长对比相关的 API 请求。
+type ComparisonHandler struct {
+	appService *application.ComparisonService
+}
+
+// NewComparisonHandler 创建 ComparisonHandler 实例。
+func NewComparisonHandler(appService *application.ComparisonService) *ComparisonHandler {
+	return &ComparisonHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看时长对比相关的路由。
+func (h *ComparisonHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/video/watch-segments/compare", h.CompareWatchTime)
+}
+
+// CompareWatchTime 处理对比两个时期观看时长的请求。
+// @Summary 对比两个时期的观看时长
+// @Description 计算当前期 (start_time 到 end_time) 和对比期 (compare_to：previous 为紧邻的上一期，year 为去年同期) 的观看分段，
+// @Description 按分段下标对齐，返回每个分段和整个范围的观看时长、变化量和变化百分比。视频的选择及其余参数与 /video/watch-segments/combined 相同。
+// @Tags VideoAnalytics
+// @Accept json
+// @Produce json
+// @Param request body dto.CompareWatchTimeRequest true "查询参数"
+// @Success 200 {object} response.APIResponse{data=dto.CompareWatchTimeResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/video/watch-segments/compare [post]
+func (h *ComparisonHandler) CompareWatchTime(c *gin.Context) {
+	var req dto.CompareWatchTimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	interval, err := application.ParseSegmentInterval(req.Interval, req.DayCutoffHour)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	offset, err := application.ParseComparisonOffset(req.CompareTo)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	result, err := h.appService.Compare(c.Request.Context(), req.BVIDs, startTime, endTime, interval, loc,
+		application.Attribution(req.Attribution), req.IncludeSuspicious, offset)
+	if errors.Is(err, application.ErrInvalidComparison) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to compare watch time: %v", err))
+		return
+	}
+
+	respData := dto.CompareWatchTimeResponse{
+		Videos:            make([]dto.VideoSummary, 0, len(result.Videos)),
+		CompareTo:         string(result.Offset),
+		CurrentStartTime:  result.CurrentStart,
+		CurrentEndTime:    result.CurrentEnd,
+		PreviousStartTime: result.PreviousStart,
+		PreviousEndTime:   result.PreviousEnd,
+		Buckets:           make([]dto.ComparisonBucket, 0, len(result.Buckets)),
+		Total:             toWatchTimeDelta(result.Total),
+	}
+	for _, video := range result.Videos {
+		respData.Videos = append(respData.Videos, dto.VideoSummary{AID: video.AID, BVID: video.BVID, Title: video.Title})
+	}
+	for _, bucket := range result.Buckets {
+		respData.Buckets = append(respData.Buckets, dto.ComparisonBucket{
+			CurrentStartTime:  optionalTime(bucket.CurrentStart),
+			CurrentEndTime:    optionalTime(bucket.CurrentEnd),
+			PreviousStartTime: optionalTime(bucket.PreviousStart),
+			PreviousEndTime:   optionalTime(bucket.PreviousEnd),
+			WatchTimeDelta:    toWatchTimeDelta(bucket.Watched),
+		})
+	}
+	response.Success(c, respData)
+}
+
+// toWatchTimeDelta 把观看时长的变化转换为 DTO。
+func toWatchTimeDelta(change application.WatchTimeChange) dto.WatchTimeDelta {
+	delta := dto.WatchTimeDelta{
+		CurrentWatchedSec:  int64(change.Current.Seconds()),
+		PreviousWatchedSec: int64(change.Previous.Seconds()),
+		DeltaSec:           int64(change.Delta().Seconds()),
+	}
+	if percent, ok := change.Percent(); ok {
+		rounded := roundPercent(percent)
+		delta.DeltaPercent = &rounded
+	}
+	return delta
+}
+
+// optionalTime 把零值时间转换为 nil。
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,274 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// scheduleOnTrackSec 与原计划相差不到该秒数时视为按计划进行。
+const scheduleOnTrackSec = 60
+
+// CoursePlanHandler 处理课程计划相关的 API 请求。
+type CoursePlanHandler struct {
+	appService *application.CoursePlanService
+}
+
+// NewCoursePlanHandler 创建 CoursePlanHandler 实例。
+func NewCoursePlanHandler(appService *application.CoursePlanService) *CoursePlanHandler {
+	return &CoursePlanHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册课程计划相关的路由。
+func (h *CoursePlanHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/course-plans", h.CreateCoursePlan)
+	rg.GET("/course-plans", h.ListCoursePlans)
+	rg.GET("/course-plans/:id/status", h.GetCoursePlanStatus)
+	rg.DELETE("/course-plans/:id", h.DeleteCoursePlan)
+}
+
+// CreateCoursePlan 处理创建课程计划的请求。
+// @Summary 创建课程计划
+// @Description 按顺序指定要观看的分P (可以来自多个视频，只给出 bvid 时为该视频的所有分P) 和目标完成日期。日期按学习日 (GOAL_DAY_CUTOFF_HOUR) 解释。
+// @Tags CoursePlans
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateCoursePlanRequest true "计划定义"
+// @Success 200 {object} response.APIResponse{data=dto.CoursePlan} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/course-plans [post]
+func (h *CoursePlanHandler) CreateCoursePlan(c *gin.Context) {
+	var req dto.CreateCoursePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	input := application.CoursePlanInput{Name: req.Name, Items: make([]application.CoursePlanItemInput, 0, len(req.Items))}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, application.CoursePlanItemInput{BVID: item.BVID, CID: item.CID, Page: item.Page})
+	}
+	var err error
+	if req.StartDate != "" {
+		if input.StartDate, err = time.Parse(time.DateOnly, req.StartDate); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_date format: %v", err))
+			return
+		}
+	}
+	if input.TargetDate, err = time.Parse(time.DateOnly, req.TargetDate); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid target_date format: %v", err))
+		return
+	}
+
+	plan, err := h.appService.CreatePlan(c.Request.Context(), input, time.Now())
+	if errors.Is(err, application.ErrInvalidCoursePlan) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to create course plan: %v", err))
+		return
+	}
+	response.Success(c, toCoursePlanDTO(plan))
+}
+
+// ListCoursePlans 处理查询所有课程计划定义的请求。
+// @Summary 查询课程计划列表
+// @Tags CoursePlans
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=dto.ListCoursePlansResponse} "成功响应"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/course-plans [get]
+func (h *CoursePlanHandler) ListCoursePlans(c *gin.Context) {
+	plans, err := h.appService.ListPlans(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to list course plans: %v", err))
+		return
+	}
+	respData := dto.ListCoursePlansResponse{Plans: make([]dto.CoursePlan, 0, len(plans))}
+	for _, plan := range plans {
+		respData.Plans = append(respData.Plans, toCoursePlanDTO(plan))
+	}
+	response.Success(c, respData)
+}
+
+// DeleteCoursePlan 处理删除课程计划的请求。
+// @Summary 删除课程计划
+// @Tags CoursePlans
+// @Produce json
+// @Param id path int true "计划 ID"
+// @Success 200 {object} response.APIResponse "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 404 {object} response.APIResponse "计划不存在"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/course-plans/{id} [delete]
+func (h *CoursePlanHandler) DeleteCoursePlan(c *gin.Context) {
+	id, ok := coursePlanIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.appService.DeletePlan(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrCoursePlanNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to delete course plan: %v", err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// GetCoursePlanStatus 处理查询课程计划进度的请求。
+// @Summary 查询课程计划的进度和课程表
+// @Description 根据实际观看进度重新排期：把今天开始时还没看过的内容按顺序平均分配到目标日期前的每个学习日，返回今天的任务 (要看的分P和位置区间，以及今天已看的部分)、
+// @Description 与原计划相比超前或落后的分钟数，以及之后每天的课程表。
+// @Tags CoursePlans
+// @Produce json
+// @Param id path int true "计划 ID"
+// @Param days query int false "返回课程表的前多少个学习日 (1-366)，默认全部"
+// @Success 200 {object} response.APIResponse{data=dto.CoursePlanStatus} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 404 {object} response.APIResponse "计划不存在"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/course-plans/{id}/status [get]
+func (h *CoursePlanHandler) GetCoursePlanStatus(c *gin.Context) {
+	id, ok := coursePlanIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.CoursePlanStatusRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	status, err := h.appService.GetStatus(c.Request.Context(), id, time.Now())
+	if errors.Is(err, repository.ErrCoursePlanNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to evaluate course plan: %v", err))
+		return
+	}
+	response.Success(c, toCoursePlanStatusDTO(status, req.Days))
+}
+
+// coursePlanIDParam 解析路径中的计划 ID，无效时写入错误响应并返回 false。
+func coursePlanIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid course plan id %q", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// toCoursePlanDTO 把计划定义转换为 DTO。
+func toCoursePlanDTO(plan *model.CoursePlan) dto.CoursePlan {
+	result := dto.CoursePlan{
+		ID:         plan.ID,
+		Name:       plan.Name,
+		StartDate:  plan.StartDate.Format(time.DateOnly),
+		TargetDate: plan.TargetDate.Format(time.DateOnly),
+		Items:      make([]dto.CoursePlanItem, 0, len(plan.Items)),
+		CreatedAt:  plan.GmtCreate,
+	}
+	for _, item := range plan.Items {
+		result.Items = append(result.Items, dto.CoursePlanItem{AID: item.AID, BVID: item.BVID, CID: item.CID})
+	}
+	return result
+}
+
+// toCoursePlanStatusDTO 把计划进度转换为 DTO，课程表只保留前 days 个学习日 (不大于 0 时全部保留)。
+func toCoursePlanStatusDTO(status *application.CoursePlanStatus, days int) dto.CoursePlanStatus {
+	offset := status.ScheduleOffsetSeconds()
+	result := dto.CoursePlanStatus{
+		Plan:                  toCoursePlanDTO(status.Plan),
+		Today:                 status.Today.Format(time.DateOnly),
+		Started:               status.Started(),
+		Completed:             status.Completed(),
+		TotalDurationSec:      status.TotalSeconds,
+		CoveredSec:            status.CoveredSeconds,
+		DaysLeft:              status.DaysLeft,
+		ExpectedSec:           status.ExpectedSeconds,
+		ScheduleState:         "on_track",
+		ScheduleOffsetMinutes: math.Round(float64(offset)/60*10) / 10, // 保留一位小数
+		Parts:                 make([]dto.CoursePlanPart, 0, len(status.Parts)),
+		HistoryPrunedBefore:   formatPrunedBefore(status.PrunedBefore),
+	}
+	if status.TotalSeconds > 0 {
+		result.CompletionPercent = roundPercent(float64(status.CoveredSeconds) * 100 / float64(status.TotalSeconds))
+	}
+	switch {
+	case offset >= scheduleOnTrackSec:
+		result.ScheduleState = "ahead"
+	case offset <= -scheduleOnTrackSec:
+		result.ScheduleState = "behind"
+	}
+
+	for _, part := range status.Parts {
+		item := dto.CoursePlanPart{
+			BVID:        part.Item.BVID,
+			CID:         part.Item.CID,
+			Page:        part.Page.Page,
+			Part:        part.Page.Part,
+			DurationSec: part.Page.Duration,
+			CoveredSec:  part.CoveredSeconds,
+			Missing:     part.Missing,
+		}
+		if part.Video != nil {
+			item.Title = part.Video.Title
+		}
+		result.Parts = append(result.Parts, item)
+	}
+
+	syllabus := status.Syllabus
+	if days > 0 && len(syllabus) > days {
+		syllabus = syllabus[:days]
+	}
+	result.Syllabus = make([]dto.CoursePlanDay, 0, len(syllabus))
+	for _, day := range syllabus {
+		result.Syllabus = append(result.Syllabus, toCoursePlanDayDTO(day))
+	}
+	if today := status.TodayAssignment(); today != nil {
+		day := toCoursePlanDayDTO(*today)
+		result.TodayAssignment = &day
+		result.TodayRemainingMinutes = roundMinutes(time.Duration(max(today.Seconds-today.WatchedSeconds, 0)) * time.Second)
+	}
+	return result
+}
+
+// toCoursePlanDayDTO 把一个学习日的任务转换为 DTO。
+func toCoursePlanDayDTO(day application.CoursePlanDay) dto.CoursePlanDay {
+	result := dto.CoursePlanDay{
+		Date:        day.Date.Format(time.DateOnly),
+		Seconds:     day.Seconds,
+		WatchedSec:  day.WatchedSeconds,
+		Assignments: make([]dto.CoursePlanAssignment, 0, len(day.Assignments)),
+	}
+	for _, a := range day.Assignments {
+		result.Assignments = append(result.Assignments, dto.CoursePlanAssignment{
+			BVID:       a.Part.Item.BVID,
+			CID:        a.Part.Item.CID,
+			Page:       a.Part.Page.Page,
+			Part:       a.Part.Page.Part,
+			Ranges:     toPositionRanges(a.Ranges),
+			Seconds:    a.Seconds,
+			WatchedSec: a.WatchedSeconds,
+			Finishes:   a.Finishes,
+		})
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// CoverageHandler 处理观看覆盖情况相关的 API 请求。
+type CoverageHandler struct {
+	appService *application.CoverageService
+}
+
+// NewCoverageHandler 创建 CoverageHandler 实例。
+func NewCoverageHandler(appService *application.CoverageService) *CoverageHandler {
+	return &CoverageHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看覆盖情况相关的路由。
+func (h *CoverageHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/videos/:bvid/coverage", h.GetCoverage)
+}
+
+// GetCoverage 处理查询视频观看覆盖情况的请求。
+// @Summary 查询视频每个分P看过的位置和完成度
+// @Description 根据原始进度记录计算每个分P看过的位置区间、没看过的缺口和完成百分比，以及整个课程的完成度。超过保留期被清理的原始记录不参与计算。
+// @Tags Coverage
+// @Produce json
+// @Param bvid path string true "BV 号"
+// @Param start_time query string false "只使用该时间 (含) 之后的进度记录"
+// @Param end_time query string false "只使用该时间 (不含) 之前的进度记录"
+// @Param tz query string false "时区 (IANA 名称)，用于解释不带偏移的时间"
+// @Success 200 {object} response.APIResponse{data=dto.GetCoverageResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/videos/{bvid}/coverage [get]
+func (h *CoverageHandler) GetCoverage(c *gin.Context) {
+	var req dto.GetCoverageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	loc, err := parseTimezone(req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	var start, end time.Time
+	if req.StartTime != "" {
+		if start, err = parseRequestTime(req.StartTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_time format: %v", err))
+			return
+		}
+	}
+	if req.EndTime != "" {
+		if end, err = parseRequestTime(req.EndTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid end_time format: %v", err))
+			return
+		}
+	}
+
+	coverage, err := h.appService.GetCoverage(c.Request.Context(), c.Param("bvid"), start, end)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate coverage: %v", err))
+		return
+	}
+
+	respData := dto.GetCoverageResponse{
+		AID:                 coverage.Video.AID,
+		BVID:                coverage.Video.BVID,
+		Title:               coverage.Video.Title,
+		TotalDurationSec:    coverage.TotalSeconds,
+		CoveredSec:          coverage.CoveredSeconds,
+		CompletionPercent:   roundPercent(coverage.Percent()),
+		Parts:               make([]dto.PartCoverage, 0, len(coverage.Pages)),
+		HistoryPrunedBefore: formatPrunedBefore(coverage.PrunedBefore),
+	}
+	for _, p := range coverage.Pages {
+		respData.Parts = append(respData.Parts, dto.PartCoverage{
+			CID:               p.Page.Cid,
+			Page:              p.Page.Page,
+			Part:              p.Page.Part,
+			DurationSec:       p.Page.Duration,
+			CoveredSec:        p.CoveredSeconds,
+			CompletionPercent: roundPercent(p.Percent()),
+			Covered:           toPositionRanges(p.Covered),
+			Gaps:              toPositionRanges(p.Gaps),
+		})
+	}
+	response.Success(c, respData)
+}
+
+// toPositionRanges 把领域层的位置区间转换为 DTO。
+func toPositionRanges(ranges []service.PositionRange) []dto.PositionRange {
+	result := make([]dto.PositionRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, dto.PositionRange{StartSec: r.Start, EndSec: r.End})
+	}
+	return result
+}
+
+// formatPrunedBefore 按 RFC3339 格式化原始记录的清理水位线，没有清理时返回空字符串。
+func formatPrunedBefore(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// roundPercent 把百分比保留两位小数。
+func roundPercent(percent float64) float64 {
+	return math.Round(percent*100) / 100
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// GetCombinedSegmentsRequest 汇总多个视频观看分段的请求体。
+type GetCombinedSegmentsRequest struct {
+	BVIDs     []string `json:"bvids" binding:"omitempty,max=100,dive,required"` // 可选，要汇总的视频 (BV 号)，为空时汇总所有视频
+	StartTime string   `json:"start_time" binding:"required"`                   // RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string   `json:"end_time" binding:"required"`                     // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string   `json:"tz" binding:"omitempty"`                          // 可选，IANA 时区名，分段和返回时间使用该时区
+	// 时间间隔，固定时长或日历单位，与 GetWatchedSegmentsRequest 相同
+	Interval string `json:"interval" binding:"required,oneof=10m 30m 1h 1d day week month quarter year"`
+	// 可选，日历单位下每天开始的整点 (0-23)，默认 0
+	DayCutoffHour int `json:"day_cutoff_hour" binding:"omitempty,min=0,max=23"`
+	// 可选，观看时长归属方式 (start, proportional)，默认由 WATCH_TIME_ATTRIBUTION 决定
+	Attribution string `json:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对，默认不计入
+	IncludeSuspicious bool `json:"include_suspicious"`
+}
+
+// VideoWatchedDuration 一个视频在分段 (或整个范围) 内的观看时长。
+type VideoWatchedDuration struct {
+	AID                  int64  `json:"aid"`
+	BVID                 string `json:"bvid"`
+	WatchedDurationSec   int64  `json:"watched_duration_seconds"` // 包含重看
+	RewatchedDurationSec int64  `json:"rewatched_duration_seconds"`
+	SkippedDurationSec   int64  `json:"skipped_duration_seconds"`
+}
+
+// CombinedSegment 所有视频在一个分段内的观看时长。
+type CombinedSegment struct {
+	SegmentStartTime     time.Time `json:"segment_start_time"`
+	SegmentEndTime       time.Time `json:"segment_end_time"`
+	WatchedDurationSec   int64     `json:"watched_duration_seconds"` // 所有视频之和，包含重看
+	RewatchedDurationSec int64     `json:"rewatched_duration_seconds"`
+	SkippedDurationSec   int64     `json:"skipped_duration_seconds"`
+	// 每个视频在该分段内的观看时长 (用于堆叠图)，顺序与响应的 videos 一致
+	Videos []VideoWatchedDuration `json:"videos"`
+}
+
+// GetCombinedSegmentsResponse 汇总多个视频观看分段的响应体 (Data 部分)。
+type GetCombinedSegmentsResponse struct {
+	Videos                    []VideoSummary         `json:"videos"`
+	Segments                  []CombinedSegment      `json:"segments"`
+	VideoTotals               []VideoWatchedDuration `json:"video_totals"` // 每个视频在整个范围内的观看时长
+	TotalWatchedDurationSec   int64                  `json:"total_watched_duration_seconds"`
+	TotalRewatchedDurationSec int64                  `json:"total_rewatched_duration_seconds"`
+	TotalSkippedDurationSec   int64                  `json:"total_skipped_duration_seconds"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// CompareWatchTimeRequest 对比两个时期观看时长的请求体，除 compare_to 外与 GetCombinedSegmentsRequest 相同。
+type CompareWatchTimeRequest struct {
+	BVIDs     []string `json:"bvids" binding:"omitempty,max=100,dive,required"` // 可选，要统计的视频 (BV 号)，为空时统计所有视频
+	StartTime string   `json:"start_time" binding:"required"`                   // 当前期的开始时间，RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string   `json:"end_time" binding:"required"`                     // 当前期的结束时间
+	TZ        string   `json:"tz" binding:"omitempty"`                          // 可选，IANA 时区名，分段和返回时间使用该时区
+	// 时间间隔，固定时长或日历单位，与 GetWatchedSegmentsRequest 相同
+	Interval string `json:"interval" binding:"required,oneof=10m 30m 1h 1d day week month quarter year"`
+	// 可选，日历单位下每天开始的整点 (0-23)，默认 0
+	DayCutoffHour int `json:"day_cutoff_hour" binding:"omitempty,min=0,max=23"`
+	// 可选，对比期：previous 为紧邻的上一期 (默认)，year 为去年同期
+	CompareTo string `json:"compare_to" binding:"omitempty,oneof=previous year"`
+	// 可选，观看时长归属方式 (start, proportional)，默认由 WATCH_TIME_ATTRIBUTION 决定
+	Attribution string `json:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对，默认不计入
+	IncludeSuspicious bool `json:"include_suspicious"`
+}
+
+// WatchTimeDelta 当前期与对比期的观看时长 (包含重看) 及其变化。
+type WatchTimeDelta struct {
+	CurrentWatchedSec  int64    `json:"current_watched_seconds"`
+	PreviousWatchedSec int64    `json:"previous_watched_seconds"`
+	DeltaSec           int64    `json:"delta_seconds"`           // 当前期减对比期，减少时为负数
+	DeltaPercent       *float64 `json:"delta_percent,omitempty"` // 变化的百分比 (保留两位小数)，对比期为 0 时省略
+}
+
+// ComparisonBucket 按下标对齐的一对分段，两期分段数不同时省略缺少的一侧的时间。
+type ComparisonBucket struct {
+	CurrentStartTime  *time.Time `json:"current_start_time,omitempty"`
+	CurrentEndTime    *time.Time `json:"current_end_time,omitempty"`
+	PreviousStartTime *time.Time `json:"previous_start_time,omitempty"`
+	PreviousEndTime   *time.Time `json:"previous_end_time,omitempty"`
+	WatchTimeDelta
+}
+
+// CompareWatchTimeResponse 对比两个时期观看时长的响应体 (Data 部分)。
+type CompareWatchTimeResponse struct {
+	Videos            []VideoSummary     `json:"videos"`
+	CompareTo         string             `json:"compare_to"`
+	CurrentStartTime  time.Time          `json:"current_start_time"` // 当前期的实际范围，日历单位的间隔会扩展到完整的周期
+	CurrentEndTime    time.Time          `json:"current_end_time"`
+	PreviousStartTime time.Time          `json:"previous_start_time"`
+	PreviousEndTime   time.Time          `json:"previous_end_time"`
+	Buckets           []ComparisonBucket `json:"buckets"`
+	Total             WatchTimeDelta     `json:"total"`
+}
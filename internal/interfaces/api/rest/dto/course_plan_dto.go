@@ -0,0 +1,98 @@
+package dto
+
+import "time"
+
+// CoursePlanItemRequest 课程计划中的一项。cid 和 page 都省略时为视频的所有分P。
+type CoursePlanItemRequest struct {
+	BVID string `json:"bvid" binding:"required"`        // BV 号
+	CID  int64  `json:"cid" binding:"omitempty"`        // 可选，分P ID
+	Page int    `json:"page" binding:"omitempty,min=1"` // 可选，分P序号 (从 1 开始)，指定 cid 时忽略
+}
+
+// CreateCoursePlanRequest 创建课程计划请求体。
+type CreateCoursePlanRequest struct {
+	Name       string                  `json:"name" binding:"required,max=255"`              // 计划名称
+	Items      []CoursePlanItemRequest `json:"items" binding:"required,min=1,max=1000,dive"` // 按观看顺序排列的分P，可以来自多个视频
+	StartDate  string                  `json:"start_date" binding:"omitempty"`               // 可选，计划开始的日期 (YYYY-MM-DD)，默认今天
+	TargetDate string                  `json:"target_date" binding:"required"`               // 目标完成日期 (YYYY-MM-DD，含当天)
+}
+
+// CoursePlanItem 课程计划中的一个分P。
+type CoursePlanItem struct {
+	AID  int64  `json:"aid"`
+	BVID string `json:"bvid"`
+	CID  int64  `json:"cid"`
+}
+
+// CoursePlan 课程计划的定义。
+type CoursePlan struct {
+	ID         uint             `json:"id"`
+	Name       string           `json:"name"`
+	StartDate  string           `json:"start_date"`
+	TargetDate string           `json:"target_date"`
+	Items      []CoursePlanItem `json:"items"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// ListCoursePlansResponse 查询课程计划列表响应体 (Data 部分)。
+type ListCoursePlansResponse struct {
+	Plans []CoursePlan `json:"plans"`
+}
+
+// CoursePlanPart 计划中一个分P的观看进度。
+type CoursePlanPart struct {
+	BVID        string `json:"bvid"`
+	Title       string `json:"title"` // 视频标题
+	CID         int64  `json:"cid"`
+	Page        int    `json:"page"` // 分P序号
+	Part        string `json:"part"` // 分P标题
+	DurationSec int64  `json:"duration_seconds"`
+	CoveredSec  int64  `json:"covered_seconds"`
+	Missing     bool   `json:"missing,omitempty"` // 分P已从视频中移除，不参与排期
+}
+
+// CoursePlanAssignment 一个学习日中要看的一个分P的一部分。
+type CoursePlanAssignment struct {
+	BVID       string          `json:"bvid"`
+	CID        int64           `json:"cid"`
+	Page       int             `json:"page"`
+	Part       string          `json:"part"`
+	Ranges     []PositionRange `json:"ranges"` // 要看的位置区间
+	Seconds    int64           `json:"seconds"`
+	WatchedSec int64           `json:"watched_seconds"` // 其中已经看过的时长
+	Finishes   bool            `json:"finishes_part"`   // 看完这部分后该分P没有剩余内容
+}
+
+// CoursePlanDay 一个学习日的任务。
+type CoursePlanDay struct {
+	Date        string                 `json:"date"` // 学习日的日期 (YYYY-MM-DD)
+	Seconds     int64                  `json:"seconds"`
+	WatchedSec  int64                  `json:"watched_seconds"`
+	Assignments []CoursePlanAssignment `json:"assignments"`
+}
+
+// CoursePlanStatus 课程计划的进度和课程表。
+type CoursePlanStatus struct {
+	Plan                  CoursePlan       `json:"plan"`
+	Today                 string           `json:"today"`   // 当前学习日 (YYYY-MM-DD)
+	Started               bool             `json:"started"` // 开始日期不晚于今天
+	Completed             bool             `json:"completed"`
+	TotalDurationSec      int64            `json:"total_duration_seconds"`
+	CoveredSec            int64            `json:"covered_seconds"`
+	CompletionPercent     float64          `json:"completion_percent"`         // 0-100
+	DaysLeft              int              `json:"days_left"`                  // 包括今天在内距离目标日期还剩的学习日，已过目标日期时为 0
+	ExpectedSec           int64            `json:"expected_seconds"`           // 按原计划截至今天结束应看完的时长
+	ScheduleState         string           `json:"schedule_state"`             // 与原计划相比：ahead 超前，behind 落后，on_track 按计划
+	ScheduleOffsetMinutes float64          `json:"schedule_offset_minutes"`    // 超前 (正数) 或落后 (负数) 的分钟数
+	TodayAssignment       *CoursePlanDay   `json:"today_assignment,omitempty"` // 今天的任务，计划尚未开始或已看完时省略
+	TodayRemainingMinutes float64          `json:"today_remaining_minutes"`    // 今天的任务还需观看的分钟数
+	Parts                 []CoursePlanPart `json:"parts"`
+	Syllabus              []CoursePlanDay  `json:"syllabus"` // 从今天 (尚未开始时为开始日期) 起按当前进度重新排期的课程表
+	// 早于该时间的原始记录已被保留策略清理，这部分观看不计入进度 (RFC3339)；原始记录完整时省略
+	HistoryPrunedBefore string `json:"history_pruned_before,omitempty"`
+}
+
+// CoursePlanStatusRequest 查询课程计划进度的查询参数。
+type CoursePlanStatusRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=366"` // 可选，返回课程表的前多少个学习日，默认全部
+}
@@ -0,0 +1,39 @@
+package dto
+
+// GetCoverageRequest 查询观看覆盖情况的查询参数。
+type GetCoverageRequest struct {
+	StartTime string `form:"start_time" binding:"omitempty"` // 可选，只使用该时间 (含) 之后的进度记录，RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"omitempty"`   // 可选，只使用该时间 (不含) 之前的进度记录
+	TZ        string `form:"tz" binding:"omitempty"`         // 可选，IANA 时区名，用于解释不带偏移的时间
+}
+
+// PositionRange 分P内的一段播放位置 [start, end)，单位秒。
+type PositionRange struct {
+	StartSec int64 `json:"start_seconds"`
+	EndSec   int64 `json:"end_seconds"`
+}
+
+// PartCoverage 单个分P的观看覆盖情况。
+type PartCoverage struct {
+	CID               int64           `json:"cid"`
+	Page              int             `json:"page"` // 分P序号，从 1 开始
+	Part              string          `json:"part"` // 分P标题
+	DurationSec       int64           `json:"duration_seconds"`
+	CoveredSec        int64           `json:"covered_seconds"`
+	CompletionPercent float64         `json:"completion_percent"` // 0-100
+	Covered           []PositionRange `json:"covered"`            // 看过的位置区间
+	Gaps              []PositionRange `json:"gaps"`               // 没看过的位置区间
+}
+
+// GetCoverageResponse 查询观看覆盖情况响应体 (Data 部分)。
+type GetCoverageResponse struct {
+	AID               int64          `json:"aid"`
+	BVID              string         `json:"bvid"`
+	Title             string         `json:"title"`
+	TotalDurationSec  int64          `json:"total_duration_seconds"`
+	CoveredSec        int64          `json:"covered_seconds"`
+	CompletionPercent float64        `json:"completion_percent"` // 整个课程的完成度，0-100
+	Parts             []PartCoverage `json:"parts"`
+	// 早于该时间的原始记录已被保留策略清理，这部分观看不计入覆盖 (RFC3339)；原始记录完整时省略
+	HistoryPrunedBefore string `json:"history_pruned_before,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+// GetForecastRequest 查询完成预测的查询参数。
+type GetForecastRequest struct {
+	Days int    `form:"days" binding:"omitempty,min=1,max=90"` // 可选，用最近多少个完整的日历日计算每日进度，默认 14
+	TZ   string `form:"tz" binding:"omitempty"`                // 可选，IANA 时区名，决定日历日的边界和返回的日期，默认使用聚合时区
+}
+
+// DailyProgress 某一天首次观看的时长。
+type DailyProgress struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	WatchedSec int64  `json:"watched_seconds"`
+}
+
+// GetForecastResponse 查询完成预测响应体 (Data 部分)。
+// 预计完成日期按"从今天起每天保持该进度"推算：今天就能看完时为今天。
+// 无法预测 (最近没有进度) 时日期字段为空；进度偏慢的一侧没有上界时 latest_completion_date 为空。
+type GetForecastResponse struct {
+	AID               int64           `json:"aid"`
+	BVID              string          `json:"bvid"`
+	Title             string          `json:"title"`
+	TotalDurationSec  int64           `json:"total_duration_seconds"`
+	CoveredSec        int64           `json:"covered_seconds"`
+	RemainingSec      int64           `json:"remaining_seconds"`
+	CompletionPercent float64         `json:"completion_percent"` // 0-100
+	WindowDays        int             `json:"window_days"`
+	DailyPaceSec      float64         `json:"daily_pace_seconds"`  // 指数加权平均的每日进度
+	PaceStdDevSec     float64         `json:"pace_stddev_seconds"` // 每日进度的指数加权标准差
+	Daily             []DailyProgress `json:"daily"`               // 计算进度使用的每一天，按日期升序排列
+	Completed         bool            `json:"completed"`           // 所有分P都已看过
+	Predictable       bool            `json:"predictable"`         // 是否给出了预测
+	EstimatedDays     float64         `json:"estimated_days"`      // 按平均进度还需的天数
+	EstimatedDate     string          `json:"estimated_completion_date,omitempty"`
+	EarliestDate      string          `json:"earliest_completion_date,omitempty"` // 置信区间的下界 (进度偏快)
+	LatestDate        string          `json:"latest_completion_date,omitempty"`   // 置信区间的上界 (进度偏慢)
+	ConfidenceLevel   float64         `json:"confidence_level"`                   // 置信区间的置信度 (0.8)
+	// 早于该时间的原始记录已被保留策略清理，剩余时长不含这部分观看 (RFC3339)；原始记录完整时省略
+	HistoryPrunedBefore string `json:"history_pruned_before,omitempty"`
+}
@@ -0,0 +1,75 @@
+package dto
+
+import "time"
+
+// CreateGoalRequest 创建学习目标请求体。
+type CreateGoalRequest struct {
+	BVID         string `json:"bvid" binding:"required"`                              // BV 号
+	Kind         string `json:"kind" binding:"required,oneof=daily deadline"`         // 目标类型：daily 每天观看时长，deadline 截止日期前看完
+	DailyMinutes int    `json:"daily_minutes" binding:"required_if=Kind daily,gte=0"` // daily 目标每天的观看分钟数
+	StartDate    string `json:"start_date" binding:"omitempty"`                       // 可选，开始统计的日期 (YYYY-MM-DD)，默认今天
+	Deadline     string `json:"deadline" binding:"required_if=Kind deadline"`         // deadline 目标的截止日期 (YYYY-MM-DD，含当天)
+}
+
+// GetGoalStatusRequest 查询目标完成情况的查询参数。
+type GetGoalStatusRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=366"` // 可选，返回最近多少个学习日的明细，默认 7
+}
+
+// Goal 学习目标的定义。
+type Goal struct {
+	ID           uint      `json:"id"`
+	AID          int64     `json:"aid"`
+	BVID         string    `json:"bvid"`
+	Kind         string    `json:"kind"`
+	DailyMinutes float64   `json:"daily_minutes,omitempty"` // 仅 daily 目标
+	StartDate    string    `json:"start_date"`
+	Deadline     string    `json:"deadline,omitempty"` // 仅 deadline 目标
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GoalDay 一个学习日相对目标的完成情况。
+type GoalDay struct {
+	Date       string `json:"date"`            // 学习日的日期 (YYYY-MM-DD)
+	WatchedSec int64  `json:"watched_seconds"` // daily 目标为观看时长 (含重看)，deadline 目标为首次观看时长
+	TargetSec  int64  `json:"target_seconds"`
+	Met        bool   `json:"met"`
+}
+
+// DeadlineProgress deadline 目标距离截止日期的进度。
+type DeadlineProgress struct {
+	Completed             bool    `json:"completed"`               // 已看完
+	RemainingSec          int64   `json:"remaining_seconds"`       // 还没看过的时长
+	DaysLeft              int     `json:"days_left"`               // 包括今天在内还剩的学习日，已过截止日期时为 0
+	RequiredDailyMinutes  float64 `json:"required_daily_minutes"`  // 从今天起每天需要观看的分钟数 (首次观看)
+	TodayRemainingMinutes float64 `json:"today_remaining_minutes"` // 今天还需观看的分钟数
+	// 早于该时间的原始记录已被保留策略清理，剩余时长不含这部分观看 (RFC3339)；原始记录完整时省略
+	HistoryPrunedBefore string `json:"history_pruned_before,omitempty"`
+}
+
+// GoalStatus 学习目标截至当前学习日的完成情况。
+type GoalStatus struct {
+	Goal            Goal              `json:"goal"`
+	Title           string            `json:"title"`
+	Today           string            `json:"today"`   // 当前学习日 (YYYY-MM-DD)
+	Started         bool              `json:"started"` // 开始日期不晚于今天
+	TodayWatchedSec int64             `json:"today_watched_seconds"`
+	TodayTargetSec  int64             `json:"today_target_seconds"`
+	TodayMet        bool              `json:"today_met"`
+	CurrentStreak   int               `json:"current_streak"` // 截至今天连续达成的天数，今天尚未达成时从昨天算起
+	LongestStreak   int               `json:"longest_streak"`
+	Days            []GoalDay         `json:"days"`                        // 最近的学习日明细，按日期升序排列
+	Deadline        *DeadlineProgress `json:"deadline_progress,omitempty"` // 仅 deadline 目标
+}
+
+// ListGoalStatusesResponse 查询所有目标完成情况响应体 (Data 部分)。
+type ListGoalStatusesResponse struct {
+	DayCutoffHour int          `json:"day_cutoff_hour"` // 学习日的开始时刻 (时区内的整点)
+	Timezone      string       `json:"timezone"`
+	Goals         []GoalStatus `json:"goals"`
+}
+
+// ListGoalsResponse 查询目标定义列表响应体 (Data 部分)。
+type ListGoalsResponse struct {
+	Goals []Goal `json:"goals"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// GetWatchHeatmapRequest 查询观看热力图的查询参数。
+type GetWatchHeatmapRequest struct {
+	AID       string `form:"aid" binding:"omitempty"`       // 可选，AV 号
+	BVID      string `form:"bvid" binding:"omitempty"`      // 可选，BV 号；aid 和 bvid 都为空时统计所有视频
+	StartTime string `form:"start_time" binding:"required"` // RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"required"`   // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string `form:"tz" binding:"omitempty"`        // 可选，IANA 时区名，决定星期和小时的划分，默认使用聚合时区
+}
+
+// VideoSummary 参与统计的视频。
+type VideoSummary struct {
+	AID   int64  `json:"aid"`
+	BVID  string `json:"bvid"`
+	Title string `json:"title"`
+}
+
+// GetWatchHeatmapResponse 查询观看热力图响应体 (Data 部分)。
+// 矩阵为 7 × 24：行按 weekdays 的顺序 (周一到周日)，列为当地时间 0-23 点。
+type GetWatchHeatmapResponse struct {
+	StartTime       time.Time      `json:"start_time"` // 对齐到整点后的开始时间
+	EndTime         time.Time      `json:"end_time"`   // 对齐到整点后的结束时间
+	Timezone        string         `json:"timezone"`
+	Videos          []VideoSummary `json:"videos"`
+	Weekdays        []string       `json:"weekdays"`          // 行对应的星期 (Monday ... Sunday)
+	WatchedSec      [][]int64      `json:"watched_seconds"`   // 观看时长，包含重看
+	RewatchedSec    [][]int64      `json:"rewatched_seconds"` // 其中重看的时长
+	HourCounts      [][]int        `json:"hour_counts"`       // 范围内每个 (星期, 小时) 出现的次数，观看时长除以它即为平均值
+	TotalWatchedSec int64          `json:"total_watched_seconds"`
+}
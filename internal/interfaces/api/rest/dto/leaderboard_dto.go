@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+// GetLeaderboardRequest 查询观看排行榜的查询参数。
+type GetLeaderboardRequest struct {
+	StartTime string `form:"start_time" binding:"required"`           // RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"required"`             // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string `form:"tz" binding:"omitempty"`                  // 可选，IANA 时区名，用于解释省略偏移的时间
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"` // 可选，每个排行榜返回的条目数，默认 10
+	// 可选，观看时长归属方式 (start, proportional)，默认由 WATCH_TIME_ATTRIBUTION 决定
+	Attribution string `form:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对，默认不计入
+	IncludeSuspicious bool `form:"include_suspicious"`
+}
+
+// LeaderboardVideo 视频排行榜中的一项。
+type LeaderboardVideo struct {
+	AID                  int64   `json:"aid"`
+	BVID                 string  `json:"bvid"`
+	Title                string  `json:"title"`
+	OwnerMID             int64   `json:"owner_mid"`
+	OwnerName            string  `json:"owner_name"`
+	TID                  int     `json:"tid"`
+	TName                string  `json:"tname"`
+	WatchedDurationSec   int64   `json:"watched_duration_seconds"` // 包含重看
+	RewatchedDurationSec int64   `json:"rewatched_duration_seconds"`
+	SharePercent         float64 `json:"share_percent"` // 占所有视频观看时长的百分比，0-100
+}
+
+// LeaderboardGroup UP主或分区排行榜中的一项。
+type LeaderboardGroup struct {
+	ID                   int64   `json:"id"` // UP主为 mid，分区为 tid；目录中缺少该信息时为 0
+	Name                 string  `json:"name"`
+	VideoCount           int     `json:"video_count"` // 有观看时长的视频数
+	WatchedDurationSec   int64   `json:"watched_duration_seconds"`
+	RewatchedDurationSec int64   `json:"rewatched_duration_seconds"`
+	SharePercent         float64 `json:"share_percent"`
+}
+
+// GetLeaderboardResponse 查询观看排行榜的响应体 (Data 部分)。
+// 每个排行榜只包含有观看时长的条目，按观看时长降序排列；*_count 为截断前的条目数。
+type GetLeaderboardResponse struct {
+	StartTime                 time.Time          `json:"start_time"`
+	EndTime                   time.Time          `json:"end_time"`
+	TotalWatchedDurationSec   int64              `json:"total_watched_duration_seconds"`
+	TotalRewatchedDurationSec int64              `json:"total_rewatched_duration_seconds"`
+	VideoCount                int                `json:"video_count"`
+	OwnerCount                int                `json:"owner_count"`
+	PartitionCount            int                `json:"partition_count"`
+	Videos                    []LeaderboardVideo `json:"videos"`
+	Owners                    []LeaderboardGroup `json:"owners"`
+	Partitions                []LeaderboardGroup `json:"partitions"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+// ExportProgressRequest 导出原始进度记录的查询参数。
+type ExportProgressRequest struct {
+	AID       string `form:"aid" binding:"omitempty"`                          // 可选，AV 号
+	BVID      string `form:"bvid" binding:"omitempty"`                         // 可选，BV 号 (都不提供时导出所有视频)
+	StartTime string `form:"start_time" binding:"omitempty"`                   // 可选，RFC3339 格式 (含)；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"omitempty"`                     // 可选，RFC3339 格式 (不含)；指定 tz 时也可省略偏移
+	TZ        string `form:"tz" binding:"omitempty"`                           // 可选，IANA 时区名，用于解释不带偏移的时间
+	Format    string `form:"format" binding:"omitempty,oneof=csv ndjson json"` // 可选，默认 ndjson
+}
+
+// ExportSegmentsRequest 导出观看分段的查询参数，与 GetWatchedSegmentsRequest 含义相同。
+type ExportSegmentsRequest struct {
+	AID       string `form:"aid" binding:"omitempty"`                          // 可选，AV 号
+	BVID      string `form:"bvid" binding:"omitempty"`                         // 可选，BV 号 (aid 和 bvid 必须提供一个)
+	StartTime string `form:"start_time" binding:"required"`                    // RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"required"`                      // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string `form:"tz" binding:"omitempty"`                           // 可选，IANA 时区名
+	Format    string `form:"format" binding:"omitempty,oneof=csv ndjson json"` // 可选，默认 csv
+	// 时间间隔，固定时长或日历单位
+	Interval string `form:"interval" binding:"required,oneof=10m 30m 1h 1d day week month quarter year"`
+	// 可选，日历单位下每天开始的整点 (0-23)，默认 0
+	DayCutoffHour int `form:"day_cutoff_hour" binding:"omitempty,min=0,max=23"`
+	// 可选，观看时长归属方式 (start, proportional)
+	Attribution string `form:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对，默认不计入
+	IncludeSuspicious bool `form:"include_suspicious"`
+}
+
+// ImportProgressRequest 导入进度记录的查询参数，请求体为导入数据。
+type ImportProgressRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson json"` // 可选，默认根据 Content-Type 判断
+}
+
+// ImportProgressResponse 导入进度记录响应体 (Data 部分)。
+type ImportProgressResponse struct {
+	Read       int64 `json:"read"`       // 读取的记录数
+	Inserted   int64 `json:"inserted"`   // 实际插入的记录数
+	Duplicates int64 `json:"duplicates"` // 已存在而跳过的记录数
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// ListProgressRequest 分页查询原始进度记录的查询参数。
+type ListProgressRequest struct {
+	CID       int64  `form:"cid" binding:"omitempty,min=1"`            // 可选，只返回上次播放分P为该 CID 的记录
+	StartTime string `form:"start_time" binding:"omitempty"`           // 可选，RFC3339 格式 (含)；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"omitempty"`             // 可选，RFC3339 格式 (不含)；指定 tz 时也可省略偏移
+	TZ        string `form:"tz" binding:"omitempty"`                   // 可选，IANA 时区名，返回的记录时间使用该时区
+	Cursor    string `form:"cursor" binding:"omitempty"`               // 可选，上一页返回的 next_cursor
+	Limit     int    `form:"limit" binding:"omitempty,min=1"`          // 可选，每页条数，默认 100，最大 1000
+	Order     string `form:"order" binding:"omitempty,oneof=asc desc"` // 可选，按记录时间排序，默认 asc
+	// 可选，只返回与上一条记录组成的记录对为该分类的记录
+	PairLabel string `form:"pair_label" binding:"omitempty,oneof=forward seek_back part_switch reset suspicious"`
+}
+
+// ProgressRecord 一条原始进度记录。
+type ProgressRecord struct {
+	ID             uint      `json:"id"`
+	AID            int64     `json:"aid"`
+	BVID           string    `json:"bvid"`
+	LastPlayCID    int64     `json:"last_play_cid"`
+	LastPlayTimeMs int64     `json:"last_play_time_ms"` // 观看进度，单位毫秒
+	RecordedAt     time.Time `json:"recorded_at"`
+	// 与上一条记录组成的记录对的分类 (forward, seek_back, part_switch, reset, suspicious)，视频的第一条记录为空
+	PairLabel string `json:"pair_label"`
+}
+
+// ListProgressResponse 分页查询原始进度记录响应体 (Data 部分)。
+type ListProgressResponse struct {
+	Records    []ProgressRecord `json:"records"`
+	NextCursor string           `json:"next_cursor"` // 下一页的游标，没有更多记录时为空
+	HasMore    bool             `json:"has_more"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// ListSessionsRequest 查询观看会话的查询参数。
+type ListSessionsRequest struct {
+	StartTime string `form:"start_time" binding:"omitempty"` // 可选，只使用该时间 (含) 之后的进度记录，RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string `form:"end_time" binding:"omitempty"`   // 可选，只使用该时间 (不含) 之前的进度记录
+	TZ        string `form:"tz" binding:"omitempty"`         // 可选，IANA 时区名，用于解释不带偏移的时间和返回的会话时间
+	IdleGap   string `form:"idle_gap" binding:"omitempty"`   // 可选，空闲间隔 (如 15m、1h)，默认使用 SESSION_IDLE_GAP
+}
+
+// SessionPart 会话经过的分P。
+type SessionPart struct {
+	CID  int64  `json:"cid"`
+	Page int    `json:"page"` // 分P序号，分P已不在最新列表中时为 0
+	Part string `json:"part"` // 分P标题
+}
+
+// SessionPosition 会话开始或结束时的播放位置。
+type SessionPosition struct {
+	CID         int64 `json:"cid"`
+	Page        int   `json:"page"`
+	PositionSec int64 `json:"position_seconds"`
+}
+
+// ViewingSession 一次观看会话。
+type ViewingSession struct {
+	StartedAt            time.Time       `json:"started_at"`
+	EndedAt              time.Time       `json:"ended_at"`
+	DurationSec          int64           `json:"duration_seconds"`           // 会话持续的时间 (结束时间 - 开始时间)
+	WatchedDurationSec   int64           `json:"watched_duration_seconds"`   // 观看的内容时长，包含重看
+	RewatchedDurationSec int64           `json:"rewatched_duration_seconds"` // 其中重看的时长
+	SkippedDurationSec   int64           `json:"skipped_duration_seconds"`   // 超出倍速上限、视为跳过的进度
+	PlaybackSpeed        float64         `json:"playback_speed"`             // 由连续播放的记录对推断的播放倍速，不超过最大倍速；无法推断时为 0
+	Start                SessionPosition `json:"start"`
+	End                  SessionPosition `json:"end"`
+	Parts                []SessionPart   `json:"parts"`
+}
+
+// ListSessionsResponse 查询观看会话响应体 (Data 部分)。
+type ListSessionsResponse struct {
+	AID                     int64            `json:"aid"`
+	BVID                    string           `json:"bvid"`
+	IdleGapSec              int64            `json:"idle_gap_seconds"`
+	Sessions                []ViewingSession `json:"sessions"`
+	TotalWatchedDurationSec int64            `json:"total_watched_duration_seconds"`
+}
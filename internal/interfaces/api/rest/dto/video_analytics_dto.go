@@ -4,22 +4,50 @@ import "time"
 
 // GetWatchedSegmentsRequest 获取观看分段请求体。
 type GetWatchedSegmentsRequest struct {
-	AID       string `json:"aid" binding:"omitempty"`                                          // 可选，AV 号
-	BVID      string `json:"bvid" binding:"omitempty"`                                         // 可选，BV 号 (aid 和 bvid 必须提供一个)
-	StartTime string `json:"start_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"` // RFC3339 格式
-	EndTime   string `json:"end_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`   // RFC3339 格式
-	Interval  string `json:"interval" binding:"required,oneof=10m 30m 1h 1d"`                  // 时间间隔 (10分钟, 30分钟, 1小时, 1天)
+	AID       string `json:"aid" binding:"omitempty"`       // 可选，AV 号
+	BVID      string `json:"bvid" binding:"omitempty"`      // 可选，BV 号 (aid 和 bvid 必须提供一个)
+	StartTime string `json:"start_time" binding:"required"` // RFC3339 格式；指定 tz 时也可省略偏移，按 tz 的当地时间解释
+	EndTime   string `json:"end_time" binding:"required"`   // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string `json:"tz" binding:"omitempty"`        // 可选，IANA 时区名 (如 Asia/Shanghai)，分段和返回时间使用该时区
+	// 时间间隔：固定时长 (10m, 30m, 1h, 1d) 从 start_time 起算；日历单位 (day, week, month, quarter, year)
+	// 在 tz 时区内对齐到日、ISO 周 (周一开始)、月、季度或年，范围向外扩展到完整的周期
+	Interval string `json:"interval" binding:"required,oneof=10m 30m 1h 1d day week month quarter year"`
+	// 可选，日历单位下每天开始的整点 (0-23)，如 4 表示凌晨 4 点前的观看计入前一天，默认 0
+	DayCutoffHour int `json:"day_cutoff_hour" binding:"omitempty,min=0,max=23"`
+	// 可选，记录对之间的时长如何归属到分段：start 全部归属到起点所在分段，proportional 按重叠时间比例拆分；默认由 WATCH_TIME_ATTRIBUTION 决定
+	Attribution string `json:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对 (pair_label 为 suspicious)，默认不计入；水位线之前已汇总的时长始终不含可疑记录对
+	IncludeSuspicious bool `json:"include_suspicious"`
+}
+
+// PartWatchedDuration 一个分P在分段 (或整个范围) 内的观看时长。
+type PartWatchedDuration struct {
+	CID                  int64  `json:"cid"`
+	Page                 int    `json:"page"`                       // 分P序号
+	Part                 string `json:"part"`                       // 分P标题
+	WatchedDurationSec   int64  `json:"watched_duration_seconds"`   // 观看的时长（秒），包含重看
+	RewatchedDurationSec int64  `json:"rewatched_duration_seconds"` // 其中重看的时长（秒）
 }
 
 // WatchedSegment 观看分段信息。
 type WatchedSegment struct {
-	SegmentStartTime   time.Time `json:"segment_start_time"`       // 分段开始时间
-	SegmentEndTime     time.Time `json:"segment_end_time"`         // 分段结束时间
-	WatchedDurationSec int64     `json:"watched_duration_seconds"` // 该分段内观看的时长（秒）
+	SegmentStartTime     time.Time `json:"segment_start_time"`         // 分段开始时间
+	SegmentEndTime       time.Time `json:"segment_end_time"`           // 分段结束时间
+	WatchedDurationSec   int64     `json:"watched_duration_seconds"`   // 该分段内观看的时长（秒），包含重看
+	RewatchedDurationSec int64     `json:"rewatched_duration_seconds"` // 其中重看的时长（秒）：分P之前到达过的最远位置之前的观看
+	SkippedDurationSec   int64     `json:"skipped_duration_seconds"`   // 超出倍速上限、视为跳过的进度（秒），不计入观看时长
+	PlaybackSpeed        float64   `json:"playback_speed"`             // 由连续播放的记录对推断的播放倍速，不超过最大倍速；无法推断时为 0
+	// 观看时长按分P的分布，按分P序号排列
+	Parts []PartWatchedDuration `json:"parts"`
 }
 
 // GetWatchedSegmentsResponse 获取观看分段响应体 (Data 部分)。
 type GetWatchedSegmentsResponse struct {
-	Segments                []WatchedSegment `json:"segments"`
-	TotalWatchedDurationSec int64            `json:"total_watched_duration_seconds"` // 新增：总观看时长（秒）
+	Segments                  []WatchedSegment `json:"segments"`
+	TotalWatchedDurationSec   int64            `json:"total_watched_duration_seconds"`   // 新增：总观看时长（秒）
+	TotalRewatchedDurationSec int64            `json:"total_rewatched_duration_seconds"` // 其中重看的总时长（秒）
+	TotalSkippedDurationSec   int64            `json:"total_skipped_duration_seconds"`   // 跳过的总进度（秒）
+	AveragePlaybackSpeed      float64          `json:"average_playback_speed"`           // 整个范围内推断的平均播放倍速，无法推断时为 0
+	// 整个范围内观看时长按分P的分布
+	Parts []PartWatchedDuration `json:"parts"`
 }
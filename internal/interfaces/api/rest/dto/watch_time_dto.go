@@ -0,0 +1,71 @@
+package dto
+
+// CalculateWatchTimeRequest 计算两个播放位置之间观看时长的请求体。
+type CalculateWatchTimeRequest struct {
+	AID              string `json:"aid" binding:"omitempty"`                // 可选，AV 号
+	BVID             string `json:"bvid" binding:"omitempty"`               // 可选，BV 号 (aid 和 bvid 必须提供一个)
+	StartCID         int64  `json:"start_cid" binding:"required"`           // 起点所在分P
+	StartPositionSec int64  `json:"start_position_seconds" binding:"min=0"` // 起点在分P中的位置 (秒)
+	EndCID           int64  `json:"end_cid" binding:"required"`             // 终点所在分P
+	EndPositionSec   int64  `json:"end_position_seconds" binding:"min=0"`   // 终点在分P中的位置 (秒)
+}
+
+// CalculateWatchTimeResponse 计算两个播放位置之间观看时长的响应体 (Data 部分)。
+type CalculateWatchTimeResponse struct {
+	PageVersion        int                   `json:"page_version"`             // 计算使用的分P列表版本
+	WatchedDurationSec int64                 `json:"watched_duration_seconds"` // 按播放顺序从起点到终点的内容时长
+	Parts              []PartWatchedDuration `json:"parts"`                    // 每个分P贡献的时长
+}
+
+// SimulationPage 模拟使用的分P。
+type SimulationPage struct {
+	CID         int64  `json:"cid" binding:"required"`
+	Page        int    `json:"page" binding:"omitempty,min=1"` // 可选，分P序号，默认按列表顺序从 1 开始
+	Part        string `json:"part"`                           // 可选，分P标题
+	DurationSec int64  `json:"duration_seconds" binding:"min=0"`
+}
+
+// SimulationPoint 模拟使用的一个进度点，相当于一条进度记录。
+type SimulationPoint struct {
+	RecordedAt  string `json:"recorded_at" binding:"required"` // RFC3339 格式；指定 tz 时也可省略偏移
+	CID         int64  `json:"cid"`                            // 分P ID，0 相当于登录失效时的记录
+	PositionSec int64  `json:"position_seconds" binding:"min=0"`
+}
+
+// SimulateWatchTimeRequest 观看时长模拟的请求体：分P列表和进度点，以及与 watch-segments 相同的分段参数。
+type SimulateWatchTimeRequest struct {
+	Pages     []SimulationPage  `json:"pages" binding:"required,min=1,max=1000,dive"`
+	Points    []SimulationPoint `json:"points" binding:"required,min=2,max=10000,dive"`
+	StartTime string            `json:"start_time" binding:"required"` // RFC3339 格式；指定 tz 时也可省略偏移
+	EndTime   string            `json:"end_time" binding:"required"`   // RFC3339 格式；指定 tz 时也可省略偏移
+	TZ        string            `json:"tz" binding:"omitempty"`        // 可选，IANA 时区名，分段和返回时间使用该时区
+	// 时间间隔，固定时长或日历单位，与 GetWatchedSegmentsRequest 相同
+	Interval string `json:"interval" binding:"required,oneof=10m 30m 1h 1d day week month quarter year"`
+	// 可选，日历单位下每天开始的整点 (0-23)，默认 0
+	DayCutoffHour int `json:"day_cutoff_hour" binding:"omitempty,min=0,max=23"`
+	// 可选，观看时长归属方式 (start, proportional)，默认由 WATCH_TIME_ATTRIBUTION 决定
+	Attribution string `json:"attribution" binding:"omitempty,oneof=start proportional"`
+	// 可选，是否计入被标记为可疑的记录对，默认不计入
+	IncludeSuspicious bool `json:"include_suspicious"`
+}
+
+// SimulatedPair 模拟中一对相邻进度点的明细。
+type SimulatedPair struct {
+	StartRecordedAt      string `json:"start_recorded_at"`
+	StartCID             int64  `json:"start_cid"`
+	StartPositionSec     int64  `json:"start_position_seconds"`
+	EndRecordedAt        string `json:"end_recorded_at"`
+	EndCID               int64  `json:"end_cid"`
+	EndPositionSec       int64  `json:"end_position_seconds"`
+	PairLabel            string `json:"pair_label"`                 // 记录对的分类，suspicious 默认不计入分段
+	WatchedDurationSec   int64  `json:"watched_duration_seconds"`   // 按观看时长策略计算，包含重看
+	RewatchedDurationSec int64  `json:"rewatched_duration_seconds"` // 其中重看的时长
+	SkippedDurationSec   int64  `json:"skipped_duration_seconds"`   // 超出倍速上限被跳过的时长
+	Error                string `json:"error,omitempty"`            // 无法计算的原因
+}
+
+// SimulateWatchTimeResponse 观看时长模拟的响应体 (Data 部分)。
+type SimulateWatchTimeResponse struct {
+	GetWatchedSegmentsResponse
+	Pairs []SimulatedPair `json:"pairs"` // 按记录时间排序的相邻进度点明细
+}
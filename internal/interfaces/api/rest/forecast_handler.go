@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// maxForecastHorizonDays 超过该天数的预测不给出日期 (进度太慢，日期没有意义)。
+const maxForecastHorizonDays = 36500
+
+// ForecastHandler 处理完成预测相关的 API 请求。
+type ForecastHandler struct {
+	appService *application.ForecastService
+}
+
+// NewForecastHandler 创建 ForecastHandler 实例。
+func NewForecastHandler(appService *application.ForecastService) *ForecastHandler {
+	return &ForecastHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册完成预测相关的路由。
+func (h *ForecastHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/videos/:bvid/forecast", h.GetForecast)
+}
+
+// GetForecast 处理查询视频完成预测的请求。
+// @Summary 预测什么时候能看完视频
+// @Description 用最近几个完整日历日 (不含今天) 的首次观看时长计算指数加权的每日进度，结合所有分P中还没看过的时长，给出预计完成日期和 80% 置信区间。
+// @Tags Forecast
+// @Produce json
+// @Param bvid path string true "BV 号"
+// @Param days query int false "用最近多少天计算每日进度 (1-90)，默认 14"
+// @Param tz query string false "时区 (IANA 名称)，决定日历日的边界和返回的日期，默认使用聚合时区"
+// @Success 200 {object} response.APIResponse{data=dto.GetForecastResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/videos/{bvid}/forecast [get]
+func (h *ForecastHandler) GetForecast(c *gin.Context) {
+	var req dto.GetForecastRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	loc, err := parseTimezone(req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	forecast, err := h.appService.GetForecast(c.Request.Context(), c.Param("bvid"), req.Days, loc, time.Now())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to forecast completion: %v", err))
+		return
+	}
+
+	respData := dto.GetForecastResponse{
+		AID:                 forecast.Video.AID,
+		BVID:                forecast.Video.BVID,
+		Title:               forecast.Video.Title,
+		TotalDurationSec:    forecast.TotalSeconds,
+		CoveredSec:          forecast.CoveredSeconds,
+		RemainingSec:        int64(forecast.Remaining.Seconds()),
+		CompletionPercent:   roundPercent(forecast.Percent()),
+		WindowDays:          len(forecast.Daily),
+		DailyPaceSec:        math.Round(forecast.DailyPace.Seconds()),
+		PaceStdDevSec:       math.Round(forecast.PaceStdDev.Seconds()),
+		Daily:               make([]dto.DailyProgress, 0, len(forecast.Daily)),
+		Completed:           forecast.Completed,
+		Predictable:         forecast.Predictable,
+		EstimatedDays:       math.Round(forecast.ExpectedDays*10) / 10,
+		ConfidenceLevel:     service.ForecastConfidence,
+		HistoryPrunedBefore: formatPrunedBefore(forecast.PrunedBefore),
+	}
+	for _, d := range forecast.Daily {
+		respData.Daily = append(respData.Daily, dto.DailyProgress{
+			Date:       d.Date.Format(time.DateOnly),
+			WatchedSec: int64(d.Watched.Seconds()),
+		})
+	}
+	if forecast.Predictable && !forecast.Completed {
+		respData.EstimatedDate = completionDate(forecast.Today, forecast.ExpectedDays)
+		respData.EarliestDate = completionDate(forecast.Today, forecast.LowerDays)
+		respData.LatestDate = completionDate(forecast.Today, forecast.UpperDays)
+	}
+	response.Success(c, respData)
+}
+
+// completionDate 返回从 today 起还需 days 天时看完的日期 (YYYY-MM-DD)：不超过 1 天时为今天。
+// 天数超出 maxForecastHorizonDays (包括 +Inf) 时返回空字符串。
+func completionDate(today time.Time, days float64) string {
+	if days > maxForecastHorizonDays {
+		return ""
+	}
+	offset := int(math.Ceil(days)) - 1
+	if offset < 0 {
+		offset = 0
+	}
+	return today.AddDate(0, 0, offset).Format(time.DateOnly)
+}
@@ -0,0 +1,278 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/repository"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// defaultGoalStatusDays 未指定 days 时返回的学习日明细天数。
+const defaultGoalStatusDays = 7
+
+// GoalHandler 处理学习目标相关的 API 请求。
+type GoalHandler struct {
+	appService *application.GoalService
+}
+
+// NewGoalHandler 创建 GoalHandler 实例。
+func NewGoalHandler(appService *application.GoalService) *GoalHandler {
+	return &GoalHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册学习目标相关的路由。
+func (h *GoalHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/goals", h.CreateGoal)
+	rg.GET("/goals", h.ListGoals)
+	rg.GET("/goals/status", h.ListGoalStatuses)
+	rg.GET("/goals/:id/status", h.GetGoalStatus)
+	rg.DELETE("/goals/:id", h.DeleteGoal)
+}
+
+// CreateGoal 处理创建学习目标的请求。
+// @Summary 创建学习目标
+// @Description 创建 daily 目标 (每天至少观看 daily_minutes 分钟) 或 deadline 目标 (在 deadline 当天结束前看完视频)。日期按学习日 (GOAL_DAY_CUTOFF_HOUR) 解释。
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateGoalRequest true "目标定义"
+// @Success 200 {object} response.APIResponse{data=dto.Goal} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/goals [post]
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	var req dto.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	input := application.GoalInput{
+		BVID:        req.BVID,
+		Kind:        model.GoalKind(req.Kind),
+		DailyTarget: time.Duration(req.DailyMinutes) * time.Minute,
+	}
+	var err error
+	if req.StartDate != "" {
+		if input.StartDate, err = time.Parse(time.DateOnly, req.StartDate); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_date format: %v", err))
+			return
+		}
+	}
+	if req.Deadline != "" {
+		if input.Deadline, err = time.Parse(time.DateOnly, req.Deadline); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid deadline format: %v", err))
+			return
+		}
+	}
+
+	goal, err := h.appService.CreateGoal(c.Request.Context(), input, time.Now())
+	if errors.Is(err, application.ErrInvalidGoal) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to create goal: %v", err))
+		return
+	}
+	response.Success(c, toGoalDTO(goal))
+}
+
+// ListGoals 处理查询所有学习目标定义的请求。
+// @Summary 查询学习目标列表
+// @Tags Goals
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=dto.ListGoalsResponse} "成功响应"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/goals [get]
+func (h *GoalHandler) ListGoals(c *gin.Context) {
+	goals, err := h.appService.ListGoals(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to list goals: %v", err))
+		return
+	}
+	respData := dto.ListGoalsResponse{Goals: make([]dto.Goal, 0, len(goals))}
+	for _, goal := range goals {
+		respData.Goals = append(respData.Goals, toGoalDTO(goal))
+	}
+	response.Success(c, respData)
+}
+
+// DeleteGoal 处理删除学习目标的请求。
+// @Summary 删除学习目标
+// @Tags Goals
+// @Produce json
+// @Param id path int true "目标 ID"
+// @Success 200 {object} response.APIResponse "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 404 {object} response.APIResponse "目标不存在"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/goals/{id} [delete]
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	id, ok := goalIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.appService.DeleteGoal(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrGoalNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to delete goal: %v", err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ListGoalStatuses 处理查询所有学习目标完成情况的请求。
+// @Summary 查询所有学习目标的完成情况
+// @Description 按学习日评估每个目标：今天的观看时长和目标、连续达成天数、最近的每日明细；deadline 目标还返回剩余时长、剩余天数和每天还需观看的分钟数。
+// @Tags Goals
+// @Produce json
+// @Param days query int false "返回最近多少个学习日的明细 (1-366)，默认 7"
+// @Success 200 {object} response.APIResponse{data=dto.ListGoalStatusesResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/goals/status [get]
+func (h *GoalHandler) ListGoalStatuses(c *gin.Context) {
+	var req dto.GetGoalStatusRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	statuses, err := h.appService.ListStatuses(c.Request.Context(), time.Now())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to evaluate goals: %v", err))
+		return
+	}
+	clock := h.appService.Clock()
+	respData := dto.ListGoalStatusesResponse{
+		DayCutoffHour: clock.CutoffHour,
+		Timezone:      clock.Location.String(),
+		Goals:         make([]dto.GoalStatus, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		respData.Goals = append(respData.Goals, toGoalStatusDTO(status, req.Days))
+	}
+	response.Success(c, respData)
+}
+
+// GetGoalStatus 处理查询单个学习目标完成情况的请求。
+// @Summary 查询学习目标的完成情况
+// @Tags Goals
+// @Produce json
+// @Param id path int true "目标 ID"
+// @Param days query int false "返回最近多少个学习日的明细 (1-366)，默认 7"
+// @Success 200 {object} response.APIResponse{data=dto.GoalStatus} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 404 {object} response.APIResponse "目标不存在"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/goals/{id}/status [get]
+func (h *GoalHandler) GetGoalStatus(c *gin.Context) {
+	id, ok := goalIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.GetGoalStatusRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	status, err := h.appService.GetStatus(c.Request.Context(), id, time.Now())
+	if errors.Is(err, repository.ErrGoalNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to evaluate goal: %v", err))
+		return
+	}
+	response.Success(c, toGoalStatusDTO(status, req.Days))
+}
+
+// goalIDParam 解析路径中的目标 ID，无效时写入错误响应并返回 false。
+func goalIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid goal id %q", c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// toGoalDTO 把目标定义转换为 DTO。
+func toGoalDTO(goal *model.Goal) dto.Goal {
+	result := dto.Goal{
+		ID:           goal.ID,
+		AID:          goal.AID,
+		BVID:         goal.BVID,
+		Kind:         string(goal.Kind),
+		DailyMinutes: roundMinutes(time.Duration(goal.DailySeconds) * time.Second),
+		StartDate:    goal.StartDate.Format(time.DateOnly),
+		CreatedAt:    goal.GmtCreate,
+	}
+	if !goal.Deadline.IsZero() {
+		result.Deadline = goal.Deadline.Format(time.DateOnly)
+	}
+	return result
+}
+
+// toGoalStatusDTO 把目标完成情况转换为 DTO，只保留最近 days 个学习日的明细 (不大于 0 时使用默认值)。
+func toGoalStatusDTO(status *application.GoalStatus, days int) dto.GoalStatus {
+	if days <= 0 {
+		days = defaultGoalStatusDays
+	}
+	result := dto.GoalStatus{
+		Goal:          toGoalDTO(status.Goal),
+		Title:         status.Video.Title,
+		Today:         status.Today.Format(time.DateOnly),
+		Started:       len(status.Days) > 0,
+		CurrentStreak: status.CurrentStreak,
+		LongestStreak: status.LongestStreak,
+		Days:          make([]dto.GoalDay, 0, min(days, len(status.Days))),
+	}
+	var today *dto.GoalDay
+	for _, day := range status.Days[max(len(status.Days)-days, 0):] {
+		result.Days = append(result.Days, dto.GoalDay{
+			Date:       day.Date.Format(time.DateOnly),
+			WatchedSec: int64(day.Watched.Seconds()),
+			TargetSec:  int64(day.Target.Seconds()),
+			Met:        day.Met,
+		})
+		today = &result.Days[len(result.Days)-1]
+	}
+	if today != nil {
+		result.TodayWatchedSec = today.WatchedSec
+		result.TodayTargetSec = today.TargetSec
+		result.TodayMet = today.Met
+	}
+
+	if status.Goal.Kind == model.GoalKindDeadline {
+		progress := &dto.DeadlineProgress{
+			Completed:            status.Completed(),
+			RemainingSec:         int64(status.Remaining.Seconds()),
+			DaysLeft:             status.DaysLeft,
+			RequiredDailyMinutes: roundMinutes(status.RequiredDaily),
+			HistoryPrunedBefore:  formatPrunedBefore(status.PrunedBefore),
+		}
+		todayRemaining := status.RequiredDaily - time.Duration(result.TodayWatchedSec)*time.Second
+		progress.TodayRemainingMinutes = roundMinutes(max(todayRemaining, 0))
+		result.Deadline = progress
+	}
+	return result
+}
+
+// roundMinutes 把时长转换为分钟数，保留一位小数并向上取整，避免 "还需 0 分钟" 时实际仍差几秒。
+func roundMinutes(d time.Duration) float64 {
+	return math.Ceil(d.Minutes()*10) / 10
+}
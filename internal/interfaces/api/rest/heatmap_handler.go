@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// HeatmapHandler 处理观看热力图相关的 API 请求。
+type HeatmapHandler struct {
+	appService *application.HeatmapService
+}
+
+// NewHeatmapHandler 创建 HeatmapHandler 实例。
+func NewHeatmapHandler(appService *application.HeatmapService) *HeatmapHandler {
+	return &HeatmapHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看热力图相关的路由。
+func (h *HeatmapHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/video/watch-heatmap", h.GetWatchHeatmap)
+}
+
+// GetWatchHeatmap 处理查询观看热力图的请求。
+// @Summary 按星期 × 小时统计观看时长
+// @Description 把时间范围内的观看时长按 tz 时区的星期和小时汇总为 7 × 24 的矩阵 (行为周一到周日，列为 0-23 点)。
+// @Description 指定 aid 或 bvid 时只统计该视频，否则统计所有视频。记录对之间的时长按与每个小时重叠的时间比例拆分；范围对齐到整点，最多 366 天。
+// @Tags VideoAnalytics
+// @Produce json
+// @Param aid query string false "AV 号"
+// @Param bvid query string false "BV 号"
+// @Param start_time query string true "开始时间"
+// @Param end_time query string true "结束时间 (不含)"
+// @Param tz query string false "时区 (IANA 名称)，决定星期和小时的划分，默认使用聚合时区"
+// @Success 200 {object} response.APIResponse{data=dto.GetWatchHeatmapResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/video/watch-heatmap [get]
+func (h *HeatmapHandler) GetWatchHeatmap(c *gin.Context) {
+	var req dto.GetWatchHeatmapRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	heatmap, err := h.appService.GetHeatmap(c.Request.Context(), req.AID, req.BVID, startTime, endTime, loc)
+	if errors.Is(err, application.ErrInvalidHeatmapRange) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate watch heatmap: %v", err))
+		return
+	}
+
+	respData := dto.GetWatchHeatmapResponse{
+		StartTime:       heatmap.Start,
+		EndTime:         heatmap.End,
+		Timezone:        heatmap.Location.String(),
+		Videos:          make([]dto.VideoSummary, 0, len(heatmap.Videos)),
+		TotalWatchedSec: int64(heatmap.Total.Seconds()),
+	}
+	for _, video := range heatmap.Videos {
+		respData.Videos = append(respData.Videos, dto.VideoSummary{AID: video.AID, BVID: video.BVID, Title: video.Title})
+	}
+	// 行按周一到周日排列
+	for i := range 7 {
+		weekday := time.Weekday((i + 1) % 7)
+		cells := heatmap.Cells[weekday]
+		watched, rewatched, hours := make([]int64, 24), make([]int64, 24), make([]int, 24)
+		for hour, cell := range cells {
+			watched[hour] = int64(cell.Watched.Seconds())
+			rewatched[hour] = int64(cell.Rewatched.Seconds())
+			hours[hour] = cell.Hours
+		}
+		respData.Weekdays = append(respData.Weekdays, weekday.String())
+		respData.WatchedSec = append(respData.WatchedSec, watched)
+		respData.RewatchedSec = append(respData.RewatchedSec, rewatched)
+		respData.HourCounts = append(respData.HourCounts, hours)
+	}
+	response.Success(c, respData)
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// LeaderboardHandler 处理观看排行榜相关的 API 请求。
+type LeaderboardHandler struct {
+	appService *application.LeaderboardService
+}
+
+// NewLeaderboardHandler 创建 LeaderboardHandler 实例。
+func NewLeaderboardHandler(appService *application.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看排行榜相关的路由。
+func (h *LeaderboardHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/video/leaderboard", h.GetLeaderboard)
+}
+
+// GetLeaderboard 处理查询观看排行榜的请求。
+// @Summary 按观看时长排名视频、UP主和分区
+// @Description 统计所有视频在时间范围内 (最多 366 天) 的观看时长，分别返回视频、UP主和分区的排行榜，每项包含观看时长、视频数和占总时长的百分比。
+// @Tags VideoAnalytics
+// @Produce json
+// @Param start_time query string true "开始时间"
+// @Param end_time query string true "结束时间 (不含)"
+// @Param tz query string false "时区 (IANA 名称)，用于解释省略偏移的时间"
+// @Param limit query int false "每个排行榜返回的条目数，默认 10，最多 100"
+// @Param attribution query string false "观看时长归属方式 (start, proportional)"
+// @Param include_suspicious query bool false "是否计入被标记为可疑的记录对"
+// @Success 200 {object} response.APIResponse{data=dto.GetLeaderboardResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/video/leaderboard [get]
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	var req dto.GetLeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	startTime, endTime, _, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	board, err := h.appService.GetLeaderboard(c.Request.Context(), startTime, endTime,
+		application.Attribution(req.Attribution), req.IncludeSuspicious, req.Limit)
+	if errors.Is(err, application.ErrInvalidLeaderboard) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate leaderboard: %v", err))
+		return
+	}
+
+	respData := dto.GetLeaderboardResponse{
+		StartTime:                 board.Start,
+		EndTime:                   board.End,
+		TotalWatchedDurationSec:   int64(board.Total.Seconds()),
+		TotalRewatchedDurationSec: int64(board.TotalRewatched.Seconds()),
+		VideoCount:                board.VideoCount,
+		OwnerCount:                board.OwnerCount,
+		PartitionCount:            board.PartitionCount,
+		Videos:                    make([]dto.LeaderboardVideo, 0, len(board.Videos)),
+		Owners:                    toLeaderboardGroups(board.Owners),
+		Partitions:                toLeaderboardGroups(board.Partitions),
+	}
+	for _, entry := range board.Videos {
+		video := entry.Video
+		respData.Videos = append(respData.Videos, dto.LeaderboardVideo{
+			AID:                  video.AID,
+			BVID:                 video.BVID,
+			Title:                video.Title,
+			OwnerMID:             video.OwnerMID,
+			OwnerName:            video.OwnerName,
+			TID:                  video.TID,
+			TName:                video.TName,
+			WatchedDurationSec:   int64(entry.Watched.Seconds()),
+			RewatchedDurationSec: int64(entry.Rewatched.Seconds()),
+			SharePercent:         roundPercent(entry.Share * 100),
+		})
+	}
+	response.Success(c, respData)
+}
+
+// toLeaderboardGroups 把UP主或分区排行榜转换为 DTO。
+func toLeaderboardGroups(entries []application.LeaderboardEntry) []dto.LeaderboardGroup {
+	result := make([]dto.LeaderboardGroup, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, dto.LeaderboardGroup{
+			ID:                   entry.ID,
+			Name:                 entry.Name,
+			VideoCount:           entry.VideoCount,
+			WatchedDurationSec:   int64(entry.Watched.Seconds()),
+			RewatchedDurationSec: int64(entry.Rewatched.Seconds()),
+			SharePercent:         roundPercent(entry.Share * 100),
+		})
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package rest
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/infrastructure/exchange"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// ProgressExchangeHandler 处理进度数据导出和导入相关的 API 请求。
+type ProgressExchangeHandler struct {
+	appService *application.ProgressExchangeService
+}
+
+// NewProgressExchangeHandler 创建 ProgressExchangeHandler 实例。
+func NewProgressExchangeHandler(appService *application.ProgressExchangeService) *ProgressExchangeHandler {
+	return &ProgressExchangeHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册导出和导入相关的路由。
+func (h *ProgressExchangeHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/progress/export", h.ExportProgress)
+	rg.POST("/progress/import", h.ImportProgress)
+	rg.GET("/video/watch-segments/export", h.ExportSegments)
+}
+
+// ExportProgress 处理导出原始进度记录的请求，结果以流的形式直接写入响应体。
+// @Summary 导出原始进度记录
+// @Description 按视频和时间范围导出 video_progress 记录，支持 CSV、NDJSON 和 JSON。
+// @Tags ProgressExchange
+// @Produce text/csv,application/x-ndjson,json
+// @Param aid query string false "AV 号"
+// @Param bvid query string false "BV 号"
+// @Param start_time query string false "开始时间 (RFC3339，含)"
+// @Param end_time query string false "结束时间 (RFC3339，不含)"
+// @Param tz query string false "时区 (IANA 名称)，用于解释不带偏移的时间"
+// @Param format query string false "导出格式 (csv, ndjson, json)，默认 ndjson"
+// @Success 200 {file} file "导出数据"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Router /api/v1/progress/export [get]
+func (h *ProgressExchangeHandler) ExportProgress(c *gin.Context) {
+	var req dto.ExportProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	loc, err := parseTimezone(req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	var startTime, endTime time.Time
+	if req.StartTime != "" {
+		if startTime, err = parseRequestTime(req.StartTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_time format: %v", err))
+			return
+		}
+	}
+	if req.EndTime != "" {
+		if endTime, err = parseRequestTime(req.EndTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid end_time format: %v", err))
+			return
+		}
+	}
+	format := exchange.FormatNDJSON
+	if req.Format != "" {
+		format = exchange.Format(req.Format)
+	}
+
+	startStream(c, format, "progress")
+	count, err := h.appService.ExportProgress(c.Request.Context(), req.AID, req.BVID, startTime, endTime,
+		exchange.NewProgressEncoder(c.Writer, format))
+	if err != nil {
+		// 响应头已发送，无法再返回错误响应，只能记录日志并中断输出
+		log.Printf("Error exporting progress after %d records: %v", count, err)
+		c.Abort()
+	}
+}
+
+// ExportSegments 处理导出观看分段的请求。
+// @Summary 导出观看分段
+// @Description 参数与 watch-segments 相同，以 CSV、NDJSON 或 JSON 文件形式返回每个分段的观看时长。
+// @Tags ProgressExchange
+// @Produce text/csv,application/x-ndjson,json
+// @Param aid query string false "AV 号"
+// @Param bvid query string false "BV 号"
+// @Param start_time query string true "开始时间 (RFC3339)"
+// @Param end_time query string true "结束时间 (RFC3339)"
+// @Param interval query string true "时间间隔 (10m, 30m, 1h, 1d)"
+// @Param tz query string false "分段所在时区 (IANA 名称，如 Asia/Shanghai)"
+// @Param format query string false "导出格式 (csv, ndjson, json)，默认 csv"
+// @Param attribution query string false "观看时长归属方式 (start, proportional)，默认由 WATCH_TIME_ATTRIBUTION 决定"
+// @Param include_suspicious query bool false "是否计入被标记为可疑的记录对，默认不计入"
+// @Success 200 {file} file "导出数据"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/video/watch-segments/export [get]
+func (h *ProgressExchangeHandler) ExportSegments(c *gin.Context) {
+	var req dto.ExportSegmentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	if req.AID == "" && req.BVID == "" {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, "Either aid or bvid must be provided")
+		return
+	}
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	interval, err := application.ParseSegmentInterval(req.Interval, req.DayCutoffHour)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	format := exchange.FormatCSV
+	if req.Format != "" {
+		format = exchange.Format(req.Format)
+	}
+
+	// 分段结果需要先完整计算，计算失败时仍可返回 JSON 错误响应
+	result, err := h.appService.ExportSegments(c.Request.Context(), req.AID, req.BVID, startTime, endTime, interval, loc,
+		application.Attribution(req.Attribution), req.IncludeSuspicious, &deferredSegmentEncoder{start: func() application.SegmentEncoder {
+			startStream(c, format, "watch-segments")
+			return exchange.NewSegmentEncoder(c.Writer, format)
+		}})
+	if err != nil && !c.Writer.Written() {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to export watched segments: %v", err))
+		return
+	}
+	if err != nil {
+		log.Printf("Error exporting watched segments after %d segments: %v", result, err)
+		c.Abort()
+	}
+}
+
+// ImportProgress 处理导入进度记录的请求，请求体以流的形式读取。
+// @Summary 导入进度记录
+// @Description 导入 CSV、NDJSON 或 JSON 格式的进度记录，(aid, recorded_at) 已存在的记录会被跳过，可重复导入。
+// @Tags ProgressExchange
+// @Accept text/csv,application/x-ndjson,json
+// @Produce json
+// @Param format query string false "导入格式 (csv, ndjson, json)，默认根据 Content-Type 判断"
+// @Success 200 {object} response.APIResponse{data=dto.ImportProgressResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Router /api/v1/progress/import [post]
+func (h *ProgressExchangeHandler) ImportProgress(c *gin.Context) {
+	var req dto.ImportProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	var format exchange.Format
+	var err error
+	if req.Format != "" {
+		format = exchange.Format(req.Format)
+	} else if format, err = exchange.FormatFromContentType(c.ContentType()); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Cannot determine import format: %v", err))
+		return
+	}
+
+	result, err := h.appService.ImportProgress(c.Request.Context(), exchange.NewProgressDecoder(c.Request.Body, format))
+	respData := dto.ImportProgressResponse{
+		Read:       result.Read,
+		Inserted:   result.Inserted,
+		Duplicates: result.Duplicates,
+	}
+	if err != nil {
+		// 出错前已写入的批次不会回滚，修正数据后重新导入即可 (已存在的记录会被跳过)
+		response.ErrorWithData(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Failed to import progress: %v", err), respData)
+		return
+	}
+	response.Success(c, respData)
+}
+
+// startStream 写出导出文件的响应头。
+func startStream(c *gin.Context, format exchange.Format, name string) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Status(http.StatusOK)
+}
+
+// deferredSegmentEncoder 在写出第一个分段 (或结尾) 时才发送响应头，
+// 使分段计算失败时仍能返回 JSON 错误响应。
+type deferredSegmentEncoder struct {
+	start func() application.SegmentEncoder
+	enc   application.SegmentEncoder
+}
+
+// Encode 写出一个观看分段。
+func (e *deferredSegmentEncoder) Encode(segment application.WatchedSegmentResult) error {
+	if e.enc == nil {
+		e.enc = e.start()
+	}
+	return e.enc.Encode(segment)
+}
+
+// Close 写出格式结尾。
+func (e *deferredSegmentEncoder) Close() error {
+	if e.enc == nil {
+		e.enc = e.start()
+	}
+	return e.enc.Close()
+}
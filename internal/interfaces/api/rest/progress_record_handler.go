@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// ProgressRecordHandler 处理原始进度记录查询相关的 API 请求。
+type ProgressRecordHandler struct {
+	appService *application.ProgressRecordService
+}
+
+// NewProgressRecordHandler 创建 ProgressRecordHandler 实例。
+func NewProgressRecordHandler(appService *application.ProgressRecordService) *ProgressRecordHandler {
+	return &ProgressRecordHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册原始进度记录相关的路由。
+func (h *ProgressRecordHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/videos/:bvid/progress", h.ListProgress)
+}
+
+// ListProgress 处理分页查询原始进度记录的请求。
+// @Summary 分页查询视频的原始进度记录
+// @Description 按 (记录时间, ID) 排序返回 video_progress 记录，使用游标分页：把响应中的 next_cursor 作为下一次请求的 cursor 参数，其余参数保持不变。
+// @Tags ProgressRecord
+// @Produce json
+// @Param bvid path string true "BV 号"
+// @Param cid query int false "只返回上次播放分P为该 CID 的记录"
+// @Param pair_label query string false "只返回与上一条记录组成的记录对为该分类的记录 (forward, seek_back, part_switch, reset, suspicious)"
+// @Param start_time query string false "开始时间 (含)"
+// @Param end_time query string false "结束时间 (不含)"
+// @Param tz query string false "时区 (IANA 名称)，用于解释不带偏移的时间和返回的记录时间"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param limit query int false "每页条数，默认 100，最大 1000"
+// @Param order query string false "asc (默认) 或 desc"
+// @Success 200 {object} response.APIResponse{data=dto.ListProgressResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/videos/{bvid}/progress [get]
+func (h *ProgressRecordHandler) ListProgress(c *gin.Context) {
+	var req dto.ListProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	loc, err := parseTimezone(req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	query := application.ProgressRecordQuery{
+		BVID:       c.Param("bvid"),
+		CID:        req.CID,
+		PairLabel:  model.PairLabel(req.PairLabel),
+		Limit:      req.Limit,
+		Cursor:     req.Cursor,
+		Descending: req.Order == "desc",
+	}
+	if req.StartTime != "" {
+		if query.Start, err = parseRequestTime(req.StartTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_time format: %v", err))
+			return
+		}
+	}
+	if req.EndTime != "" {
+		if query.End, err = parseRequestTime(req.EndTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid end_time format: %v", err))
+			return
+		}
+	}
+
+	page, err := h.appService.ListRecords(c.Request.Context(), query)
+	if errors.Is(err, application.ErrInvalidCursor) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to list progress records: %v", err))
+		return
+	}
+
+	respData := dto.ListProgressResponse{
+		Records:    make([]dto.ProgressRecord, 0, len(page.Records)),
+		NextCursor: page.NextCursor,
+		HasMore:    page.NextCursor != "",
+	}
+	for _, p := range page.Records {
+		recordedAt := p.RecordedAt
+		if loc != nil {
+			recordedAt = recordedAt.In(loc)
+		}
+		respData.Records = append(respData.Records, dto.ProgressRecord{
+			ID:             p.ID,
+			AID:            p.AID,
+			BVID:           p.BVID,
+			LastPlayCID:    p.LastPlayCID,
+			LastPlayTimeMs: p.LastPlayTime,
+			RecordedAt:     recordedAt,
+			PairLabel:      string(p.PairLabel),
+		})
+	}
+	response.Success(c, respData)
+}
@@ -13,14 +13,29 @@ import (
 	// "github.com/krisxia0506/bilibili-watcher/internal/application"
 )
 
-// SetupRouter 配置并返回 Gin 引擎实例。
-// 需要传入所有依赖项，以便创建和注册 handlers。
-func SetupRouter(
-	db *gorm.DB,
-	ginMode string,
-	videoAnalyticsService application.VideoAnalyticsService,
+// Services 路由中各个 handler 依赖的应用服务。
+type Services struct {
+	VideoAnalytics    application.VideoAnalyticsService
+	ProgressExchange  *application.ProgressExchangeService
+	ProgressRecord    *application.ProgressRecordService
+	Coverage          *application.CoverageService
+	Session           *application.SessionService
+	Forecast          *application.ForecastService
+	Goal              *application.GoalService
+	Heatmap           *application.HeatmapService
+	CombinedAnalytics *application.CombinedAnalyticsService
+	Comparison        *application.ComparisonService
+	Leaderboard       *application.LeaderboardService
+	WatchTime         application.WatchTimeService
+	Simulation        *application.SimulationService
+	CoursePlan        *application.CoursePlanService
 	// ... 其他需要的服务
-) *gin.Engine {
+}
+
+// SetupRouter 配置并返回 Gin 引擎实例。
+// services 中的应用服务用于创建和注册 handlers。
+// db 为 nil 时 (演示模式) 健康检查不检测数据库。
+func SetupRouter(db *gorm.DB, ginMode string, services Services) *gin.Engine {
 	gin.SetMode(ginMode)
 	router := gin.Default()
 
@@ -32,6 +47,13 @@ func SetupRouter(
 		}
 		httpCode := http.StatusOK
 
+		if db == nil {
+			// 演示模式下不连接数据库
+			healthStatus["db"] = "disabled"
+			response.Success(c, healthStatus)
+			return
+		}
+
 		sqlDB, err := db.DB()
 		if err != nil {
 			healthStatus["db"] = "error getting DB instance"
@@ -57,9 +79,57 @@ func SetupRouter(
 	apiV1 := router.Group("/api/v1")
 	{
 		// 初始化并注册 Video Analytics Handler
-		videoAnalyticsHandler := NewVideoAnalyticsHandler(videoAnalyticsService)
+		videoAnalyticsHandler := NewVideoAnalyticsHandler(services.VideoAnalytics)
 		videoAnalyticsHandler.RegisterRoutes(apiV1)
 
+		// 初始化并注册进度数据导出/导入 Handler
+		progressExchangeHandler := NewProgressExchangeHandler(services.ProgressExchange)
+		progressExchangeHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册原始进度记录查询 Handler
+		progressRecordHandler := NewProgressRecordHandler(services.ProgressRecord)
+		progressRecordHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看覆盖情况 Handler
+		coverageHandler := NewCoverageHandler(services.Coverage)
+		coverageHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看会话 Handler
+		sessionHandler := NewSessionHandler(services.Session)
+		sessionHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册完成预测 Handler
+		forecastHandler := NewForecastHandler(services.Forecast)
+		forecastHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册学习目标 Handler
+		goalHandler := NewGoalHandler(services.Goal)
+		goalHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册课程计划 Handler
+		coursePlanHandler := NewCoursePlanHandler(services.CoursePlan)
+		coursePlanHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看热力图 Handler
+		heatmapHandler := NewHeatmapHandler(services.Heatmap)
+		heatmapHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册多个视频观看分段汇总 Handler
+		combinedAnalyticsHandler := NewCombinedAnalyticsHandler(services.CombinedAnalytics)
+		combinedAnalyticsHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看时长对比 Handler
+		comparisonHandler := NewComparisonHandler(services.Comparison)
+		comparisonHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看排行榜 Handler
+		leaderboardHandler := NewLeaderboardHandler(services.Leaderboard)
+		leaderboardHandler.RegisterRoutes(apiV1)
+
+		// 初始化并注册观看时长计算 Handler
+		watchTimeHandler := NewWatchTimeHandler(services.WatchTime, services.Simulation)
+		watchTimeHandler.RegisterRoutes(apiV1)
+
 		// 注册其他 handlers...
 		apiV1.GET("/ping", func(c *gin.Context) {
 			response.Success(c, "pong")
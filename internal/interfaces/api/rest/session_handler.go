@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// SessionHandler 处理观看会话相关的 API 请求。
+type SessionHandler struct {
+	appService *application.SessionService
+}
+
+// NewSessionHandler 创建 SessionHandler 实例。
+func NewSessionHandler(appService *application.SessionService) *SessionHandler {
+	return &SessionHandler{appService: appService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看会话相关的路由。
+func (h *SessionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/videos/:bvid/sessions", h.ListSessions)
+}
+
+// ListSessions 处理查询观看会话的请求。
+// @Summary 查询视频的观看会话
+// @Description 把相邻的有效观看记录合并为会话，两次观看间隔超过 idle_gap 时划分为新的会话。返回每个会话的起止时间、经过的分P、观看时长和起止播放位置。
+// @Tags Session
+// @Produce json
+// @Param bvid path string true "BV 号"
+// @Param start_time query string false "只使用该时间 (含) 之后的进度记录"
+// @Param end_time query string false "只使用该时间 (不含) 之前的进度记录"
+// @Param tz query string false "时区 (IANA 名称)，用于解释不带偏移的时间和返回的会话时间"
+// @Param idle_gap query string false "空闲间隔，如 15m、1h，默认使用 SESSION_IDLE_GAP"
+// @Success 200 {object} response.APIResponse{data=dto.ListSessionsResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/videos/{bvid}/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	var req dto.ListSessionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid query parameters: %v", err))
+		return
+	}
+	loc, err := parseTimezone(req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	var start, end time.Time
+	if req.StartTime != "" {
+		if start, err = parseRequestTime(req.StartTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_time format: %v", err))
+			return
+		}
+	}
+	if req.EndTime != "" {
+		if end, err = parseRequestTime(req.EndTime, loc); err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid end_time format: %v", err))
+			return
+		}
+	}
+	var idleGap time.Duration
+	if req.IdleGap != "" {
+		if idleGap, err = time.ParseDuration(req.IdleGap); err != nil || idleGap <= 0 {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid idle_gap: %q", req.IdleGap))
+			return
+		}
+	}
+
+	result, err := h.appService.GetSessions(c.Request.Context(), c.Param("bvid"), start, end, idleGap)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to list sessions: %v", err))
+		return
+	}
+
+	pages := make(map[int64]model.VideoPage, len(result.Pages))
+	for _, p := range result.Pages {
+		pages[p.Cid] = p
+	}
+	respData := dto.ListSessionsResponse{
+		AID:      result.Video.AID,
+		BVID:     result.Video.BVID,
+		Sessions: make([]dto.ViewingSession, 0, len(result.Sessions)),
+	}
+	if idleGap > 0 {
+		respData.IdleGapSec = int64(idleGap.Seconds())
+	} else {
+		respData.IdleGapSec = int64(h.appService.DefaultIdleGap().Seconds())
+	}
+	for _, s := range result.Sessions {
+		session := dto.ViewingSession{
+			StartedAt:            inLocation(s.StartedAt, loc),
+			EndedAt:              inLocation(s.EndedAt, loc),
+			DurationSec:          int64(s.EndedAt.Sub(s.StartedAt).Seconds()),
+			WatchedDurationSec:   int64(s.Total().Seconds()),
+			RewatchedDurationSec: int64(s.Rewatch.Seconds()),
+			SkippedDurationSec:   int64(s.Skipped.Seconds()),
+			PlaybackSpeed:        s.Speed(result.MaxSpeed),
+			Start:                sessionPosition(pages, s.Start),
+			End:                  sessionPosition(pages, s.End),
+			Parts:                make([]dto.SessionPart, 0, len(s.Parts)),
+		}
+		for _, cid := range s.Parts {
+			page := pages[cid]
+			session.Parts = append(session.Parts, dto.SessionPart{CID: cid, Page: page.Page, Part: page.Part})
+		}
+		respData.Sessions = append(respData.Sessions, session)
+		respData.TotalWatchedDurationSec += session.WatchedDurationSec
+	}
+	response.Success(c, respData)
+}
+
+// sessionPosition 把播放位置转换为 DTO，分P序号取自最新的分P列表。
+func sessionPosition(pages map[int64]model.VideoPage, point service.PlaybackPoint) dto.SessionPosition {
+	return dto.SessionPosition{CID: point.CID, Page: pages[point.CID].Page, PositionSec: point.PositionSec}
+}
+
+// inLocation 在指定了时区时把时间转换到该时区。
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
@@ -3,8 +3,6 @@ package rest
 import (
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -32,6 +30,9 @@ func (h *VideoAnalyticsHandler) RegisterRoutes(rg *gin.RouterGroup) {
 // GetWatchedSegments 处理获取观看分段的请求。
 // @Summary 获取指定时间范围和间隔的视频观看时长分段
 // @Description 根据提供的AID或BVID、开始/结束时间和时间间隔，计算每个时间段内的观看时长。
+// @Description 分段在 tz 指定的时区内划分，返回的时间也使用该时区；未指定时使用服务器的聚合时区。
+// @Description interval 为日历单位 (day, week, month, quarter, year) 时分段对齐到 tz 时区的日历，范围扩展到完整的周期，day_cutoff_hour 设置每天开始的整点。
+// @Description 被标记为可疑的记录对默认不计入，include_suspicious 为 true 时计入。
 // @Tags VideoAnalytics
 // @Accept json
 // @Produce json
@@ -54,43 +55,23 @@ func (h *VideoAnalyticsHandler) GetWatchedSegments(c *gin.Context) {
 		return
 	}
 
-	// 解析时间字符串
-	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	// 解析时区和时间字符串
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid start_time format: %v", err))
-		return
-	}
-	endTime, err := time.Parse(time.RFC3339, req.EndTime)
-	if err != nil {
-		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid end_time format: %v", err))
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
 		return
 	}
 
 	// 解析时间间隔字符串
-	var interval time.Duration
-	intervalStr := req.Interval
-
-	if strings.HasSuffix(intervalStr, "d") {
-		// 如果单位是 'd' (天)
-		daysStr := strings.TrimSuffix(intervalStr, "d")
-		days, parseErr := strconv.Atoi(daysStr)
-		if parseErr != nil {
-			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid interval format (days): %v", parseErr))
-			return
-		}
-		// 将天转换为小时，再用 time.ParseDuration 解析
-		intervalStr = fmt.Sprintf("%dh", days*24)
-	}
-
-	interval, err = time.ParseDuration(intervalStr)
+	interval, err := application.ParseSegmentInterval(req.Interval, req.DayCutoffHour)
 	if err != nil {
-		// 如果转换后或原始格式仍然无效 (例如，gin binding oneof 漏掉了某些校验)
-		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid interval format: %v for input '%s' (parsed as '%s')", err, req.Interval, intervalStr))
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
 		return
 	}
 
 	// 调用应用服务
-	analyticsResult, err := h.appService.GetWatchedSegments(c.Request.Context(), req.AID, req.BVID, startTime, endTime, interval)
+	analyticsResult, err := h.appService.GetWatchedSegments(c.Request.Context(), req.AID, req.BVID, startTime, endTime, interval, loc,
+		application.Attribution(req.Attribution), req.IncludeSuspicious)
 	if err != nil {
 		// 根据应用层返回的错误类型决定 HTTP 状态码和业务码
 		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate watched segments: %v", err))
@@ -98,17 +79,89 @@ func (h *VideoAnalyticsHandler) GetWatchedSegments(c *gin.Context) {
 	}
 
 	// 映射结果到响应 DTO
+	response.Success(c, toWatchedSegmentsResponse(analyticsResult))
+}
+
+// toWatchedSegmentsResponse 把观看分段的计算结果转换为响应 DTO。
+func toWatchedSegmentsResponse(result application.VideoAnalyticsResult) dto.GetWatchedSegmentsResponse {
 	respData := dto.GetWatchedSegmentsResponse{
-		Segments:                make([]dto.WatchedSegment, 0, len(analyticsResult.Segments)),
-		TotalWatchedDurationSec: int64(analyticsResult.TotalWatchedDuration.Seconds()),
+		Segments:                  make([]dto.WatchedSegment, 0, len(result.Segments)),
+		TotalWatchedDurationSec:   int64(result.TotalWatchedDuration.Seconds()),
+		TotalRewatchedDurationSec: int64(result.TotalRewatchedDuration.Seconds()),
+		TotalSkippedDurationSec:   int64(result.TotalSkippedDuration.Seconds()),
+		AveragePlaybackSpeed:      result.AveragePlaybackSpeed,
+		Parts:                     toPartWatchedDurations(result.Parts),
 	}
-	for _, seg := range analyticsResult.Segments {
+	for _, seg := range result.Segments {
 		respData.Segments = append(respData.Segments, dto.WatchedSegment{
-			SegmentStartTime:   seg.SegmentStartTime,
-			SegmentEndTime:     seg.SegmentEndTime,
-			WatchedDurationSec: int64(seg.WatchedDuration.Seconds()), // 转换为秒
+			SegmentStartTime:     seg.SegmentStartTime,
+			SegmentEndTime:       seg.SegmentEndTime,
+			WatchedDurationSec:   int64(seg.WatchedDuration.Seconds()), // 转换为秒
+			RewatchedDurationSec: int64(seg.RewatchedDuration.Seconds()),
+			SkippedDurationSec:   int64(seg.SkippedDuration.Seconds()),
+			PlaybackSpeed:        seg.PlaybackSpeed,
+			Parts:                toPartWatchedDurations(seg.Parts),
 		})
 	}
+	return respData
+}
+
+// toPartWatchedDurations 把按分P的观看时长转换为 DTO。
+func toPartWatchedDurations(parts []application.PartWatchTime) []dto.PartWatchedDuration {
+	result := make([]dto.PartWatchedDuration, 0, len(parts))
+	for _, p := range parts {
+		result = append(result, dto.PartWatchedDuration{
+			CID:                  p.CID,
+			Page:                 p.Page,
+			Part:                 p.Part,
+			WatchedDurationSec:   int64(p.WatchedDuration.Seconds()),
+			RewatchedDurationSec: int64(p.RewatchedDuration.Seconds()),
+		})
+	}
+	return result
+}
+
+// requestTimeLayouts 未带时区偏移的时间格式，按请求的 tz 解释。
+var requestTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
 
-	response.Success(c, respData)
+// parseTimeRange 解析请求中的时区 (IANA 名称，如 Asia/Shanghai) 和开始/结束时间。
+// 时间可以是 RFC3339 格式；指定 tz 时也可以省略偏移 (如 2025-05-01 或 2025-05-01T08:00)，按 tz 的当地时间解释。
+// tz 为空时返回的 loc 为 nil，由应用服务使用默认时区。
+func parseTimeRange(startStr, endStr, tz string) (start, end time.Time, loc *time.Location, err error) {
+	if loc, err = parseTimezone(tz); err != nil {
+		return start, end, nil, err
+	}
+	if start, err = parseRequestTime(startStr, loc); err != nil {
+		return start, end, nil, fmt.Errorf("invalid start_time format: %w", err)
+	}
+	if end, err = parseRequestTime(endStr, loc); err != nil {
+		return start, end, nil, fmt.Errorf("invalid end_time format: %w", err)
+	}
+	return start, end, loc, nil
+}
+
+// parseTimezone 解析 IANA 时区名，为空时返回 nil。
+func parseTimezone(tz string) (*time.Location, error) {
+	if tz == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz: %w", err)
+	}
+	return loc, nil
+}
+
+// parseRequestTime 解析单个时间参数，未带偏移的格式只在指定了 loc 时接受。
+func parseRequestTime(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil || loc == nil {
+		return t, err
+	}
+	for _, layout := range requestTimeLayouts {
+		if t, layoutErr := time.ParseInLocation(layout, value, loc); layoutErr == nil {
+			return t, nil
+		}
+	}
+	return t, err
 }
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		name               string
+		start, end, tz     string
+		wantStart, wantEnd string // RFC3339 (UTC)
+		wantLoc            string // 为空表示 loc 为 nil
+		wantErr            string // 错误信息的前缀
+	}{
+		{
+			name: "rfc3339 without tz", start: "2025-03-09T00:00:00-05:00", end: "2025-03-10T00:00:00-04:00",
+			wantStart: "2025-03-09T05:00:00Z", wantEnd: "2025-03-10T04:00:00Z",
+		},
+		{
+			name: "local dates in new york across spring forward", start: "2025-03-09", end: "2025-03-10", tz: "America/New_York",
+			wantStart: "2025-03-09T05:00:00Z", wantEnd: "2025-03-10T04:00:00Z", wantLoc: "America/New_York",
+		},
+		{
+			name: "local times in new york on fall back day", start: "2025-11-02T00:00", end: "2025-11-02T03:00:00", tz: "America/New_York",
+			wantStart: "2025-11-02T04:00:00Z", wantEnd: "2025-11-02T08:00:00Z", wantLoc: "America/New_York",
+		},
+		{
+			name: "local dates in berlin across fall back", start: "2025-10-26", end: "2025-10-27", tz: "Europe/Berlin",
+			wantStart: "2025-10-25T22:00:00Z", wantEnd: "2025-10-26T23:00:00Z", wantLoc: "Europe/Berlin",
+		},
+		{
+			name: "local dates in berlin across spring forward", start: "2025-03-30", end: "2025-03-31", tz: "Europe/Berlin",
+			wantStart: "2025-03-29T23:00:00Z", wantEnd: "2025-03-30T22:00:00Z", wantLoc: "Europe/Berlin",
+		},
+		{
+			name: "local dates in half hour zone", start: "2025-01-01", end: "2025-01-01T12:30", tz: "Asia/Kolkata",
+			wantStart: "2024-12-31T18:30:00Z", wantEnd: "2025-01-01T07:00:00Z", wantLoc: "Asia/Kolkata",
+		},
+		{
+			name: "rfc3339 keeps its offset when tz is given", start: "2025-01-01T00:00:00Z", end: "2025-01-02T00:00:00+05:30", tz: "Asia/Kolkata",
+			wantStart: "2025-01-01T00:00:00Z", wantEnd: "2025-01-01T18:30:00Z", wantLoc: "Asia/Kolkata",
+		},
+		{name: "local date without tz", start: "2025-01-01", end: "2025-01-02T00:00:00Z", wantErr: "invalid start_time format"},
+		{name: "invalid end", start: "2025-01-01", end: "tomorrow", tz: "Europe/Berlin", wantErr: "invalid end_time format"},
+		{name: "invalid tz", start: "2025-01-01", end: "2025-01-02", tz: "Mars/Olympus_Mons", wantErr: "invalid tz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, loc, err := parseTimeRange(tt.start, tt.end, tt.tz)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want prefix %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, _ := time.Parse(time.RFC3339, tt.wantStart); !start.Equal(want) {
+				t.Errorf("start = %s, want %s", start.UTC(), want)
+			}
+			if want, _ := time.Parse(time.RFC3339, tt.wantEnd); !end.Equal(want) {
+				t.Errorf("end = %s, want %s", end.UTC(), want)
+			}
+			switch {
+			case tt.wantLoc == "" && loc != nil:
+				t.Errorf("loc = %s, want nil", loc)
+			case tt.wantLoc != "" && (loc == nil || loc.String() != tt.wantLoc):
+				t.Errorf("loc = %v, want %s", loc, tt.wantLoc)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/krisxia0506/bilibili-watcher/internal/application"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/model"
+	"github.com/krisxia0506/bilibili-watcher/internal/domain/service"
+	"github.com/krisxia0506/bilibili-watcher/internal/interfaces/api/rest/dto"
+	"github.com/krisxia0506/bilibili-watcher/pkg/response"
+)
+
+// WatchTimeHandler 处理观看时长计算相关的 API 请求，用于调试观看时长的计算。
+type WatchTimeHandler struct {
+	appService        application.WatchTimeService
+	simulationService *application.SimulationService
+}
+
+// NewWatchTimeHandler 创建 WatchTimeHandler 实例。
+func NewWatchTimeHandler(appService application.WatchTimeService, simulationService *application.SimulationService) *WatchTimeHandler {
+	return &WatchTimeHandler{appService: appService, simulationService: simulationService}
+}
+
+// RegisterRoutes 在 Gin 路由组上注册观看时长计算相关的路由。
+func (h *WatchTimeHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/watch-time/calculate", h.Calculate)
+	rg.POST("/watch-time/simulate", h.Simulate)
+}
+
+// Calculate 处理计算两个播放位置之间观看时长的请求。
+// @Summary 计算两个播放位置之间的观看时长
+// @Description 按视频的分P列表计算从起点到终点按播放顺序经过的内容时长，返回每个分P贡献的时长。
+// @Description 使用同时包含两个分P的最新分P列表版本；不考虑记录时间，也不受倍速上限限制。
+// @Tags WatchTime
+// @Accept json
+// @Produce json
+// @Param request body dto.CalculateWatchTimeRequest true "起点和终点"
+// @Success 200 {object} response.APIResponse{data=dto.CalculateWatchTimeResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误，或分P不存在、位置无效、起点晚于终点"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/watch-time/calculate [post]
+func (h *WatchTimeHandler) Calculate(c *gin.Context) {
+	var req dto.CalculateWatchTimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.AID == "" && req.BVID == "" {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, "Either aid or bvid must be provided")
+		return
+	}
+
+	pages, watched, err := h.appService.CalculateWatchTimeBetweenPoints(c.Request.Context(), req.AID, req.BVID,
+		req.StartCID, req.StartPositionSec, req.EndCID, req.EndPositionSec)
+	if isWatchTimeInputError(err) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to calculate watch time: %v", err))
+		return
+	}
+
+	response.Success(c, dto.CalculateWatchTimeResponse{
+		PageVersion:        pages.Version,
+		WatchedDurationSec: int64(watched.Total().Seconds()),
+		Parts:              toPageWatchTimes(pages.Pages, watched),
+	})
+}
+
+// Simulate 处理观看时长模拟的请求。
+// @Summary 用给定的进度点模拟观看时长的计算
+// @Description 把请求中的进度点作为一个虚拟视频的进度记录，像正式数据一样分类记录对、重建聚合并计算观看分段，不读写数据库。
+// @Description 分段参数与 /api/v1/video/watch-segments 相同；pairs 返回每对相邻进度点的分类和按观看时长策略计算的结果，用于排查时长的归属。
+// @Tags WatchTime
+// @Accept json
+// @Produce json
+// @Param request body dto.SimulateWatchTimeRequest true "分P列表、进度点和分段参数"
+// @Success 200 {object} response.APIResponse{data=dto.SimulateWatchTimeResponse} "成功响应"
+// @Failure 400 {object} response.APIResponse "请求参数错误"
+// @Failure 500 {object} response.APIResponse "服务器内部错误"
+// @Router /api/v1/watch-time/simulate [post]
+func (h *WatchTimeHandler) Simulate(c *gin.Context) {
+	var req dto.SimulateWatchTimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	startTime, endTime, loc, err := parseTimeRange(req.StartTime, req.EndTime, req.TZ)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	interval, err := application.ParseSegmentInterval(req.Interval, req.DayCutoffHour)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	pages := make([]model.VideoPage, 0, len(req.Pages))
+	for i, p := range req.Pages {
+		page := p.Page
+		if page == 0 {
+			page = i + 1
+		}
+		pages = append(pages, model.VideoPage{Cid: p.CID, Page: page, Part: p.Part, Duration: p.DurationSec})
+	}
+	points := make([]service.PlaybackPoint, 0, len(req.Points))
+	for i, p := range req.Points {
+		recordedAt, err := parseRequestTime(p.RecordedAt, loc)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, fmt.Sprintf("Invalid points[%d].recorded_at format: %v", i, err))
+			return
+		}
+		points = append(points, service.PlaybackPoint{CID: p.CID, PositionSec: p.PositionSec, RecordedAt: recordedAt})
+	}
+
+	result, err := h.simulationService.Simulate(c.Request.Context(), pages, points, startTime, endTime, interval, loc,
+		application.Attribution(req.Attribution), req.IncludeSuspicious)
+	if errors.Is(err, application.ErrInvalidSimulation) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParams, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, fmt.Sprintf("Failed to simulate watch time: %v", err))
+		return
+	}
+
+	respData := dto.SimulateWatchTimeResponse{
+		GetWatchedSegmentsResponse: toWatchedSegmentsResponse(result.Analytics),
+		Pairs:                      make([]dto.SimulatedPair, 0, len(result.Pairs)),
+	}
+	for _, pair := range result.Pairs {
+		item := dto.SimulatedPair{
+			StartRecordedAt:      formatResponseTime(pair.Start.RecordedAt, loc),
+			StartCID:             pair.Start.CID,
+			StartPositionSec:     pair.Start.PositionSec,
+			EndRecordedAt:        formatResponseTime(pair.End.RecordedAt, loc),
+			EndCID:               pair.End.CID,
+			EndPositionSec:       pair.End.PositionSec,
+			PairLabel:            string(pair.Label),
+			WatchedDurationSec:   int64((pair.Breakdown.FirstTime + pair.Breakdown.Rewatch).Seconds()),
+			RewatchedDurationSec: int64(pair.Breakdown.Rewatch.Seconds()),
+			SkippedDurationSec:   int64(pair.Breakdown.Skipped.Seconds()),
+		}
+		if pair.Err != nil {
+			item.Error = pair.Err.Error()
+		}
+		respData.Pairs = append(respData.Pairs, item)
+	}
+	response.Success(c, respData)
+}
+
+// isWatchTimeInputError 判断错误是否由无效的分P或播放位置引起。
+func isWatchTimeInputError(err error) bool {
+	return errors.Is(err, service.ErrPageNotFound) || errors.Is(err, service.ErrInvalidTime) ||
+		errors.Is(err, service.ErrStartAfterEnd)
+}
+
+// toPageWatchTimes 把各分P的时长转换为 DTO，分P序号和标题取自 pages。
+func toPageWatchTimes(pages []model.VideoPage, watched service.PageWatchTimes) []dto.PartWatchedDuration {
+	result := make([]dto.PartWatchedDuration, 0, len(watched))
+	for _, w := range watched {
+		item := dto.PartWatchedDuration{CID: w.CID, WatchedDurationSec: int64(w.Duration.Seconds())}
+		for _, p := range pages {
+			if p.Cid == w.CID {
+				item.Page, item.Part = p.Page, p.Part
+				break
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// formatResponseTime 按 RFC3339 格式化时间，loc 为 nil 时保持原时区。
+func formatResponseTime(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(time.RFC3339)
+}
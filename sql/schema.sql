@@ -1,6 +1,7 @@
 -- Schema for bilibili-watcher
 -- Target: MySQL 8
 -- Applying Alibaba spec (mandatory fields, NOT NULL, defaults, singular table name).
+-- 所有 datetime 列均保存 UTC 时间，连接会话使用 time_zone='+00:00'，因此 CURRENT_TIMESTAMP 默认值也是 UTC。
 
 -- 视频观看进度表 (Video Progress Table)
 CREATE TABLE IF NOT EXISTS `video_progress` (
@@ -10,16 +11,167 @@ CREATE TABLE IF NOT EXISTS `video_progress` (
   `last_play_cid` bigint NOT NULL DEFAULT 0 COMMENT '上次播放的视频分 P ID',
   `last_play_time` int NOT NULL DEFAULT 0 COMMENT '上次播放时间/进度 (毫秒)',
   `recorded_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '记录时间',
+  `pair_label` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '与上一条记录组成的记录对的分类 (forward, seek_back, part_switch, reset, suspicious)',
   `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
   `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
   PRIMARY KEY (`id`),
-  INDEX `idx_video_progress_aid` (`aid`),
+  UNIQUE INDEX `uk_video_progress_aid_recorded_at` (`aid`, `recorded_at`),
+  INDEX `idx_video_progress_aid_cid_recorded_at` (`aid`, `last_play_cid`, `recorded_at`),
   INDEX `idx_video_progress_last_play_cid` (`last_play_cid`),
   INDEX `idx_video_progress_recorded_at` (`recorded_at`),
   INDEX `idx_bvid` (`bvid`),
   INDEX `idx_gmt_create` (`gmt_create`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='视频观看进度记录';
 
+-- 视频目录表 (Video Catalog Table)
+CREATE TABLE IF NOT EXISTS `video` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `bvid` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '视频 BV 号',
+  `title` varchar(512) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '视频标题',
+  `owner_name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'UP主名称',
+  `owner_mid` bigint NOT NULL DEFAULT 0 COMMENT 'UP主 ID (mid)',
+  `tid` int NOT NULL DEFAULT 0 COMMENT '分区 ID',
+  `tname` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '分区名称',
+  `duration` bigint NOT NULL DEFAULT 0 COMMENT '总时长 (秒)',
+  `pubdate` bigint NOT NULL DEFAULT 0 COMMENT '发布时间戳',
+  `page_version` int NOT NULL DEFAULT 0 COMMENT '当前分P列表版本号',
+  `refreshed_at` datetime(3) NOT NULL COMMENT '最近刷新时间',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_video_aid` (`aid`),
+  UNIQUE INDEX `uk_video_bvid` (`bvid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='视频目录';
+
+-- 视频分P表，每个分P列表版本一组记录 (Video Page Table)
+CREATE TABLE IF NOT EXISTS `video_page` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `version` int NOT NULL DEFAULT 0 COMMENT '分P列表版本号',
+  `page` int NOT NULL DEFAULT 0 COMMENT '分P序号',
+  `cid` bigint NOT NULL DEFAULT 0 COMMENT '分P ID',
+  `part` varchar(512) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '分P标题',
+  `duration` bigint NOT NULL DEFAULT 0 COMMENT '分P时长 (秒)',
+  `effective_from` datetime(3) NOT NULL COMMENT '该版本生效时间',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_video_page_aid_version_page` (`aid`, `version`, `page`),
+  INDEX `idx_video_page_cid` (`cid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='视频分P列表历史';
+
+-- 小时级观看时长聚合表，每保存一条进度记录增量更新 (Hourly Watch Time Table)
+CREATE TABLE IF NOT EXISTS `watch_time_hourly` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `cid` bigint NOT NULL DEFAULT 0 COMMENT '分P ID',
+  `hour_start` datetime(3) NOT NULL COMMENT '小时开始时间',
+  `watched_seconds` bigint NOT NULL DEFAULT 0 COMMENT '观看时长 (秒)',
+  `rewatched_seconds` bigint NOT NULL DEFAULT 0 COMMENT '其中重看时长 (秒)',
+  `skipped_seconds` bigint NOT NULL DEFAULT 0 COMMENT '跳过的进度 (秒)',
+  `continuous_seconds` bigint NOT NULL DEFAULT 0 COMMENT '连续播放的观看时长 (秒)',
+  `playback_seconds` bigint NOT NULL DEFAULT 0 COMMENT '连续播放的实际播放时间 (秒)',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_watch_time_hourly_aid_cid_hour` (`aid`, `cid`, `hour_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='小时级观看时长聚合';
+
+-- 天级观看时长聚合表，等于当天所有小时聚合之和，用于按天查询 (Daily Watch Time Table)
+CREATE TABLE IF NOT EXISTS `watch_time_daily` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `cid` bigint NOT NULL DEFAULT 0 COMMENT '分P ID',
+  `day_start` datetime(3) NOT NULL COMMENT '当天零点 (聚合时区)',
+  `watched_seconds` bigint NOT NULL DEFAULT 0 COMMENT '观看时长 (秒)',
+  `rewatched_seconds` bigint NOT NULL DEFAULT 0 COMMENT '其中重看时长 (秒)',
+  `skipped_seconds` bigint NOT NULL DEFAULT 0 COMMENT '跳过的进度 (秒)',
+  `continuous_seconds` bigint NOT NULL DEFAULT 0 COMMENT '连续播放的观看时长 (秒)',
+  `playback_seconds` bigint NOT NULL DEFAULT 0 COMMENT '连续播放的实际播放时间 (秒)',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_watch_time_daily_aid_cid_day` (`aid`, `cid`, `day_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='天级观看时长聚合';
+
+-- Bilibili API 原始响应归档表，响应体 gzip 压缩保存 (Raw Response Archive Table)
+CREATE TABLE IF NOT EXISTS `raw_response` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `kind` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '接口类型 (progress, view)',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `bvid` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '视频 BV 号',
+  `cid` bigint NOT NULL DEFAULT 0 COMMENT '请求的分P ID',
+  `fetched_at` datetime(3) NOT NULL COMMENT '收到响应的时间',
+  `body_gzip` mediumblob NOT NULL COMMENT 'gzip 压缩的响应体',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  INDEX `idx_raw_response_aid_kind_fetched` (`aid`, `kind`, `fetched_at`),
+  INDEX `idx_raw_response_fetched_at` (`fetched_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Bilibili API 原始响应归档';
+
+-- 原始记录汇总水位线表 (Progress Rollup State Table)
+CREATE TABLE IF NOT EXISTS `progress_rollup_state` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `rolled_up_until` datetime(3) NOT NULL COMMENT '原始记录已汇总到的时间点',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_progress_rollup_state_aid` (`aid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='原始进度记录汇总水位线';
+
+-- 学习目标表，日历日以 YYYY-MM-DD 保存 (Study Goal Table)
+CREATE TABLE IF NOT EXISTS `study_goal` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `bvid` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '视频 BV 号',
+  `kind` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '目标类型 (daily, deadline)',
+  `daily_seconds` bigint NOT NULL DEFAULT 0 COMMENT '每天的目标观看时长 (秒)',
+  `start_date` varchar(10) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '开始统计的日期 (YYYY-MM-DD)',
+  `deadline` varchar(10) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '截止日期 (YYYY-MM-DD，含)',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  INDEX `idx_study_goal_aid` (`aid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='学习目标';
+
+-- 课程计划表，日历日以 YYYY-MM-DD 保存 (Course Plan Table)
+CREATE TABLE IF NOT EXISTS `course_plan` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '计划名称',
+  `start_date` varchar(10) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '计划开始的日期 (YYYY-MM-DD)',
+  `target_date` varchar(10) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '目标完成日期 (YYYY-MM-DD，含)',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='课程计划';
+
+-- 课程计划的分P表，按 position 排列 (Course Plan Item Table)
+CREATE TABLE IF NOT EXISTS `course_plan_item` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `plan_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '课程计划 ID',
+  `position` int NOT NULL DEFAULT 0 COMMENT '分P在计划中的顺序 (从 0 开始)',
+  `aid` bigint NOT NULL DEFAULT 0 COMMENT '视频稿件 ID (AV 号)',
+  `bvid` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '视频 BV 号',
+  `cid` bigint NOT NULL DEFAULT 0 COMMENT '分P ID',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_course_plan_item_plan_position` (`plan_id`, `position`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='课程计划的分P';
+
+-- 已执行的一次性数据迁移 (Schema Migration Table)，例如 migrate-utc 子命令
+CREATE TABLE IF NOT EXISTS `schema_migration` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键 ID',
+  `name` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '迁移名称',
+  `applied_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '执行时间',
+  `gmt_create` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `gmt_modified` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_schema_migration_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='已执行的一次性数据迁移';
+
 -- Note:
 -- Mandatory fields: id, gmt_create, gmt_modified.
 -- All fields are NOT NULL with defaults.
@@ -7,6 +7,15 @@ interface ChartDataPoint {
   duration: number;        // Y轴显示的观看时长（秒）
   originalStartTime: string; // 原始的 segment_start_time (UTC ISO string)
   originalEndTime: string;   // 原始的 segment_end_time (UTC ISO string)
+  parts: PartWatchedDuration[]; // 该分段内各分P的观看时长
+}
+
+// 分段内一个分P的观看时长
+interface PartWatchedDuration {
+  cid: number;
+  page: number;
+  part: string;
+  watched_duration_seconds: number;
 }
 
 // 定义 WatchSegment 类型，与 _index.tsx 中的保持一致
@@ -14,6 +23,7 @@ interface WatchSegment {
   segment_start_time: string;
   segment_end_time: string;
   watched_duration_seconds: number;
+  parts?: PartWatchedDuration[];
 }
 
 interface WatchTimeChartProps {
@@ -45,6 +55,7 @@ const transformDataForChart = (segments: WatchSegment[]): ChartDataPoint[] => {
       duration: segment.watched_duration_seconds,
       originalStartTime: segment.segment_start_time,
       originalEndTime: segment.segment_end_time,
+      parts: segment.parts || [],
     };
   });
 };
@@ -78,6 +89,11 @@ const CustomTooltip = ({ active, payload }: TooltipProps<ValueType, NameType>) =
         <p className="text-blue-600">
           <span className="font-medium">Duration:</span> {data.duration} seconds
         </p>
+        {data.parts.map(part => (
+          <p key={part.cid} className="text-xs text-gray-500">
+            P{part.page} {part.part}: {part.watched_duration_seconds} seconds
+          </p>
+        ))}
       </div>
     );
   }
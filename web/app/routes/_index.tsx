@@ -60,6 +60,8 @@ export async function loader({ request }: LoaderFunctionArgs) {
   const startTimeFromParams = url.searchParams.get("startTime"); // Expected to be UTC ISO string from client
   const endTimeFromParams = url.searchParams.get("endTime");     // Expected to be UTC ISO string from client
   const intervalFromParams = url.searchParams.get("interval");
+  const tzFromParams = url.searchParams.get("tz"); // IANA timezone of the client, used for bucketing
+  const attributionFromParams = url.searchParams.get("attribution"); // Optional: "start" or "proportional", defaults to the server setting
 
   // Determine the final BVID to use: from params or the default
   const bvid = bvidFromParams || defaultBvid;
@@ -78,6 +80,8 @@ export async function loader({ request }: LoaderFunctionArgs) {
     start_time: finalStartTimeForApi,
     end_time: finalEndTimeForApi,
     interval,
+    ...(tzFromParams ? { tz: tzFromParams } : {}),
+    ...(attributionFromParams ? { attribution: attributionFromParams } : {}),
   } : null;
   
   let segments: WatchSegment[] = [];
@@ -223,6 +227,7 @@ export default function Index() {
       params.append("interval", intervalValue);
       params.append("startTime", startTimeUtcIso);
       params.append("endTime", endTimeUtcIso);
+      params.append("tz", Intl.DateTimeFormat().resolvedOptions().timeZone); // 按浏览器所在时区分段
       
       console.log("[Index Component] Submitting with client-converted UTC params:", params.toString());
       submit(params, { method: "get", replace: false }); // Use replace: false for manual submits